@@ -50,6 +50,12 @@ func init() {
 	rootCmd.Flags().String("gemini-oauth-creds-base64", "", "Gemini OAuth credentials (base64)")
 	rootCmd.Flags().String("gemini-oauth-creds-file", "", "Gemini OAuth credentials file")
 	rootCmd.Flags().String("project-id", "", "Google Cloud project ID")
+	rootCmd.Flags().String("gemini-workload-identity-audience", "", "Gemini Workload Identity Federation audience (enables external_account auth without a service-account key)")
+	rootCmd.Flags().String("gemini-workload-identity-subject-token-type", "", "Gemini Workload Identity Federation subject token type, e.g. urn:ietf:params:oauth:token-type:jwt")
+	rootCmd.Flags().String("gemini-workload-identity-credential-source-file", "", "Path to the file holding the Workload Identity Federation subject token (e.g. a GitHub Actions OIDC token)")
+	rootCmd.Flags().String("gemini-workload-identity-impersonation-url", "", "Service account impersonation URL for Workload Identity Federation, if impersonating")
+	rootCmd.Flags().String("gemini-location", "us-central1", "Vertex AI region for OAuth/service-account requests, e.g. europe-west4")
+	rootCmd.Flags().String("gemini-vertex-endpoint-override", "", "Replace the whole Vertex AI host (e.g. for a private Service Connect endpoint); empty derives it from --gemini-location")
 
 	// System prompt flags
 	rootCmd.Flags().String("system-prompt-file", "", "System prompt file path")
@@ -63,6 +69,89 @@ func init() {
 	rootCmd.Flags().String("provider-pools-file", "", "Provider pools configuration file")
 	rootCmd.Flags().Int("request-max-retries", 3, "Maximum retries for failed requests")
 	rootCmd.Flags().Int("request-base-delay", 1000, "Base delay between retries (ms)")
+
+	// Redis flags
+	rootCmd.Flags().String("redis-addr", "", "Redis address (host:port); enables the Redis response cache when set")
+	rootCmd.Flags().String("redis-password", "", "Redis password")
+	rootCmd.Flags().Int("redis-db", 0, "Redis database number")
+	rootCmd.Flags().String("redis-mode", "standalone", "Redis deployment mode: standalone, sentinel, or cluster")
+	rootCmd.Flags().StringSlice("redis-cluster-addrs", nil, "Redis Cluster seed node addresses, used when --redis-mode=cluster (comma-separated)")
+	rootCmd.Flags().StringSlice("redis-sentinel-addrs", nil, "Redis Sentinel node addresses, used when --redis-mode=sentinel (comma-separated)")
+	rootCmd.Flags().String("redis-sentinel-master", "", "Redis Sentinel monitored master name, used when --redis-mode=sentinel")
+	rootCmd.Flags().String("redis-sentinel-password", "", "Password for the Redis Sentinel nodes themselves, used when --redis-mode=sentinel (distinct from --redis-password)")
+
+	// Usage accounting flags
+	rootCmd.Flags().Float64("usage-budget-usd", 0, "Per-API-key spending budget in USD per window (0 disables quota enforcement)")
+	rootCmd.Flags().Int("usage-budget-window-minutes", 60, "Rolling window length for the usage budget, in minutes")
+
+	// Response cache flags
+	rootCmd.Flags().Bool("cache-enabled", false, "Cache non-streaming completion responses keyed on the canonicalized request")
+	rootCmd.Flags().Int("cache-ttl-minutes", 5, "Default cache entry TTL in minutes (overridable per model)")
+	rootCmd.Flags().Int64("cache-max-size-mb", 256, "Maximum in-memory cache size in megabytes")
+	rootCmd.Flags().StringSlice("stream-cache-models", nil, "Models that record streaming completions for replay on a repeated identical request (comma-separated)")
+	rootCmd.Flags().Int("stream-cache-chunk-delay-ms", 0, "Fixed delay between replayed stream-cache chunks; 0 reproduces the original recorded delays")
+
+	// Semantic cache flags
+	rootCmd.Flags().Bool("semantic-cache-enabled", false, "On a cache miss, fall back to an embedding-similarity match against other cached prompts for the same provider+model")
+	rootCmd.Flags().Float64("semantic-cache-threshold", 0.95, "Minimum cosine similarity for a semantic cache hit")
+	rootCmd.Flags().Float64("semantic-cache-temperature-max", 0.3, "Only index/match requests with temperature at or below this value")
+	rootCmd.Flags().String("semantic-cache-embedding-model", "text-embedding-3-small", "Embedding model to request from the embeddings endpoint")
+	rootCmd.Flags().String("semantic-cache-embedding-url", "https://api.openai.com/v1", "Base URL of an OpenAI-compatible /embeddings endpoint")
+	rootCmd.Flags().String("semantic-cache-embedding-key", "", "API key for the embeddings endpoint")
+
+	// Provider response cache flags (Redis-backed, in front of each
+	// provider's GenerateContent; distinct from --cache-enabled above,
+	// which caches the canonicalized cross-protocol request instead)
+	rootCmd.Flags().String("cache-mode", "off", "Provider response cache mode: off, exact, or semantic (requires --redis-addr or --redis-mode)")
+	rootCmd.Flags().Int("cache-ttl", 300, "Provider response cache entry TTL in seconds")
+	rootCmd.Flags().Float64("cache-similarity-threshold", 0.95, "Minimum cosine similarity for a semantic provider-cache hit")
+	rootCmd.Flags().String("cache-embedding-model", "text-embedding-3-small", "Embedding model requested for semantic provider-cache lookups")
+
+	// OIDC authentication flags
+	rootCmd.Flags().String("oidc-issuer-url", "", "OIDC issuer URL; when set, Bearer tokens are validated against this provider instead of --api-key")
+	rootCmd.Flags().String("oidc-audience", "", "Expected OIDC token audience (aud claim)")
+	rootCmd.Flags().StringSlice("oidc-required-scopes", nil, "Scopes that must all be present in the token's scope claim")
+	rootCmd.Flags().StringSlice("oidc-allowed-subjects", nil, "If set, restrict valid tokens to these sub claims")
+	rootCmd.Flags().StringSlice("oidc-allowed-groups", nil, "If set, require at least one of these values in the token's groups claim")
+	rootCmd.Flags().String("oidc-caller-policies-file", "", "Path to a JSON file mapping authenticated callers' sub/email/groups to per-caller rate limits and provider/model access")
+
+	// Token store flags
+	rootCmd.Flags().String("token-store-type", "memory", "Where OAuth tokens are persisted across restarts: memory, file, or redis")
+	rootCmd.Flags().String("token-store-dir", "./.aiproxy/tokens", "Directory for the file token store")
+	rootCmd.Flags().String("token-store-encryption-key", "", "Base64-encoded 32-byte AES-256-GCM key for the file token store")
+
+	// mTLS / SPIFFE flags
+	rootCmd.Flags().String("tls-cert-file", "", "Server TLS certificate; when set, the server terminates TLS itself")
+	rootCmd.Flags().String("tls-key-file", "", "Server TLS private key")
+	rootCmd.Flags().String("tls-client-ca-file", "", "CA bundle to verify client certificates against; enables mTLS authentication")
+	rootCmd.Flags().String("tls-client-ca-require", "verify", "Client certificate requirement: request, require, or verify")
+	rootCmd.Flags().String("spiffe-trust-domain", "", "If set, require the client certificate's SPIFFE URI SAN to belong to this trust domain")
+
+	// gRPC flags
+	rootCmd.Flags().Bool("grpc-enabled", false, "Serve the gRPC/Connect streaming API alongside the HTTP API")
+	rootCmd.Flags().String("grpc-addr", ":9090", "Address the gRPC/Connect API listens on")
+
+	// Hedged-request flags
+	rootCmd.Flags().Bool("hedge-enabled", false, "Fire a duplicate request against the next provider if the primary hasn't responded after hedge-after-ms")
+	rootCmd.Flags().Int("hedge-after-ms", 2000, "Milliseconds to wait for the primary provider before hedging")
+
+	// Observability flags
+	rootCmd.Flags().String("otlp-endpoint", "", "OTLP/HTTP collector host:port to export OpenTelemetry traces to (empty disables tracing)")
+	rootCmd.Flags().MarkDeprecated("otlp-endpoint", "use --otel-endpoint instead")
+	rootCmd.Flags().String("otel-endpoint", "", "OTLP/HTTP collector host:port to export OpenTelemetry traces to (empty disables tracing); supersedes --otlp-endpoint")
+	rootCmd.Flags().String("otel-service-name", "go-aiproxy", "service.name resource attribute reported on exported spans")
+	rootCmd.Flags().String("otel-sampler", "always_on", "Root trace sampler: always_on, always_off, or a ratio like 0.1 for a TraceIDRatioBased sampler")
+	rootCmd.Flags().StringSlice("otel-headers", nil, "Extra headers sent with every OTLP export request, as key=value pairs (comma-separated)")
+
+	// Metrics flags
+	rootCmd.Flags().Bool("metrics-enabled", true, "Serve the Prometheus /metrics scrape endpoint")
+	rootCmd.Flags().String("metrics-addr", "", "Serve /metrics on a dedicated listener at this address instead of the main API port (empty uses the main router)")
+	rootCmd.Flags().String("metrics-path", "/metrics", "Path the Prometheus scrape endpoint is served on")
+	rootCmd.Flags().String("active-users-state-file", "", "Persist the rolling active-users bucket sketch to this path so aiproxy_active_users_1h/24h survive a restart (empty keeps it in-memory only)")
+
+	// Plugin flags
+	rootCmd.Flags().String("plugin-dir", "", "Directory scanned at startup for aiproxy-plugin-* out-of-process provider binaries (empty disables plugin loading)")
+	rootCmd.Flags().Int("plugin-max-memory-mb", 0, "Kill and relaunch a plugin whose resident memory exceeds this many MB (0 is unbounded)")
 }
 
 func initConfig() {
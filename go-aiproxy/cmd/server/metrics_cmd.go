@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/aiproxy/go-aiproxy/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+var metricsDumpOutput string
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Inspect the Prometheus metrics this server registers",
+}
+
+var metricsDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Write every registered metric's name, help, type, labels, and buckets to a JSON file",
+	Long: `dump constructs the same Metrics registry the server would and writes a
+Descriptor for every metric it registers - name, help text, type, label
+names, and histogram buckets - to a JSON file. Compare its output against
+a checked-in golden file (see internal/metrics/prometheus_test.go) to
+catch accidental breaking changes to metric names or labels in review.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		metrics.Default()
+		descriptors, err := metrics.DumpDescriptors()
+		if err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(descriptors, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal descriptors: %w", err)
+		}
+		data = append(data, '\n')
+
+		if metricsDumpOutput == "-" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		return os.WriteFile(metricsDumpOutput, data, 0o644)
+	},
+}
+
+func init() {
+	metricsDumpCmd.Flags().StringVarP(&metricsDumpOutput, "output", "o", "-", "File to write the metric descriptor dump to (\"-\" writes to stdout)")
+	metricsCmd.AddCommand(metricsDumpCmd)
+	rootCmd.AddCommand(metricsCmd)
+}
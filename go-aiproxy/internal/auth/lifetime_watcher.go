@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// RenewBehavior controls how LifetimeWatcher reacts to a renewal attempt
+// that returns an error.
+type RenewBehavior int
+
+const (
+	// RenewBehaviorIgnoreErrors keeps retrying renewal on a short interval
+	// instead of giving up, appropriate for a transient network/API error
+	// against a token that isn't expired yet.
+	RenewBehaviorIgnoreErrors RenewBehavior = iota
+	// RenewBehaviorFailOnError stops the watcher and reports the error
+	// once and for all, for callers that would rather fail fast.
+	RenewBehaviorFailOnError
+)
+
+// RenewerInput configures a LifetimeWatcher, named after Vault's
+// api.RenewerInput: the watcher doesn't fetch the initial token, it only
+// watches one TokenManager already holds and proactively refreshes it
+// ahead of expiry.
+type RenewerInput struct {
+	TokenManager *TokenManager
+	// RenewBehavior controls what happens when a refresh attempt fails.
+	RenewBehavior RenewBehavior
+	// RetryInterval is how long to wait before retrying a failed refresh.
+	// Defaults to 30s.
+	RetryInterval time.Duration
+}
+
+// LifetimeWatcher runs a background goroutine that refreshes an OAuth
+// token at roughly 2/3 of its remaining lifetime, modeled on Vault's
+// api.Renewer/LifetimeWatcher: rather than waiting for a 401 to discover a
+// token has gone stale, it renews proactively and publishes the result so
+// callers needing the latest token (e.g. to rebuild an http.Client) can
+// react through RenewCh instead of polling.
+type LifetimeWatcher struct {
+	input RenewerInput
+
+	renewCh chan *oauth2.Token
+	errCh   chan error
+	doneCh  chan struct{}
+}
+
+// NewLifetimeWatcher creates a LifetimeWatcher. Call Start to begin
+// watching; the watcher does nothing until then.
+func NewLifetimeWatcher(input RenewerInput) *LifetimeWatcher {
+	if input.RetryInterval <= 0 {
+		input.RetryInterval = 30 * time.Second
+	}
+
+	return &LifetimeWatcher{
+		input:   input,
+		renewCh: make(chan *oauth2.Token, 1),
+		errCh:   make(chan error, 1),
+		doneCh:  make(chan struct{}),
+	}
+}
+
+// RenewCh receives a newly-refreshed token each time one is obtained.
+func (w *LifetimeWatcher) RenewCh() <-chan *oauth2.Token {
+	return w.renewCh
+}
+
+// ErrCh receives a renewal failure. With RenewBehaviorIgnoreErrors the
+// watcher keeps retrying after sending one, so callers should treat this
+// as a health signal rather than a terminal event.
+func (w *LifetimeWatcher) ErrCh() <-chan error {
+	return w.errCh
+}
+
+// Start begins the watch loop. It blocks until ctx is canceled or Stop is
+// called, so callers should invoke it with `go watcher.Start(ctx)`.
+func (w *LifetimeWatcher) Start(ctx context.Context) {
+	retrying := false
+	for {
+		wait := w.nextRenewal()
+		if retrying {
+			wait = w.input.RetryInterval
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		case <-w.doneCh:
+			return
+		}
+
+		token, err := w.input.TokenManager.RefreshToken(ctx)
+		if err != nil {
+			select {
+			case w.errCh <- fmt.Errorf("lifetime watcher: renewal failed: %w", err):
+			default:
+			}
+
+			if w.input.RenewBehavior == RenewBehaviorFailOnError {
+				return
+			}
+			// RenewBehaviorIgnoreErrors: loop back around and retry after
+			// RetryInterval rather than waiting for the full 2/3-lifetime
+			// window again.
+			retrying = true
+			continue
+		}
+		retrying = false
+
+		select {
+		case w.renewCh <- token:
+		default:
+		}
+	}
+}
+
+// Stop cancels the watch loop.
+func (w *LifetimeWatcher) Stop() {
+	close(w.doneCh)
+}
+
+// nextRenewal computes how long to wait before the next renewal attempt:
+// roughly 2/3 of the token's remaining lifetime. A token with no known
+// expiry (zero expiry time) is retried on RetryInterval instead of
+// spinning; a token that has already expired is renewed immediately.
+func (w *LifetimeWatcher) nextRenewal() time.Duration {
+	expiry := w.input.TokenManager.GetExpiryTime()
+	if expiry.IsZero() {
+		return w.input.RetryInterval
+	}
+
+	remaining := time.Until(expiry)
+	if remaining <= 0 {
+		return 0
+	}
+
+	wait := remaining * 2 / 3
+	if wait <= 0 {
+		return 0
+	}
+	return wait
+}
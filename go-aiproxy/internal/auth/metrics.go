@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Refresh metrics are registered lazily behind a sync.Once because, unlike
+// most promauto call sites in this repo, NewTokenManager can run more than
+// once per process (one per OAuth-enabled provider), and promauto panics on
+// a duplicate collector registration.
+var (
+	registerRefreshMetricsOnce sync.Once
+	tokenRefreshTotal          *prometheus.CounterVec
+	tokenRefreshDuration       *prometheus.HistogramVec
+)
+
+func registerRefreshMetrics() {
+	registerRefreshMetricsOnce.Do(func() {
+		tokenRefreshTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "aiproxy_oauth_token_refresh_total",
+			Help: "Total number of OAuth token refresh attempts by provider and outcome",
+		}, []string{"provider", "status"})
+
+		tokenRefreshDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "aiproxy_oauth_token_refresh_duration_seconds",
+			Help:    "Duration of OAuth token refresh calls by provider",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"provider"})
+	})
+}
+
+// recordRefreshMetrics is called after every proactive (StartAutoRefresh)
+// token refresh attempt to track success/failure counts and latency.
+func recordRefreshMetrics(provider string, duration time.Duration, err error) {
+	registerRefreshMetrics()
+
+	status := "success"
+	if err != nil {
+		status = "failure"
+	}
+	tokenRefreshTotal.WithLabelValues(provider, status).Inc()
+	tokenRefreshDuration.WithLabelValues(provider).Observe(duration.Seconds())
+}
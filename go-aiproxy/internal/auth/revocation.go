@@ -0,0 +1,46 @@
+package auth
+
+import "sync"
+
+// RevocationStore tracks access tokens that have been explicitly
+// invalidated, keyed by the token's raw AccessToken string. Unlike
+// TokenStore (which persists the current token so it survives a
+// restart), this only ever needs to answer "has this token been
+// revoked", so a pluggable backing store can choose whatever retention
+// policy fits (e.g. expire entries once the underlying token itself
+// would have expired anyway).
+type RevocationStore interface {
+	// IsRevoked reports whether token has been revoked.
+	IsRevoked(token string) (bool, error)
+
+	// Revoke marks token as invalid.
+	Revoke(token string) error
+}
+
+// MemoryRevocationStore is the default RevocationStore: an in-memory set,
+// good enough for a single-process deployment but, like
+// MemoryTokenStore, lost on restart. Deployments that need revocations to
+// survive a restart or be shared across replicas should supply their own
+// RevocationStore backed by Redis/a database instead.
+type MemoryRevocationStore struct {
+	mu      sync.RWMutex
+	revoked map[string]bool
+}
+
+// NewMemoryRevocationStore creates an empty in-memory RevocationStore.
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{revoked: make(map[string]bool)}
+}
+
+func (s *MemoryRevocationStore) IsRevoked(token string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.revoked[token], nil
+}
+
+func (s *MemoryRevocationStore) Revoke(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[token] = true
+	return nil
+}
@@ -0,0 +1,23 @@
+package auth
+
+import "testing"
+
+func TestMemoryRevocationStore_RevokeAndIsRevoked(t *testing.T) {
+	store := NewMemoryRevocationStore()
+
+	if revoked, err := store.IsRevoked("tok"); err != nil || revoked {
+		t.Fatalf("IsRevoked(tok) = %v, %v, want false, nil", revoked, err)
+	}
+
+	if err := store.Revoke("tok"); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+
+	if revoked, err := store.IsRevoked("tok"); err != nil || !revoked {
+		t.Fatalf("IsRevoked(tok) after Revoke() = %v, %v, want true, nil", revoked, err)
+	}
+
+	if revoked, err := store.IsRevoked("other"); err != nil || revoked {
+		t.Fatalf("IsRevoked(other) = %v, %v, want false, nil", revoked, err)
+	}
+}
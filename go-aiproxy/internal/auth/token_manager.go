@@ -6,12 +6,29 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/aiproxy/go-aiproxy/pkg/models"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
+	"golang.org/x/oauth2/google/externalaccount"
+)
+
+// lockTimeout bounds how long a RefreshToken call waits to acquire the
+// distributed refresh lock before giving up.
+const lockTimeout = 30 * time.Second
+
+// Auto-refresh backoff bounds: on a failed proactive refresh, the next
+// attempt is delayed by autoRefreshInitialBackoff, doubling up to
+// autoRefreshMaxBackoff, so a provider outage doesn't turn into a tight
+// retry loop against its token endpoint.
+const (
+	autoRefreshInitialBackoff = 5 * time.Second
+	autoRefreshMaxBackoff     = 5 * time.Minute
+	autoRefreshJitter         = 30 * time.Second
+	autoRefreshFallback       = time.Minute
 )
 
 // TokenManager manages OAuth tokens with automatic refresh
@@ -21,25 +38,293 @@ type TokenManager struct {
 	tokenSource  oauth2.TokenSource
 	currentToken *oauth2.Token
 	expiryBuffer time.Duration
+
+	store      TokenStore
+	locker     TokenLocker
+	lockKey    string
+	revocation RevocationStore
+
+	// rejectZeroExpiry, when set via WithRejectZeroExpiry, makes a token
+	// source response with a zero/absent expires_in an error instead of
+	// the default of treating it as "never expires". See
+	// validateFreshToken.
+	rejectZeroExpiry bool
+
+	// refreshWindow and refreshTime implement proactive refresh jitter on
+	// top of expiryBuffer: refreshTime is randomized once per cached
+	// token (token.Expiry minus somewhere between 0 and refreshWindow),
+	// so that a fleet of replicas that all loaded the same persisted
+	// token from a shared TokenStore don't all hit the IdP the instant
+	// expiryBuffer is crossed. See WithRefreshWindow and computeRefreshTime.
+	refreshWindow time.Duration
+	refreshTime   time.Time
+	rng           *rand.Rand
+
+	// reuseSource wraps RefreshToken (and therefore the cross-process
+	// lock/persistence it does) in oauth2.ReuseTokenSource, so Token()
+	// gives TokenManager the standard library's caching semantics while
+	// still going through our own refresh coordination instead of
+	// oauth2's default of calling the raw, lock-unaware tokenSource.
+	reuseSource oauth2.TokenSource
+
+	subMu       sync.Mutex
+	subscribers []chan<- *oauth2.Token
+
+	autoRefreshOnce   sync.Once
+	autoRefreshCancel context.CancelFunc
+}
+
+// Option configures optional TokenManager behavior.
+type Option func(*TokenManager)
+
+// WithTokenStore persists tokens across restarts and, when store also
+// implements TokenLocker, coordinates refreshes across processes so only
+// one holder hits the token endpoint at a time. Defaults to an in-memory
+// store with no cross-process coordination.
+func WithTokenStore(store TokenStore) Option {
+	return func(tm *TokenManager) {
+		tm.store = store
+		if locker, ok := store.(TokenLocker); ok {
+			tm.locker = locker
+		}
+	}
+}
+
+// WithRevocationStore configures where RevokeToken/GetToken look up and
+// record revoked tokens. Defaults to an in-memory MemoryRevocationStore
+// when not supplied.
+func WithRevocationStore(store RevocationStore) Option {
+	return func(tm *TokenManager) {
+		tm.revocation = store
+	}
+}
+
+// WithRejectZeroExpiry makes a token source response with a zero/absent
+// expires_in an error instead of the default of treating it as "never
+// expires". Enable this for providers where a missing expires_in
+// indicates a broken token endpoint rather than a genuinely long-lived
+// token.
+func WithRejectZeroExpiry() Option {
+	return func(tm *TokenManager) {
+		tm.rejectZeroExpiry = true
+	}
+}
+
+// WithRefreshWindow enables randomized proactive refresh: each cached
+// token gets its own refresh time, uniformly distributed between
+// token.Expiry-window and token.Expiry, so GetToken refreshes that token
+// early even if it's still within expiryBuffer. Defaults to zero, which
+// disables the feature entirely (refresh is governed by expiryBuffer
+// alone, as before).
+func WithRefreshWindow(window time.Duration) Option {
+	return func(tm *TokenManager) {
+		tm.refreshWindow = window
+	}
+}
+
+// WithRandSeed seeds the RNG WithRefreshWindow uses to pick each token's
+// refresh time, for deterministic tests. Not meant for production use,
+// where the default (the global math/rand source) already gives every
+// instance an independent sequence.
+func WithRandSeed(seed int64) Option {
+	return func(tm *TokenManager) {
+		tm.rng = rand.New(rand.NewSource(seed))
+	}
 }
 
 // NewTokenManager creates a new token manager
-func NewTokenManager(config *models.ProviderConfig) (*TokenManager, error) {
+func NewTokenManager(config *models.ProviderConfig, opts ...Option) (*TokenManager, error) {
 	tm := &TokenManager{
 		config:       config,
 		expiryBuffer: 5 * time.Minute, // Refresh 5 minutes before expiry
 	}
 
+	for _, opt := range opts {
+		opt(tm)
+	}
+	if tm.store == nil {
+		mem := NewMemoryTokenStore()
+		tm.store = mem
+		tm.locker = mem
+	}
+	if tm.revocation == nil {
+		tm.revocation = NewMemoryRevocationStore()
+	}
+
 	// Initialize token source
 	if err := tm.initializeTokenSource(context.Background()); err != nil {
 		return nil, err
 	}
 
+	// A persisted token from a previous run takes precedence over
+	// whatever the (possibly stale) credentials file would otherwise
+	// hand us. If it's still within its refresh window we're done: this
+	// restart never touches the token endpoint at all. Only a missing or
+	// due-for-refresh persisted token falls through to an initial fetch.
+	persisted, err := tm.store.Load(tm.lockKey)
+	if err == nil && persisted != nil {
+		tm.currentToken = persisted
+		tm.computeRefreshTime(persisted)
+	}
+	if tm.currentToken == nil || tm.shouldRefresh(tm.currentToken) {
+		token, err := tm.tokenSource.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get initial token: %w", err)
+		}
+		if err := tm.validateFreshToken(token); err != nil {
+			return nil, fmt.Errorf("invalid initial token: %w", err)
+		}
+		tm.currentToken = token
+		tm.computeRefreshTime(token)
+		// Best-effort, same as RefreshToken: losing this write just means
+		// the next restart re-hits the token endpoint too.
+		_ = tm.store.Save(tm.lockKey, token)
+	}
+
+	// oauth2.ReuseTokenSource only recomputes a token once the cached one
+	// needs refreshing, so wrapping tm here gives callers a standard
+	// oauth2.TokenSource (for oauth2.NewClient/oauth2.Transport) that still
+	// goes through RefreshToken's distributed lock and persistence instead
+	// of bypassing them by reusing tm.tokenSource directly.
+	tm.reuseSource = oauth2.ReuseTokenSource(tm.currentToken, refreshFunc(func() (*oauth2.Token, error) {
+		return tm.RefreshToken(context.Background())
+	}))
+
 	return tm, nil
 }
 
-// initializeTokenSource sets up the OAuth2 token source
+// refreshFunc adapts a plain function to oauth2.TokenSource.
+type refreshFunc func() (*oauth2.Token, error)
+
+func (f refreshFunc) Token() (*oauth2.Token, error) {
+	return f()
+}
+
+// Token implements oauth2.TokenSource, so a TokenManager can be passed
+// directly to oauth2.NewClient/oauth2.Transport. It returns the current
+// token if still fresh, otherwise delegates to RefreshToken (preserving
+// the distributed lock and persistence that bypassing it with
+// oauth2.ReuseTokenSource(tm.tokenSource, ...) would lose).
+func (tm *TokenManager) Token() (*oauth2.Token, error) {
+	return tm.reuseSource.Token()
+}
+
+// StartAutoRefresh launches a background goroutine that proactively
+// refreshes the token before it expires, so requests never block on a
+// refresh round-trip. It is idempotent; only the first call per
+// TokenManager starts the goroutine. Stop it with Close.
+func (tm *TokenManager) StartAutoRefresh(ctx context.Context) {
+	tm.autoRefreshOnce.Do(func() {
+		ctx, cancel := context.WithCancel(ctx)
+		tm.autoRefreshCancel = cancel
+		go tm.autoRefreshLoop(ctx)
+	})
+}
+
+// Close stops the auto-refresh goroutine started by StartAutoRefresh, if
+// any. Safe to call even if StartAutoRefresh was never called.
+func (tm *TokenManager) Close() {
+	if tm.autoRefreshCancel != nil {
+		tm.autoRefreshCancel()
+	}
+}
+
+// autoRefreshLoop wakes shortly before the current token expires, calls
+// RefreshToken, and backs off exponentially on failure so an outage at
+// the provider's token endpoint doesn't turn into a tight retry loop.
+func (tm *TokenManager) autoRefreshLoop(ctx context.Context) {
+	backoff := autoRefreshInitialBackoff
+	for {
+		wait := tm.nextAutoRefresh()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		start := time.Now()
+		_, err := tm.RefreshToken(ctx)
+		recordRefreshMetrics(string(tm.config.Provider), time.Since(start), err)
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > autoRefreshMaxBackoff {
+				backoff = autoRefreshMaxBackoff
+			}
+			continue
+		}
+		backoff = autoRefreshInitialBackoff
+	}
+}
+
+// nextAutoRefresh computes how long to sleep before the next proactive
+// refresh attempt: expiry minus the refresh buffer minus a random jitter,
+// so replicas sharing a TokenStore don't all wake and refresh at once.
+// Falls back to a fixed interval when the current token has no expiry.
+func (tm *TokenManager) nextAutoRefresh() time.Duration {
+	tm.mu.RLock()
+	expiry := time.Time{}
+	if tm.currentToken != nil {
+		expiry = tm.currentToken.Expiry
+	}
+	tm.mu.RUnlock()
+
+	if expiry.IsZero() {
+		return autoRefreshFallback
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(autoRefreshJitter)))
+	wait := time.Until(expiry) - tm.expiryBuffer - jitter
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// geminiCredentialIdentity picks a stable identifier for the refresh lock
+// key out of a Google credentials JSON blob. Service account keys have a
+// client_email; external_account credentials instead identify the caller
+// by audience (and, when impersonating, the target service account).
+func geminiCredentialIdentity(creds []byte) string {
+	var probe struct {
+		ClientEmail                   string `json:"client_email"`
+		Audience                       string `json:"audience"`
+		ServiceAccountImpersonationURL string `json:"service_account_impersonation_url"`
+	}
+	if err := json.Unmarshal(creds, &probe); err != nil {
+		return "gemini"
+	}
+	switch {
+	case probe.ClientEmail != "":
+		return probe.ClientEmail
+	case probe.ServiceAccountImpersonationURL != "":
+		return probe.ServiceAccountImpersonationURL
+	case probe.Audience != "":
+		return probe.Audience
+	default:
+		return "gemini"
+	}
+}
+
+// initializeTokenSource parses config's credentials and builds tm.tokenSource
+// plus tm.lockKey. It does not itself contact the token endpoint — see
+// NewTokenManager, which only does that if no still-valid token is found in
+// the configured TokenStore, so a restart with a warm cache never makes an
+// unnecessary round trip.
 func (tm *TokenManager) initializeTokenSource(ctx context.Context) error {
+	// Workload Identity Federation via explicit ProviderConfig fields is
+	// only available for Gemini/Vertex, and only when no full credentials
+	// blob was supplied (that still takes precedence, since it can
+	// express credential sources this shortcut doesn't, like AWS/URL/
+	// executable-sourced subject tokens).
+	if tm.config.Provider == models.ProviderGemini && tm.config.OAuthCredsBase64 == "" && tm.config.OAuthCredsFile == "" && tm.config.WorkloadIdentityAudience != "" {
+		return tm.initializeExternalAccountTokenSource(ctx)
+	}
+
 	var creds []byte
 	var err error
 
@@ -63,12 +348,22 @@ func (tm *TokenManager) initializeTokenSource(ctx context.Context) error {
 	// Parse credentials based on provider
 	switch tm.config.Provider {
 	case models.ProviderGemini:
-		// Google OAuth2 for Gemini
-		config, err := google.JWTConfigFromJSON(creds, "https://www.googleapis.com/auth/cloud-platform")
+		// google.CredentialsFromJSON dispatches on the JSON's "type" field,
+		// so the same code path handles a classic service_account key as
+		// well as "external_account" / "external_account_authorized_user"
+		// workload-identity credentials (AWS IMDSv2+SigV4, Azure IMDS, a
+		// URL- or file-sourced OIDC token, or an executable credential
+		// source) and, when present, a
+		// service_account_impersonation_url — all implemented by the
+		// golang.org/x/oauth2/google/externalaccount package we already
+		// depend on transitively, so there's nothing provider-specific to
+		// hand-roll here.
+		googleCreds, err := google.CredentialsFromJSON(ctx, creds, "https://www.googleapis.com/auth/cloud-platform")
 		if err != nil {
-			return fmt.Errorf("failed to create JWT config: %w", err)
+			return fmt.Errorf("failed to load Google credentials: %w", err)
 		}
-		tm.tokenSource = config.TokenSource(ctx)
+		tm.tokenSource = googleCreds.TokenSource
+		tm.lockKey = fmt.Sprintf("%s:%s", tm.config.Provider, geminiCredentialIdentity(creds))
 
 	case models.ProviderKiro:
 		// Custom OAuth2 for Kiro
@@ -95,6 +390,7 @@ func (tm *TokenManager) initializeTokenSource(ctx context.Context) error {
 			RefreshToken: oauthCreds.RefreshToken,
 		}
 		tm.tokenSource = config.TokenSource(ctx, token)
+		tm.lockKey = fmt.Sprintf("%s:%s", tm.config.Provider, oauthCreds.ClientID)
 
 	case models.ProviderQwen:
 		// Custom OAuth2 for Qwen
@@ -123,27 +419,85 @@ func (tm *TokenManager) initializeTokenSource(ctx context.Context) error {
 			RefreshToken: qwenCreds.RefreshToken,
 		}
 		tm.tokenSource = config.TokenSource(ctx, token)
+		tm.lockKey = fmt.Sprintf("%s:%s", tm.config.Provider, qwenCreds.ClientID)
 
 	default:
 		return fmt.Errorf("unsupported provider for OAuth: %s", tm.config.Provider)
 	}
 
-	// Get initial token
-	token, err := tm.tokenSource.Token()
+	return nil
+}
+
+// initializeExternalAccountTokenSource builds a Workload Identity
+// Federation token source straight from ProviderConfig's
+// WorkloadIdentity* fields, an alternative to requiring callers to
+// hand-assemble an external_account credentials JSON blob for the common
+// case of a file-sourced subject token (e.g. a GitHub Actions OIDC token
+// written to disk by actions/id-token, or any other OIDC provider's token
+// similarly staged on disk).
+func (tm *TokenManager) initializeExternalAccountTokenSource(ctx context.Context) error {
+	extCfg := externalaccount.Config{
+		Audience:                       tm.config.WorkloadIdentityAudience,
+		SubjectTokenType:               tm.config.WorkloadIdentitySubjectTokenType,
+		TokenURL:                       "https://sts.googleapis.com/v1/token",
+		ServiceAccountImpersonationURL: tm.config.WorkloadIdentityImpersonationURL,
+		CredentialSource: &externalaccount.CredentialSource{
+			File: tm.config.WorkloadIdentityCredentialSourceFile,
+		},
+		Scopes: []string{"https://www.googleapis.com/auth/cloud-platform"},
+	}
+
+	ts, err := externalaccount.NewTokenSource(ctx, extCfg)
 	if err != nil {
-		return fmt.Errorf("failed to get initial token: %w", err)
+		return fmt.Errorf("failed to build external_account token source: %w", err)
 	}
 
-	tm.currentToken = token
+	tm.tokenSource = ts
+	if tm.config.WorkloadIdentityImpersonationURL != "" {
+		tm.lockKey = fmt.Sprintf("%s:%s", tm.config.Provider, tm.config.WorkloadIdentityImpersonationURL)
+	} else {
+		tm.lockKey = fmt.Sprintf("%s:%s", tm.config.Provider, tm.config.WorkloadIdentityAudience)
+	}
+	return nil
+}
+
+// validateFreshToken checks an oauth2.Token immediately after it comes
+// back from tokenSource.Token(), before it's cached as tm.currentToken.
+// This is the only point a negative expires_in can be detected: the
+// oauth2 library computes Expiry as time.Now().Add(expiresIn) at the
+// moment the token is issued, so a negative expires_in leaves Expiry
+// already in the past right now, whereas a currentToken checked later by
+// shouldRefresh may simply have aged past a perfectly valid expiry. A
+// zero/absent expires_in is indistinguishable from Expiry never having
+// been set at all, so it's only rejected when rejectZeroExpiry opts in;
+// otherwise it's treated as "never expires", as it always has been.
+func (tm *TokenManager) validateFreshToken(token *oauth2.Token) error {
+	if token == nil || token.Expiry.IsZero() {
+		if tm.rejectZeroExpiry {
+			return fmt.Errorf("token source returned no expires_in")
+		}
+		return nil
+	}
+	if token.Expiry.Before(time.Now()) {
+		return fmt.Errorf("token source returned a negative expires_in (expiry %s is already in the past)", token.Expiry)
+	}
 	return nil
 }
 
-// GetToken returns a valid access token, refreshing if necessary
+// GetToken returns a valid access token, refreshing if necessary. A token
+// that RevokeToken has marked as revoked is never returned from cache,
+// even if it's not yet due for its normal expiry-buffer refresh.
 func (tm *TokenManager) GetToken(ctx context.Context) (*oauth2.Token, error) {
 	tm.mu.RLock()
 	token := tm.currentToken
 	tm.mu.RUnlock()
 
+	if token != nil && tm.revocation != nil {
+		if revoked, err := tm.revocation.IsRevoked(token.AccessToken); err == nil && revoked {
+			return tm.RefreshToken(ctx)
+		}
+	}
+
 	// Check if token needs refresh
 	if tm.shouldRefresh(token) {
 		return tm.RefreshToken(ctx)
@@ -152,21 +506,103 @@ func (tm *TokenManager) GetToken(ctx context.Context) (*oauth2.Token, error) {
 	return token, nil
 }
 
-// RefreshToken forces a token refresh
+// RevokeToken marks token as invalid in the configured RevocationStore, so
+// a subsequent GetToken forces a refresh instead of serving it from cache.
+// Use this when a token is known to be compromised in the field, rather
+// than waiting for it to age past expiryBuffer naturally.
+func (tm *TokenManager) RevokeToken(ctx context.Context, token string) error {
+	if tm.revocation == nil {
+		tm.revocation = NewMemoryRevocationStore()
+	}
+	return tm.revocation.Revoke(token)
+}
+
+// RefreshToken forces a token refresh. If a TokenLocker is configured
+// (WithTokenStore with a store that also implements TokenLocker), it
+// takes a distributed lock keyed by provider+client_id first, so that
+// when multiple replicas hit an expired token at once only one of them
+// calls the token endpoint; the rest wait for the lock, then re-read the
+// token the winner persisted instead of racing it (important for
+// providers like Qwen that invalidate a refresh_token as soon as it's
+// used, so a second refresh with the now-stale token would fail).
 func (tm *TokenManager) RefreshToken(ctx context.Context) (*oauth2.Token, error) {
-	tm.mu.Lock()
-	defer tm.mu.Unlock()
+	if tm.locker != nil {
+		lockCtx, cancel := context.WithTimeout(ctx, lockTimeout)
+		defer cancel()
+
+		unlock, err := tm.locker.Lock(lockCtx, tm.lockKey, lockTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire refresh lock: %w", err)
+		}
+		defer unlock()
+
+		// Another replica may have refreshed while we waited for the
+		// lock; prefer its result over hitting the token endpoint again.
+		if persisted, err := tm.store.Load(tm.lockKey); err == nil && persisted != nil {
+			tm.mu.RLock()
+			alreadyFresh := !tm.shouldRefresh(persisted)
+			tm.mu.RUnlock()
+			if alreadyFresh {
+				tm.mu.Lock()
+				tm.currentToken = persisted
+				tm.computeRefreshTime(persisted)
+				tm.mu.Unlock()
+				tm.notifySubscribers(persisted)
+				return persisted, nil
+			}
+		}
+	}
 
-	// Get new token from token source
+	tm.mu.Lock()
 	token, err := tm.tokenSource.Token()
 	if err != nil {
+		tm.mu.Unlock()
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+	if err := tm.validateFreshToken(token); err != nil {
+		tm.mu.Unlock()
 		return nil, fmt.Errorf("failed to refresh token: %w", err)
 	}
-
 	tm.currentToken = token
+	tm.computeRefreshTime(token)
+	tm.mu.Unlock()
+
+	// Best-effort: losing the persisted copy doesn't invalidate the token
+	// we already have in memory, it just means the next restart re-hits
+	// the token endpoint instead of reusing this rotation. tm.store is
+	// nil for TokenManagers built directly rather than via
+	// NewTokenManager (e.g. in tests), so guard against that too.
+	if tm.store != nil {
+		_ = tm.store.Save(tm.lockKey, token)
+	}
+
+	tm.notifySubscribers(token)
 	return token, nil
 }
 
+// Subscribe registers ch to receive every token this TokenManager obtains,
+// whether from its own refresh or one picked up from another process's
+// rotation via the shared TokenStore, so subsystems like a provider's
+// HTTP client can react to rotation without polling. Sends are
+// non-blocking, so callers should use a buffered channel if they can't
+// guarantee they'll drain it promptly.
+func (tm *TokenManager) Subscribe(ch chan<- *oauth2.Token) {
+	tm.subMu.Lock()
+	defer tm.subMu.Unlock()
+	tm.subscribers = append(tm.subscribers, ch)
+}
+
+func (tm *TokenManager) notifySubscribers(token *oauth2.Token) {
+	tm.subMu.Lock()
+	defer tm.subMu.Unlock()
+	for _, ch := range tm.subscribers {
+		select {
+		case ch <- token:
+		default:
+		}
+	}
+}
+
 // IsTokenValid checks if the current token is valid
 func (tm *TokenManager) IsTokenValid() bool {
 	tm.mu.RLock()
@@ -187,9 +623,33 @@ func (tm *TokenManager) shouldRefresh(token *oauth2.Token) bool {
 		return false // No expiry, assume valid
 	}
 
+	if tm.refreshWindow > 0 && !tm.refreshTime.IsZero() && !time.Now().Before(tm.refreshTime) {
+		return true
+	}
+
 	return time.Now().Add(tm.expiryBuffer).After(expiryTime)
 }
 
+// computeRefreshTime picks token's randomized proactive refresh time when
+// WithRefreshWindow is in effect. Must be called with tm.mu held, every
+// time tm.currentToken changes (initial fetch, refresh, or hydrating from
+// a persisted cache) so the randomization happens once per token rather
+// than being re-rolled on every shouldRefresh check.
+func (tm *TokenManager) computeRefreshTime(token *oauth2.Token) {
+	if tm.refreshWindow <= 0 || token == nil || token.Expiry.IsZero() {
+		tm.refreshTime = time.Time{}
+		return
+	}
+
+	var jitter time.Duration
+	if tm.rng != nil {
+		jitter = time.Duration(tm.rng.Int63n(int64(tm.refreshWindow)))
+	} else {
+		jitter = time.Duration(rand.Int63n(int64(tm.refreshWindow)))
+	}
+	tm.refreshTime = token.Expiry.Add(-jitter)
+}
+
 // GetExpiryTime returns the token expiry time
 func (tm *TokenManager) GetExpiryTime() time.Time {
 	tm.mu.RLock()
@@ -4,6 +4,10 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -95,6 +99,371 @@ func TestTokenManager_GetToken_ExpiredToken(t *testing.T) {
 	}
 }
 
+func TestTokenManager_RevokeToken_ForcesRefresh(t *testing.T) {
+	mockToken := &oauth2.Token{
+		AccessToken: "refreshed-token",
+		Expiry:      time.Now().Add(1 * time.Hour),
+	}
+
+	tm := &TokenManager{
+		tokenSource: &mockTokenSource{token: mockToken},
+		revocation:  NewMemoryRevocationStore(),
+		currentToken: &oauth2.Token{
+			AccessToken: "compromised-token",
+			Expiry:      time.Now().Add(1 * time.Hour), // still well within expiryBuffer
+		},
+		expiryBuffer: 5 * time.Minute,
+	}
+
+	if err := tm.RevokeToken(context.Background(), "compromised-token"); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+
+	token, err := tm.GetToken(context.Background())
+	if err != nil {
+		t.Fatalf("GetToken() error = %v", err)
+	}
+	if token.AccessToken != mockToken.AccessToken {
+		t.Errorf("GetToken() after RevokeToken() = %v, want refreshed token %v", token.AccessToken, mockToken.AccessToken)
+	}
+}
+
+func TestTokenManager_GetToken_IgnoresUnrevokedToken(t *testing.T) {
+	validToken := &oauth2.Token{
+		AccessToken: "still-valid",
+		Expiry:      time.Now().Add(1 * time.Hour),
+	}
+
+	tm := &TokenManager{
+		tokenSource:  &mockTokenSource{token: &oauth2.Token{AccessToken: "should-not-be-used"}},
+		revocation:   NewMemoryRevocationStore(),
+		currentToken: validToken,
+		expiryBuffer: 5 * time.Minute,
+	}
+
+	token, err := tm.GetToken(context.Background())
+	if err != nil {
+		t.Fatalf("GetToken() error = %v", err)
+	}
+	if token.AccessToken != validToken.AccessToken {
+		t.Errorf("GetToken() = %v, want unrevoked cached token %v", token.AccessToken, validToken.AccessToken)
+	}
+}
+
+func TestTokenManager_GetToken_NilRevocationStoreIsSafe(t *testing.T) {
+	validToken := &oauth2.Token{
+		AccessToken: "still-valid",
+		Expiry:      time.Now().Add(1 * time.Hour),
+	}
+
+	tm := &TokenManager{
+		tokenSource:  &mockTokenSource{token: validToken},
+		currentToken: validToken,
+		expiryBuffer: 5 * time.Minute,
+	}
+
+	if _, err := tm.GetToken(context.Background()); err != nil {
+		t.Fatalf("GetToken() with nil revocation store error = %v", err)
+	}
+}
+
+func TestTokenManager_ValidateFreshToken_NegativeExpiresIn(t *testing.T) {
+	tm := &TokenManager{expiryBuffer: 5 * time.Minute}
+
+	// A negative expires_in leaves Expiry already in the past at the
+	// moment the token is issued; this must always be rejected,
+	// regardless of rejectZeroExpiry.
+	negative := &oauth2.Token{
+		AccessToken: "bad-token",
+		Expiry:      time.Now().Add(-1 * time.Second),
+	}
+	if err := tm.validateFreshToken(negative); err == nil {
+		t.Error("validateFreshToken() with negative expires_in = nil, want error")
+	}
+}
+
+func TestTokenManager_ValidateFreshToken_ZeroOrAbsentExpiresIn(t *testing.T) {
+	tests := []struct {
+		name             string
+		rejectZeroExpiry bool
+		wantErr          bool
+	}{
+		{"default accepts as no-expiry", false, false},
+		{"rejected when opted in", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := &TokenManager{expiryBuffer: 5 * time.Minute, rejectZeroExpiry: tt.rejectZeroExpiry}
+			// expires_in unset and expires_in == 0 both surface as a zero
+			// Expiry, so one token covers both cases.
+			token := &oauth2.Token{AccessToken: "no-expiry"}
+
+			err := tm.validateFreshToken(token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateFreshToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTokenManager_RefreshToken_RejectsNegativeExpiresIn(t *testing.T) {
+	tm := &TokenManager{
+		tokenSource: &mockTokenSource{token: &oauth2.Token{
+			AccessToken: "bad-token",
+			Expiry:      time.Now().Add(-1 * time.Minute),
+		}},
+		expiryBuffer: 5 * time.Minute,
+	}
+
+	if _, err := tm.RefreshToken(context.Background()); err == nil {
+		t.Error("RefreshToken() with negative expires_in = nil, want error")
+	}
+}
+
+func TestTokenManager_RefreshWindow_DifferentSeedsPickDifferentRefreshTimes(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+
+	tm1 := &TokenManager{expiryBuffer: 5 * time.Minute, refreshWindow: 10 * time.Minute}
+	WithRandSeed(1)(tm1)
+	tm1.computeRefreshTime(&oauth2.Token{AccessToken: "tok", Expiry: expiry})
+
+	tm2 := &TokenManager{expiryBuffer: 5 * time.Minute, refreshWindow: 10 * time.Minute}
+	WithRandSeed(2)(tm2)
+	tm2.computeRefreshTime(&oauth2.Token{AccessToken: "tok", Expiry: expiry})
+
+	if tm1.refreshTime.Equal(tm2.refreshTime) {
+		t.Errorf("two TokenManagers seeded differently computed the same refreshTime %v", tm1.refreshTime)
+	}
+	for _, rt := range []time.Time{tm1.refreshTime, tm2.refreshTime} {
+		if rt.After(expiry) || rt.Before(expiry.Add(-10*time.Minute)) {
+			t.Errorf("refreshTime %v not within [expiry-window, expiry] = [%v, %v]", rt, expiry.Add(-10*time.Minute), expiry)
+		}
+	}
+}
+
+func TestTokenManager_RefreshWindow_SameSeedPicksSameRefreshTime(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+
+	tm1 := &TokenManager{expiryBuffer: 5 * time.Minute, refreshWindow: 10 * time.Minute}
+	WithRandSeed(42)(tm1)
+	tm1.computeRefreshTime(&oauth2.Token{AccessToken: "tok", Expiry: expiry})
+
+	tm2 := &TokenManager{expiryBuffer: 5 * time.Minute, refreshWindow: 10 * time.Minute}
+	WithRandSeed(42)(tm2)
+	tm2.computeRefreshTime(&oauth2.Token{AccessToken: "tok", Expiry: expiry})
+
+	if !tm1.refreshTime.Equal(tm2.refreshTime) {
+		t.Errorf("two TokenManagers seeded identically computed different refreshTimes: %v vs %v", tm1.refreshTime, tm2.refreshTime)
+	}
+}
+
+func TestTokenManager_GetToken_RefreshesOncePastRandomizedWindow(t *testing.T) {
+	mockToken := &oauth2.Token{
+		AccessToken: "refreshed-token",
+		Expiry:      time.Now().Add(time.Hour),
+	}
+
+	tm := &TokenManager{
+		tokenSource:   &mockTokenSource{token: mockToken},
+		expiryBuffer:  5 * time.Minute,
+		refreshWindow: time.Minute,
+		currentToken: &oauth2.Token{
+			AccessToken: "still-technically-valid",
+			Expiry:      time.Now().Add(10 * time.Minute), // outside expiryBuffer
+		},
+	}
+	// Force the randomized refresh time into the past, simulating that
+	// it has now elapsed even though expiryBuffer alone would not yet
+	// trigger a refresh.
+	tm.refreshTime = time.Now().Add(-time.Second)
+
+	token, err := tm.GetToken(context.Background())
+	if err != nil {
+		t.Fatalf("GetToken() error = %v", err)
+	}
+	if token.AccessToken != mockToken.AccessToken {
+		t.Errorf("GetToken() = %v, want refresh triggered by elapsed refreshTime -> %v", token.AccessToken, mockToken.AccessToken)
+	}
+}
+
+func TestNewTokenManager_RestartHydratesFromTokenStoreWithoutContactingSource(t *testing.T) {
+	var tokenRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"first-token","refresh_token":"rt","expires_in":3600,"token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	creds, _ := json.Marshal(map[string]string{
+		"client_id":     "client-1",
+		"client_secret": "secret",
+		"token_url":     server.URL,
+		"refresh_token": "initial-refresh-token",
+	})
+	config := &models.ProviderConfig{
+		Provider:         models.ProviderKiro,
+		OAuthCredsBase64: base64.StdEncoding.EncodeToString(creds),
+	}
+
+	store := NewMemoryTokenStore()
+
+	tm1, err := NewTokenManager(config, WithTokenStore(store))
+	if err != nil {
+		t.Fatalf("NewTokenManager() (first instance) error = %v", err)
+	}
+	if tokenRequests != 1 {
+		t.Fatalf("tokenRequests after first NewTokenManager = %d, want 1 (initial fetch)", tokenRequests)
+	}
+	if tm1.currentToken.AccessToken != "first-token" {
+		t.Fatalf("tm1.currentToken.AccessToken = %v, want first-token", tm1.currentToken.AccessToken)
+	}
+
+	// Simulate a process restart: a fresh TokenManager built against the
+	// same (shared) TokenStore should pick up the persisted token and
+	// never call the token endpoint, since it's still well within its
+	// expiry.
+	tm2, err := NewTokenManager(config, WithTokenStore(store))
+	if err != nil {
+		t.Fatalf("NewTokenManager() (restarted instance) error = %v", err)
+	}
+	if tokenRequests != 1 {
+		t.Errorf("tokenRequests after restart = %d, want still 1 (no new fetch)", tokenRequests)
+	}
+	if tm2.currentToken.AccessToken != "first-token" {
+		t.Errorf("tm2.currentToken.AccessToken = %v, want hydrated first-token", tm2.currentToken.AccessToken)
+	}
+
+	token, err := tm2.GetToken(context.Background())
+	if err != nil {
+		t.Fatalf("tm2.GetToken() error = %v", err)
+	}
+	if token.AccessToken != "first-token" {
+		t.Errorf("tm2.GetToken() = %v, want first-token (no refresh until expiry)", token.AccessToken)
+	}
+	if tokenRequests != 1 {
+		t.Errorf("tokenRequests after GetToken() = %d, want still 1", tokenRequests)
+	}
+}
+
+// newSTSTestServer fakes the Google STS token-exchange endpoint that
+// externalaccount.NewTokenSource's resulting TokenSource calls under the
+// hood, so tests can exercise the external_account path without a real
+// workload identity pool.
+func newSTSTestServer(t *testing.T, expiresIn int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		resp := map[string]interface{}{
+			"access_token":      "sts-exchanged-token",
+			"issued_token_type": "urn:ietf:params:oauth:token-type:access_token",
+			"token_type":        "Bearer",
+		}
+		if expiresIn != 0 {
+			resp["expires_in"] = expiresIn
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func writeSubjectTokenFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "subject-token")
+	if err := os.WriteFile(path, []byte("fake-oidc-subject-token"), 0o600); err != nil {
+		t.Fatalf("failed to write subject token file: %v", err)
+	}
+	return path
+}
+
+func TestNewTokenManager_ExternalAccountCredentialsBlob_BuildsTokenSource(t *testing.T) {
+	sts := newSTSTestServer(t, 3600)
+	defer sts.Close()
+
+	subjectTokenPath := writeSubjectTokenFile(t)
+
+	creds, _ := json.Marshal(map[string]interface{}{
+		"type":               "external_account",
+		"audience":           "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/github",
+		"subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+		"token_url":          sts.URL,
+		"credential_source": map[string]string{
+			"file": subjectTokenPath,
+		},
+	})
+
+	config := &models.ProviderConfig{
+		Provider:         models.ProviderGemini,
+		OAuthCredsBase64: base64.StdEncoding.EncodeToString(creds),
+	}
+
+	tm, err := NewTokenManager(config)
+	if err != nil {
+		t.Fatalf("NewTokenManager() with external_account creds error = %v", err)
+	}
+
+	token, err := tm.GetToken(context.Background())
+	if err != nil {
+		t.Fatalf("GetToken() error = %v", err)
+	}
+	if token.AccessToken != "sts-exchanged-token" {
+		t.Errorf("GetToken().AccessToken = %v, want sts-exchanged-token", token.AccessToken)
+	}
+}
+
+func TestNewTokenManager_WorkloadIdentityFields_BuildsTokenSource(t *testing.T) {
+	sts := newSTSTestServer(t, 3600)
+	defer sts.Close()
+
+	subjectTokenPath := writeSubjectTokenFile(t)
+
+	// The STS endpoint is only configurable through a full credentials
+	// blob, not through the WorkloadIdentity* fields (which always target
+	// the real sts.googleapis.com), so this exercises
+	// initializeExternalAccountTokenSource's config assembly directly
+	// rather than a full NewTokenManager round trip.
+	tm := &TokenManager{
+		config: &models.ProviderConfig{
+			Provider:                             models.ProviderGemini,
+			WorkloadIdentityAudience:              "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/github",
+			WorkloadIdentitySubjectTokenType:      "urn:ietf:params:oauth:token-type:jwt",
+			WorkloadIdentityCredentialSourceFile:  subjectTokenPath,
+		},
+		expiryBuffer: 5 * time.Minute,
+	}
+
+	if err := tm.initializeTokenSource(context.Background()); err != nil {
+		t.Fatalf("initializeTokenSource() error = %v", err)
+	}
+	if tm.tokenSource == nil {
+		t.Fatal("initializeTokenSource() left tm.tokenSource nil")
+	}
+	if tm.lockKey != "gemini:"+tm.config.WorkloadIdentityAudience {
+		t.Errorf("lockKey = %v, want gemini:%v", tm.lockKey, tm.config.WorkloadIdentityAudience)
+	}
+}
+
+func TestGeminiCredentialIdentity(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"service_account", `{"type":"service_account","client_email":"sa@project.iam.gserviceaccount.com"}`, "sa@project.iam.gserviceaccount.com"},
+		{"external_account_impersonated", `{"type":"external_account","audience":"//iam.googleapis.com/projects/123/pool","service_account_impersonation_url":"https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/sa@project.iam.gserviceaccount.com:generateAccessToken"}`, "https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/sa@project.iam.gserviceaccount.com:generateAccessToken"},
+		{"external_account_no_impersonation", `{"type":"external_account","audience":"//iam.googleapis.com/projects/123/pool"}`, "//iam.googleapis.com/projects/123/pool"},
+		{"malformed", `not json`, "gemini"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := geminiCredentialIdentity([]byte(tt.json)); got != tt.want {
+				t.Errorf("geminiCredentialIdentity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestNewTokenManager_InvalidCredentials(t *testing.T) {
 	config := &models.ProviderConfig{
 		Provider: models.ProviderGemini,
@@ -136,6 +505,59 @@ func TestNewTokenManager_WithBase64Credentials(t *testing.T) {
 	}
 }
 
+func TestTokenManager_Token_ReusesUnexpiredToken(t *testing.T) {
+	mockToken := &oauth2.Token{
+		AccessToken: "still-valid",
+		Expiry:      time.Now().Add(time.Hour),
+	}
+
+	tm := &TokenManager{
+		tokenSource:  &mockTokenSource{err: context.DeadlineExceeded},
+		expiryBuffer: 5 * time.Minute,
+		currentToken: mockToken,
+	}
+	tm.reuseSource = oauth2.ReuseTokenSource(mockToken, refreshFunc(func() (*oauth2.Token, error) {
+		return tm.RefreshToken(context.Background())
+	}))
+
+	token, err := tm.Token()
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if token.AccessToken != mockToken.AccessToken {
+		t.Errorf("Token() = %v, want cached token %v (should not have refreshed)", token.AccessToken, mockToken.AccessToken)
+	}
+}
+
+func TestTokenManager_NextAutoRefresh_FallsBackWithoutExpiry(t *testing.T) {
+	tm := &TokenManager{
+		expiryBuffer: 5 * time.Minute,
+		currentToken: &oauth2.Token{AccessToken: "no-expiry"},
+	}
+
+	if got := tm.nextAutoRefresh(); got != autoRefreshFallback {
+		t.Errorf("nextAutoRefresh() = %v, want fallback %v", got, autoRefreshFallback)
+	}
+}
+
+func TestTokenManager_StartAutoRefresh_CloseStopsLoop(t *testing.T) {
+	tm := &TokenManager{
+		config:       &models.ProviderConfig{Provider: models.ProviderGemini},
+		expiryBuffer: 5 * time.Minute,
+		currentToken: &oauth2.Token{AccessToken: "tok", Expiry: time.Now().Add(time.Hour)},
+		tokenSource:  &mockTokenSource{token: &oauth2.Token{AccessToken: "tok", Expiry: time.Now().Add(time.Hour)}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tm.StartAutoRefresh(ctx)
+	tm.Close()
+	cancel()
+
+	// StartAutoRefresh must be idempotent: calling it again should not
+	// panic or start a second goroutine.
+	tm.StartAutoRefresh(context.Background())
+}
+
 func BenchmarkTokenManager_GetToken(b *testing.B) {
 	mockToken := &oauth2.Token{
 		AccessToken: "test-token",
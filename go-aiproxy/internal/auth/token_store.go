@@ -0,0 +1,331 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/oauth2"
+)
+
+// NewTokenStoreFromConfig builds the TokenStore named by cfg.TokenStoreType,
+// the convention providers that call auth.NewTokenManager use instead of
+// constructing a store by hand. An unset TokenStoreType defaults to
+// memory-only, matching TokenManager's own behavior when no store is
+// supplied at all.
+func NewTokenStoreFromConfig(cfg *models.ProviderConfig) (TokenStore, error) {
+	switch cfg.TokenStoreType {
+	case "", "memory":
+		return NewMemoryTokenStore(), nil
+	case "file":
+		return NewFileTokenStore(cfg.TokenStoreDir, cfg.TokenStoreEncKeyBase64)
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.TokenStoreRedisAddr,
+			Password: cfg.TokenStoreRedisPassword,
+			DB:       cfg.TokenStoreRedisDB,
+		})
+		return NewRedisTokenStore(client, "aiproxy:"), nil
+	default:
+		return nil, fmt.Errorf("token store: unknown type %q", cfg.TokenStoreType)
+	}
+}
+
+// TokenStore persists an OAuth token across process restarts, keyed by an
+// opaque string the caller controls (conventionally "<provider>:<client_id>").
+// Load returns (nil, nil) when no token has been persisted for key yet. This
+// doubles as TokenManager's persistent token cache: NewTokenManager hydrates
+// currentToken from it on startup and only falls through to the token
+// endpoint if nothing still-valid comes back, and RefreshToken writes every
+// successful refresh back through it.
+type TokenStore interface {
+	Load(key string) (*oauth2.Token, error)
+	Save(key string, token *oauth2.Token) error
+}
+
+// TokenLocker coordinates refreshes across processes/replicas so that only
+// one holder refreshes a given key at a time; others should call Load again
+// after Lock returns, since the holder that ran first likely persisted a
+// fresh token.
+type TokenLocker interface {
+	// Lock blocks until the lock for key is acquired or ctx is done, then
+	// returns an unlock function the caller must call to release it. ttl
+	// bounds how long the lock is held if the caller crashes before
+	// unlocking.
+	Lock(ctx context.Context, key string, ttl time.Duration) (unlock func(), err error)
+}
+
+// MemoryTokenStore keeps tokens in memory only, the TokenManager's
+// original behavior before persistence was added. It also implements
+// TokenLocker with a plain per-key mutex, since there's only ever one
+// process to coordinate within.
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]*oauth2.Token
+	locks  map[string]*sync.Mutex
+}
+
+// NewMemoryTokenStore creates an in-memory TokenStore/TokenLocker.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		tokens: make(map[string]*oauth2.Token),
+		locks:  make(map[string]*sync.Mutex),
+	}
+}
+
+func (s *MemoryTokenStore) Load(key string) (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[key], nil
+}
+
+func (s *MemoryTokenStore) Save(key string, token *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[key] = token
+	return nil
+}
+
+func (s *MemoryTokenStore) Lock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	s.mu.Lock()
+	l, ok := s.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		s.locks[key] = l
+	}
+	s.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock, nil
+}
+
+// FileTokenStore persists tokens as AES-GCM encrypted files under Dir,
+// one file per key, with the encryption key taken from the environment
+// (or an external KMS, via whatever populates that env var before this
+// process starts). Cross-process refresh coordination uses a sibling
+// ".lock" file created with O_EXCL and a retry loop, since file locking
+// needs to work without assuming flock is available on every platform
+// this proxy is deployed to.
+type FileTokenStore struct {
+	dir       string
+	encKey    []byte
+	pollEvery time.Duration
+}
+
+// NewFileTokenStore creates a FileTokenStore rooted at dir (created if it
+// doesn't exist) using encKeyBase64 (32 raw bytes, base64-encoded) as the
+// AES-256-GCM key.
+func NewFileTokenStore(dir string, encKeyBase64 string) (*FileTokenStore, error) {
+	key, err := base64.StdEncoding.DecodeString(encKeyBase64)
+	if err != nil {
+		return nil, fmt.Errorf("token store: invalid encryption key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("token store: encryption key must decode to 32 bytes, got %d", len(key))
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("token store: failed to create %s: %w", dir, err)
+	}
+	return &FileTokenStore{dir: dir, encKey: key, pollEvery: 100 * time.Millisecond}, nil
+}
+
+func (s *FileTokenStore) path(key string) string {
+	return filepath.Join(s.dir, sanitizeKey(key)+".token")
+}
+
+func (s *FileTokenStore) Load(key string) (*oauth2.Token, error) {
+	data, err := os.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("token store: failed to read %s: %w", key, err)
+	}
+
+	plaintext, err := decryptAESGCM(s.encKey, data)
+	if err != nil {
+		return nil, fmt.Errorf("token store: failed to decrypt %s: %w", key, err)
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal(plaintext, &token); err != nil {
+		return nil, fmt.Errorf("token store: failed to unmarshal %s: %w", key, err)
+	}
+	return &token, nil
+}
+
+func (s *FileTokenStore) Save(key string, token *oauth2.Token) error {
+	plaintext, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("token store: failed to marshal token: %w", err)
+	}
+
+	ciphertext, err := encryptAESGCM(s.encKey, plaintext)
+	if err != nil {
+		return fmt.Errorf("token store: failed to encrypt token: %w", err)
+	}
+
+	tmp := s.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, ciphertext, 0o600); err != nil {
+		return fmt.Errorf("token store: failed to write %s: %w", key, err)
+	}
+	return os.Rename(tmp, s.path(key))
+}
+
+// Lock acquires an exclusive lock for key by creating a lock file with
+// O_EXCL, retrying until ctx is done. A lock file older than ttl is
+// treated as abandoned (the holder crashed without cleaning up) and is
+// removed so a new holder can proceed.
+func (s *FileTokenStore) Lock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	lockPath := s.path(key) + ".lock"
+
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("token store: failed to create lock %s: %w", lockPath, err)
+		}
+
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > ttl {
+			os.Remove(lockPath)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("token store: timed out waiting for lock on %s: %w", key, ctx.Err())
+		case <-time.After(s.pollEvery):
+		}
+	}
+}
+
+func sanitizeKey(key string) string {
+	out := make([]byte, len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+			out[i] = c
+		default:
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
+
+func encryptAESGCM(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func decryptAESGCM(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// RedisTokenStore persists tokens in Redis so every replica behind the
+// same RedisAddr shares one copy, and uses Redis's SET NX PX as a
+// distributed lock so only one replica refreshes a given key at a time.
+type RedisTokenStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisTokenStore creates a RedisTokenStore against an already-dialed
+// client, reusing the same *redis.Client conventions as cache.RedisCache.
+func NewRedisTokenStore(client *redis.Client, prefix string) *RedisTokenStore {
+	return &RedisTokenStore{client: client, prefix: prefix}
+}
+
+func (s *RedisTokenStore) key(key string) string {
+	return s.prefix + "token:" + key
+}
+
+func (s *RedisTokenStore) Load(key string) (*oauth2.Token, error) {
+	ctx := context.Background()
+	val, err := s.client.Get(ctx, s.key(key)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("token store: failed to load %s from Redis: %w", key, err)
+	}
+
+	var token oauth2.Token
+	if err := json.Unmarshal([]byte(val), &token); err != nil {
+		return nil, fmt.Errorf("token store: failed to unmarshal %s: %w", key, err)
+	}
+	return &token, nil
+}
+
+func (s *RedisTokenStore) Save(key string, token *oauth2.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("token store: failed to marshal token: %w", err)
+	}
+	return s.client.Set(context.Background(), s.key(key), data, 0).Err()
+}
+
+// Lock acquires a distributed lock via SET NX PX, polling until it
+// succeeds or ctx is done.
+func (s *RedisTokenStore) Lock(ctx context.Context, key string, ttl time.Duration) (func(), error) {
+	lockKey := s.key(key) + ":lock"
+	token := fmt.Sprintf("%d", time.Now().UnixNano())
+
+	for {
+		ok, err := s.client.SetNX(ctx, lockKey, token, ttl).Result()
+		if err != nil {
+			return nil, fmt.Errorf("token store: failed to acquire lock for %s: %w", key, err)
+		}
+		if ok {
+			return func() {
+				// Best-effort release; a stale lock still expires via ttl.
+				if cur, err := s.client.Get(context.Background(), lockKey).Result(); err == nil && cur == token {
+					s.client.Del(context.Background(), lockKey)
+				}
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("token store: timed out waiting for lock on %s: %w", key, ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestMemoryTokenStore_SaveAndLoad(t *testing.T) {
+	store := NewMemoryTokenStore()
+
+	if token, err := store.Load("missing"); err != nil || token != nil {
+		t.Fatalf("Load(missing) = %v, %v, want nil, nil", token, err)
+	}
+
+	want := &oauth2.Token{AccessToken: "abc", RefreshToken: "refresh"}
+	if err := store.Save("key", want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := store.Load("key")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got.AccessToken != want.AccessToken || got.RefreshToken != want.RefreshToken {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMemoryTokenStore_LockSerializesByKey(t *testing.T) {
+	store := NewMemoryTokenStore()
+
+	unlockA, err := store.Lock(context.Background(), "k", time.Second)
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		unlockB, err := store.Lock(context.Background(), "k", time.Second)
+		if err != nil {
+			t.Errorf("second Lock() error = %v", err)
+			return
+		}
+		close(acquired)
+		unlockB()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second Lock() acquired before first was released")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlockA()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second Lock() never acquired after release")
+	}
+}
+
+func TestFileTokenStore_SaveAndLoadRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tokens")
+	key := "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=" // 32 raw bytes, base64
+
+	store, err := NewFileTokenStore(dir, key)
+	if err != nil {
+		t.Fatalf("NewFileTokenStore() error = %v", err)
+	}
+
+	want := &oauth2.Token{AccessToken: "abc", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}
+	if err := store.Save("provider:client", want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := store.Load("provider:client")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got.AccessToken != want.AccessToken || got.RefreshToken != want.RefreshToken {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFileTokenStore_LoadMissingReturnsNil(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tokens")
+	key := "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+	store, err := NewFileTokenStore(dir, key)
+	if err != nil {
+		t.Fatalf("NewFileTokenStore() error = %v", err)
+	}
+
+	token, err := store.Load("nope")
+	if err != nil || token != nil {
+		t.Fatalf("Load(nope) = %v, %v, want nil, nil", token, err)
+	}
+}
+
+func TestFileTokenStore_LockIsExclusiveAndReleasable(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tokens")
+	key := "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+	store, err := NewFileTokenStore(dir, key)
+	if err != nil {
+		t.Fatalf("NewFileTokenStore() error = %v", err)
+	}
+
+	unlock, err := store.Lock(context.Background(), "k", time.Second)
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := store.Lock(ctx, "k", time.Second); err == nil {
+		t.Fatal("expected second Lock() to time out while first is held")
+	}
+
+	unlock()
+
+	unlock2, err := store.Lock(context.Background(), "k", time.Second)
+	if err != nil {
+		t.Fatalf("Lock() after release error = %v", err)
+	}
+	unlock2()
+}
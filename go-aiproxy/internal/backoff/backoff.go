@@ -0,0 +1,55 @@
+// Package backoff implements exponential backoff with jitter, in the style
+// used by gRPC and similar connection-management libraries, so that retrying
+// subsystems (provider pools, load balancer health checks, ...) don't
+// stampede a recovering provider.
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff computes the delay before the next retry attempt.
+type Backoff struct {
+	BaseDelay time.Duration
+	Factor    float64
+	MaxDelay  time.Duration
+	Jitter    float64
+}
+
+// Default returns the backoff policy used by provider health probes unless
+// overridden: 1s base delay, 1.6x growth, capped at 120s, +/-20% jitter.
+func Default() Backoff {
+	return Backoff{
+		BaseDelay: time.Second,
+		Factor:    1.6,
+		MaxDelay:  120 * time.Second,
+		Jitter:    0.2,
+	}
+}
+
+// Next returns the delay to wait before the next attempt, given the number
+// of consecutive failures observed so far. The delay grows exponentially up
+// to MaxDelay and is perturbed by +/-Jitter to avoid concurrent retries
+// stampeding the same provider at the same instant.
+func (b Backoff) Next(consecutiveFailures int) time.Duration {
+	if consecutiveFailures < 0 {
+		consecutiveFailures = 0
+	}
+
+	delay := float64(b.BaseDelay) * math.Pow(b.Factor, float64(consecutiveFailures))
+	if max := float64(b.MaxDelay); b.MaxDelay > 0 && delay > max {
+		delay = max
+	}
+
+	if b.Jitter > 0 {
+		delay *= 1 + b.Jitter*rand.Float64()*2 - b.Jitter
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+
+	return time.Duration(delay)
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/patrickmn/go-cache"
+	"golang.org/x/sync/singleflight"
 )
 
 // CacheManager manages request/response caching
@@ -20,14 +21,56 @@ type CacheManager struct {
 	enabled     bool
 	maxSize     int64
 	currentSize int64
+	defaultTTL  time.Duration
+	modelTTL    map[string]time.Duration
+	semantic    *SemanticIndex
+
+	// compute coalesces concurrent GetOrCompute calls for the same key into
+	// a single call to fn, so a stampede of identical requests for a
+	// not-yet-cached prompt reaches the provider once instead of N times.
+	compute singleflight.Group
+
+	// streamCacheModels is the set of models opted in to StreamRecorder
+	// replay via EnableStreamCacheForModel; see stream.go.
+	streamCacheModels map[string]bool
+
+	// metricsHook, if set via SetMetricsHook, is called after each
+	// GetSemantic and GetStream lookup with the cache kind ("exact",
+	// "semantic", or "stream") and whether it hit; plain Get and
+	// GetOrCompute do not report to it. This lets an external metrics package (internal/observability)
+	// observe cache behavior without CacheManager importing it directly,
+	// the same pattern websocket.Hub.SetMetricsCallback uses.
+	metricsHook func(kind string, hit bool)
+}
+
+// SetMetricsHook installs hook to be called after each GetSemantic and
+// GetStream lookup.
+func (cm *CacheManager) SetMetricsHook(hook func(kind string, hit bool)) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.metricsHook = hook
+}
+
+// reportHit calls the installed metrics hook, if any, for a lookup of the
+// given kind.
+func (cm *CacheManager) reportHit(kind string, hit bool) {
+	cm.mu.RLock()
+	hook := cm.metricsHook
+	cm.mu.RUnlock()
+
+	if hook != nil {
+		hook(kind, hit)
+	}
 }
 
 // CacheStats tracks cache performance
 type CacheStats struct {
-	Hits       int64
-	Misses     int64
-	Evictions  int64
-	TotalBytes int64
+	Hits         int64
+	Misses       int64
+	Evictions    int64
+	TotalBytes   int64
+	SemanticHits int64
 }
 
 // CacheEntry represents a cached item
@@ -43,13 +86,37 @@ type CacheEntry struct {
 // NewCacheManager creates a new cache manager
 func NewCacheManager(defaultExpiration, cleanupInterval time.Duration, maxSizeMB int64) *CacheManager {
 	return &CacheManager{
-		cache:   cache.New(defaultExpiration, cleanupInterval),
-		stats:   &CacheStats{},
-		enabled: true,
-		maxSize: maxSizeMB * 1024 * 1024, // Convert MB to bytes
+		cache:      cache.New(defaultExpiration, cleanupInterval),
+		stats:      &CacheStats{},
+		enabled:    true,
+		maxSize:    maxSizeMB * 1024 * 1024, // Convert MB to bytes
+		defaultTTL: defaultExpiration,
+		modelTTL:   make(map[string]time.Duration),
 	}
 }
 
+// SetModelTTL overrides the cache TTL used for a specific model. Models
+// that change frequently (e.g. fast-moving preview releases) can be given
+// a shorter TTL than cm's default without affecting every other model.
+func (cm *CacheManager) SetModelTTL(model string, ttl time.Duration) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.modelTTL[model] = ttl
+}
+
+// TTLForModel returns the configured TTL for model, falling back to cm's
+// default expiration when no override is set.
+func (cm *CacheManager) TTLForModel(model string) time.Duration {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	if ttl, ok := cm.modelTTL[model]; ok {
+		return ttl
+	}
+	return cm.defaultTTL
+}
+
 // GenerateCacheKey creates a unique cache key from request data
 func (cm *CacheManager) GenerateCacheKey(provider, model string, request interface{}) (string, error) {
 	// Serialize request to JSON for consistent hashing
@@ -252,6 +319,17 @@ func (m *CacheMiddleware) ShouldCache(method, path string, isStream bool) bool {
 	return false
 }
 
+// ShouldCacheStream reports whether a streaming POST to path is eligible
+// for StreamRecorder-based caching. It mirrors ShouldCache's POST branch
+// without the !isStream restriction: streaming itself is handled by
+// recording chunks rather than the whole response, so it is a separate
+// opt-in path gated per-model by CacheManager.StreamCacheEnabledFor rather
+// than by ShouldCache.
+func (m *CacheMiddleware) ShouldCacheStream(method, path string) bool {
+	return method == "POST" && (strings.Contains(path, "/completions") ||
+		strings.Contains(path, "/generateContent"))
+}
+
 // CacheDuration returns the cache duration for different request types
 func (m *CacheMiddleware) CacheDuration(path string) time.Duration {
 	// Model listings can be cached longer
@@ -0,0 +1,31 @@
+package cache
+
+import "time"
+
+// GetOrCompute returns the cached value for key if present, otherwise
+// calls fn to produce it, caches the result for ttl, and returns it.
+// Concurrent calls for the same key are coalesced via singleflight.Group
+// so only one of them actually calls fn - the rest block and receive its
+// result - instead of every one of N identical in-flight requests for an
+// uncached prompt reaching the provider independently.
+func (cm *CacheManager) GetOrCompute(key string, ttl time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	if value, found := cm.Get(key); found {
+		return value, nil
+	}
+
+	value, err, _ := cm.compute.Do(key, func() (interface{}, error) {
+		if value, found := cm.Get(key); found {
+			return value, nil
+		}
+
+		value, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		if err := cm.Set(key, value, ttl); err != nil {
+			return nil, err
+		}
+		return value, nil
+	})
+	return value, err
+}
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheManager_GetOrCompute_CoalescesConcurrentCalls(t *testing.T) {
+	cm := NewCacheManager(5*time.Minute, 10*time.Minute, 100)
+
+	var calls int64
+	var wg sync.WaitGroup
+	results := make([]interface{}, 20)
+	errs := make([]error, 20)
+
+	start := make(chan struct{})
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			results[i], errs[i] = cm.GetOrCompute("key", time.Minute, func() (interface{}, error) {
+				atomic.AddInt64(&calls, 1)
+				time.Sleep(10 * time.Millisecond)
+				return "computed-value", nil
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("expected fn to be called exactly once, got %d calls", calls)
+	}
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("result %d: unexpected error %v", i, err)
+		}
+		if results[i] != "computed-value" {
+			t.Errorf("result %d: expected %q, got %v", i, "computed-value", results[i])
+		}
+	}
+}
+
+func TestCacheManager_GetOrCompute_UsesCachedValue(t *testing.T) {
+	cm := NewCacheManager(5*time.Minute, 10*time.Minute, 100)
+	cm.Set("key", "cached-value", time.Minute)
+
+	value, err := cm.GetOrCompute("key", time.Minute, func() (interface{}, error) {
+		return nil, fmt.Errorf("fn should not be called for an already-cached key")
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "cached-value" {
+		t.Errorf("expected cached-value, got %v", value)
+	}
+}
+
+func TestCacheManager_GetOrCompute_PropagatesError(t *testing.T) {
+	cm := NewCacheManager(5*time.Minute, 10*time.Minute, 100)
+
+	wantErr := fmt.Errorf("upstream failed")
+	_, err := cm.GetOrCompute("key", time.Minute, func() (interface{}, error) {
+		return nil, wantErr
+	})
+	if err != wantErr {
+		t.Fatalf("expected %v, got %v", wantErr, err)
+	}
+	if _, found := cm.Get("key"); found {
+		t.Error("a failed compute should not populate the cache")
+	}
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/aiproxy/go-aiproxy/internal/convert"
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+// GenerateCanonicalCacheKey hashes req's canonicalized form (see
+// convert.Converter.CanonicalizeRequest) rather than its raw protocol
+// shape, so the same logical conversation hits the same cache entry
+// whether it arrived as an OpenAI, Claude, or Gemini request.
+func (cm *CacheManager) GenerateCanonicalCacheKey(converter convert.Converter, req interface{}, fromProtocol models.ProtocolPrefix) (string, error) {
+	canonical, err := converter.CanonicalizeRequest(req, fromProtocol)
+	if err != nil {
+		return "", fmt.Errorf("failed to canonicalize request for cache key: %w", err)
+	}
+
+	hash := md5.Sum(canonical)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// ShouldUseSemanticKey reports whether a request should be keyed on its
+// canonicalized content rather than an exact byte match: either the
+// caller asked for it explicitly via "X-Cache: semantic", or the request
+// is already deterministic (an explicit temperature of 0 means the same
+// conversation always produces the same completion, so reuse is safe by
+// default). temperature is nil when the request omitted the field
+// entirely, which is not the same thing as asking for 0: the caller is
+// expecting the provider's own, usually non-deterministic, default, so a
+// nil temperature never qualifies on its own.
+func ShouldUseSemanticKey(temperature *float64, cacheHeader string) bool {
+	return cacheHeader == "semantic" || (temperature != nil && *temperature == 0)
+}
+
+// Mode identifies how a single request opts into (or out of) the response
+// cache, via the X-Cache-Mode header - distinct from ShouldUseSemanticKey's
+// X-Cache header, which only controls canonical cache-key generation.
+type Mode string
+
+const (
+	// ModeExact matches only an identical previously cached request; an
+	// embedding-similarity fallback is never consulted.
+	ModeExact Mode = "exact"
+	// ModeSemantic allows an embedding-similarity match when no exact
+	// entry exists, subject to SemanticIndex's own threshold and
+	// temperature cutoff. This is the default.
+	ModeSemantic Mode = "semantic"
+	// ModeBypass skips the cache entirely: neither read nor write.
+	ModeBypass Mode = "bypass"
+)
+
+// ParseMode reads an X-Cache-Mode header value, defaulting to ModeSemantic
+// for an absent or unrecognized value since that's today's existing
+// exact-then-semantic lookup behavior.
+func ParseMode(header string) Mode {
+	switch Mode(header) {
+	case ModeExact, ModeBypass:
+		return Mode(header)
+	default:
+		return ModeSemantic
+	}
+}
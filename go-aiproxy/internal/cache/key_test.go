@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/aiproxy/go-aiproxy/internal/convert"
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+func TestCacheManager_GenerateCanonicalCacheKey_SameAcrossProtocols(t *testing.T) {
+	cm := NewCacheManager(5*60, 10*60, 100)
+	converter := convert.NewConverter()
+
+	openaiReq := &models.OpenAIRequest{
+		Model: "gpt-4o",
+		Messages: []models.OpenAIMessage{
+			{Role: "user", Content: "hello there"},
+		},
+	}
+	claudeReq := &models.ClaudeRequest{
+		Model: "gpt-4o",
+		Messages: []models.ClaudeMessage{
+			{Role: "user", Content: []models.ClaudeContent{{Type: "text", Text: "hello there"}}},
+		},
+	}
+
+	openaiKey, err := cm.GenerateCanonicalCacheKey(converter, openaiReq, models.ProtocolOpenAI)
+	if err != nil {
+		t.Fatalf("GenerateCanonicalCacheKey(openai) error: %v", err)
+	}
+
+	claudeKey, err := cm.GenerateCanonicalCacheKey(converter, claudeReq, models.ProtocolClaude)
+	if err != nil {
+		t.Fatalf("GenerateCanonicalCacheKey(claude) error: %v", err)
+	}
+
+	if openaiKey != claudeKey {
+		t.Errorf("expected equivalent requests from different protocols to hash the same, got %s vs %s", openaiKey, claudeKey)
+	}
+}
+
+func TestShouldUseSemanticKey(t *testing.T) {
+	zero := 0.0
+	nonzero := 0.7
+
+	cases := []struct {
+		name        string
+		temperature *float64
+		cacheHeader string
+		want        bool
+	}{
+		{"explicit zero temperature", &zero, "", true},
+		{"omitted temperature no header", nil, "", false},
+		{"omitted temperature explicit semantic", nil, "semantic", true},
+		{"nonzero temperature no header", &nonzero, "", false},
+		{"nonzero temperature explicit semantic", &nonzero, "semantic", true},
+		{"nonzero temperature unrelated header", &nonzero, "exact", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ShouldUseSemanticKey(tc.temperature, tc.cacheHeader)
+			if got != tc.want {
+				t.Errorf("ShouldUseSemanticKey(%v, %q) = %v, want %v", tc.temperature, tc.cacheHeader, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	cases := []struct {
+		header string
+		want   Mode
+	}{
+		{"exact", ModeExact},
+		{"semantic", ModeSemantic},
+		{"bypass", ModeBypass},
+		{"", ModeSemantic},
+		{"garbage", ModeSemantic},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.header, func(t *testing.T) {
+			if got := ParseMode(tc.header); got != tc.want {
+				t.Errorf("ParseMode(%q) = %v, want %v", tc.header, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCacheManager_TTLForModel(t *testing.T) {
+	cm := NewCacheManager(5*60, 10*60, 100)
+
+	cm.SetModelTTL("gpt-4o", 2*60)
+
+	if got := cm.TTLForModel("gpt-4o"); got != 2*60 {
+		t.Errorf("expected overridden TTL, got %v", got)
+	}
+
+	if got := cm.TTLForModel("unconfigured-model"); got != cm.defaultTTL {
+		t.Errorf("expected default TTL for unconfigured model, got %v", got)
+	}
+}
@@ -2,36 +2,95 @@ package cache
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"math"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/aiproxy/go-aiproxy/internal/metrics"
 	"github.com/go-redis/redis/v8"
 )
 
+// RedisMode selects which go-redis client constructor backs a RedisCache.
+type RedisMode string
+
+const (
+	RedisModeStandalone RedisMode = "standalone"
+	RedisModeSentinel   RedisMode = "sentinel"
+	RedisModeCluster    RedisMode = "cluster"
+)
+
 // RedisCache implements distributed caching using Redis
 type RedisCache struct {
-	client  *redis.Client
+	client redis.UniversalClient
+
+	// cluster is non-nil only when Mode is RedisModeCluster, and is the
+	// same value as client under a concrete type. Clear/GetStats type-check
+	// against it to iterate every master shard via ForEachMaster instead of
+	// a single Scan, since a cluster Scan cursor only covers whichever node
+	// it happens to be routed to.
+	cluster *redis.ClusterClient
+
 	prefix  string
 	enabled bool
 }
 
 // RedisConfig holds Redis connection configuration
 type RedisConfig struct {
+	// Mode is "standalone" (default, redis.NewClient), "sentinel"
+	// (redis.NewFailoverClient), or "cluster" (redis.NewClusterClient).
+	Mode RedisMode
+
 	Addr     string
 	Password string
 	DB       int
 	Prefix   string
+
+	// ClusterAddrs lists the cluster's seed nodes, consulted when Mode is
+	// RedisModeCluster; the rest of the topology is discovered from them.
+	ClusterAddrs []string
+
+	// Sentinel* are consulted when Mode is RedisModeSentinel. SentinelAddrs
+	// are the sentinel nodes, not the Redis nodes themselves; SentinelMaster
+	// is the monitored master's name, and SentinelPassword authenticates to
+	// the sentinels, distinct from Password which authenticates to the
+	// master/replicas they report.
+	SentinelAddrs    []string
+	SentinelMaster   string
+	SentinelPassword string
 }
 
 // NewRedisCache creates a new Redis cache instance
 func NewRedisCache(config RedisConfig) (*RedisCache, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:     config.Addr,
-		Password: config.Password,
-		DB:       config.DB,
-	})
+	var client redis.UniversalClient
+	var cluster *redis.ClusterClient
+
+	switch config.Mode {
+	case RedisModeSentinel:
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       config.SentinelMaster,
+			SentinelAddrs:    config.SentinelAddrs,
+			SentinelPassword: config.SentinelPassword,
+			Password:         config.Password,
+			DB:               config.DB,
+		})
+	case RedisModeCluster:
+		cc := redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    config.ClusterAddrs,
+			Password: config.Password,
+		})
+		client = cc
+		cluster = cc
+	default:
+		client = redis.NewClient(&redis.Options{
+			Addr:     config.Addr,
+			Password: config.Password,
+			DB:       config.DB,
+		})
+	}
 
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -43,6 +102,7 @@ func NewRedisCache(config RedisConfig) (*RedisCache, error) {
 
 	return &RedisCache{
 		client:  client,
+		cluster: cluster,
 		prefix:  config.Prefix,
 		enabled: true,
 	}, nil
@@ -57,6 +117,7 @@ func (rc *RedisCache) Get(ctx context.Context, key string) (interface{}, error)
 	fullKey := rc.prefix + key
 	val, err := rc.client.Get(ctx, fullKey).Result()
 	if err == redis.Nil {
+		metrics.Default().RecordCacheMetrics("redis", false)
 		return nil, nil // Key not found
 	} else if err != nil {
 		return nil, fmt.Errorf("failed to get from Redis: %w", err)
@@ -70,6 +131,7 @@ func (rc *RedisCache) Get(ctx context.Context, key string) (interface{}, error)
 
 	// Update access count
 	rc.client.HIncrBy(ctx, fullKey+":stats", "hits", 1)
+	metrics.Default().RecordCacheMetrics("redis", true)
 
 	return result, nil
 }
@@ -109,7 +171,10 @@ func (rc *RedisCache) Set(ctx context.Context, key string, value interface{}, ex
 func (rc *RedisCache) Delete(ctx context.Context, key string) error {
 	fullKey := rc.prefix + key
 
-	// Delete both value and stats
+	// Delete both value and stats. In cluster mode the two keys can land on
+	// different shards; UniversalClient's Pipeline routes each command to
+	// its own node automatically, so this doesn't need the shard-scoping
+	// Clear/GetStats require for their SCAN loops.
 	pipe := rc.client.Pipeline()
 	pipe.Del(ctx, fullKey)
 	pipe.Del(ctx, fullKey+":stats")
@@ -118,12 +183,26 @@ func (rc *RedisCache) Delete(ctx context.Context, key string) error {
 	return err
 }
 
-// Clear removes all cached items with the prefix
+// Clear removes all cached items with the prefix. In cluster mode it scans
+// and deletes within each master shard independently via ForEachMaster,
+// since a single SCAN cursor against a cluster only ever covers the one
+// node it's routed to, and a pipeline spanning keys on different shards
+// would fail with CROSSSLOT.
 func (rc *RedisCache) Clear(ctx context.Context) error {
-	// Use SCAN to find all keys with our prefix
-	iter := rc.client.Scan(ctx, 0, rc.prefix+"*", 0).Iterator()
+	if rc.cluster != nil {
+		return rc.cluster.ForEachMaster(ctx, func(ctx context.Context, shard *redis.Client) error {
+			return clearShard(ctx, shard, rc.prefix)
+		})
+	}
+	return clearShard(ctx, rc.client, rc.prefix)
+}
 
-	pipe := rc.client.Pipeline()
+// clearShard deletes every key matching prefix+"*" on a single node/shard,
+// batching deletes into a pipeline scoped to that shard.
+func clearShard(ctx context.Context, shard redis.UniversalClient, prefix string) error {
+	iter := shard.Scan(ctx, 0, prefix+"*", 0).Iterator()
+
+	pipe := shard.Pipeline()
 	count := 0
 
 	for iter.Next(ctx) {
@@ -135,7 +214,7 @@ func (rc *RedisCache) Clear(ctx context.Context) error {
 			if _, err := pipe.Exec(ctx); err != nil {
 				return fmt.Errorf("failed to clear cache: %w", err)
 			}
-			pipe = rc.client.Pipeline()
+			pipe = shard.Pipeline()
 		}
 	}
 
@@ -149,42 +228,187 @@ func (rc *RedisCache) Clear(ctx context.Context) error {
 	return iter.Err()
 }
 
-// GetStats retrieves cache statistics from Redis
+// GetStats retrieves cache statistics from Redis. In cluster mode, every
+// master shard is scanned via ForEachMaster (which runs concurrently), so
+// the running totals are guarded by a mutex.
 func (rc *RedisCache) GetStats(ctx context.Context) (map[string]int64, error) {
-	stats := make(map[string]int64)
-
-	// Count total keys
-	iter := rc.client.Scan(ctx, 0, rc.prefix+"*", 0).Iterator()
-	keyCount := int64(0)
-	totalSize := int64(0)
-	totalHits := int64(0)
-
-	for iter.Next(ctx) {
-		key := iter.Val()
-		if !strings.HasSuffix(key, ":stats") {
-			keyCount++
+	var mu sync.Mutex
+	var keyCount, totalSize, totalHits int64
+
+	accumulate := func(ctx context.Context, shard redis.UniversalClient) error {
+		iter := shard.Scan(ctx, 0, rc.prefix+"*", 0).Iterator()
+		for iter.Next(ctx) {
+			key := iter.Val()
+			if strings.HasSuffix(key, ":stats") {
+				continue
+			}
 
-			// Get stats for this key
-			if statsData, err := rc.client.HGetAll(ctx, key+":stats").Result(); err == nil {
-				if size, ok := statsData["size"]; ok {
-					if s, err := parseInt64(size); err == nil {
-						totalSize += s
+			var size, hits int64
+			if statsData, err := shard.HGetAll(ctx, key+":stats").Result(); err == nil {
+				if s, ok := statsData["size"]; ok {
+					if v, err := parseInt64(s); err == nil {
+						size = v
 					}
 				}
-				if hits, ok := statsData["hits"]; ok {
-					if h, err := parseInt64(hits); err == nil {
-						totalHits += h
+				if h, ok := statsData["hits"]; ok {
+					if v, err := parseInt64(h); err == nil {
+						hits = v
 					}
 				}
 			}
+
+			mu.Lock()
+			keyCount++
+			totalSize += size
+			totalHits += hits
+			mu.Unlock()
 		}
+		return iter.Err()
+	}
+
+	var err error
+	if rc.cluster != nil {
+		err = rc.cluster.ForEachMaster(ctx, func(ctx context.Context, shard *redis.Client) error {
+			return accumulate(ctx, shard)
+		})
+	} else {
+		err = accumulate(ctx, rc.client)
+	}
+
+	metrics.Default().CacheSizeBytes.Set(float64(totalSize))
+
+	stats := map[string]int64{
+		"keys":  keyCount,
+		"bytes": totalSize,
+		"hits":  totalHits,
+	}
+	return stats, err
+}
+
+// VectorMatch is one FT.SEARCH KNN hit against an index created by
+// EnsureVectorIndex: the hash key it matched (with rc.prefix stripped),
+// its cosine similarity to the query vector, and the payload it was
+// stored with.
+type VectorMatch struct {
+	Key        string
+	Similarity float64
+	Payload    []byte
+}
+
+// EnsureVectorIndex creates a RediSearch index named indexName over hash
+// keys under prefix, with a dim-dimensional FLAT vector field ("embedding")
+// scored by cosine distance, plus a "payload" text field carrying whatever
+// opaque value StoreVector was given. It's idempotent: FT.CREATE's "Index
+// already exists" error (the only expected failure mode on any call after
+// the first) is swallowed.
+func (rc *RedisCache) EnsureVectorIndex(ctx context.Context, indexName, prefix string, dim int) error {
+	err := rc.client.Do(ctx, "FT.CREATE", indexName,
+		"ON", "HASH",
+		"PREFIX", "1", prefix,
+		"SCHEMA",
+		"payload", "TEXT",
+		"embedding", "VECTOR", "FLAT", "6",
+		"TYPE", "FLOAT64",
+		"DIM", dim,
+		"DISTANCE_METRIC", "COSINE",
+	).Err()
+	if err != nil && !strings.Contains(err.Error(), "Index already exists") {
+		return fmt.Errorf("failed to create vector index %s: %w", indexName, err)
+	}
+	return nil
+}
+
+// StoreVector writes vec and payload into a hash under rc.prefix+key, for
+// later FT.SEARCH KNN lookup via SearchVectors against an index covering
+// that prefix.
+func (rc *RedisCache) StoreVector(ctx context.Context, key string, vec []float64, payload []byte, expiration time.Duration) error {
+	fullKey := rc.prefix + key
+	if err := rc.client.HSet(ctx, fullKey, map[string]interface{}{
+		"embedding": encodeVector(vec),
+		"payload":   payload,
+	}).Err(); err != nil {
+		return fmt.Errorf("failed to store vector: %w", err)
 	}
+	return rc.client.Expire(ctx, fullKey, expiration).Err()
+}
 
-	stats["keys"] = keyCount
-	stats["bytes"] = totalSize
-	stats["hits"] = totalHits
+// SearchVectors runs an FT.SEARCH KNN query against indexName for the k
+// hash entries closest to vec, returning each match best-first.
+func (rc *RedisCache) SearchVectors(ctx context.Context, indexName string, vec []float64, k int) ([]VectorMatch, error) {
+	res, err := rc.client.Do(ctx, "FT.SEARCH", indexName,
+		fmt.Sprintf("*=>[KNN %d @embedding $vec AS score]", k),
+		"PARAMS", "2", "vec", encodeVector(vec),
+		"SORTBY", "score",
+		"RETURN", "2", "score", "payload",
+		"DIALECT", "2",
+	).Result()
+	if err != nil {
+		return nil, fmt.Errorf("vector search failed: %w", err)
+	}
+	return parseVectorSearchReply(res, rc.prefix)
+}
+
+// encodeVector packs vec into the little-endian FLOAT64 blob RediSearch
+// expects for a vector field or KNN query parameter.
+func encodeVector(vec []float64) []byte {
+	buf := make([]byte, 8*len(vec))
+	for i, v := range vec {
+		binary.LittleEndian.PutUint64(buf[i*8:], math.Float64bits(v))
+	}
+	return buf
+}
 
-	return stats, iter.Err()
+// parseVectorSearchReply decodes FT.SEARCH's raw reply: a total count
+// followed by, per match, the document key and a flat [field, value, ...]
+// slice (since the query above RETURNs only "score" and "payload"). The
+// distance FT.SEARCH reports for a COSINE vector field is 1-similarity, so
+// it's converted back here.
+func parseVectorSearchReply(reply interface{}, prefix string) ([]VectorMatch, error) {
+	items, ok := reply.([]interface{})
+	if !ok || len(items) < 1 {
+		return nil, nil
+	}
+
+	var matches []VectorMatch
+	for i := 1; i+1 < len(items); i += 2 {
+		key, ok := items[i].(string)
+		if !ok {
+			continue
+		}
+		fields, ok := items[i+1].([]interface{})
+		if !ok {
+			continue
+		}
+
+		match := VectorMatch{Key: strings.TrimPrefix(key, prefix)}
+		for j := 0; j+1 < len(fields); j += 2 {
+			name, _ := fields[j].(string)
+			switch name {
+			case "score":
+				if distance, err := parseFloat64(fields[j+1]); err == nil {
+					match.Similarity = 1 - distance
+				}
+			case "payload":
+				if s, ok := fields[j+1].(string); ok {
+					match.Payload = []byte(s)
+				}
+			}
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// parseFloat64 converts an FT.SEARCH reply field (a string in go-redis's
+// RESP2 decoding) to a float64.
+func parseFloat64(v interface{}) (float64, error) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected type %T", v)
+	}
+	var f float64
+	_, err := fmt.Sscanf(s, "%g", &f)
+	return f, err
 }
 
 // SetEnabled enables or disables the cache
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStreamRecorder_RecordAndChunks(t *testing.T) {
+	r := NewStreamRecorder()
+
+	r.Record("chunk one")
+	r.Record("chunk two")
+
+	chunks := r.Chunks()
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 recorded chunks, got %d", len(chunks))
+	}
+	if chunks[0].Data != "chunk one" || chunks[1].Data != "chunk two" {
+		t.Errorf("unexpected chunk data: %+v", chunks)
+	}
+}
+
+func TestReplay_Instant(t *testing.T) {
+	chunks := []StreamChunk{
+		{Data: "a", DelayMs: 50},
+		{Data: "b", DelayMs: 50},
+	}
+
+	var got []string
+	start := time.Now()
+	Replay(chunks, ReplayInstant, func(data string) {
+		got = append(got, data)
+	})
+	elapsed := time.Since(start)
+
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("unexpected replay output: %v", got)
+	}
+	if elapsed >= 50*time.Millisecond {
+		t.Errorf("expected ReplayInstant to ignore delays, took %v", elapsed)
+	}
+}
+
+func TestReplay_OriginalTiming(t *testing.T) {
+	chunks := []StreamChunk{
+		{Data: "a", DelayMs: 0},
+		{Data: "b", DelayMs: 30},
+	}
+
+	start := time.Now()
+	Replay(chunks, ReplayOriginalTiming, func(string) {})
+	elapsed := time.Since(start)
+
+	if elapsed < 30*time.Millisecond {
+		t.Errorf("expected ReplayOriginalTiming to wait for delays, took %v", elapsed)
+	}
+}
@@ -0,0 +1,304 @@
+package cache
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/internal/cache/vector"
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+// Embedder computes a dense vector embedding for a piece of text, e.g. by
+// calling one of the configured providers' /embeddings endpoint. It's
+// pluggable so the semantic cache isn't tied to any one embedding model.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float64, error)
+}
+
+// semanticScope identifies every dimension two requests must agree on for
+// one's cached response to stand in for the other, besides prompt
+// similarity itself: a tool call made available to one request but not
+// the other changes what a "correct" response looks like, and so does a
+// different response_format constraint.
+type semanticScope struct {
+	provider    string
+	model       string
+	toolsFormat string
+}
+
+// semanticEntry records the scope a cached response was produced under,
+// alongside its temperature, so lookup can re-check the scope after the
+// vector index returns it as a similarity candidate. The temperature is
+// not re-checked there; Add only indexes entries at or below
+// temperatureMax.
+type semanticEntry struct {
+	scope       semanticScope
+	temperature float64
+}
+
+// SemanticIndex finds a cached response for a prompt that is similar to,
+// but not byte-identical to, a previously cached one. Candidates come from
+// an approximate nearest-neighbor search (internal/cache/vector's HNSW
+// index) over every indexed prompt's embedding; lookup then re-checks each
+// candidate's scope and similarity score before accepting it, since the
+// ANN index itself has no notion of provider/model/tool-set scoping.
+type SemanticIndex struct {
+	mu             sync.RWMutex
+	embedder       Embedder
+	threshold      float64
+	temperatureMax float64
+	index          *vector.Index
+	entries        map[string]semanticEntry
+}
+
+// NewSemanticIndex creates a semantic index. threshold is the minimum
+// cosine similarity (0-1) required for a lookup to count as a hit.
+// temperatureMax bounds which requests are eligible for semantic caching
+// at all: a high-temperature request's response is one of many valid
+// completions for its own prompt, so serving it as a "similar enough"
+// match for a different prompt is far more likely to surprise the caller
+// than it is for a low-temperature one.
+func NewSemanticIndex(embedder Embedder, threshold, temperatureMax float64) *SemanticIndex {
+	return &SemanticIndex{
+		embedder:       embedder,
+		threshold:      threshold,
+		temperatureMax: temperatureMax,
+		index:          vector.NewIndex(0, 0),
+		entries:        make(map[string]semanticEntry),
+	}
+}
+
+// SetThreshold updates the minimum cosine similarity required for a lookup
+// to count as a hit, e.g. from POST /cache/semantic/threshold.
+func (si *SemanticIndex) SetThreshold(threshold float64) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	si.threshold = threshold
+}
+
+// Stats reports the index's current threshold and live entry count, for
+// GET /cache/semantic/stats.
+func (si *SemanticIndex) Stats() (threshold float64, entries int) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+	return si.threshold, si.index.Len()
+}
+
+// Add indexes prompt's embedding under key so a future similar-enough
+// prompt, under the same scope, can be served from cache instead of
+// hitting the provider.
+func (si *SemanticIndex) Add(ctx context.Context, key, provider, model string, temperature float64, toolsFormat, prompt string) error {
+	if temperature > si.temperatureMax {
+		return nil
+	}
+
+	vec, err := si.embedder.Embed(ctx, prompt)
+	if err != nil {
+		return err
+	}
+
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	si.entries[key] = semanticEntry{
+		scope:       semanticScope{provider: provider, model: model, toolsFormat: toolsFormat},
+		temperature: temperature,
+	}
+	si.index.Insert(key, vec)
+	return nil
+}
+
+// candidatePoolSize bounds how many nearest neighbors lookup asks the ANN
+// index for before filtering by scope. It's an approximation: a match in
+// scope that isn't among the globally closest candidatePoolSize vectors is
+// missed, the same tradeoff any ANN index makes for sublinear query time.
+const candidatePoolSize = 20
+
+// lookup returns the cache key of the most similar previously indexed
+// prompt in the same provider+model+toolsFormat scope, if its similarity
+// meets threshold.
+func (si *SemanticIndex) lookup(ctx context.Context, provider, model string, temperature float64, toolsFormat, prompt string) (string, bool) {
+	if temperature > si.temperatureMax {
+		return "", false
+	}
+
+	vec, err := si.embedder.Embed(ctx, prompt)
+	if err != nil {
+		return "", false
+	}
+
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	wantScope := semanticScope{provider: provider, model: model, toolsFormat: toolsFormat}
+	for _, candidate := range si.index.SearchNearest(vec, candidatePoolSize) {
+		if candidate.Score < si.threshold {
+			break // SearchNearest returns results best-first
+		}
+		entry, ok := si.entries[candidate.Key]
+		if !ok || entry.scope != wantScope {
+			continue
+		}
+		return candidate.Key, true
+	}
+	return "", false
+}
+
+// Remove drops the entry indexed under key, e.g. when the underlying cache
+// entry expires or is evicted.
+func (si *SemanticIndex) Remove(key string) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	delete(si.entries, key)
+	si.index.Remove(key)
+}
+
+// FingerprintToolsFormat hashes tools and responseFormat into a stable
+// string two requests can be compared by, so the semantic cache never
+// serves a cached response across a change in what tools the model could
+// call or what shape its output was constrained to.
+func FingerprintToolsFormat(tools interface{}, responseFormat interface{}) string {
+	data, err := json.Marshal(struct {
+		Tools          interface{} `json:"tools,omitempty"`
+		ResponseFormat interface{} `json:"response_format,omitempty"`
+	}{Tools: tools, ResponseFormat: responseFormat})
+	if err != nil {
+		return ""
+	}
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0
+// if either is the zero vector or they differ in length.
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// PromptFromMessages flattens a chat completion's messages into a single
+// string suitable for embedding, preserving role ordering since "who said
+// what" changes the meaning of an otherwise identical message list.
+func PromptFromMessages(messages []models.OpenAIMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		b.WriteString(m.Role)
+		b.WriteString(": ")
+		if text, ok := m.Content.(string); ok {
+			b.WriteString(text)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// EnableSemanticCache turns on embedding-based similarity lookups for
+// cache misses on exact key matching. Safe to call once during setup;
+// calling it again replaces the existing index.
+func (cm *CacheManager) EnableSemanticCache(embedder Embedder, threshold, temperatureMax float64) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.semantic = NewSemanticIndex(embedder, threshold, temperatureMax)
+}
+
+// GetSemantic looks up a response for prompt by exact cache key first,
+// falling back to a semantic similarity match scoped to
+// provider+model+toolsFormat (see FingerprintToolsFormat) when no exact
+// entry exists and semantic caching is enabled.
+func (cm *CacheManager) GetSemantic(ctx context.Context, key, provider, model string, temperature float64, toolsFormat, prompt string) (interface{}, bool) {
+	if value, found := cm.Get(key); found {
+		cm.reportHit("exact", true)
+		return value, found
+	}
+
+	cm.mu.RLock()
+	semantic := cm.semantic
+	hook := cm.metricsHook
+	cm.mu.RUnlock()
+	if semantic == nil {
+		if hook != nil {
+			hook("exact", false)
+		}
+		return nil, false
+	}
+
+	matchKey, ok := semantic.lookup(ctx, provider, model, temperature, toolsFormat, prompt)
+	if !ok {
+		cm.reportHit("semantic", false)
+		return nil, false
+	}
+	value, found := cm.Get(matchKey)
+	if !found {
+		// The semantic index outlived its cache entry (e.g. TTL
+		// eviction); drop the stale reference instead of matching it again.
+		semantic.Remove(matchKey)
+		cm.reportHit("semantic", false)
+		return nil, false
+	}
+
+	cm.mu.Lock()
+	cm.stats.SemanticHits++
+	cm.mu.Unlock()
+	cm.reportHit("semantic", true)
+	return value, true
+}
+
+// SetSemantic stores value under key as usual and, when semantic caching
+// is enabled, also indexes prompt's embedding so future similar prompts in
+// the same provider+model+toolsFormat scope can be served from this entry.
+func (cm *CacheManager) SetSemantic(ctx context.Context, key, provider, model string, temperature float64, toolsFormat, prompt string, value interface{}, duration time.Duration) error {
+	if err := cm.Set(key, value, duration); err != nil {
+		return err
+	}
+
+	cm.mu.RLock()
+	semantic := cm.semantic
+	cm.mu.RUnlock()
+	if semantic == nil {
+		return nil
+	}
+	return semantic.Add(ctx, key, provider, model, temperature, toolsFormat, prompt)
+}
+
+// SetSemanticThreshold updates the minimum cosine similarity required for
+// a semantic lookup to count as a hit. A no-op if semantic caching isn't
+// enabled.
+func (cm *CacheManager) SetSemanticThreshold(threshold float64) {
+	cm.mu.RLock()
+	semantic := cm.semantic
+	cm.mu.RUnlock()
+	if semantic != nil {
+		semantic.SetThreshold(threshold)
+	}
+}
+
+// SemanticStats reports whether semantic caching is enabled and, if so,
+// its current threshold and live indexed-entry count.
+func (cm *CacheManager) SemanticStats() (enabled bool, threshold float64, entries int) {
+	cm.mu.RLock()
+	semantic := cm.semantic
+	cm.mu.RUnlock()
+	if semantic == nil {
+		return false, 0, 0
+	}
+	threshold, entries = semantic.Stats()
+	return true, threshold, entries
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubEmbedder returns a deterministic embedding based on which of a
+// fixed set of known phrases the text contains, so similarity scores are
+// predictable without calling out to a real embeddings endpoint.
+type stubEmbedder struct{}
+
+func (stubEmbedder) Embed(ctx context.Context, text string) ([]float64, error) {
+	if strings.Contains(text, "capital of France") {
+		return []float64{1, 0, 0}, nil
+	}
+	if strings.Contains(text, "capital city of France") {
+		return []float64{0.99, 0.01, 0}, nil
+	}
+	return []float64{0, 0, 1}, nil
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	if got := cosineSimilarity([]float64{1, 0}, []float64{1, 0}); got != 1 {
+		t.Errorf("cosineSimilarity(identical) = %v, want 1", got)
+	}
+	if got := cosineSimilarity([]float64{1, 0}, []float64{0, 1}); got != 0 {
+		t.Errorf("cosineSimilarity(orthogonal) = %v, want 0", got)
+	}
+	if got := cosineSimilarity([]float64{1, 0}, []float64{1, 0, 0}); got != 0 {
+		t.Errorf("cosineSimilarity(mismatched length) = %v, want 0", got)
+	}
+}
+
+func TestCacheManager_GetSemantic_MatchesSimilarPrompt(t *testing.T) {
+	cm := NewCacheManager(5*time.Minute, 10*time.Minute, 100)
+	cm.EnableSemanticCache(stubEmbedder{}, 0.95, 1.0)
+
+	ctx := context.Background()
+	if err := cm.SetSemantic(ctx, "key1", "openai", "gpt-4", 0, "", "What is the capital of France?", "Paris", time.Minute); err != nil {
+		t.Fatalf("SetSemantic() error = %v", err)
+	}
+
+	got, found := cm.GetSemantic(ctx, "key2", "openai", "gpt-4", 0, "", "What is the capital city of France?")
+	if !found {
+		t.Fatal("GetSemantic() did not find a semantic match")
+	}
+	if got != "Paris" {
+		t.Errorf("GetSemantic() = %v, want Paris", got)
+	}
+
+	stats := cm.GetStats()
+	if stats.SemanticHits != 1 {
+		t.Errorf("SemanticHits = %d, want 1", stats.SemanticHits)
+	}
+}
+
+func TestCacheManager_GetSemantic_NoMatchForDissimilarPrompt(t *testing.T) {
+	cm := NewCacheManager(5*time.Minute, 10*time.Minute, 100)
+	cm.EnableSemanticCache(stubEmbedder{}, 0.95, 1.0)
+
+	ctx := context.Background()
+	cm.SetSemantic(ctx, "key1", "openai", "gpt-4", 0, "", "What is the capital of France?", "Paris", time.Minute)
+
+	if _, found := cm.GetSemantic(ctx, "key2", "openai", "gpt-4", 0, "", "Write me a poem about the ocean"); found {
+		t.Error("GetSemantic() matched a dissimilar prompt")
+	}
+}
+
+func TestCacheManager_GetSemantic_DisabledReturnsExactOnly(t *testing.T) {
+	cm := NewCacheManager(5*time.Minute, 10*time.Minute, 100)
+
+	ctx := context.Background()
+	cm.Set("key1", "Paris", time.Minute)
+
+	if _, found := cm.GetSemantic(ctx, "key2", "openai", "gpt-4", 0, "", "What is the capital of France?"); found {
+		t.Error("GetSemantic() found a match with semantic caching disabled")
+	}
+	if got, found := cm.GetSemantic(ctx, "key1", "openai", "gpt-4", 0, "", "anything"); !found || got != "Paris" {
+		t.Errorf("GetSemantic() exact match = %v, %v, want Paris, true", got, found)
+	}
+}
+
+func TestCacheManager_GetSemantic_ScopesByToolsFormat(t *testing.T) {
+	cm := NewCacheManager(5*time.Minute, 10*time.Minute, 100)
+	cm.EnableSemanticCache(stubEmbedder{}, 0.95, 1.0)
+
+	ctx := context.Background()
+	cm.SetSemantic(ctx, "key1", "openai", "gpt-4", 0, "tools-a", "What is the capital of France?", "Paris", time.Minute)
+
+	if _, found := cm.GetSemantic(ctx, "key2", "openai", "gpt-4", 0, "tools-b", "What is the capital city of France?"); found {
+		t.Error("GetSemantic() matched across different toolsFormat scopes")
+	}
+	if got, found := cm.GetSemantic(ctx, "key2", "openai", "gpt-4", 0, "tools-a", "What is the capital city of France?"); !found || got != "Paris" {
+		t.Errorf("GetSemantic() same-scope match = %v, %v, want Paris, true", got, found)
+	}
+}
+
+func TestCacheManager_SemanticStats_ReportsThresholdAndEntryCount(t *testing.T) {
+	cm := NewCacheManager(5*time.Minute, 10*time.Minute, 100)
+
+	if enabled, _, _ := cm.SemanticStats(); enabled {
+		t.Fatal("SemanticStats() reported enabled before EnableSemanticCache was called")
+	}
+
+	cm.EnableSemanticCache(stubEmbedder{}, 0.95, 1.0)
+	ctx := context.Background()
+	cm.SetSemantic(ctx, "key1", "openai", "gpt-4", 0, "", "What is the capital of France?", "Paris", time.Minute)
+
+	enabled, threshold, entries := cm.SemanticStats()
+	if !enabled || threshold != 0.95 || entries != 1 {
+		t.Fatalf("SemanticStats() = %v, %v, %v, want true, 0.95, 1", enabled, threshold, entries)
+	}
+
+	cm.SetSemanticThreshold(0.8)
+	if _, threshold, _ := cm.SemanticStats(); threshold != 0.8 {
+		t.Fatalf("SetSemanticThreshold() did not take effect, threshold = %v", threshold)
+	}
+}
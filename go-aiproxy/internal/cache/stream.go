@@ -0,0 +1,108 @@
+package cache
+
+import "time"
+
+// streamCacheKeyPrefix disambiguates recorded SSE chunk lists from plain
+// response cache entries that share the same GenerateCacheKey/
+// GenerateCanonicalCacheKey hash, since both are stored in the same
+// underlying cache.
+const streamCacheKeyPrefix = "stream:"
+
+// StreamChunk is one recorded SSE "data: ..." payload, paired with how long
+// after the previous chunk (or the start of the stream) it arrived, so a
+// replay can reproduce the original pacing instead of flushing everything
+// at once.
+type StreamChunk struct {
+	Data    string `json:"data"`
+	DelayMs int64  `json:"delay_ms"`
+}
+
+// StreamRecorder tees a streaming completion's chunks into an ordered list
+// suitable for caching via CacheManager.SetStream, so a later streaming
+// request for the same cache key can replay them instead of calling the
+// provider again.
+type StreamRecorder struct {
+	chunks   []StreamChunk
+	lastEmit time.Time
+}
+
+// NewStreamRecorder starts a recorder; its inter-chunk delay clock begins
+// now, so the first Record call's delay reflects time-to-first-chunk.
+func NewStreamRecorder() *StreamRecorder {
+	return &StreamRecorder{lastEmit: time.Now()}
+}
+
+// Record appends data as the next recorded chunk, timing its delay from the
+// previous Record call (or from NewStreamRecorder for the first one).
+func (r *StreamRecorder) Record(data string) {
+	now := time.Now()
+	r.chunks = append(r.chunks, StreamChunk{Data: data, DelayMs: now.Sub(r.lastEmit).Milliseconds()})
+	r.lastEmit = now
+}
+
+// Chunks returns the chunks recorded so far, in order.
+func (r *StreamRecorder) Chunks() []StreamChunk {
+	return r.chunks
+}
+
+// StreamCacheEnabledFor reports whether model has opted in to streaming
+// cache via CacheManager.EnableStreamCacheForModel.
+func (cm *CacheManager) StreamCacheEnabledFor(model string) bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	return cm.streamCacheModels[model]
+}
+
+// EnableStreamCacheForModel opts model in to StreamRecorder-based caching
+// of its streaming completions.
+func (cm *CacheManager) EnableStreamCacheForModel(model string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if cm.streamCacheModels == nil {
+		cm.streamCacheModels = make(map[string]bool)
+	}
+	cm.streamCacheModels[model] = true
+}
+
+// GetStream retrieves a previously recorded ordered list of SSE chunks for
+// key, as populated by SetStream.
+func (cm *CacheManager) GetStream(key string) ([]StreamChunk, bool) {
+	value, found := cm.Get(streamCacheKeyPrefix + key)
+	if !found {
+		cm.reportHit("stream", false)
+		return nil, false
+	}
+	chunks, ok := value.([]StreamChunk)
+	cm.reportHit("stream", ok)
+	return chunks, ok
+}
+
+// SetStream records chunks under key so a later streaming request with the
+// same cache key can replay them instead of calling the provider again.
+func (cm *CacheManager) SetStream(key string, chunks []StreamChunk, duration time.Duration) error {
+	return cm.Set(streamCacheKeyPrefix+key, chunks, duration)
+}
+
+// ReplayMode controls the pacing used when replaying a cached stream.
+type ReplayMode int
+
+const (
+	// ReplayOriginalTiming sleeps for each chunk's recorded DelayMs before
+	// sending it, reproducing the original stream's pacing.
+	ReplayOriginalTiming ReplayMode = iota
+	// ReplayInstant sends every chunk back to back, ignoring delays.
+	ReplayInstant
+)
+
+// Replay sends each of chunks to send, pacing it per mode. It blocks for the
+// duration of the replay when mode is ReplayOriginalTiming.
+func Replay(chunks []StreamChunk, mode ReplayMode, send func(string)) {
+	for _, c := range chunks {
+		if mode == ReplayOriginalTiming && c.DelayMs > 0 {
+			time.Sleep(time.Duration(c.DelayMs) * time.Millisecond)
+		}
+		send(c.Data)
+	}
+}
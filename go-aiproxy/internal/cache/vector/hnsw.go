@@ -0,0 +1,356 @@
+// Package vector provides a small in-memory approximate nearest-neighbor
+// index for embedding vectors, used by the semantic response cache to find
+// a previously cached prompt similar to a new one without a linear scan
+// over every cached entry.
+package vector
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Index is an approximate nearest-neighbor index over cosine similarity
+// using Hierarchical Navigable Small World graphs (Malkov & Yashunin,
+// 2016): entries are linked into a small number of layers, with higher
+// layers containing exponentially fewer nodes, so a search descends from a
+// sparse top layer into denser ones instead of comparing against every
+// entry. Level assignment uses a coin-flip (each level has half the
+// entries of the one below it) rather than the paper's log-uniform
+// sampling, a common simplification that keeps construction free of
+// degenerate cases (a sample of exactly 0 making log(x) undefined) at the
+// index sizes this cache reaches.
+type Index struct {
+	mu sync.RWMutex
+
+	m              int // max neighbors kept per node per layer
+	efConstruction int // candidates explored while inserting a node
+	rng            *rand.Rand
+
+	entryPoint int // index into nodes, or -1 if the index is empty
+	nodes      []*node
+	byKey      map[string]int
+}
+
+type node struct {
+	key       string
+	vec       []float64
+	level     int
+	neighbors [][]int // neighbors[layer] = neighbor node ids at that layer
+	deleted   bool
+}
+
+// Result is one match returned by SearchNearest, ordered best-first.
+type Result struct {
+	Key   string
+	Score float64
+}
+
+type candidate struct {
+	id  int
+	sim float64
+}
+
+// NewIndex creates an empty index. m bounds how many neighbors each node
+// keeps per layer; efConstruction bounds how many candidates are explored
+// while inserting a node, trading build time for graph quality. Both fall
+// back to HNSW's commonly used defaults when given as zero.
+func NewIndex(m, efConstruction int) *Index {
+	if m <= 0 {
+		m = 16
+	}
+	if efConstruction <= 0 {
+		efConstruction = 200
+	}
+	return &Index{
+		m:              m,
+		efConstruction: efConstruction,
+		rng:            rand.New(rand.NewSource(time.Now().UnixNano())),
+		entryPoint:     -1,
+		byKey:          make(map[string]int),
+	}
+}
+
+// Len reports how many live (non-deleted) entries the index holds.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	n := 0
+	for _, nd := range idx.nodes {
+		if !nd.deleted {
+			n++
+		}
+	}
+	return n
+}
+
+// Insert adds vec under key, replacing any existing entry for the same key.
+func (idx *Index) Insert(key string, vec []float64) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if existing, ok := idx.byKey[key]; ok {
+		idx.nodes[existing].deleted = true
+	}
+
+	level := idx.randomLevel()
+	n := &node{
+		key:       key,
+		vec:       vec,
+		level:     level,
+		neighbors: make([][]int, level+1),
+	}
+	id := len(idx.nodes)
+	idx.nodes = append(idx.nodes, n)
+	idx.byKey[key] = id
+
+	if idx.entryPoint == -1 {
+		idx.entryPoint = id
+		return
+	}
+
+	entry := idx.entryPoint
+	entryLevel := idx.nodes[entry].level
+
+	cur := entry
+	for l := entryLevel; l > level; l-- {
+		cur = idx.greedyClosest(cur, vec, l)
+	}
+
+	top := level
+	if entryLevel < top {
+		top = entryLevel
+	}
+	for l := top; l >= 0; l-- {
+		candidates := idx.searchLayer(vec, cur, idx.efConstruction, l)
+		neighbors := selectNeighbors(candidates, idx.m)
+		n.neighbors[l] = neighbors
+
+		for _, nb := range neighbors {
+			idx.addBacklink(nb, id, l)
+		}
+		if len(candidates) > 0 {
+			cur = candidates[0].id
+		}
+	}
+
+	if level > entryLevel {
+		idx.entryPoint = id
+	}
+}
+
+// Remove drops key from the index. The underlying node is tombstoned
+// rather than compacted out, since HNSW's neighbor lists would otherwise
+// need rewriting across every layer that referenced it.
+func (idx *Index) Remove(key string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	id, ok := idx.byKey[key]
+	if !ok {
+		return
+	}
+	idx.nodes[id].deleted = true
+	delete(idx.byKey, key)
+}
+
+// SearchNearest returns up to k entries most similar to vec, best-first.
+func (idx *Index) SearchNearest(vec []float64, k int) []Result {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if idx.entryPoint == -1 || k <= 0 {
+		return nil
+	}
+
+	entryLevel := idx.nodes[idx.entryPoint].level
+	cur := idx.entryPoint
+	for l := entryLevel; l > 0; l-- {
+		cur = idx.greedyClosest(cur, vec, l)
+	}
+
+	ef := idx.efConstruction
+	if ef < k {
+		ef = k
+	}
+	candidates := idx.searchLayer(vec, cur, ef, 0)
+
+	results := make([]Result, 0, len(candidates))
+	for _, c := range candidates {
+		if idx.nodes[c.id].deleted {
+			continue
+		}
+		results = append(results, Result{Key: idx.nodes[c.id].key, Score: c.sim})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// randomLevel picks a node's top layer by repeated coin flips: each level
+// is half as likely as the one below it, the same degree distribution
+// HNSW's log-uniform sampling targets.
+func (idx *Index) randomLevel() int {
+	level := 0
+	for idx.rng.Float64() < 0.5 && level < 31 {
+		level++
+	}
+	return level
+}
+
+// greedyClosest walks from the given node at layer, repeatedly moving to
+// whichever neighbor is more similar to vec than the current node, until
+// no neighbor improves on it. This is HNSW's single-path descent used to
+// find a good entry point in the layer below.
+func (idx *Index) greedyClosest(from int, vec []float64, layer int) int {
+	current := from
+	currentSim := cosineSimilarity(vec, idx.nodes[current].vec)
+
+	for {
+		improved := false
+		if layer < len(idx.nodes[current].neighbors) {
+			for _, nb := range idx.nodes[current].neighbors[layer] {
+				if idx.nodes[nb].deleted {
+					continue
+				}
+				sim := cosineSimilarity(vec, idx.nodes[nb].vec)
+				if sim > currentSim {
+					current = nb
+					currentSim = sim
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			return current
+		}
+	}
+}
+
+// searchLayer performs a best-first expansion from entry at layer,
+// returning up to ef candidates ordered best-first. It stops expanding a
+// frontier once the full result set already beats it, since nothing
+// reachable through a worse candidate can improve the results further.
+func (idx *Index) searchLayer(vec []float64, entry int, ef int, layer int) []candidate {
+	visited := map[int]bool{entry: true}
+	entrySim := cosineSimilarity(vec, idx.nodes[entry].vec)
+
+	frontier := []candidate{{entry, entrySim}}
+	results := []candidate{{entry, entrySim}}
+
+	for len(frontier) > 0 {
+		bestIdx := 0
+		for i := 1; i < len(frontier); i++ {
+			if frontier[i].sim > frontier[bestIdx].sim {
+				bestIdx = i
+			}
+		}
+		c := frontier[bestIdx]
+		frontier = append(frontier[:bestIdx], frontier[bestIdx+1:]...)
+
+		if len(results) >= ef && c.sim < worstSim(results) {
+			break
+		}
+
+		if layer < len(idx.nodes[c.id].neighbors) {
+			for _, nb := range idx.nodes[c.id].neighbors[layer] {
+				if visited[nb] || idx.nodes[nb].deleted {
+					continue
+				}
+				visited[nb] = true
+				sim := cosineSimilarity(vec, idx.nodes[nb].vec)
+				frontier = append(frontier, candidate{nb, sim})
+				results = append(results, candidate{nb, sim})
+				if len(results) > ef {
+					results = dropWorst(results)
+				}
+			}
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].sim > results[j].sim })
+	return results
+}
+
+// addBacklink appends candidateID as a neighbor of node ofID at layer,
+// pruning to the m neighbors closest to ofID's own vector if that would
+// exceed the degree bound.
+func (idx *Index) addBacklink(ofID, candidateID, layer int) {
+	n := idx.nodes[ofID]
+	if layer >= len(n.neighbors) {
+		return
+	}
+	n.neighbors[layer] = append(n.neighbors[layer], candidateID)
+	if len(n.neighbors[layer]) <= idx.m {
+		return
+	}
+
+	cands := make([]candidate, len(n.neighbors[layer]))
+	for i, id := range n.neighbors[layer] {
+		cands[i] = candidate{id, cosineSimilarity(n.vec, idx.nodes[id].vec)}
+	}
+	n.neighbors[layer] = selectNeighbors(cands, idx.m)
+}
+
+// selectNeighbors returns the ids of the m candidates with the highest
+// similarity, best-first.
+func selectNeighbors(candidates []candidate, m int) []int {
+	sorted := make([]candidate, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].sim > sorted[j].sim })
+	if len(sorted) > m {
+		sorted = sorted[:m]
+	}
+
+	ids := make([]int, len(sorted))
+	for i, c := range sorted {
+		ids[i] = c.id
+	}
+	return ids
+}
+
+// worstSim returns the lowest similarity score among results.
+func worstSim(results []candidate) float64 {
+	worst := results[0].sim
+	for _, r := range results {
+		if r.sim < worst {
+			worst = r.sim
+		}
+	}
+	return worst
+}
+
+// dropWorst removes the lowest-scoring candidate from results.
+func dropWorst(results []candidate) []candidate {
+	worstIdx := 0
+	for i := 1; i < len(results); i++ {
+		if results[i].sim < results[worstIdx].sim {
+			worstIdx = i
+		}
+	}
+	return append(results[:worstIdx], results[worstIdx+1:]...)
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0
+// if either is the zero vector or they differ in length.
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
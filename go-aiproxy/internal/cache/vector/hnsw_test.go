@@ -0,0 +1,94 @@
+package vector
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestIndex_SearchNearest_FindsClosestVector(t *testing.T) {
+	idx := NewIndex(8, 32)
+
+	idx.Insert("a", []float64{1, 0, 0})
+	idx.Insert("b", []float64{0, 1, 0})
+	idx.Insert("c", []float64{0.95, 0.05, 0})
+
+	results := idx.SearchNearest([]float64{1, 0, 0}, 1)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].Key != "a" {
+		t.Fatalf("expected closest match to be 'a', got %q (score %f)", results[0].Key, results[0].Score)
+	}
+}
+
+func TestIndex_SearchNearest_OrdersBestFirst(t *testing.T) {
+	idx := NewIndex(8, 32)
+
+	idx.Insert("exact", []float64{1, 0, 0})
+	idx.Insert("close", []float64{0.9, 0.1, 0})
+	idx.Insert("far", []float64{0, 0, 1})
+
+	results := idx.SearchNearest([]float64{1, 0, 0}, 3)
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[0].Key != "exact" || results[1].Key != "close" || results[2].Key != "far" {
+		t.Fatalf("results not ordered best-first: %+v", results)
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Score > results[i-1].Score {
+			t.Fatalf("results not sorted descending by score: %+v", results)
+		}
+	}
+}
+
+func TestIndex_Remove_ExcludesFromFutureSearches(t *testing.T) {
+	idx := NewIndex(8, 32)
+
+	idx.Insert("a", []float64{1, 0, 0})
+	idx.Insert("b", []float64{0.9, 0.1, 0})
+
+	idx.Remove("a")
+
+	results := idx.SearchNearest([]float64{1, 0, 0}, 2)
+	for _, r := range results {
+		if r.Key == "a" {
+			t.Fatalf("removed key still returned: %+v", results)
+		}
+	}
+	if idx.Len() != 1 {
+		t.Fatalf("expected Len() 1 after removing one of two entries, got %d", idx.Len())
+	}
+}
+
+func TestIndex_Insert_ReplacesExistingKey(t *testing.T) {
+	idx := NewIndex(8, 32)
+
+	idx.Insert("a", []float64{1, 0, 0})
+	idx.Insert("a", []float64{0, 1, 0})
+
+	if idx.Len() != 1 {
+		t.Fatalf("expected Len() 1 after re-inserting the same key, got %d", idx.Len())
+	}
+
+	results := idx.SearchNearest([]float64{0, 1, 0}, 1)
+	if len(results) != 1 || results[0].Key != "a" {
+		t.Fatalf("expected re-inserted vector to take effect, got %+v", results)
+	}
+}
+
+func TestIndex_SearchNearest_ScalesToSeveralHundredEntries(t *testing.T) {
+	idx := NewIndex(16, 64)
+
+	for i := 0; i < 500; i++ {
+		idx.Insert(fmt.Sprintf("item-%d", i), []float64{float64(i), 1, 0})
+	}
+
+	results := idx.SearchNearest([]float64{250, 1, 0}, 5)
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+	if results[0].Key != "item-250" {
+		t.Fatalf("expected exact match 'item-250' to rank first, got %q", results[0].Key)
+	}
+}
@@ -35,6 +35,22 @@ type Config struct {
 	GeminiOAuthCredsFile   string
 	ProjectID            string
 
+	// Gemini Workload Identity Federation (external_account), an
+	// alternative to GeminiOAuthCreds* for authenticating without a
+	// service-account private key. See models.ProviderConfig's
+	// WorkloadIdentity* fields for what each controls.
+	GeminiWorkloadIdentityAudience             string
+	GeminiWorkloadIdentitySubjectTokenType     string
+	GeminiWorkloadIdentityCredentialSourceFile string
+	GeminiWorkloadIdentityImpersonationURL     string
+
+	// GeminiLocation is the Vertex AI region for OAuth/service-account
+	// requests (e.g. "europe-west4"); empty defaults to "us-central1".
+	// GeminiVertexEndpointOverride replaces the whole Vertex AI host,
+	// for private Service Connect endpoints or other non-standard hosts.
+	GeminiLocation               string
+	GeminiVertexEndpointOverride string
+
 	// System prompt configuration
 	SystemPromptFile string
 	SystemPromptMode string
@@ -52,7 +68,29 @@ type Config struct {
 	RedisAddr     string
 	RedisPassword string
 	RedisDB       int
-	
+
+	// RedisMode selects the cache.RedisMode used to construct the response
+	// cache's Redis client: "standalone" (default), "sentinel", or
+	// "cluster". Sentinel* below are only consulted in sentinel mode.
+	RedisMode             string
+	RedisClusterAddrs     []string
+	RedisSentinelAddrs    []string
+	RedisSentinelMaster   string
+	RedisSentinelPassword string
+
+	// Metrics configuration. MetricsAddr, when set, serves /metrics on a
+	// dedicated listener instead of the main API router (useful when the
+	// scrape port shouldn't share the main port's auth/TLS posture).
+	MetricsEnabled bool
+	MetricsAddr    string
+	MetricsPath    string
+
+	// ActiveUsersStateFile, when set, persists the rolling active-users
+	// bucket sketch (metrics.ActiveUserTracker) to this path on every
+	// advance so aiproxy_active_users_1h/24h don't momentarily zero out
+	// across a restart. Empty keeps that state in-memory only.
+	ActiveUsersStateFile string
+
 	// Load balancer configuration
 	LoadBalancerEnabled   bool
 	LoadBalancerAlgorithm string
@@ -62,6 +100,137 @@ type Config struct {
 	NodeID         string
 	NodeAddress    string
 	SeedNodes      []string
+
+	// Usage accounting configuration
+	UsageBudgetUSD        float64
+	UsageBudgetWindowMins int
+
+	// Response cache configuration
+	CacheEnabled   bool
+	CacheTTLMins   int
+	CacheMaxSizeMB int64
+
+	// StreamCacheModels lists models that opt in to recording streaming
+	// completions (see cache.StreamRecorder) so a later identical streaming
+	// request replays the cached chunks instead of calling the provider
+	// again. Empty means no model records streams, even if CacheEnabled.
+	StreamCacheModels []string
+
+	// StreamCacheChunkDelayMs, when non-zero, paces a replayed streaming
+	// cache hit at this fixed interval between chunks instead of
+	// reproducing the original recorded inter-chunk delays.
+	StreamCacheChunkDelayMs int
+
+	// Semantic cache configuration. When enabled, a cache miss on the
+	// exact key falls back to an embedding-similarity scan against other
+	// cached prompts for the same provider+model before giving up.
+	SemanticCacheEnabled        bool
+	SemanticCacheThreshold      float64
+	SemanticCacheTemperatureMax float64
+	SemanticCacheEmbeddingModel string
+	SemanticCacheEmbeddingURL   string
+	SemanticCacheEmbeddingKey   string
+
+	// ProviderCache configuration: an independent, Redis-backed response
+	// cache that sits in front of each provider's GenerateContent (see
+	// providers.CachingProvider), keyed on provider+model+normalized
+	// request rather than the canonicalized cross-protocol request the
+	// CacheEnabled/SemanticCache* fields above key on. Requires Redis to be
+	// configured (RedisAddr or RedisMode); "semantic" mode also reuses
+	// SemanticCacheEmbeddingURL/SemanticCacheEmbeddingKey for the
+	// embeddings endpoint.
+	ProviderCacheMode                string
+	ProviderCacheTTLSeconds          int
+	ProviderCacheSimilarityThreshold float64
+	ProviderCacheEmbeddingModel      string
+
+	// OIDC authentication configuration. When OIDCIssuerURL is set, the
+	// server validates Bearer tokens against this issuer instead of the
+	// static APIKey.
+	OIDCIssuerURL       string
+	OIDCAudience        string
+	OIDCRequiredScopes  []string
+	OIDCAllowedSubjects []string
+	OIDCAllowedGroups   []string
+
+	// OIDCCallerPoliciesFile points at a middleware.CallerPolicies JSON
+	// document mapping an authenticated caller's sub/email/groups to
+	// per-caller rate limits and provider/model access. Empty disables
+	// per-caller policy resolution; every authenticated caller is then
+	// only subject to the provider-level rate limits and quota.
+	OIDCCallerPoliciesFile string
+
+	// OAuth token persistence configuration, used by auth.TokenManager so
+	// OAuth providers (Gemini, Kiro, Qwen) survive restarts without
+	// re-hitting the token endpoint and coordinate refreshes across
+	// replicas. TokenStoreType is one of "memory" (default), "file", or
+	// "redis" (reuses RedisAddr/RedisPassword/RedisDB above).
+	TokenStoreType         string
+	TokenStoreDir          string
+	TokenStoreEncKeyBase64 string
+
+	// mTLS / SPIFFE authentication for the proxy front door. When
+	// TLSCertFile/TLSKeyFile are set, the server terminates TLS itself
+	// instead of running plain HTTP. Additionally setting TLSClientCAFile
+	// requires a client certificate (per TLSClientCARequire: "request",
+	// "require", or "verify", default "verify") and, when SPIFFETrustDomain
+	// is set, middleware.MTLSAuth checks the leaf certificate's SPIFFE URI
+	// SAN against it. "require" and "verify" both fully validate the
+	// client certificate against TLSClientCAFile (see
+	// server.clientAuthType); only "request" skips validation, and must
+	// not be combined with SPIFFETrustDomain.
+	TLSCertFile        string
+	TLSKeyFile         string
+	TLSClientCAFile    string
+	TLSClientCARequire string
+	SPIFFETrustDomain  string
+
+	// gRPC/Connect streaming transport, served alongside the SSE-based
+	// HTTP API. When GRPCEnabled is set, internal/grpcapi's service is
+	// mounted over h2c on GRPCAddr (default ":9090"), so backend-to-backend
+	// clients can stream via gRPC, gRPC-Web, or Connect's own protocol
+	// without HTTP/1.1 chunked-SSE parsing overhead.
+	GRPCEnabled bool
+	GRPCAddr    string
+
+	// Hedged-request configuration for handleChatCompletions' cross-
+	// provider failover. When HedgeEnabled, a request that hasn't
+	// completed after HedgeAfterMs fires a duplicate against the next
+	// provider in the failover chain and returns whichever responds
+	// first, cancelling the loser.
+	HedgeEnabled bool
+	HedgeAfterMs int
+
+	// OTLPEndpoint, if set, is the host:port of an OTLP/HTTP collector
+	// that internal/observability exports spans to. Empty leaves tracing
+	// as a no-op, same as not configuring an exporter at all. Set by the
+	// deprecated --otlp-endpoint flag, or --otel-endpoint, which supersedes
+	// it when both are given.
+	OTLPEndpoint string
+
+	// OTelServiceName is the service.name resource attribute exported spans
+	// carry; empty uses internal/observability's "go-aiproxy" default.
+	OTelServiceName string
+
+	// OTelSampler selects the root trace sampler: "always_on" (default),
+	// "always_off", or a ratio like "0.1" for a TraceIDRatioBased sampler.
+	OTelSampler string
+
+	// OTelHeaders are extra headers (e.g. collector authentication) sent
+	// with every OTLP export request, as "key=value" pairs.
+	OTelHeaders []string
+
+	// PluginDir, if set, is scanned at startup for out-of-process provider
+	// plugins (see internal/providers/plugin) named aiproxy-plugin-*; each
+	// is launched as a child process and registered into the provider
+	// pool under the name it advertises in its handshake. Empty disables
+	// plugin loading entirely.
+	PluginDir string
+
+	// PluginMaxMemoryMB caps each plugin's resident set size; a plugin
+	// that exceeds it is killed and relaunched by its supervisor like any
+	// other crash. 0 leaves plugins unbounded.
+	PluginMaxMemoryMB int
 }
 
 // New creates a new configuration instance
@@ -100,6 +269,12 @@ func (c *Config) LoadFromFlags(cmd *cobra.Command) error {
 	c.GeminiOAuthCredsBase64, _ = cmd.Flags().GetString("gemini-oauth-creds-base64")
 	c.GeminiOAuthCredsFile, _ = cmd.Flags().GetString("gemini-oauth-creds-file")
 	c.ProjectID, _ = cmd.Flags().GetString("project-id")
+	c.GeminiWorkloadIdentityAudience, _ = cmd.Flags().GetString("gemini-workload-identity-audience")
+	c.GeminiWorkloadIdentitySubjectTokenType, _ = cmd.Flags().GetString("gemini-workload-identity-subject-token-type")
+	c.GeminiWorkloadIdentityCredentialSourceFile, _ = cmd.Flags().GetString("gemini-workload-identity-credential-source-file")
+	c.GeminiWorkloadIdentityImpersonationURL, _ = cmd.Flags().GetString("gemini-workload-identity-impersonation-url")
+	c.GeminiLocation, _ = cmd.Flags().GetString("gemini-location")
+	c.GeminiVertexEndpointOverride, _ = cmd.Flags().GetString("gemini-vertex-endpoint-override")
 
 	// System prompt flags
 	c.SystemPromptFile, _ = cmd.Flags().GetString("system-prompt-file")
@@ -114,6 +289,88 @@ func (c *Config) LoadFromFlags(cmd *cobra.Command) error {
 	c.RequestMaxRetries, _ = cmd.Flags().GetInt("request-max-retries")
 	c.RequestBaseDelay, _ = cmd.Flags().GetInt("request-base-delay")
 
+	// Redis flags
+	c.RedisAddr, _ = cmd.Flags().GetString("redis-addr")
+	c.RedisPassword, _ = cmd.Flags().GetString("redis-password")
+	c.RedisDB, _ = cmd.Flags().GetInt("redis-db")
+	c.RedisMode, _ = cmd.Flags().GetString("redis-mode")
+	c.RedisClusterAddrs, _ = cmd.Flags().GetStringSlice("redis-cluster-addrs")
+	c.RedisSentinelAddrs, _ = cmd.Flags().GetStringSlice("redis-sentinel-addrs")
+	c.RedisSentinelMaster, _ = cmd.Flags().GetString("redis-sentinel-master")
+	c.RedisSentinelPassword, _ = cmd.Flags().GetString("redis-sentinel-password")
+
+	// Metrics flags
+	c.MetricsEnabled, _ = cmd.Flags().GetBool("metrics-enabled")
+	c.MetricsAddr, _ = cmd.Flags().GetString("metrics-addr")
+	c.MetricsPath, _ = cmd.Flags().GetString("metrics-path")
+	c.ActiveUsersStateFile, _ = cmd.Flags().GetString("active-users-state-file")
+
+	// Usage accounting flags
+	c.UsageBudgetUSD, _ = cmd.Flags().GetFloat64("usage-budget-usd")
+	c.UsageBudgetWindowMins, _ = cmd.Flags().GetInt("usage-budget-window-minutes")
+
+	// Response cache flags
+	c.CacheEnabled, _ = cmd.Flags().GetBool("cache-enabled")
+	c.CacheTTLMins, _ = cmd.Flags().GetInt("cache-ttl-minutes")
+	c.CacheMaxSizeMB, _ = cmd.Flags().GetInt64("cache-max-size-mb")
+	c.StreamCacheModels, _ = cmd.Flags().GetStringSlice("stream-cache-models")
+	c.StreamCacheChunkDelayMs, _ = cmd.Flags().GetInt("stream-cache-chunk-delay-ms")
+
+	// Semantic cache flags
+	c.SemanticCacheEnabled, _ = cmd.Flags().GetBool("semantic-cache-enabled")
+	c.SemanticCacheThreshold, _ = cmd.Flags().GetFloat64("semantic-cache-threshold")
+	c.SemanticCacheTemperatureMax, _ = cmd.Flags().GetFloat64("semantic-cache-temperature-max")
+	c.SemanticCacheEmbeddingModel, _ = cmd.Flags().GetString("semantic-cache-embedding-model")
+	c.SemanticCacheEmbeddingURL, _ = cmd.Flags().GetString("semantic-cache-embedding-url")
+	c.SemanticCacheEmbeddingKey, _ = cmd.Flags().GetString("semantic-cache-embedding-key")
+
+	// Provider response cache flags
+	c.ProviderCacheMode, _ = cmd.Flags().GetString("cache-mode")
+	c.ProviderCacheTTLSeconds, _ = cmd.Flags().GetInt("cache-ttl")
+	c.ProviderCacheSimilarityThreshold, _ = cmd.Flags().GetFloat64("cache-similarity-threshold")
+	c.ProviderCacheEmbeddingModel, _ = cmd.Flags().GetString("cache-embedding-model")
+
+	// OIDC flags
+	c.OIDCIssuerURL, _ = cmd.Flags().GetString("oidc-issuer-url")
+	c.OIDCAudience, _ = cmd.Flags().GetString("oidc-audience")
+	c.OIDCRequiredScopes, _ = cmd.Flags().GetStringSlice("oidc-required-scopes")
+	c.OIDCAllowedSubjects, _ = cmd.Flags().GetStringSlice("oidc-allowed-subjects")
+	c.OIDCAllowedGroups, _ = cmd.Flags().GetStringSlice("oidc-allowed-groups")
+	c.OIDCCallerPoliciesFile, _ = cmd.Flags().GetString("oidc-caller-policies-file")
+
+	// Token store flags
+	c.TokenStoreType, _ = cmd.Flags().GetString("token-store-type")
+	c.TokenStoreDir, _ = cmd.Flags().GetString("token-store-dir")
+	c.TokenStoreEncKeyBase64, _ = cmd.Flags().GetString("token-store-encryption-key")
+
+	// mTLS / SPIFFE flags
+	c.TLSCertFile, _ = cmd.Flags().GetString("tls-cert-file")
+	c.TLSKeyFile, _ = cmd.Flags().GetString("tls-key-file")
+	c.TLSClientCAFile, _ = cmd.Flags().GetString("tls-client-ca-file")
+	c.TLSClientCARequire, _ = cmd.Flags().GetString("tls-client-ca-require")
+	c.SPIFFETrustDomain, _ = cmd.Flags().GetString("spiffe-trust-domain")
+
+	// gRPC flags
+	c.GRPCEnabled, _ = cmd.Flags().GetBool("grpc-enabled")
+	c.GRPCAddr, _ = cmd.Flags().GetString("grpc-addr")
+
+	// Hedged-request flags
+	c.HedgeEnabled, _ = cmd.Flags().GetBool("hedge-enabled")
+	c.HedgeAfterMs, _ = cmd.Flags().GetInt("hedge-after-ms")
+
+	// Observability flags
+	c.OTLPEndpoint, _ = cmd.Flags().GetString("otlp-endpoint")
+	if endpoint, _ := cmd.Flags().GetString("otel-endpoint"); endpoint != "" {
+		c.OTLPEndpoint = endpoint
+	}
+	c.OTelServiceName, _ = cmd.Flags().GetString("otel-service-name")
+	c.OTelSampler, _ = cmd.Flags().GetString("otel-sampler")
+	c.OTelHeaders, _ = cmd.Flags().GetStringSlice("otel-headers")
+
+	// Plugin flags
+	c.PluginDir, _ = cmd.Flags().GetString("plugin-dir")
+	c.PluginMaxMemoryMB, _ = cmd.Flags().GetInt("plugin-max-memory-mb")
+
 	// Build provider configurations
 	c.buildProviderConfigs()
 
@@ -167,6 +424,197 @@ func (c *Config) LoadFromEnv() {
 	if projectID := os.Getenv("GOOGLE_CLOUD_PROJECT"); projectID != "" {
 		c.ProjectID = projectID
 	}
+	if audience := os.Getenv("GEMINI_WORKLOAD_IDENTITY_AUDIENCE"); audience != "" {
+		c.GeminiWorkloadIdentityAudience = audience
+	}
+	if tokenType := os.Getenv("GEMINI_WORKLOAD_IDENTITY_SUBJECT_TOKEN_TYPE"); tokenType != "" {
+		c.GeminiWorkloadIdentitySubjectTokenType = tokenType
+	}
+	if file := os.Getenv("GEMINI_WORKLOAD_IDENTITY_CREDENTIAL_SOURCE_FILE"); file != "" {
+		c.GeminiWorkloadIdentityCredentialSourceFile = file
+	}
+	if url := os.Getenv("GEMINI_WORKLOAD_IDENTITY_IMPERSONATION_URL"); url != "" {
+		c.GeminiWorkloadIdentityImpersonationURL = url
+	}
+	if location := os.Getenv("GEMINI_LOCATION"); location != "" {
+		c.GeminiLocation = location
+	}
+	if endpoint := os.Getenv("GEMINI_VERTEX_ENDPOINT_OVERRIDE"); endpoint != "" {
+		c.GeminiVertexEndpointOverride = endpoint
+	}
+
+	// Usage accounting environment variables
+	if budget := os.Getenv("AIPROXY_USAGE_BUDGET_USD"); budget != "" {
+		fmt.Sscanf(budget, "%f", &c.UsageBudgetUSD)
+	}
+
+	// Response cache environment variables
+	if enabled := os.Getenv("AIPROXY_CACHE_ENABLED"); enabled != "" {
+		c.CacheEnabled = enabled == "true" || enabled == "1"
+	}
+	if models := os.Getenv("AIPROXY_STREAM_CACHE_MODELS"); models != "" {
+		c.StreamCacheModels = strings.Split(models, ",")
+	}
+	if delay := os.Getenv("AIPROXY_STREAM_CACHE_CHUNK_DELAY_MS"); delay != "" {
+		fmt.Sscanf(delay, "%d", &c.StreamCacheChunkDelayMs)
+	}
+
+	// Semantic cache environment variables
+	if enabled := os.Getenv("AIPROXY_SEMANTIC_CACHE_ENABLED"); enabled != "" {
+		c.SemanticCacheEnabled = enabled == "true" || enabled == "1"
+	}
+	if model := os.Getenv("AIPROXY_SEMANTIC_CACHE_EMBEDDING_MODEL"); model != "" {
+		c.SemanticCacheEmbeddingModel = model
+	}
+	if url := os.Getenv("AIPROXY_SEMANTIC_CACHE_EMBEDDING_URL"); url != "" {
+		c.SemanticCacheEmbeddingURL = url
+	}
+	if key := os.Getenv("AIPROXY_SEMANTIC_CACHE_EMBEDDING_KEY"); key != "" {
+		c.SemanticCacheEmbeddingKey = key
+	}
+
+	// Provider response cache environment variables
+	if mode := os.Getenv("AIPROXY_CACHE_MODE"); mode != "" {
+		c.ProviderCacheMode = mode
+	}
+	if ttl := os.Getenv("AIPROXY_CACHE_TTL"); ttl != "" {
+		fmt.Sscanf(ttl, "%d", &c.ProviderCacheTTLSeconds)
+	}
+	if threshold := os.Getenv("AIPROXY_CACHE_SIMILARITY_THRESHOLD"); threshold != "" {
+		fmt.Sscanf(threshold, "%g", &c.ProviderCacheSimilarityThreshold)
+	}
+	if model := os.Getenv("AIPROXY_CACHE_EMBEDDING_MODEL"); model != "" {
+		c.ProviderCacheEmbeddingModel = model
+	}
+
+	// OIDC environment variables
+	if issuer := os.Getenv("AIPROXY_OIDC_ISSUER_URL"); issuer != "" {
+		c.OIDCIssuerURL = issuer
+	}
+	if audience := os.Getenv("AIPROXY_OIDC_AUDIENCE"); audience != "" {
+		c.OIDCAudience = audience
+	}
+	if scopes := os.Getenv("AIPROXY_OIDC_REQUIRED_SCOPES"); scopes != "" {
+		c.OIDCRequiredScopes = strings.Split(scopes, ",")
+	}
+	if subjects := os.Getenv("AIPROXY_OIDC_ALLOWED_SUBJECTS"); subjects != "" {
+		c.OIDCAllowedSubjects = strings.Split(subjects, ",")
+	}
+	if groups := os.Getenv("AIPROXY_OIDC_ALLOWED_GROUPS"); groups != "" {
+		c.OIDCAllowedGroups = strings.Split(groups, ",")
+	}
+	if file := os.Getenv("AIPROXY_OIDC_CALLER_POLICIES_FILE"); file != "" {
+		c.OIDCCallerPoliciesFile = file
+	}
+
+	// Redis environment variables
+	if addr := os.Getenv("AIPROXY_REDIS_ADDR"); addr != "" {
+		c.RedisAddr = addr
+	}
+	if password := os.Getenv("AIPROXY_REDIS_PASSWORD"); password != "" {
+		c.RedisPassword = password
+	}
+	if db := os.Getenv("AIPROXY_REDIS_DB"); db != "" {
+		fmt.Sscanf(db, "%d", &c.RedisDB)
+	}
+	if mode := os.Getenv("AIPROXY_REDIS_MODE"); mode != "" {
+		c.RedisMode = mode
+	}
+	if addrs := os.Getenv("AIPROXY_REDIS_CLUSTER_ADDRS"); addrs != "" {
+		c.RedisClusterAddrs = strings.Split(addrs, ",")
+	}
+	if addrs := os.Getenv("AIPROXY_REDIS_SENTINEL_ADDRS"); addrs != "" {
+		c.RedisSentinelAddrs = strings.Split(addrs, ",")
+	}
+	if master := os.Getenv("AIPROXY_REDIS_SENTINEL_MASTER"); master != "" {
+		c.RedisSentinelMaster = master
+	}
+	if password := os.Getenv("AIPROXY_REDIS_SENTINEL_PASSWORD"); password != "" {
+		c.RedisSentinelPassword = password
+	}
+
+	// Metrics environment variables
+	if enabled := os.Getenv("AIPROXY_METRICS_ENABLED"); enabled != "" {
+		c.MetricsEnabled = enabled == "true" || enabled == "1"
+	}
+	if addr := os.Getenv("AIPROXY_METRICS_ADDR"); addr != "" {
+		c.MetricsAddr = addr
+	}
+	if path := os.Getenv("AIPROXY_METRICS_PATH"); path != "" {
+		c.MetricsPath = path
+	}
+	if path := os.Getenv("AIPROXY_ACTIVE_USERS_STATE_FILE"); path != "" {
+		c.ActiveUsersStateFile = path
+	}
+
+	// Token store environment variables
+	if storeType := os.Getenv("AIPROXY_TOKEN_STORE_TYPE"); storeType != "" {
+		c.TokenStoreType = storeType
+	}
+	if dir := os.Getenv("AIPROXY_TOKEN_STORE_DIR"); dir != "" {
+		c.TokenStoreDir = dir
+	}
+	if key := os.Getenv("AIPROXY_TOKEN_STORE_ENCRYPTION_KEY"); key != "" {
+		c.TokenStoreEncKeyBase64 = key
+	}
+
+	// mTLS / SPIFFE environment variables
+	if file := os.Getenv("AIPROXY_TLS_CERT_FILE"); file != "" {
+		c.TLSCertFile = file
+	}
+	if file := os.Getenv("AIPROXY_TLS_KEY_FILE"); file != "" {
+		c.TLSKeyFile = file
+	}
+	if file := os.Getenv("AIPROXY_TLS_CLIENT_CA_FILE"); file != "" {
+		c.TLSClientCAFile = file
+	}
+	if mode := os.Getenv("AIPROXY_TLS_CLIENT_CA_REQUIRE"); mode != "" {
+		c.TLSClientCARequire = mode
+	}
+	if domain := os.Getenv("AIPROXY_SPIFFE_TRUST_DOMAIN"); domain != "" {
+		c.SPIFFETrustDomain = domain
+	}
+
+	// gRPC environment variables
+	if enabled := os.Getenv("AIPROXY_GRPC_ENABLED"); enabled != "" {
+		c.GRPCEnabled = enabled == "true" || enabled == "1"
+	}
+	if addr := os.Getenv("AIPROXY_GRPC_ADDR"); addr != "" {
+		c.GRPCAddr = addr
+	}
+
+	// Hedged-request environment variables
+	if enabled := os.Getenv("AIPROXY_HEDGE_ENABLED"); enabled != "" {
+		c.HedgeEnabled = enabled == "true" || enabled == "1"
+	}
+	if ms := os.Getenv("AIPROXY_HEDGE_AFTER_MS"); ms != "" {
+		fmt.Sscanf(ms, "%d", &c.HedgeAfterMs)
+	}
+
+	// Observability environment variables
+	if endpoint := os.Getenv("AIPROXY_OTLP_ENDPOINT"); endpoint != "" {
+		c.OTLPEndpoint = endpoint
+	}
+	if endpoint := os.Getenv("AIPROXY_OTEL_ENDPOINT"); endpoint != "" {
+		c.OTLPEndpoint = endpoint
+	}
+	if name := os.Getenv("AIPROXY_OTEL_SERVICE_NAME"); name != "" {
+		c.OTelServiceName = name
+	}
+	if sampler := os.Getenv("AIPROXY_OTEL_SAMPLER"); sampler != "" {
+		c.OTelSampler = sampler
+	}
+	if headers := os.Getenv("AIPROXY_OTEL_HEADERS"); headers != "" {
+		c.OTelHeaders = strings.Split(headers, ",")
+	}
+
+	// Plugin environment variables
+	if dir := os.Getenv("AIPROXY_PLUGIN_DIR"); dir != "" {
+		c.PluginDir = dir
+	}
+	if maxMB := os.Getenv("AIPROXY_PLUGIN_MAX_MEMORY_MB"); maxMB != "" {
+		fmt.Sscanf(maxMB, "%d", &c.PluginMaxMemoryMB)
+	}
 
 	// Rebuild provider configurations with environment variables
 	c.buildProviderConfigs()
@@ -190,11 +638,17 @@ func (c *Config) buildProviderConfigs() {
 			}
 		case "gemini-cli", "gemini-cli-oauth":
 			c.ProviderConfigs[provider] = &models.ProviderConfig{
-				Provider:         models.ProviderGemini,
-				APIKey:           c.GeminiAPIKey,
-				ProjectID:        c.ProjectID,
-				OAuthCredsBase64: c.GeminiOAuthCredsBase64,
-				OAuthCredsFile:   c.GeminiOAuthCredsFile,
+				Provider:                             models.ProviderGemini,
+				APIKey:                               c.GeminiAPIKey,
+				ProjectID:                            c.ProjectID,
+				OAuthCredsBase64:                     c.GeminiOAuthCredsBase64,
+				OAuthCredsFile:                       c.GeminiOAuthCredsFile,
+				WorkloadIdentityAudience:             c.GeminiWorkloadIdentityAudience,
+				WorkloadIdentitySubjectTokenType:     c.GeminiWorkloadIdentitySubjectTokenType,
+				WorkloadIdentityCredentialSourceFile: c.GeminiWorkloadIdentityCredentialSourceFile,
+				WorkloadIdentityImpersonationURL:     c.GeminiWorkloadIdentityImpersonationURL,
+				Location:                             c.GeminiLocation,
+				VertexEndpointOverride:               c.GeminiVertexEndpointOverride,
 			}
 		}
 	}
@@ -209,16 +663,45 @@ func (c *Config) GetProviderConfig(provider string) (*models.ProviderConfig, err
 	return cfg, nil
 }
 
+// OTelHeaderMap parses OTelHeaders' "key=value" entries into a map,
+// silently dropping any entry without an "=" since it's not addressed to
+// InitTracing's exporter headers anyway.
+func (c *Config) OTelHeaderMap() map[string]string {
+	if len(c.OTelHeaders) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(c.OTelHeaders))
+	for _, kv := range c.OTelHeaders {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		headers[key] = value
+	}
+	return headers
+}
+
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
-	if c.APIKey == "" {
-		return fmt.Errorf("API key is required")
+	if c.APIKey == "" && c.OIDCIssuerURL == "" {
+		return fmt.Errorf("API key is required unless OIDC authentication is configured")
 	}
 
 	if len(c.ModelProviders) == 0 {
 		return fmt.Errorf("at least one model provider must be configured")
 	}
 
+	if c.TLSClientCAFile != "" {
+		if c.TLSCertFile == "" || c.TLSKeyFile == "" {
+			return fmt.Errorf("tls-cert-file and tls-key-file are required when tls-client-ca-file is set")
+		}
+		switch c.TLSClientCARequire {
+		case "", "request", "require", "verify":
+		default:
+			return fmt.Errorf("invalid tls-client-ca-require %q: must be request, require, or verify", c.TLSClientCARequire)
+		}
+	}
+
 	// Validate each provider configuration
 	for name, cfg := range c.ProviderConfigs {
 		if err := c.validateProviderConfig(name, cfg); err != nil {
@@ -59,10 +59,14 @@ func (c *Config) AddKiroConfig() {
 
 	if kiroCredsFile != "" || kiroCredsBase64 != "" {
 		c.ProviderConfigs["kiro-api"] = &models.ProviderConfig{
-			Provider:         models.ProviderKiro,
-			OAuthCredsFile:   kiroCredsFile,
-			OAuthCredsBase64: kiroCredsBase64,
-			BaseURL:          getEnvOrDefault("KIRO_BASE_URL", "https://api.kiro.com"),
+			Provider:               models.ProviderKiro,
+			OAuthCredsFile:         kiroCredsFile,
+			OAuthCredsBase64:       kiroCredsBase64,
+			BaseURL:                getEnvOrDefault("KIRO_BASE_URL", "https://api.kiro.com"),
+			TokenStoreType:         getEnvOrDefault("AIPROXY_TOKEN_STORE_TYPE", "memory"),
+			TokenStoreDir:          getEnvOrDefault("AIPROXY_TOKEN_STORE_DIR", "./.aiproxy/tokens"),
+			TokenStoreEncKeyBase64: getEnvOrDefault("AIPROXY_TOKEN_STORE_ENCRYPTION_KEY", ""),
+			TokenStoreRedisAddr:    getEnvOrDefault("AIPROXY_REDIS_ADDR", ""),
 		}
 
 		// Add to model providers if not already present
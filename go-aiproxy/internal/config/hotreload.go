@@ -0,0 +1,102 @@
+package config
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce coalesces a burst of fsnotify events on the same file
+// (e.g. an editor's write-then-rename, or a Kubernetes ConfigMap's
+// atomic symlink swap touching several watched paths at once) into a
+// single callback instead of firing once per event.
+const reloadDebounce = 500 * time.Millisecond
+
+// CredentialWatcher watches a set of files on disk (credential files,
+// config snippets) and invokes onChange with the path whenever one of them
+// is rewritten, debounced so a burst of events on the same file collapses
+// into a single callback. It is deliberately narrower than a full config
+// reload: callers own re-parsing and validating whatever onChange tells
+// them changed, the same way tlsReloader owns reloading its own
+// certificate/key pair.
+type CredentialWatcher struct {
+	watcher  *fsnotify.Watcher
+	onChange func(path string)
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+// NewCredentialWatcher watches every path in paths and calls onChange
+// (after debouncing) when one of them is written or created. Paths that
+// don't exist yet are skipped rather than failing the whole watcher, since
+// not every deployment configures every provider's credentials file.
+func NewCredentialWatcher(paths []string, onChange func(path string)) (*CredentialWatcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &CredentialWatcher{
+		watcher:  fsw,
+		onChange: onChange,
+		timers:   make(map[string]*time.Timer),
+	}
+
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		if err := fsw.Add(p); err != nil {
+			log.Printf("config: failed to watch %s: %v", p, err)
+		}
+	}
+
+	go w.watch()
+	return w, nil
+}
+
+// watch reacts to write/create events. Credential files managed by an
+// external rotator are often rewritten via rename-into-place, which
+// fsnotify reports as Create on the destination path rather than Write, so
+// both are treated the same way.
+func (w *CredentialWatcher) watch() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			w.scheduleChange(event.Name)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("config: watcher error: %v", err)
+		}
+	}
+}
+
+// scheduleChange (re)starts path's debounce timer so a burst of events on
+// the same path within reloadDebounce fires onChange exactly once.
+func (w *CredentialWatcher) scheduleChange(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if t, ok := w.timers[path]; ok {
+		t.Stop()
+	}
+	w.timers[path] = time.AfterFunc(reloadDebounce, func() {
+		w.onChange(path)
+	})
+}
+
+// Close stops the underlying fsnotify watcher.
+func (w *CredentialWatcher) Close() error {
+	return w.watcher.Close()
+}
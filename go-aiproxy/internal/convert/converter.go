@@ -1,8 +1,12 @@
 package convert
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
 	"time"
 
@@ -16,6 +20,15 @@ const (
 	DefaultGeminiMaxTokens = 65536
 	DefaultTemperature     = 1.0
 	DefaultTopP            = 0.9
+
+	// maxFetchBytes bounds how much of a remote image/document URL's body
+	// the default Fetcher will read before giving up, so one oversized
+	// attachment can't exhaust memory mid-conversion.
+	maxFetchBytes = 20 << 20 // 20MiB
+
+	// fetchTimeout bounds the default Fetcher's round trip, since
+	// ConvertRequest has no context.Context of its own to carry a deadline.
+	fetchTimeout = 10 * time.Second
 )
 
 // Converter interface defines the conversion methods
@@ -24,14 +37,116 @@ type Converter interface {
 	ConvertResponse(data interface{}, fromProvider, toProvider models.ProtocolPrefix, model string) (interface{}, error)
 	ConvertStreamChunk(data interface{}, fromProvider, toProvider models.ProtocolPrefix, model string) (interface{}, error)
 	ConvertModelList(data interface{}, fromProvider, toProvider models.ProtocolPrefix) (interface{}, error)
+
+	// CanonicalizeRequest produces stable JSON identifying data's semantic
+	// content for cache-key hashing, regardless of which protocol it
+	// arrived as. Claude is the canonical shape every protocol converts
+	// through, and only the fields that can change the completion
+	// (model, system, messages, tools) are included.
+	CanonicalizeRequest(data interface{}, fromProvider models.ProtocolPrefix) ([]byte, error)
+}
+
+// Fetcher retrieves the bytes at url and reports its MIME type. It backs
+// remote image_url/document inlining for protocols (Claude, Gemini) that
+// can't reference an arbitrary URL the way OpenAI's image_url can.
+type Fetcher func(ctx context.Context, url string) (data []byte, mimeType string, err error)
+
+// ConverterOption configures a DefaultConverter.
+type ConverterOption func(*DefaultConverter)
+
+// WithFetcher overrides how remote URLs are fetched when inlining them
+// into a protocol that needs the bytes rather than a link, letting tests
+// inject a fake instead of making real network calls.
+func WithFetcher(f Fetcher) ConverterOption {
+	return func(c *DefaultConverter) {
+		c.fetch = f
+	}
+}
+
+// WithHTTPClient overrides the http.Client the default Fetcher uses.
+func WithHTTPClient(client *http.Client) ConverterOption {
+	return func(c *DefaultConverter) {
+		c.httpClient = client
+	}
 }
 
 // DefaultConverter implements the Converter interface
-type DefaultConverter struct{}
+type DefaultConverter struct {
+	httpClient *http.Client
+	fetch      Fetcher
+}
 
 // NewConverter creates a new converter instance
-func NewConverter() Converter {
-	return &DefaultConverter{}
+func NewConverter(opts ...ConverterOption) Converter {
+	c := &DefaultConverter{httpClient: http.DefaultClient}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.fetch == nil {
+		c.fetch = c.defaultFetch
+	}
+	return c
+}
+
+// defaultFetch is the Fetcher used unless WithFetcher overrides it: a
+// plain GET through c.httpClient, capped at maxFetchBytes, that sniffs
+// the MIME type from the Content-Type header and falls back to content
+// sniffing when that header is missing or generic.
+func (c *DefaultConverter) defaultFetch(ctx context.Context, url string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("build request for %s: %w", url, err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, "", fmt.Errorf("fetch %s: HTTP %d", url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("read %s: %w", url, err)
+	}
+	if len(data) > maxFetchBytes {
+		return nil, "", fmt.Errorf("fetch %s: exceeds %d byte limit", url, maxFetchBytes)
+	}
+
+	mimeType := resp.Header.Get("Content-Type")
+	if idx := strings.IndexByte(mimeType, ';'); idx >= 0 {
+		mimeType = mimeType[:idx]
+	}
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = http.DetectContentType(data)
+	}
+	return data, mimeType, nil
+}
+
+// fetchInline fetches url via c.fetch, bounded by fetchTimeout, and
+// returns its bytes base64-encoded alongside the sniffed MIME type.
+func (c *DefaultConverter) fetchInline(url string) (data string, mimeType string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	raw, mime, err := c.fetch(ctx, url)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(raw), mime, nil
+}
+
+// audioFormatFromMimeType derives OpenAI's input_audio "format" (a bare
+// extension like "wav" or "mp3") from a full audio/* MIME type.
+func audioFormatFromMimeType(mimeType string) string {
+	format := strings.TrimPrefix(mimeType, "audio/")
+	if format == "mpeg" {
+		return "mp3"
+	}
+	return format
 }
 
 // ConvertRequest converts request between different formats
@@ -41,27 +156,35 @@ func (c *DefaultConverter) ConvertRequest(data interface{}, fromProvider, toProv
 		return data, nil
 	}
 
+	// Vertex AI is Gemini's request/response shape under different
+	// routing/auth, and Azure OpenAI is OpenAI's shape under a
+	// deployment-name path plus an api-version query param, so both
+	// reuse the underlying protocol's conversion functions.
 	switch toProvider {
-	case models.ProtocolOpenAI:
+	case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
 		switch fromProvider {
-		case models.ProtocolGemini:
+		case models.ProtocolGemini, models.ProtocolVertexAI:
 			return c.toOpenAIRequestFromGemini(data)
 		case models.ProtocolClaude:
 			return c.toOpenAIRequestFromClaude(data)
+		case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
+			return data, nil
 		}
 	case models.ProtocolClaude:
 		switch fromProvider {
-		case models.ProtocolOpenAI:
+		case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
 			return c.toClaudeRequestFromOpenAI(data)
-		case models.ProtocolGemini:
+		case models.ProtocolGemini, models.ProtocolVertexAI:
 			return c.toClaudeRequestFromGemini(data)
 		}
-	case models.ProtocolGemini:
+	case models.ProtocolGemini, models.ProtocolVertexAI:
 		switch fromProvider {
-		case models.ProtocolOpenAI:
+		case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
 			return c.toGeminiRequestFromOpenAI(data)
 		case models.ProtocolClaude:
 			return c.toGeminiRequestFromClaude(data)
+		case models.ProtocolGemini, models.ProtocolVertexAI:
+			return data, nil
 		}
 	}
 
@@ -75,20 +198,31 @@ func (c *DefaultConverter) ConvertResponse(data interface{}, fromProvider, toPro
 	}
 
 	switch toProvider {
-	case models.ProtocolOpenAI:
+	case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
 		switch fromProvider {
-		case models.ProtocolGemini:
+		case models.ProtocolGemini, models.ProtocolVertexAI:
 			return c.toOpenAIChatCompletionFromGemini(data, model)
 		case models.ProtocolClaude:
 			return c.toOpenAIChatCompletionFromClaude(data, model)
+		case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
+			return data, nil
 		}
 	case models.ProtocolClaude:
 		switch fromProvider {
-		case models.ProtocolOpenAI:
+		case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
 			return c.toClaudeChatCompletionFromOpenAI(data, model)
-		case models.ProtocolGemini:
+		case models.ProtocolGemini, models.ProtocolVertexAI:
 			return c.toClaudeChatCompletionFromGemini(data, model)
 		}
+	case models.ProtocolGemini, models.ProtocolVertexAI:
+		switch fromProvider {
+		case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
+			return c.toGeminiChatCompletionFromOpenAI(data, model)
+		case models.ProtocolClaude:
+			return c.toGeminiChatCompletionFromClaude(data, model)
+		case models.ProtocolGemini, models.ProtocolVertexAI:
+			return data, nil
+		}
 	}
 
 	return nil, fmt.Errorf("unsupported response conversion from %s to %s", fromProvider, toProvider)
@@ -101,18 +235,18 @@ func (c *DefaultConverter) ConvertStreamChunk(data interface{}, fromProvider, to
 	}
 
 	switch toProvider {
-	case models.ProtocolOpenAI:
+	case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
 		switch fromProvider {
-		case models.ProtocolGemini:
+		case models.ProtocolGemini, models.ProtocolVertexAI:
 			return c.toOpenAIStreamChunkFromGemini(data, model)
 		case models.ProtocolClaude:
 			return c.toOpenAIStreamChunkFromClaude(data, model)
 		}
 	case models.ProtocolClaude:
 		switch fromProvider {
-		case models.ProtocolOpenAI:
+		case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
 			return c.toClaudeStreamChunkFromOpenAI(data, model)
-		case models.ProtocolGemini:
+		case models.ProtocolGemini, models.ProtocolVertexAI:
 			return c.toClaudeStreamChunkFromGemini(data, model)
 		}
 	}
@@ -127,9 +261,9 @@ func (c *DefaultConverter) ConvertModelList(data interface{}, fromProvider, toPr
 	}
 
 	switch toProvider {
-	case models.ProtocolOpenAI:
+	case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
 		switch fromProvider {
-		case models.ProtocolGemini:
+		case models.ProtocolGemini, models.ProtocolVertexAI:
 			return c.toOpenAIModelListFromGemini(data)
 		case models.ProtocolClaude:
 			return c.toOpenAIModelListFromClaude(data)
@@ -139,6 +273,43 @@ func (c *DefaultConverter) ConvertModelList(data interface{}, fromProvider, toPr
 	return nil, fmt.Errorf("unsupported model list conversion from %s to %s", fromProvider, toProvider)
 }
 
+// canonicalRequest is the subset of a Claude request that determines the
+// completion it produces. stream, max_tokens, and top_p are deliberately
+// left out so requests that only differ in those still hash the same.
+type canonicalRequest struct {
+	Model    string                 `json:"model"`
+	System   string                 `json:"system,omitempty"`
+	Messages []models.ClaudeMessage `json:"messages"`
+	Tools    []models.ClaudeTool    `json:"tools"`
+}
+
+// CanonicalizeRequest converts data to Claude's shape (the canonical form
+// every protocol in this converter goes through) and marshals the fields
+// that determine the completion, so an OpenAI, Gemini, and Claude request
+// carrying the same conversation hash identically.
+func (c *DefaultConverter) CanonicalizeRequest(data interface{}, fromProvider models.ProtocolPrefix) ([]byte, error) {
+	converted, err := c.ConvertRequest(data, fromProvider, models.ProtocolClaude)
+	if err != nil {
+		return nil, fmt.Errorf("canonicalize request: %w", err)
+	}
+
+	claudeReq, ok := converted.(*models.ClaudeRequest)
+	if !ok {
+		jsonData, _ := json.Marshal(converted)
+		claudeReq = &models.ClaudeRequest{}
+		if err := json.Unmarshal(jsonData, claudeReq); err != nil {
+			return nil, fmt.Errorf("canonicalize request: %w", err)
+		}
+	}
+
+	return json.Marshal(canonicalRequest{
+		Model:    claudeReq.Model,
+		System:   claudeReq.System,
+		Messages: claudeReq.Messages,
+		Tools:    claudeReq.Tools,
+	})
+}
+
 // Helper function to check and assign default values
 func checkAndAssignOrDefault[T comparable](value T, defaultValue T) T {
 	var zero T
@@ -148,6 +319,216 @@ func checkAndAssignOrDefault[T comparable](value T, defaultValue T) T {
 	return defaultValue
 }
 
+// floatPtr returns a pointer to v, for populating OpenAIRequest.Temperature
+// (a *float64, so an explicit 0 survives round-tripping through a
+// protocol whose own Temperature field can't distinguish it from "unset").
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+// reasoningEffortToBudgetTokens maps OpenAI's coarse reasoning_effort
+// ("low"/"medium"/"high") to an approximate Claude/Gemini thinking token
+// budget, since neither of those protocols has a matching tri-level enum.
+func reasoningEffortToBudgetTokens(effort string) int {
+	switch effort {
+	case "low":
+		return 4096
+	case "medium":
+		return 16384
+	case "high":
+		return 32768
+	default:
+		return 0
+	}
+}
+
+// budgetTokensToReasoningEffort is reasoningEffortToBudgetTokens' inverse,
+// bucketing a thinking token budget back into OpenAI's tri-level enum.
+func budgetTokensToReasoningEffort(budgetTokens int) string {
+	switch {
+	case budgetTokens <= 0:
+		return ""
+	case budgetTokens <= 4096:
+		return "low"
+	case budgetTokens <= 16384:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// extractClaudeReasoning pulls the first thinking or redacted_thinking
+// block out of content, returning the reasoning text (empty for a
+// redacted block, since Claude never reveals it) and the signature
+// needed to replay the block back to Claude unchanged on a later turn.
+// For a redacted block, the signature return carries the block's opaque
+// Data instead.
+func (c *DefaultConverter) extractClaudeReasoning(content []models.ClaudeContent) (text string, signature string) {
+	for _, block := range content {
+		switch block.Type {
+		case "thinking":
+			return block.Thinking, block.Signature
+		case "redacted_thinking":
+			return "", block.Data
+		}
+	}
+	return "", ""
+}
+
+// reasoningClaudeBlock is extractClaudeReasoning's inverse: it rebuilds
+// the thinking or redacted_thinking block a ReasoningContent/
+// ReasoningSignature pair came from, so replaying an assistant turn back
+// to Claude doesn't reserialize its reasoning as plain text (which
+// Claude rejects).
+func reasoningClaudeBlock(reasoningContent, reasoningSignature string) models.ClaudeContent {
+	if reasoningContent == "" {
+		return models.ClaudeContent{Type: "redacted_thinking", Data: reasoningSignature}
+	}
+	return models.ClaudeContent{Type: "thinking", Thinking: reasoningContent, Signature: reasoningSignature}
+}
+
+// extractGeminiReasoning joins any thought-flagged parts' text, Gemini's
+// equivalent of a Claude thinking block.
+func extractGeminiReasoning(parts []models.GeminiPart) string {
+	var reasoning []string
+	for _, part := range parts {
+		if part.Thought && part.Text != "" {
+			reasoning = append(reasoning, part.Text)
+		}
+	}
+	return strings.Join(reasoning, "\n")
+}
+
+// normalizeStopSequences accepts OpenAI's `stop` field, which on the wire
+// may be a single string or an array of strings, and normalizes it to a
+// slice for protocols (Claude, Gemini) that only accept an array.
+func normalizeStopSequences(stop interface{}) []string {
+	switch v := stop.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		seqs := make([]string, 0, len(v))
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				seqs = append(seqs, str)
+			}
+		}
+		return seqs
+	default:
+		return nil
+	}
+}
+
+// normalizeResponseFormat extracts a canonical models.ResponseFormat from
+// OpenAI's response_format wire shape — {"type":"json_object"} or
+// {"type":"json_schema","json_schema":{"schema":{...}}} — returning nil
+// for anything else (including text mode, which needs no translation).
+func (c *DefaultConverter) normalizeResponseFormat(raw interface{}) *models.ResponseFormat {
+	if raw == nil {
+		return nil
+	}
+
+	var wire struct {
+		Type       string `json:"type"`
+		JSONSchema struct {
+			Schema map[string]interface{} `json:"schema"`
+		} `json:"json_schema"`
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return nil
+	}
+
+	switch wire.Type {
+	case "json_object":
+		return &models.ResponseFormat{Type: "json_object"}
+	case "json_schema":
+		return &models.ResponseFormat{Type: "json_schema", Schema: wire.JSONSchema.Schema}
+	default:
+		return nil
+	}
+}
+
+// openAIResponseFormat builds OpenAI's response_format wire shape for rf,
+// the inverse of normalizeResponseFormat.
+func (c *DefaultConverter) openAIResponseFormat(rf *models.ResponseFormat) interface{} {
+	if rf == nil {
+		return nil
+	}
+	if rf.Type == "json_schema" && len(rf.Schema) > 0 {
+		return map[string]interface{}{
+			"type":        "json_schema",
+			"json_schema": map[string]interface{}{"schema": rf.Schema},
+		}
+	}
+	return map[string]interface{}{"type": "json_object"}
+}
+
+// claudeResponseFormatSuffix returns a system-prompt instruction embedding
+// rf's schema. Claude has no native response_format equivalent, so asking
+// it nicely via the system prompt (and repairing the result afterward in
+// toOpenAIChatCompletionFromClaude) is the only lever available.
+func claudeResponseFormatSuffix(rf *models.ResponseFormat) string {
+	if rf.Type == "json_schema" && len(rf.Schema) > 0 {
+		schemaJSON, _ := json.Marshal(rf.Schema)
+		return fmt.Sprintf("\n\nRespond with only a single JSON value matching this schema, with no surrounding prose or code fences:\n%s", schemaJSON)
+	}
+	return "\n\nRespond with only a single JSON value, with no surrounding prose or code fences."
+}
+
+// repairJSONContent attempts to recover a clean JSON payload from text a
+// model wrapped in a markdown code fence or padded with stray prose,
+// which Claude tends to do when it's merely asked (rather than natively
+// constrained) to emit JSON. The repair is speculative: if stripping
+// fences and trimming to the outermost {...}/[...] doesn't leave valid
+// JSON, the original text is returned untouched.
+func repairJSONContent(text string) string {
+	trimmed := strings.TrimSpace(text)
+	if fenced := stripCodeFence(trimmed); fenced != "" {
+		trimmed = fenced
+	}
+
+	if start := strings.IndexAny(trimmed, "{["); start >= 0 {
+		closing := byte('}')
+		if trimmed[start] == '[' {
+			closing = ']'
+		}
+		if end := strings.LastIndexByte(trimmed, closing); end > start {
+			trimmed = trimmed[start : end+1]
+		}
+	}
+
+	if json.Valid([]byte(trimmed)) {
+		return trimmed
+	}
+	return text
+}
+
+// stripCodeFence strips a leading/trailing ``` or ```json fence from
+// text, returning "" if text isn't fenced.
+func stripCodeFence(text string) string {
+	if !strings.HasPrefix(text, "```") {
+		return ""
+	}
+	lines := strings.SplitN(text, "\n", 2)
+	if len(lines) < 2 {
+		return ""
+	}
+	body := lines[1]
+	if idx := strings.LastIndex(body, "```"); idx >= 0 {
+		body = body[:idx]
+	}
+	return strings.TrimSpace(body)
+}
+
 // OpenAI conversion functions
 func (c *DefaultConverter) toOpenAIRequestFromGemini(data interface{}) (*models.OpenAIRequest, error) {
 	geminiReq, ok := data.(*models.GeminiRequest)
@@ -164,7 +545,7 @@ func (c *DefaultConverter) toOpenAIRequestFromGemini(data interface{}) (*models.
 		Model:       "gpt-3.5-turbo", // Default model
 		Messages:    []models.OpenAIMessage{},
 		MaxTokens:   DefaultMaxTokens,
-		Temperature: DefaultTemperature,
+		Temperature: floatPtr(DefaultTemperature),
 		TopP:        DefaultTopP,
 	}
 
@@ -181,35 +562,134 @@ func (c *DefaultConverter) toOpenAIRequestFromGemini(data interface{}) (*models.
 
 	// Process contents
 	for _, content := range geminiReq.Contents {
+		role := content.Role
+		if role == models.RoleModel {
+			role = models.RoleAssistant
+		}
+
+		if toolResponses := c.extractGeminiToolResponses(content.Parts); len(toolResponses) > 0 {
+			openaiReq.Messages = append(openaiReq.Messages, toolResponses...)
+			continue
+		}
+
+		// Gemini carries functionCall as an ordinary part alongside text,
+		// so a single content can need both an OpenAI tool_call and the
+		// model's accompanying text preserved in the same message.
+		msg := models.OpenAIMessage{Role: role}
+		if toolCalls := c.extractGeminiToolCalls(content.Parts); len(toolCalls) > 0 {
+			msg.ToolCalls = toolCalls
+		}
+		msg.ReasoningContent = extractGeminiReasoning(content.Parts)
 		openaiContent := c.processGeminiPartsToOpenAIContent(content.Parts)
-		if openaiContent != nil {
-			role := content.Role
-			if role == models.RoleModel {
-				role = models.RoleAssistant
-			}
-			openaiReq.Messages = append(openaiReq.Messages, models.OpenAIMessage{
-				Role:    role,
-				Content: openaiContent,
-			})
+		if s, isStr := openaiContent.(string); !isStr || s != "" {
+			msg.Content = openaiContent
+		}
+		if msg.Content != nil || len(msg.ToolCalls) > 0 || msg.ReasoningContent != "" {
+			openaiReq.Messages = append(openaiReq.Messages, msg)
 		}
 	}
 
 	// Process generation config
-	if geminiReq.GenerationConfig != nil {
-		if geminiReq.GenerationConfig.MaxOutputTokens > 0 {
-			openaiReq.MaxTokens = geminiReq.GenerationConfig.MaxOutputTokens
+	if gc := geminiReq.GenerationConfig; gc != nil {
+		if gc.MaxOutputTokens > 0 {
+			openaiReq.MaxTokens = gc.MaxOutputTokens
+		}
+		if gc.Temperature > 0 {
+			openaiReq.Temperature = floatPtr(gc.Temperature)
+		}
+		if gc.TopP > 0 {
+			openaiReq.TopP = gc.TopP
 		}
-		if geminiReq.GenerationConfig.Temperature > 0 {
-			openaiReq.Temperature = geminiReq.GenerationConfig.Temperature
+		if gc.TopK > 0 {
+			openaiReq.TopK = gc.TopK
 		}
-		if geminiReq.GenerationConfig.TopP > 0 {
-			openaiReq.TopP = geminiReq.GenerationConfig.TopP
+		if len(gc.StopSequences) > 0 {
+			openaiReq.Stop = gc.StopSequences
+		}
+		if gc.ResponseMimeType == "application/json" {
+			rf := &models.ResponseFormat{Type: "json_object"}
+			if len(gc.ResponseSchema) > 0 {
+				rf.Type = "json_schema"
+				rf.Schema = gc.ResponseSchema
+			}
+			openaiReq.ResponseFormat = c.openAIResponseFormat(rf)
+		}
+		if gc.ThinkingConfig != nil {
+			openaiReq.ReasoningEffort = budgetTokensToReasoningEffort(gc.ThinkingConfig.ThinkingBudget)
+		}
+	}
+
+	// Convert tools. Gemini/Vertex nests its function declarations one
+	// level deeper than OpenAI does (a GeminiTool wraps a
+	// FunctionDeclarations slice), so they're flattened into OpenAI's flat
+	// Tools list here.
+	for _, tool := range geminiReq.Tools {
+		for _, fn := range tool.FunctionDeclarations {
+			openaiReq.Tools = append(openaiReq.Tools, models.Tool{
+				Type: "function",
+				Function: models.ToolFunction{
+					Name:        fn.Name,
+					Description: fn.Description,
+					Parameters:  fn.Parameters,
+				},
+			})
 		}
 	}
 
 	return openaiReq, nil
 }
 
+// geminiFunctionCallID returns id if the functionCall/functionResponse
+// carried one, otherwise derives a stable id from the function name so a
+// functionCall and its matching functionResponse round-trip to the same
+// OpenAI tool_call_id even when talking to a Gemini client that predates
+// the "id" field.
+func geminiFunctionCallID(id, name string) string {
+	if id != "" {
+		return id
+	}
+	return "call_" + name
+}
+
+// extractGeminiToolCalls converts any functionCall parts into OpenAI
+// tool_calls, preserving (or deriving) the call ID so the response can be
+// matched back up by the caller.
+func (c *DefaultConverter) extractGeminiToolCalls(parts []models.GeminiPart) []models.ToolCall {
+	var toolCalls []models.ToolCall
+	for _, part := range parts {
+		if part.FunctionCall == nil {
+			continue
+		}
+		toolCalls = append(toolCalls, models.ToolCall{
+			ID:   geminiFunctionCallID(part.FunctionCall.ID, part.FunctionCall.Name),
+			Type: "function",
+			Function: models.ToolCallFunction{
+				Name:      part.FunctionCall.Name,
+				Arguments: c.marshalJSON(part.FunctionCall.Args),
+			},
+		})
+	}
+	return toolCalls
+}
+
+// extractGeminiToolResponses converts any functionResponse parts into
+// OpenAI tool messages, one per response, since OpenAI represents each
+// tool result as its own message keyed by tool_call_id.
+func (c *DefaultConverter) extractGeminiToolResponses(parts []models.GeminiPart) []models.OpenAIMessage {
+	var messages []models.OpenAIMessage
+	for _, part := range parts {
+		if part.FunctionResponse == nil {
+			continue
+		}
+		messages = append(messages, models.OpenAIMessage{
+			Role:       models.RoleTool,
+			ToolCallID: geminiFunctionCallID(part.FunctionResponse.ID, part.FunctionResponse.Name),
+			Content:    c.marshalJSON(part.FunctionResponse.Response),
+		})
+	}
+	return messages
+}
+
 func (c *DefaultConverter) processGeminiPartsToOpenAIContent(parts []models.GeminiPart) interface{} {
 	if len(parts) == 0 {
 		return ""
@@ -219,6 +699,11 @@ func (c *DefaultConverter) processGeminiPartsToOpenAIContent(parts []models.Gemi
 	hasMultimodal := false
 
 	for _, part := range parts {
+		if part.Thought {
+			// Reasoning is surfaced separately via ReasoningContent, not
+			// folded into the user-facing content.
+			continue
+		}
 		if part.Text != "" {
 			contentParts = append(contentParts, models.ContentPart{
 				Type: "text",
@@ -228,12 +713,36 @@ func (c *DefaultConverter) processGeminiPartsToOpenAIContent(parts []models.Gemi
 
 		if part.InlineData != nil {
 			hasMultimodal = true
-			contentParts = append(contentParts, models.ContentPart{
-				Type: "image_url",
-				ImageURL: &models.ImageURL{
-					URL: fmt.Sprintf("data:%s;base64,%s", part.InlineData.MimeType, part.InlineData.Data),
-				},
-			})
+			switch {
+			case strings.HasPrefix(part.InlineData.MimeType, "image/"):
+				contentParts = append(contentParts, models.ContentPart{
+					Type: "image_url",
+					ImageURL: &models.ImageURL{
+						URL: fmt.Sprintf("data:%s;base64,%s", part.InlineData.MimeType, part.InlineData.Data),
+					},
+				})
+			case strings.HasPrefix(part.InlineData.MimeType, "audio/"):
+				contentParts = append(contentParts, models.ContentPart{
+					Type: "input_audio",
+					InputAudio: &models.InputAudio{
+						Data:   part.InlineData.Data,
+						Format: audioFormatFromMimeType(part.InlineData.MimeType),
+					},
+				})
+			case part.InlineData.MimeType == "application/pdf":
+				contentParts = append(contentParts, models.ContentPart{
+					Type: "document",
+					Document: &models.DocumentPart{
+						MimeType: part.InlineData.MimeType,
+						Data:     part.InlineData.Data,
+					},
+				})
+			case strings.HasPrefix(part.InlineData.MimeType, "video/"):
+				contentParts = append(contentParts, models.ContentPart{
+					Type: "text",
+					Text: fmt.Sprintf("[Video attachment (%s), not representable in OpenAI's chat content]", part.InlineData.MimeType),
+				})
+			}
 		}
 
 		if part.FileData != nil {
@@ -259,6 +768,14 @@ func (c *DefaultConverter) processGeminiPartsToOpenAIContent(parts []models.Gemi
 		return contentParts[0].Text
 	}
 
+	// Parts that are entirely functionCall/functionResponse (no text or
+	// media) leave contentParts empty; normalize that to "" rather than a
+	// typed-nil slice so callers can use a plain != "" check to decide
+	// whether there's any content worth attaching alongside tool calls.
+	if len(contentParts) == 0 {
+		return ""
+	}
+
 	return contentParts
 }
 
@@ -273,7 +790,22 @@ func (c *DefaultConverter) toOpenAIChatCompletionFromGemini(data interface{}, mo
 	}
 
 	content := c.processGeminiResponseContent(geminiResp)
-	
+
+	message := &models.OpenAIMessage{
+		Role:    models.RoleAssistant,
+		Content: content,
+	}
+	finishReason := "stop"
+
+	if len(geminiResp.Candidates) > 0 {
+		candidate := geminiResp.Candidates[0]
+		finishReason = c.mapGeminiFinishReason(candidate.FinishReason)
+		if toolCalls := c.extractGeminiToolCalls(candidate.Content.Parts); len(toolCalls) > 0 {
+			message.ToolCalls = toolCalls
+			finishReason = "tool_calls"
+		}
+	}
+
 	response := &models.OpenAIResponse{
 		ID:      fmt.Sprintf("chatcmpl-%s", uuid.New().String()),
 		Object:  "chat.completion",
@@ -281,12 +813,9 @@ func (c *DefaultConverter) toOpenAIChatCompletionFromGemini(data interface{}, mo
 		Model:   model,
 		Choices: []models.OpenAIChoice{
 			{
-				Index: 0,
-				Message: &models.OpenAIMessage{
-					Role:    models.RoleAssistant,
-					Content: content,
-				},
-				FinishReason: "stop",
+				Index:        0,
+				Message:      message,
+				FinishReason: finishReason,
 			},
 		},
 	}
@@ -319,6 +848,24 @@ func (c *DefaultConverter) processGeminiResponseContent(resp *models.GeminiRespo
 	return strings.Join(contents, "\n")
 }
 
+// mapGeminiFinishReason maps a Gemini candidate's finishReason to the
+// equivalent OpenAI finish_reason value. The "tool_calls" case isn't
+// reachable through this mapping alone: Gemini reports "STOP" even when the
+// candidate's content is a functionCall, so callers override to "tool_calls"
+// once they've checked the content for tool calls directly.
+func (c *DefaultConverter) mapGeminiFinishReason(reason string) string {
+	switch reason {
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION":
+		return "content_filter"
+	case "STOP", "":
+		return "stop"
+	default:
+		return "stop"
+	}
+}
+
 func (c *DefaultConverter) toOpenAIStreamChunkFromGemini(data interface{}, model string) (*models.StreamChunk, error) {
 	chunkText, ok := data.(string)
 	if !ok {
@@ -366,10 +913,17 @@ func (c *DefaultConverter) toOpenAIRequestFromClaude(data interface{}) (*models.
 		Model:       claudeReq.Model,
 		Messages:    []models.OpenAIMessage{},
 		MaxTokens:   checkAndAssignOrDefault(claudeReq.MaxTokens, DefaultMaxTokens),
-		Temperature: checkAndAssignOrDefault(claudeReq.Temperature, DefaultTemperature),
+		Temperature: floatPtr(checkAndAssignOrDefault(claudeReq.Temperature, DefaultTemperature)),
 		TopP:        checkAndAssignOrDefault(claudeReq.TopP, DefaultTopP),
+		TopK:        claudeReq.TopK,
 		Stream:      claudeReq.Stream,
 	}
+	if len(claudeReq.StopSequences) > 0 {
+		openaiReq.Stop = claudeReq.StopSequences
+	}
+	if claudeReq.Thinking != nil {
+		openaiReq.ReasoningEffort = budgetTokensToReasoningEffort(claudeReq.Thinking.BudgetTokens)
+	}
 
 	// Add system message if present
 	if claudeReq.System != "" {
@@ -388,6 +942,7 @@ func (c *DefaultConverter) toOpenAIRequestFromClaude(data interface{}) (*models.
 		// Process content
 		content := c.processClaudeContentToOpenAI(msg.Content)
 		openaiMsg.Content = content
+		openaiMsg.ReasoningContent, openaiMsg.ReasoningSignature = c.extractClaudeReasoning(msg.Content)
 
 		// Handle tool results
 		if msg.Role == models.RoleUser {
@@ -419,12 +974,14 @@ func (c *DefaultConverter) toOpenAIRequestFromClaude(data interface{}) (*models.
 				}
 			}
 			if len(toolCalls) > 0 {
+				// Claude allows an assistant turn to carry text alongside
+				// tool_use blocks, so keep whatever text processClaudeContentToOpenAI
+				// already extracted instead of discarding it here.
 				openaiMsg.ToolCalls = toolCalls
-				openaiMsg.Content = ""
 			}
 		}
 
-		if openaiMsg.Content != nil || len(openaiMsg.ToolCalls) > 0 {
+		if openaiMsg.Content != nil || len(openaiMsg.ToolCalls) > 0 || openaiMsg.ReasoningContent != "" || openaiMsg.ReasoningSignature != "" {
 			openaiReq.Messages = append(openaiReq.Messages, openaiMsg)
 		}
 	}
@@ -474,6 +1031,17 @@ func (c *DefaultConverter) processClaudeContentToOpenAI(content []models.ClaudeC
 					},
 				})
 			}
+		case "document":
+			if block.Source != nil && block.Source.Type == "base64" {
+				hasMultimodal = true
+				parts = append(parts, models.ContentPart{
+					Type: "document",
+					Document: &models.DocumentPart{
+						MimeType: block.Source.MediaType,
+						Data:     block.Source.Data,
+					},
+				})
+			}
 		}
 	}
 
@@ -482,6 +1050,13 @@ func (c *DefaultConverter) processClaudeContentToOpenAI(content []models.ClaudeC
 		return parts[0].Text
 	}
 
+	// Same normalization as processGeminiPartsToOpenAIContent: a
+	// tool_use-only content list leaves parts empty, so return "" rather
+	// than a typed-nil slice.
+	if len(parts) == 0 {
+		return ""
+	}
+
 	return parts
 }
 
@@ -497,6 +1072,26 @@ func (c *DefaultConverter) toOpenAIChatCompletionFromClaude(data interface{}, mo
 
 	content := c.processClaudeResponseContent(claudeResp.Content)
 	finishReason := c.mapClaudeStopReason(claudeResp.StopReason)
+	reasoningContent, reasoningSignature := c.extractClaudeReasoning(claudeResp.Content)
+
+	// Claude has no native JSON mode, so a response asked (via a synthesized
+	// system prompt) to return JSON can come back fenced or padded with
+	// prose; repair it speculatively before handing it to an OpenAI caller
+	// that may well decode it as JSON.
+	if s, ok := content.(string); ok && s != "" {
+		content = repairJSONContent(s)
+	}
+
+	message := &models.OpenAIMessage{
+		Role:               models.RoleAssistant,
+		Content:            content,
+		ReasoningContent:   reasoningContent,
+		ReasoningSignature: reasoningSignature,
+	}
+	if toolCalls := c.extractClaudeToolCalls(claudeResp.Content); len(toolCalls) > 0 {
+		message.ToolCalls = toolCalls
+		finishReason = "tool_calls"
+	}
 
 	response := &models.OpenAIResponse{
 		ID:      fmt.Sprintf("chatcmpl-%s", uuid.New().String()),
@@ -505,11 +1100,8 @@ func (c *DefaultConverter) toOpenAIChatCompletionFromClaude(data interface{}, mo
 		Model:   model,
 		Choices: []models.OpenAIChoice{
 			{
-				Index: 0,
-				Message: &models.OpenAIMessage{
-					Role:    models.RoleAssistant,
-					Content: content,
-				},
+				Index:        0,
+				Message:      message,
 				FinishReason: finishReason,
 			},
 		},
@@ -539,12 +1131,8 @@ func (c *DefaultConverter) processClaudeResponseContent(content []models.ClaudeC
 				Type: "text",
 				Text: block.Text,
 			})
-		case "thinking":
-			// Extract thinking content if needed
-			parts = append(parts, models.ContentPart{
-				Type: "text",
-				Text: fmt.Sprintf("<thinking>%s</thinking>", block.Thinking),
-			})
+			// "thinking"/"redacted_thinking" blocks are surfaced via
+			// extractClaudeReasoning instead of folded into this content.
 		}
 	}
 
@@ -555,6 +1143,27 @@ func (c *DefaultConverter) processClaudeResponseContent(content []models.ClaudeC
 	return parts
 }
 
+// extractClaudeToolCalls converts any tool_use blocks into OpenAI tool_calls,
+// marshaling Input (a parsed object) back to the JSON string OpenAI expects
+// for ToolCallFunction.Arguments.
+func (c *DefaultConverter) extractClaudeToolCalls(content []models.ClaudeContent) []models.ToolCall {
+	var toolCalls []models.ToolCall
+	for _, block := range content {
+		if block.Type != "tool_use" {
+			continue
+		}
+		toolCalls = append(toolCalls, models.ToolCall{
+			ID:   block.ID,
+			Type: "function",
+			Function: models.ToolCallFunction{
+				Name:      block.Name,
+				Arguments: c.marshalJSON(block.Input),
+			},
+		})
+	}
+	return toolCalls
+}
+
 func (c *DefaultConverter) mapClaudeStopReason(reason string) string {
 	switch reason {
 	case "end_turn":
@@ -563,6 +1172,8 @@ func (c *DefaultConverter) mapClaudeStopReason(reason string) string {
 		return "length"
 	case "stop_sequence":
 		return "stop"
+	case "tool_use":
+		return "tool_calls"
 	default:
 		return "stop"
 	}
@@ -614,10 +1225,20 @@ func (c *DefaultConverter) toClaudeRequestFromOpenAI(data interface{}) (*models.
 		Model:       openaiReq.Model,
 		Messages:    []models.ClaudeMessage{},
 		MaxTokens:   checkAndAssignOrDefault(openaiReq.MaxTokens, DefaultMaxTokens),
-		Temperature: checkAndAssignOrDefault(openaiReq.Temperature, DefaultTemperature),
+		Temperature: models.TemperatureOrDefault(openaiReq.Temperature, DefaultTemperature),
 		TopP:        checkAndAssignOrDefault(openaiReq.TopP, DefaultTopP),
+		TopK:        openaiReq.TopK,
 		Stream:      openaiReq.Stream,
 	}
+	if stops := normalizeStopSequences(openaiReq.Stop); len(stops) > 0 {
+		claudeReq.StopSequences = stops
+	}
+	if openaiReq.ReasoningEffort != "" {
+		claudeReq.Thinking = &models.ClaudeThinking{
+			Type:         "enabled",
+			BudgetTokens: reasoningEffortToBudgetTokens(openaiReq.ReasoningEffort),
+		}
+	}
 
 	// Process messages
 	for _, msg := range openaiReq.Messages {
@@ -630,6 +1251,13 @@ func (c *DefaultConverter) toClaudeRequestFromOpenAI(data interface{}) (*models.
 			Role: msg.Role,
 		}
 
+		// A thinking block must come before any other content in the
+		// turn, or Claude rejects it; re-emit the original block (with
+		// its signature) instead of reserializing the reasoning as text.
+		if msg.ReasoningContent != "" || msg.ReasoningSignature != "" {
+			claudeMsg.Content = append(claudeMsg.Content, reasoningClaudeBlock(msg.ReasoningContent, msg.ReasoningSignature))
+		}
+
 		// Convert content
 		contentParts := msg.GetContentAsParts()
 		for _, part := range contentParts {
@@ -640,14 +1268,17 @@ func (c *DefaultConverter) toClaudeRequestFromOpenAI(data interface{}) (*models.
 					Text: part.Text,
 				})
 			case "image_url":
-				if part.ImageURL != nil && strings.HasPrefix(part.ImageURL.URL, "data:") {
+				if part.ImageURL == nil {
+					continue
+				}
+				if strings.HasPrefix(part.ImageURL.URL, "data:") {
 					// Parse data URL
 					parts := strings.SplitN(part.ImageURL.URL, ",", 2)
 					if len(parts) == 2 {
 						header := parts[0]
 						data := parts[1]
 						mediaType := strings.TrimPrefix(strings.Split(header, ";")[0], "data:")
-						
+
 						claudeMsg.Content = append(claudeMsg.Content, models.ClaudeContent{
 							Type: "image",
 							Source: &models.ClaudeImageSource{
@@ -657,6 +1288,43 @@ func (c *DefaultConverter) toClaudeRequestFromOpenAI(data interface{}) (*models.
 							},
 						})
 					}
+				} else {
+					// Claude has no URL-image variant, so fetch and inline
+					// the bytes ourselves, same as the Gemini direction.
+					data, mimeType, err := c.fetchInline(part.ImageURL.URL)
+					if err != nil {
+						claudeMsg.Content = append(claudeMsg.Content, models.ClaudeContent{
+							Type: "text",
+							Text: fmt.Sprintf("[failed to fetch image %s: %v]", part.ImageURL.URL, err),
+						})
+						continue
+					}
+					claudeMsg.Content = append(claudeMsg.Content, models.ClaudeContent{
+						Type: "image",
+						Source: &models.ClaudeImageSource{
+							Type:      "base64",
+							MediaType: mimeType,
+							Data:      data,
+						},
+					})
+				}
+			case "document":
+				if part.Document != nil {
+					claudeMsg.Content = append(claudeMsg.Content, models.ClaudeContent{
+						Type: "document",
+						Source: &models.ClaudeImageSource{
+							Type:      "base64",
+							MediaType: part.Document.MimeType,
+							Data:      part.Document.Data,
+						},
+					})
+				}
+			case "input_audio":
+				if part.InputAudio != nil {
+					claudeMsg.Content = append(claudeMsg.Content, models.ClaudeContent{
+						Type: "text",
+						Text: fmt.Sprintf("[Audio input (%s), not supported by Claude]", part.InputAudio.Format),
+					})
 				}
 			}
 		}
@@ -673,20 +1341,20 @@ func (c *DefaultConverter) toClaudeRequestFromOpenAI(data interface{}) (*models.
 			}
 		}
 
-		// Handle tool calls
-		if len(msg.ToolCalls) > 0 {
-			claudeMsg.Content = []models.ClaudeContent{}
-			for _, tc := range msg.ToolCalls {
-				var input map[string]interface{}
-				json.Unmarshal([]byte(tc.Function.Arguments), &input)
-				
-				claudeMsg.Content = append(claudeMsg.Content, models.ClaudeContent{
-					Type:  "tool_use",
-					ID:    tc.ID,
-					Name:  tc.Function.Name,
-					Input: input,
-				})
-			}
+		// Handle tool calls. Append rather than replace claudeMsg.Content:
+		// OpenAI allows an assistant message to carry both regular content
+		// and tool_calls, and Claude allows the same text/tool_use mix in
+		// one turn, so the text converted above must survive alongside it.
+		for _, tc := range msg.ToolCalls {
+			var input map[string]interface{}
+			json.Unmarshal([]byte(tc.Function.Arguments), &input)
+
+			claudeMsg.Content = append(claudeMsg.Content, models.ClaudeContent{
+				Type:  "tool_use",
+				ID:    tc.ID,
+				Name:  tc.Function.Name,
+				Input: input,
+			})
 		}
 
 		if len(claudeMsg.Content) > 0 {
@@ -694,6 +1362,13 @@ func (c *DefaultConverter) toClaudeRequestFromOpenAI(data interface{}) (*models.
 		}
 	}
 
+	// Claude has no native response_format: fold the request into a
+	// system-prompt instruction instead, repaired on the way back out in
+	// toOpenAIChatCompletionFromClaude.
+	if rf := c.normalizeResponseFormat(openaiReq.ResponseFormat); rf != nil {
+		claudeReq.System += claudeResponseFormatSuffix(rf)
+	}
+
 	// Convert tools
 	if len(openaiReq.Tools) > 0 {
 		claudeReq.Tools = make([]models.ClaudeTool, len(openaiReq.Tools))
@@ -755,6 +1430,58 @@ func (c *DefaultConverter) toGeminiRequestFromOpenAI(data interface{}) (*models.
 			Role:  role,
 			Parts: []models.GeminiPart{},
 		}
+		if msg.ReasoningContent != "" {
+			geminiContent.Parts = append(geminiContent.Parts, models.GeminiPart{
+				Text:    msg.ReasoningContent,
+				Thought: true,
+			})
+		}
+
+		// Tool results become a functionResponse part under the "function"
+		// role; Gemini has no separate tool-message concept.
+		if msg.Role == models.RoleTool {
+			var responseArgs map[string]interface{}
+			json.Unmarshal([]byte(msg.GetContentAsString()), &responseArgs)
+			if responseArgs == nil {
+				// functionResponse.response must be an object; wrap a plain
+				// string tool result instead of sending it bare.
+				responseArgs = map[string]interface{}{"content": msg.GetContentAsString()}
+			}
+			geminiContent.Role = models.RoleFunction
+			geminiContent.Parts = append(geminiContent.Parts, models.GeminiPart{
+				FunctionResponse: &models.GeminiFunctionResponse{
+					ID:       msg.ToolCallID,
+					Name:     msg.Name,
+					Response: responseArgs,
+				},
+			})
+			geminiReq.Contents = append(geminiReq.Contents, geminiContent)
+			continue
+		}
+
+		// Assistant tool calls become functionCall parts, carrying the
+		// OpenAI tool_call_id through so the eventual functionResponse can
+		// be matched back up. OpenAI/Claude both allow text alongside the
+		// tool calls in the same turn, so emit any accompanying text as a
+		// part first instead of dropping it.
+		if len(msg.ToolCalls) > 0 {
+			if text := msg.GetContentAsString(); text != "" {
+				geminiContent.Parts = append(geminiContent.Parts, models.GeminiPart{Text: text})
+			}
+			for _, tc := range msg.ToolCalls {
+				var args map[string]interface{}
+				json.Unmarshal([]byte(tc.Function.Arguments), &args)
+				geminiContent.Parts = append(geminiContent.Parts, models.GeminiPart{
+					FunctionCall: &models.GeminiFunctionCall{
+						ID:   tc.ID,
+						Name: tc.Function.Name,
+						Args: args,
+					},
+				})
+			}
+			geminiReq.Contents = append(geminiReq.Contents, geminiContent)
+			continue
+		}
 
 		// Convert content
 		contentParts := msg.GetContentAsParts()
@@ -773,7 +1500,7 @@ func (c *DefaultConverter) toGeminiRequestFromOpenAI(data interface{}) (*models.
 							header := parts[0]
 							data := parts[1]
 							mimeType := strings.TrimPrefix(strings.Split(header, ";")[0], "data:")
-							
+
 							geminiContent.Parts = append(geminiContent.Parts, models.GeminiPart{
 								InlineData: &models.GeminiInlineData{
 									MimeType: mimeType,
@@ -782,15 +1509,43 @@ func (c *DefaultConverter) toGeminiRequestFromOpenAI(data interface{}) (*models.
 							})
 						}
 					} else {
-						// Regular URL
+						// Gemini's fileData requires a Files-API URI, not an
+						// arbitrary HTTP URL, so fetch and inline the bytes
+						// ourselves instead of hardcoding a MIME type we
+						// haven't actually checked.
+						data, mimeType, err := c.fetchInline(part.ImageURL.URL)
+						if err != nil {
+							geminiContent.Parts = append(geminiContent.Parts, models.GeminiPart{
+								Text: fmt.Sprintf("[failed to fetch image %s: %v]", part.ImageURL.URL, err),
+							})
+							continue
+						}
 						geminiContent.Parts = append(geminiContent.Parts, models.GeminiPart{
-							FileData: &models.GeminiFileData{
-								MimeType: "image/jpeg",
-								FileURI:  part.ImageURL.URL,
+							InlineData: &models.GeminiInlineData{
+								MimeType: mimeType,
+								Data:     data,
 							},
 						})
 					}
 				}
+			case "document":
+				if part.Document != nil {
+					geminiContent.Parts = append(geminiContent.Parts, models.GeminiPart{
+						InlineData: &models.GeminiInlineData{
+							MimeType: part.Document.MimeType,
+							Data:     part.Document.Data,
+						},
+					})
+				}
+			case "input_audio":
+				if part.InputAudio != nil {
+					geminiContent.Parts = append(geminiContent.Parts, models.GeminiPart{
+						InlineData: &models.GeminiInlineData{
+							MimeType: "audio/" + part.InputAudio.Format,
+							Data:     part.InputAudio.Data,
+						},
+					})
+				}
 			}
 		}
 
@@ -801,9 +1556,22 @@ func (c *DefaultConverter) toGeminiRequestFromOpenAI(data interface{}) (*models.
 
 	// Set generation config
 	config := &models.GeminiGenerationConfig{
-		Temperature:     checkAndAssignOrDefault(openaiReq.Temperature, DefaultTemperature),
+		Temperature:     models.TemperatureOrDefault(openaiReq.Temperature, DefaultTemperature),
 		TopP:            checkAndAssignOrDefault(openaiReq.TopP, DefaultTopP),
+		TopK:            openaiReq.TopK,
 		MaxOutputTokens: checkAndAssignOrDefault(openaiReq.MaxTokens, DefaultGeminiMaxTokens),
+		StopSequences:   normalizeStopSequences(openaiReq.Stop),
+	}
+	if rf := c.normalizeResponseFormat(openaiReq.ResponseFormat); rf != nil {
+		config.ResponseMimeType = "application/json"
+		if rf.Type == "json_schema" {
+			config.ResponseSchema = rf.Schema
+		}
+	}
+	if openaiReq.ReasoningEffort != "" {
+		config.ThinkingConfig = &models.GeminiThinkingConfig{
+			ThinkingBudget: reasoningEffortToBudgetTokens(openaiReq.ReasoningEffort),
+		}
 	}
 	geminiReq.GenerationConfig = config
 
@@ -833,33 +1601,318 @@ func (c *DefaultConverter) marshalJSON(v interface{}) string {
 	return string(data)
 }
 
-// Stub implementations for remaining conversions
+// toClaudeRequestFromGemini converts a Gemini/Vertex AI request directly
+// to Claude's shape, going through OpenAI's intermediate representation
+// since Claude and Gemini share no request fields of their own beyond
+// what OpenAI already normalizes (messages, tools, generation params).
 func (c *DefaultConverter) toClaudeRequestFromGemini(data interface{}) (*models.ClaudeRequest, error) {
-	// Implementation would follow similar pattern
-	return &models.ClaudeRequest{}, nil
+	openaiReq, err := c.toOpenAIRequestFromGemini(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.toClaudeRequestFromOpenAI(openaiReq)
 }
 
+// toGeminiRequestFromClaude is the inverse of toClaudeRequestFromGemini.
 func (c *DefaultConverter) toGeminiRequestFromClaude(data interface{}) (*models.GeminiRequest, error) {
-	// Implementation would follow similar pattern
-	return &models.GeminiRequest{}, nil
+	openaiReq, err := c.toOpenAIRequestFromClaude(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.toGeminiRequestFromOpenAI(openaiReq)
 }
 
 func (c *DefaultConverter) toClaudeChatCompletionFromOpenAI(data interface{}, model string) (*models.ClaudeResponse, error) {
-	// Implementation would follow similar pattern
-	return &models.ClaudeResponse{}, nil
+	openaiResp, ok := data.(*models.OpenAIResponse)
+	if !ok {
+		jsonData, _ := json.Marshal(data)
+		openaiResp = &models.OpenAIResponse{}
+		if err := json.Unmarshal(jsonData, openaiResp); err != nil {
+			return nil, err
+		}
+	}
+
+	response := &models.ClaudeResponse{
+		ID:         fmt.Sprintf("msg_%s", uuid.New().String()),
+		Type:       "message",
+		Role:       models.RoleAssistant,
+		Model:      model,
+		StopReason: "end_turn",
+	}
+
+	if len(openaiResp.Choices) > 0 {
+		choice := openaiResp.Choices[0]
+		response.StopReason = c.mapOpenAIFinishReason(choice.FinishReason)
+
+		if choice.Message != nil {
+			// A thinking block must lead the turn, or Claude rejects it.
+			if choice.Message.ReasoningContent != "" || choice.Message.ReasoningSignature != "" {
+				response.Content = append(response.Content, reasoningClaudeBlock(choice.Message.ReasoningContent, choice.Message.ReasoningSignature))
+			}
+			// Claude allows a turn to carry text and tool_use blocks
+			// together, text first, so preserve both instead of only
+			// emitting one.
+			if text := choice.Message.GetContentAsString(); text != "" {
+				response.Content = append(response.Content, models.ClaudeContent{Type: "text", Text: text})
+			}
+			for _, tc := range choice.Message.ToolCalls {
+				var input map[string]interface{}
+				json.Unmarshal([]byte(tc.Function.Arguments), &input)
+				response.Content = append(response.Content, models.ClaudeContent{
+					Type:  "tool_use",
+					ID:    tc.ID,
+					Name:  tc.Function.Name,
+					Input: input,
+				})
+			}
+		}
+	}
+
+	if openaiResp.Usage != nil {
+		response.Usage = &models.ClaudeUsage{
+			InputTokens:  openaiResp.Usage.PromptTokens,
+			OutputTokens: openaiResp.Usage.CompletionTokens,
+		}
+	}
+
+	return response, nil
 }
 
 func (c *DefaultConverter) toClaudeChatCompletionFromGemini(data interface{}, model string) (*models.ClaudeResponse, error) {
-	// Implementation would follow similar pattern
-	return &models.ClaudeResponse{}, nil
+	geminiResp, ok := data.(*models.GeminiResponse)
+	if !ok {
+		jsonData, _ := json.Marshal(data)
+		geminiResp = &models.GeminiResponse{}
+		if err := json.Unmarshal(jsonData, geminiResp); err != nil {
+			return nil, err
+		}
+	}
+
+	response := &models.ClaudeResponse{
+		ID:         fmt.Sprintf("msg_%s", uuid.New().String()),
+		Type:       "message",
+		Role:       models.RoleAssistant,
+		Model:      model,
+		StopReason: "end_turn",
+	}
+
+	if len(geminiResp.Candidates) > 0 {
+		candidate := geminiResp.Candidates[0]
+		response.StopReason = c.mapGeminiFinishReasonToClaude(candidate.FinishReason)
+		for _, part := range candidate.Content.Parts {
+			switch {
+			case part.FunctionCall != nil:
+				response.Content = append(response.Content, models.ClaudeContent{
+					Type:  "tool_use",
+					ID:    geminiFunctionCallID(part.FunctionCall.ID, part.FunctionCall.Name),
+					Name:  part.FunctionCall.Name,
+					Input: part.FunctionCall.Args,
+				})
+			case part.Thought && part.Text != "":
+				response.Content = append(response.Content, models.ClaudeContent{Type: "thinking", Thinking: part.Text})
+			case part.Text != "":
+				response.Content = append(response.Content, models.ClaudeContent{Type: "text", Text: part.Text})
+			}
+		}
+
+		// Gemini reports "STOP" even when the candidate's content is a
+		// functionCall, so a trailing tool_use block overrides whatever
+		// mapGeminiFinishReasonToClaude came up with.
+		if len(response.Content) > 0 && response.Content[len(response.Content)-1].Type == "tool_use" {
+			response.StopReason = "tool_use"
+		}
+	}
+
+	if geminiResp.UsageMetadata != nil {
+		response.Usage = &models.ClaudeUsage{
+			InputTokens:  geminiResp.UsageMetadata.PromptTokenCount,
+			OutputTokens: geminiResp.UsageMetadata.CandidatesTokenCount,
+		}
+	}
+
+	return response, nil
+}
+
+// mapOpenAIFinishReason is the inverse of mapClaudeStopReason.
+func (c *DefaultConverter) mapOpenAIFinishReason(reason string) string {
+	switch reason {
+	case "length":
+		return "max_tokens"
+	case "tool_calls":
+		return "tool_use"
+	default:
+		return "end_turn"
+	}
+}
+
+// mapGeminiFinishReasonToClaude maps a Gemini candidate's finishReason to the
+// equivalent Claude stop_reason value. Like mapGeminiFinishReason, "tool_use"
+// isn't reachable through this mapping alone; callers override based on
+// whether the candidate's content ends in a functionCall.
+func (c *DefaultConverter) mapGeminiFinishReasonToClaude(reason string) string {
+	switch reason {
+	case "MAX_TOKENS":
+		return "max_tokens"
+	case "STOP", "":
+		return "end_turn"
+	default:
+		return "end_turn"
+	}
+}
+
+// mapFinishReasonToGemini maps an OpenAI finish_reason to the equivalent
+// Gemini finishReason value.
+func (c *DefaultConverter) mapFinishReasonToGemini(reason string) string {
+	switch reason {
+	case "length":
+		return "MAX_TOKENS"
+	case "content_filter":
+		return "SAFETY"
+	default:
+		return "STOP"
+	}
+}
+
+// mapClaudeStopReasonToGemini maps a Claude stop_reason to the equivalent
+// Gemini finishReason value.
+func (c *DefaultConverter) mapClaudeStopReasonToGemini(reason string) string {
+	switch reason {
+	case "max_tokens":
+		return "MAX_TOKENS"
+	default:
+		return "STOP"
+	}
+}
+
+// toGeminiChatCompletionFromOpenAI converts an OpenAI chat completion
+// response to Gemini's shape, the response-side counterpart of
+// toGeminiRequestFromOpenAI: text becomes a plain part and tool_calls become
+// functionCall parts in the same candidate content.
+func (c *DefaultConverter) toGeminiChatCompletionFromOpenAI(data interface{}, model string) (*models.GeminiResponse, error) {
+	openaiResp, ok := data.(*models.OpenAIResponse)
+	if !ok {
+		jsonData, _ := json.Marshal(data)
+		openaiResp = &models.OpenAIResponse{}
+		if err := json.Unmarshal(jsonData, openaiResp); err != nil {
+			return nil, err
+		}
+	}
+
+	candidate := models.GeminiCandidate{
+		Content:      models.GeminiContent{Role: models.RoleModel},
+		FinishReason: "STOP",
+	}
+
+	if len(openaiResp.Choices) > 0 {
+		choice := openaiResp.Choices[0]
+		candidate.FinishReason = c.mapFinishReasonToGemini(choice.FinishReason)
+
+		if choice.Message != nil {
+			if choice.Message.ReasoningContent != "" {
+				candidate.Content.Parts = append(candidate.Content.Parts, models.GeminiPart{
+					Text:    choice.Message.ReasoningContent,
+					Thought: true,
+				})
+			}
+			if text := choice.Message.GetContentAsString(); text != "" {
+				candidate.Content.Parts = append(candidate.Content.Parts, models.GeminiPart{Text: text})
+			}
+			for _, tc := range choice.Message.ToolCalls {
+				var args map[string]interface{}
+				json.Unmarshal([]byte(tc.Function.Arguments), &args)
+				candidate.Content.Parts = append(candidate.Content.Parts, models.GeminiPart{
+					FunctionCall: &models.GeminiFunctionCall{
+						ID:   tc.ID,
+						Name: tc.Function.Name,
+						Args: args,
+					},
+				})
+			}
+		}
+	}
+
+	response := &models.GeminiResponse{
+		Candidates: []models.GeminiCandidate{candidate},
+	}
+
+	if openaiResp.Usage != nil {
+		response.UsageMetadata = &models.GeminiUsage{
+			PromptTokenCount:     openaiResp.Usage.PromptTokens,
+			CandidatesTokenCount: openaiResp.Usage.CompletionTokens,
+			TotalTokenCount:      openaiResp.Usage.TotalTokens,
+		}
+	}
+
+	return response, nil
+}
+
+// toGeminiChatCompletionFromClaude is toGeminiChatCompletionFromOpenAI's
+// counterpart for Claude responses: text/thinking blocks become plain parts
+// and tool_use blocks become functionCall parts in the same candidate
+// content, in the order Claude returned them.
+func (c *DefaultConverter) toGeminiChatCompletionFromClaude(data interface{}, model string) (*models.GeminiResponse, error) {
+	claudeResp, ok := data.(*models.ClaudeResponse)
+	if !ok {
+		jsonData, _ := json.Marshal(data)
+		claudeResp = &models.ClaudeResponse{}
+		if err := json.Unmarshal(jsonData, claudeResp); err != nil {
+			return nil, err
+		}
+	}
+
+	candidate := models.GeminiCandidate{
+		Content:      models.GeminiContent{Role: models.RoleModel},
+		FinishReason: c.mapClaudeStopReasonToGemini(claudeResp.StopReason),
+	}
+
+	for _, block := range claudeResp.Content {
+		switch block.Type {
+		case "text":
+			candidate.Content.Parts = append(candidate.Content.Parts, models.GeminiPart{Text: block.Text})
+		case "thinking":
+			candidate.Content.Parts = append(candidate.Content.Parts, models.GeminiPart{
+				Text:    block.Thinking,
+				Thought: true,
+			})
+		case "tool_use":
+			candidate.Content.Parts = append(candidate.Content.Parts, models.GeminiPart{
+				FunctionCall: &models.GeminiFunctionCall{
+					ID:   block.ID,
+					Name: block.Name,
+					Args: block.Input,
+				},
+			})
+		}
+	}
+
+	response := &models.GeminiResponse{
+		Candidates: []models.GeminiCandidate{candidate},
+	}
+
+	if claudeResp.Usage != nil {
+		response.UsageMetadata = &models.GeminiUsage{
+			PromptTokenCount:     claudeResp.Usage.InputTokens,
+			CandidatesTokenCount: claudeResp.Usage.OutputTokens,
+			TotalTokenCount:      claudeResp.Usage.InputTokens + claudeResp.Usage.OutputTokens,
+		}
+	}
+
+	return response, nil
 }
 
 func (c *DefaultConverter) toClaudeStreamChunkFromOpenAI(data interface{}, model string) (interface{}, error) {
-	// Implementation would follow similar pattern
-	return nil, nil
+	chunkText, ok := data.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected string chunk for OpenAI stream")
+	}
+
+	return &models.ClaudeStreamEvent{
+		Type:  "content_block_delta",
+		Index: 0,
+		Delta: &models.ClaudeStreamDelta{Type: "text_delta", Text: chunkText},
+	}, nil
 }
 
 func (c *DefaultConverter) toClaudeStreamChunkFromGemini(data interface{}, model string) (interface{}, error) {
-	// Implementation would follow similar pattern
-	return nil, nil
-}
\ No newline at end of file
+	return c.toClaudeStreamChunkFromOpenAI(data, model)
+}
@@ -1,6 +1,11 @@
 package convert
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/aiproxy/go-aiproxy/pkg/models"
@@ -272,6 +277,789 @@ func TestConverter_ConvertModelList(t *testing.T) {
 	}
 }
 
+func TestConverter_ConvertVertexAIToClaude_WithTools(t *testing.T) {
+	converter := NewConverter()
+
+	vertexReq := &models.GeminiRequest{
+		Contents: []models.GeminiContent{
+			{Role: "user", Parts: []models.GeminiPart{{Text: "What's the weather?"}}},
+		},
+		Tools: []models.GeminiTool{
+			{
+				FunctionDeclarations: []models.GeminiFunctionDeclaration{
+					{
+						Name:        "get_weather",
+						Description: "Get weather information",
+						Parameters: map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"location": map[string]interface{}{"type": "string"},
+							},
+						},
+					},
+				},
+			},
+		},
+		SafetySettings: []models.VertexSafetySetting{
+			{Category: "HARM_CATEGORY_HARASSMENT", Threshold: "BLOCK_ONLY_HIGH"},
+		},
+	}
+
+	result, err := converter.ConvertRequest(vertexReq, models.ProtocolVertexAI, models.ProtocolClaude)
+	if err != nil {
+		t.Fatalf("ConvertRequest failed: %v", err)
+	}
+
+	claudeReq, ok := result.(*models.ClaudeRequest)
+	if !ok {
+		t.Fatalf("Expected *models.ClaudeRequest, got %T", result)
+	}
+	if len(claudeReq.Tools) != 1 || claudeReq.Tools[0].Name != "get_weather" {
+		t.Errorf("Vertex functionDeclarations did not survive conversion to Claude: %#v", claudeReq.Tools)
+	}
+}
+
+func TestConverter_ConvertResponse_VertexAIToolCallToOpenAI(t *testing.T) {
+	converter := NewConverter()
+
+	vertexResp := &models.GeminiResponse{
+		Candidates: []models.GeminiCandidate{
+			{
+				Content: models.GeminiContent{
+					Role: "model",
+					Parts: []models.GeminiPart{
+						{FunctionCall: &models.GeminiFunctionCall{ID: "call_1", Name: "get_weather", Args: map[string]interface{}{"location": "Tokyo"}}},
+					},
+				},
+				FinishReason: "STOP",
+			},
+		},
+	}
+
+	result, err := converter.ConvertResponse(vertexResp, models.ProtocolVertexAI, models.ProtocolOpenAI, "gemini-1.5-pro")
+	if err != nil {
+		t.Fatalf("ConvertResponse failed: %v", err)
+	}
+
+	openaiResp, ok := result.(*models.OpenAIResponse)
+	if !ok {
+		t.Fatalf("Expected *models.OpenAIResponse, got %T", result)
+	}
+	if openaiResp.Choices[0].FinishReason != "tool_calls" {
+		t.Errorf("Expected finish_reason tool_calls, got %s", openaiResp.Choices[0].FinishReason)
+	}
+	if len(openaiResp.Choices[0].Message.ToolCalls) != 1 || openaiResp.Choices[0].Message.ToolCalls[0].ID != "call_1" {
+		t.Errorf("functionCall id did not round-trip to tool_call id: %#v", openaiResp.Choices[0].Message.ToolCalls)
+	}
+}
+
+func TestConverter_AzureOpenAI_PromptFilterResultsSurviveSameProtocolConversion(t *testing.T) {
+	converter := NewConverter()
+
+	azureResp := &models.OpenAIResponse{
+		ID:    "chatcmpl-azure-1",
+		Model: "gpt-4o",
+		Choices: []models.OpenAIChoice{
+			{
+				Index:        0,
+				Message:      &models.OpenAIMessage{Role: "assistant", Content: "Hello!"},
+				FinishReason: "stop",
+				ContentFilterResults: &models.AzureContentFilterResult{
+					Hate: &models.AzureContentFilterCategory{Filtered: false, Severity: "safe"},
+				},
+			},
+		},
+		PromptFilterResults: []models.AzurePromptFilterResult{
+			{PromptIndex: 0, ContentFilterResults: &models.AzureContentFilterResult{Hate: &models.AzureContentFilterCategory{Severity: "safe"}}},
+		},
+	}
+
+	result, err := converter.ConvertResponse(azureResp, models.ProtocolAzureOpenAI, models.ProtocolAzureOpenAI, "gpt-4o")
+	if err != nil {
+		t.Fatalf("ConvertResponse failed: %v", err)
+	}
+
+	resp, ok := result.(*models.OpenAIResponse)
+	if !ok {
+		t.Fatalf("Expected *models.OpenAIResponse, got %T", result)
+	}
+	if len(resp.PromptFilterResults) != 1 {
+		t.Fatalf("prompt_filter_results did not survive conversion: %#v", resp.PromptFilterResults)
+	}
+	if resp.Choices[0].ContentFilterResults == nil {
+		t.Fatalf("content_filter_results did not survive conversion")
+	}
+}
+
+func TestConverter_AzureOpenAIToClaude(t *testing.T) {
+	converter := NewConverter()
+
+	azureResp := &models.OpenAIResponse{
+		Choices: []models.OpenAIChoice{
+			{
+				Message:      &models.OpenAIMessage{Role: "assistant", Content: "It is sunny."},
+				FinishReason: "stop",
+			},
+		},
+		PromptFilterResults: []models.AzurePromptFilterResult{{PromptIndex: 0}},
+	}
+
+	result, err := converter.ConvertResponse(azureResp, models.ProtocolAzureOpenAI, models.ProtocolClaude, "claude-3-opus")
+	if err != nil {
+		t.Fatalf("ConvertResponse failed: %v", err)
+	}
+
+	claudeResp, ok := result.(*models.ClaudeResponse)
+	if !ok {
+		t.Fatalf("Expected *models.ClaudeResponse, got %T", result)
+	}
+	if len(claudeResp.Content) == 0 || claudeResp.Content[0].Text != "It is sunny." {
+		t.Errorf("Content not converted correctly: %#v", claudeResp.Content)
+	}
+}
+
+// TestConverter_RequestRoundTrip_TextAndToolCallsSurvive builds, in each
+// protocol's own native shape, an assistant/model turn carrying both plain
+// text and a tool call, then round-trips it out to each other protocol and
+// back (A->B->A). Both the text and the tool call's name/arguments must
+// survive the round trip, per the "don't blank Content when tool calls
+// exist" requirement.
+func TestConverter_RequestRoundTrip_TextAndToolCallsSurvive(t *testing.T) {
+	converter := NewConverter()
+
+	tests := []struct {
+		name     string
+		protocol models.ProtocolPrefix
+		build    func() interface{}
+		extract  func(t *testing.T, data interface{}) (text string, toolName string, toolArgLocation string)
+	}{
+		{
+			name:     "claude",
+			protocol: models.ProtocolClaude,
+			build: func() interface{} {
+				return &models.ClaudeRequest{
+					Model: "claude-3-opus",
+					Messages: []models.ClaudeMessage{
+						{
+							Role: models.RoleAssistant,
+							Content: []models.ClaudeContent{
+								{Type: "text", Text: "Let me check that for you."},
+								{Type: "tool_use", ID: "call_1", Name: "get_weather", Input: map[string]interface{}{"location": "Tokyo"}},
+							},
+						},
+					},
+				}
+			},
+			extract: func(t *testing.T, data interface{}) (string, string, string) {
+				req, ok := data.(*models.ClaudeRequest)
+				if !ok {
+					t.Fatalf("expected *models.ClaudeRequest, got %T", data)
+				}
+				if len(req.Messages) == 0 {
+					t.Fatalf("expected at least one message, got none")
+				}
+				var text, toolName, location string
+				for _, block := range req.Messages[0].Content {
+					switch block.Type {
+					case "text":
+						text = block.Text
+					case "tool_use":
+						toolName = block.Name
+						if loc, ok := block.Input["location"].(string); ok {
+							location = loc
+						}
+					}
+				}
+				return text, toolName, location
+			},
+		},
+		{
+			name:     "openai",
+			protocol: models.ProtocolOpenAI,
+			build: func() interface{} {
+				return &models.OpenAIRequest{
+					Model: "gpt-4o",
+					Messages: []models.OpenAIMessage{
+						{
+							Role:    models.RoleAssistant,
+							Content: "Let me check that for you.",
+							ToolCalls: []models.ToolCall{
+								{ID: "call_1", Type: "function", Function: models.ToolCallFunction{Name: "get_weather", Arguments: `{"location":"Tokyo"}`}},
+							},
+						},
+					},
+				}
+			},
+			extract: func(t *testing.T, data interface{}) (string, string, string) {
+				req, ok := data.(*models.OpenAIRequest)
+				if !ok {
+					t.Fatalf("expected *models.OpenAIRequest, got %T", data)
+				}
+				if len(req.Messages) == 0 {
+					t.Fatalf("expected at least one message, got none")
+				}
+				msg := req.Messages[0]
+				text := msg.GetContentAsString()
+				if len(msg.ToolCalls) == 0 {
+					t.Fatalf("expected a tool call to survive, got none: %#v", msg)
+				}
+				var args map[string]interface{}
+				json.Unmarshal([]byte(msg.ToolCalls[0].Function.Arguments), &args)
+				location, _ := args["location"].(string)
+				return text, msg.ToolCalls[0].Function.Name, location
+			},
+		},
+		{
+			name:     "gemini",
+			protocol: models.ProtocolGemini,
+			build: func() interface{} {
+				return &models.GeminiRequest{
+					Contents: []models.GeminiContent{
+						{
+							Role: models.RoleModel,
+							Parts: []models.GeminiPart{
+								{Text: "Let me check that for you."},
+								{FunctionCall: &models.GeminiFunctionCall{ID: "call_1", Name: "get_weather", Args: map[string]interface{}{"location": "Tokyo"}}},
+							},
+						},
+					},
+				}
+			},
+			extract: func(t *testing.T, data interface{}) (string, string, string) {
+				req, ok := data.(*models.GeminiRequest)
+				if !ok {
+					t.Fatalf("expected *models.GeminiRequest, got %T", data)
+				}
+				if len(req.Contents) == 0 {
+					t.Fatalf("expected at least one content, got none")
+				}
+				var text, toolName, location string
+				for _, part := range req.Contents[0].Parts {
+					if part.Text != "" {
+						text = part.Text
+					}
+					if part.FunctionCall != nil {
+						toolName = part.FunctionCall.Name
+						if loc, ok := part.FunctionCall.Args["location"].(string); ok {
+							location = loc
+						}
+					}
+				}
+				return text, toolName, location
+			},
+		},
+	}
+
+	for _, from := range tests {
+		for _, to := range tests {
+			if from.protocol == to.protocol {
+				continue
+			}
+			t.Run(from.name+"_to_"+to.name+"_and_back", func(t *testing.T) {
+				original := from.build()
+
+				converted, err := converter.ConvertRequest(original, from.protocol, to.protocol)
+				if err != nil {
+					t.Fatalf("ConvertRequest %s->%s failed: %v", from.name, to.name, err)
+				}
+
+				roundTripped, err := converter.ConvertRequest(converted, to.protocol, from.protocol)
+				if err != nil {
+					t.Fatalf("ConvertRequest %s->%s failed: %v", to.name, from.name, err)
+				}
+
+				text, toolName, location := from.extract(t, roundTripped)
+				if text != "Let me check that for you." {
+					t.Errorf("text did not survive round trip: got %q", text)
+				}
+				if toolName != "get_weather" {
+					t.Errorf("tool call name did not survive round trip: got %q", toolName)
+				}
+				if location != "Tokyo" {
+					t.Errorf("tool call argument did not survive round trip: got %q", location)
+				}
+			})
+		}
+	}
+}
+
+// TestConverter_ConvertResponse_ToGemini_PreservesTextAndToolCalls covers the
+// previously-missing OpenAI/Claude -> Gemini response direction.
+func TestConverter_ConvertResponse_ToGemini_PreservesTextAndToolCalls(t *testing.T) {
+	converter := NewConverter()
+
+	t.Run("from_openai", func(t *testing.T) {
+		openaiResp := &models.OpenAIResponse{
+			Choices: []models.OpenAIChoice{
+				{
+					Message: &models.OpenAIMessage{
+						Role:    models.RoleAssistant,
+						Content: "Sure, checking now.",
+						ToolCalls: []models.ToolCall{
+							{ID: "call_1", Type: "function", Function: models.ToolCallFunction{Name: "get_weather", Arguments: `{"location":"Tokyo"}`}},
+						},
+					},
+					FinishReason: "tool_calls",
+				},
+			},
+		}
+
+		result, err := converter.ConvertResponse(openaiResp, models.ProtocolOpenAI, models.ProtocolGemini, "gemini-1.5-pro")
+		if err != nil {
+			t.Fatalf("ConvertResponse failed: %v", err)
+		}
+
+		geminiResp, ok := result.(*models.GeminiResponse)
+		if !ok {
+			t.Fatalf("expected *models.GeminiResponse, got %T", result)
+		}
+		if len(geminiResp.Candidates) == 0 {
+			t.Fatalf("expected at least one candidate")
+		}
+
+		var sawText, sawToolCall bool
+		for _, part := range geminiResp.Candidates[0].Content.Parts {
+			if part.Text == "Sure, checking now." {
+				sawText = true
+			}
+			if part.FunctionCall != nil && part.FunctionCall.Name == "get_weather" {
+				sawToolCall = true
+			}
+		}
+		if !sawText {
+			t.Errorf("expected text to survive conversion to Gemini, got %#v", geminiResp.Candidates[0].Content.Parts)
+		}
+		if !sawToolCall {
+			t.Errorf("expected tool call to survive conversion to Gemini, got %#v", geminiResp.Candidates[0].Content.Parts)
+		}
+	})
+
+	t.Run("from_claude", func(t *testing.T) {
+		claudeResp := &models.ClaudeResponse{
+			StopReason: "tool_use",
+			Content: []models.ClaudeContent{
+				{Type: "text", Text: "Sure, checking now."},
+				{Type: "tool_use", ID: "call_1", Name: "get_weather", Input: map[string]interface{}{"location": "Tokyo"}},
+			},
+		}
+
+		result, err := converter.ConvertResponse(claudeResp, models.ProtocolClaude, models.ProtocolGemini, "gemini-1.5-pro")
+		if err != nil {
+			t.Fatalf("ConvertResponse failed: %v", err)
+		}
+
+		geminiResp, ok := result.(*models.GeminiResponse)
+		if !ok {
+			t.Fatalf("expected *models.GeminiResponse, got %T", result)
+		}
+		if len(geminiResp.Candidates) == 0 {
+			t.Fatalf("expected at least one candidate")
+		}
+
+		var sawText, sawToolCall bool
+		for _, part := range geminiResp.Candidates[0].Content.Parts {
+			if part.Text == "Sure, checking now." {
+				sawText = true
+			}
+			if part.FunctionCall != nil && part.FunctionCall.Name == "get_weather" {
+				sawToolCall = true
+			}
+		}
+		if !sawText {
+			t.Errorf("expected text to survive conversion to Gemini, got %#v", geminiResp.Candidates[0].Content.Parts)
+		}
+		if !sawToolCall {
+			t.Errorf("expected tool call to survive conversion to Gemini, got %#v", geminiResp.Candidates[0].Content.Parts)
+		}
+	})
+}
+
+// TestConverter_ConvertResponse_ClaudeToOpenAI_PreservesTextAlongsideToolCalls
+// guards against reintroducing the "blank Content when tool calls exist" bug.
+func TestConverter_ConvertResponse_ClaudeToOpenAI_PreservesTextAlongsideToolCalls(t *testing.T) {
+	converter := NewConverter()
+
+	claudeResp := &models.ClaudeResponse{
+		StopReason: "tool_use",
+		Content: []models.ClaudeContent{
+			{Type: "text", Text: "Sure, checking now."},
+			{Type: "tool_use", ID: "call_1", Name: "get_weather", Input: map[string]interface{}{"location": "Tokyo"}},
+		},
+	}
+
+	result, err := converter.ConvertResponse(claudeResp, models.ProtocolClaude, models.ProtocolOpenAI, "gpt-4o")
+	if err != nil {
+		t.Fatalf("ConvertResponse failed: %v", err)
+	}
+
+	openaiResp, ok := result.(*models.OpenAIResponse)
+	if !ok {
+		t.Fatalf("expected *models.OpenAIResponse, got %T", result)
+	}
+
+	message := openaiResp.Choices[0].Message
+	if message.GetContentAsString() != "Sure, checking now." {
+		t.Errorf("expected text to survive alongside tool calls, got content %#v", message.Content)
+	}
+	if len(message.ToolCalls) != 1 || message.ToolCalls[0].Function.Name != "get_weather" {
+		t.Errorf("expected tool call to survive, got %#v", message.ToolCalls)
+	}
+	if openaiResp.Choices[0].FinishReason != "tool_calls" {
+		t.Errorf("expected finish_reason tool_calls, got %s", openaiResp.Choices[0].FinishReason)
+	}
+}
+
+func TestConverter_ConvertRequest_OpenAIToGemini_WiresResponseFormatAndSamplingControls(t *testing.T) {
+	converter := NewConverter()
+
+	req := &models.OpenAIRequest{
+		Model: "gpt-4o",
+		Messages: []models.OpenAIMessage{
+			{Role: "user", Content: "Give me a contact card."},
+		},
+		TopK: 20,
+		Stop: []string{"END"},
+		ResponseFormat: map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"schema": map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"name": map[string]interface{}{"type": "string"}},
+				},
+			},
+		},
+	}
+
+	result, err := converter.ConvertRequest(req, models.ProtocolOpenAI, models.ProtocolGemini)
+	if err != nil {
+		t.Fatalf("ConvertRequest failed: %v", err)
+	}
+
+	geminiReq, ok := result.(*models.GeminiRequest)
+	if !ok {
+		t.Fatalf("expected *models.GeminiRequest, got %T", result)
+	}
+
+	gc := geminiReq.GenerationConfig
+	if gc == nil || gc.TopK != 20 {
+		t.Fatalf("expected topK 20, got %#v", gc)
+	}
+	if len(gc.StopSequences) != 1 || gc.StopSequences[0] != "END" {
+		t.Fatalf("expected stopSequences [END], got %#v", gc.StopSequences)
+	}
+	if gc.ResponseMimeType != "application/json" || gc.ResponseSchema["type"] != "object" {
+		t.Fatalf("expected responseMimeType/responseSchema to carry the schema, got %#v", gc)
+	}
+}
+
+func TestConverter_ConvertRequest_OpenAIToClaude_FoldsResponseFormatIntoSystemPrompt(t *testing.T) {
+	converter := NewConverter()
+
+	req := &models.OpenAIRequest{
+		Model: "gpt-4o",
+		Messages: []models.OpenAIMessage{
+			{Role: "system", Content: "You are helpful."},
+			{Role: "user", Content: "Give me a contact card."},
+		},
+		TopK:           12,
+		Stop:           "END",
+		ResponseFormat: map[string]interface{}{"type": "json_object"},
+	}
+
+	result, err := converter.ConvertRequest(req, models.ProtocolOpenAI, models.ProtocolClaude)
+	if err != nil {
+		t.Fatalf("ConvertRequest failed: %v", err)
+	}
+
+	claudeReq, ok := result.(*models.ClaudeRequest)
+	if !ok {
+		t.Fatalf("expected *models.ClaudeRequest, got %T", result)
+	}
+
+	if claudeReq.TopK != 12 {
+		t.Errorf("expected top_k to survive, got %d", claudeReq.TopK)
+	}
+	if len(claudeReq.StopSequences) != 1 || claudeReq.StopSequences[0] != "END" {
+		t.Errorf("expected stop_sequences [END], got %#v", claudeReq.StopSequences)
+	}
+	if !strings.Contains(claudeReq.System, "You are helpful.") || !strings.Contains(claudeReq.System, "JSON") {
+		t.Errorf("expected the original system prompt plus a JSON instruction, got %q", claudeReq.System)
+	}
+}
+
+func TestConverter_ConvertResponse_ClaudeToOpenAI_RepairsFencedJSON(t *testing.T) {
+	converter := NewConverter()
+
+	claudeResp := &models.ClaudeResponse{
+		StopReason: "end_turn",
+		Content: []models.ClaudeContent{
+			{Type: "text", Text: "Here you go:\n```json\n{\"name\":\"Ada\"}\n```\nHope that helps!"},
+		},
+	}
+
+	result, err := converter.ConvertResponse(claudeResp, models.ProtocolClaude, models.ProtocolOpenAI, "gpt-4o")
+	if err != nil {
+		t.Fatalf("ConvertResponse failed: %v", err)
+	}
+
+	openaiResp, ok := result.(*models.OpenAIResponse)
+	if !ok {
+		t.Fatalf("expected *models.OpenAIResponse, got %T", result)
+	}
+
+	got := openaiResp.Choices[0].Message.GetContentAsString()
+	if got != `{"name":"Ada"}` {
+		t.Fatalf("expected the fenced JSON to be repaired to a bare object, got %q", got)
+	}
+}
+
+func TestConverter_ConvertResponse_ClaudeToOpenAI_LeavesPlainProseAlone(t *testing.T) {
+	converter := NewConverter()
+
+	claudeResp := &models.ClaudeResponse{
+		StopReason: "end_turn",
+		Content:    []models.ClaudeContent{{Type: "text", Text: "It's sunny in Tokyo."}},
+	}
+
+	result, err := converter.ConvertResponse(claudeResp, models.ProtocolClaude, models.ProtocolOpenAI, "gpt-4o")
+	if err != nil {
+		t.Fatalf("ConvertResponse failed: %v", err)
+	}
+
+	got := result.(*models.OpenAIResponse).Choices[0].Message.GetContentAsString()
+	if got != "It's sunny in Tokyo." {
+		t.Fatalf("expected ordinary prose to pass through unchanged, got %q", got)
+	}
+}
+
+func fakeImageFetcher(data []byte, mimeType string) Fetcher {
+	return func(ctx context.Context, url string) ([]byte, string, error) {
+		return data, mimeType, nil
+	}
+}
+
+func TestConverter_ConvertRequest_OpenAIToClaude_FetchesRemoteImageURL(t *testing.T) {
+	converter := NewConverter(WithFetcher(fakeImageFetcher([]byte("fake-png-bytes"), "image/png")))
+
+	req := &models.OpenAIRequest{
+		Model: "gpt-4o",
+		Messages: []models.OpenAIMessage{
+			{Role: "user", Content: []models.ContentPart{
+				{Type: "text", Text: "What's in this image?"},
+				{Type: "image_url", ImageURL: &models.ImageURL{URL: "https://example.com/cat.png"}},
+			}},
+		},
+	}
+
+	result, err := converter.ConvertRequest(req, models.ProtocolOpenAI, models.ProtocolClaude)
+	if err != nil {
+		t.Fatalf("ConvertRequest failed: %v", err)
+	}
+
+	claudeReq := result.(*models.ClaudeRequest)
+	if len(claudeReq.Messages) != 1 || len(claudeReq.Messages[0].Content) != 2 {
+		t.Fatalf("expected text + image blocks, got %#v", claudeReq.Messages)
+	}
+	img := claudeReq.Messages[0].Content[1]
+	if img.Type != "image" || img.Source == nil || img.Source.MediaType != "image/png" {
+		t.Fatalf("expected a base64 image block sourced from the fetch, got %#v", img)
+	}
+	if img.Source.Data != base64.StdEncoding.EncodeToString([]byte("fake-png-bytes")) {
+		t.Fatalf("expected fetched bytes to be base64-encoded, got %q", img.Source.Data)
+	}
+}
+
+func TestConverter_ConvertRequest_OpenAIToGemini_FetchesRemoteImageURL(t *testing.T) {
+	converter := NewConverter(WithFetcher(fakeImageFetcher([]byte("fake-png-bytes"), "image/png")))
+
+	req := &models.OpenAIRequest{
+		Model: "gpt-4o",
+		Messages: []models.OpenAIMessage{
+			{Role: "user", Content: []models.ContentPart{
+				{Type: "image_url", ImageURL: &models.ImageURL{URL: "https://example.com/cat.png"}},
+			}},
+		},
+	}
+
+	result, err := converter.ConvertRequest(req, models.ProtocolOpenAI, models.ProtocolGemini)
+	if err != nil {
+		t.Fatalf("ConvertRequest failed: %v", err)
+	}
+
+	geminiReq := result.(*models.GeminiRequest)
+	if len(geminiReq.Contents) != 1 || len(geminiReq.Contents[0].Parts) != 1 {
+		t.Fatalf("expected a single inlineData part, got %#v", geminiReq.Contents)
+	}
+	part := geminiReq.Contents[0].Parts[0]
+	if part.InlineData == nil || part.InlineData.MimeType != "image/png" {
+		t.Fatalf("expected inlineData carrying the sniffed MIME type, not a hardcoded one, got %#v", part)
+	}
+}
+
+func TestConverter_ConvertRequest_OpenAIToClaude_ImageFetchFailureFallsBackToText(t *testing.T) {
+	converter := NewConverter(WithFetcher(func(ctx context.Context, url string) ([]byte, string, error) {
+		return nil, "", fmt.Errorf("boom")
+	}))
+
+	req := &models.OpenAIRequest{
+		Model: "gpt-4o",
+		Messages: []models.OpenAIMessage{
+			{Role: "user", Content: []models.ContentPart{
+				{Type: "image_url", ImageURL: &models.ImageURL{URL: "https://example.com/cat.png"}},
+			}},
+		},
+	}
+
+	result, err := converter.ConvertRequest(req, models.ProtocolOpenAI, models.ProtocolClaude)
+	if err != nil {
+		t.Fatalf("ConvertRequest failed: %v", err)
+	}
+
+	claudeReq := result.(*models.ClaudeRequest)
+	block := claudeReq.Messages[0].Content[0]
+	if block.Type != "text" || !strings.Contains(block.Text, "failed to fetch image") {
+		t.Fatalf("expected a text fallback noting the fetch failure, got %#v", block)
+	}
+}
+
+func TestConverter_ConvertRequest_GeminiToOpenAI_SplitsInlineDataByMimeType(t *testing.T) {
+	converter := NewConverter()
+
+	geminiReq := &models.GeminiRequest{
+		Contents: []models.GeminiContent{
+			{
+				Role: models.RoleUser,
+				Parts: []models.GeminiPart{
+					{InlineData: &models.GeminiInlineData{MimeType: "application/pdf", Data: "cGRm"}},
+					{InlineData: &models.GeminiInlineData{MimeType: "audio/mpeg", Data: "YXVkaW8="}},
+				},
+			},
+		},
+	}
+
+	result, err := converter.ConvertRequest(geminiReq, models.ProtocolGemini, models.ProtocolOpenAI)
+	if err != nil {
+		t.Fatalf("ConvertRequest failed: %v", err)
+	}
+
+	openaiReq := result.(*models.OpenAIRequest)
+	if len(openaiReq.Messages) != 1 {
+		t.Fatalf("expected a single message, got %#v", openaiReq.Messages)
+	}
+	parts, ok := openaiReq.Messages[0].Content.([]models.ContentPart)
+	if !ok || len(parts) != 2 {
+		t.Fatalf("expected 2 content parts, got %#v", openaiReq.Messages[0].Content)
+	}
+	if parts[0].Type != "document" || parts[0].Document == nil || parts[0].Document.MimeType != "application/pdf" {
+		t.Fatalf("expected a document part for the PDF inlineData, got %#v", parts[0])
+	}
+	if parts[1].Type != "input_audio" || parts[1].InputAudio == nil || parts[1].InputAudio.Format != "mp3" {
+		t.Fatalf("expected an input_audio part with format mp3 for audio/mpeg, got %#v", parts[1])
+	}
+}
+
+func TestConverter_ConvertResponse_ClaudeToOpenAI_SurfacesThinkingAsReasoningContent(t *testing.T) {
+	converter := NewConverter()
+
+	claudeResp := &models.ClaudeResponse{
+		StopReason: "end_turn",
+		Content: []models.ClaudeContent{
+			{Type: "thinking", Thinking: "First, let's consider...", Signature: "sig-abc"},
+			{Type: "text", Text: "The answer is 42."},
+		},
+	}
+
+	result, err := converter.ConvertResponse(claudeResp, models.ProtocolClaude, models.ProtocolOpenAI, "gpt-4o")
+	if err != nil {
+		t.Fatalf("ConvertResponse failed: %v", err)
+	}
+
+	message := result.(*models.OpenAIResponse).Choices[0].Message
+	if message.ReasoningContent != "First, let's consider..." || message.ReasoningSignature != "sig-abc" {
+		t.Fatalf("expected thinking block surfaced as reasoning_content/signature, got %#v", message)
+	}
+	if message.GetContentAsString() != "The answer is 42." {
+		t.Fatalf("expected the thinking block kept out of the visible content, got %q", message.GetContentAsString())
+	}
+}
+
+func TestConverter_ConvertRequest_OpenAIToClaude_ReplaysReasoningAsLeadingThinkingBlock(t *testing.T) {
+	converter := NewConverter()
+
+	req := &models.OpenAIRequest{
+		Model:           "gpt-4o",
+		ReasoningEffort: "high",
+		Messages: []models.OpenAIMessage{
+			{Role: "user", Content: "What's 6*7?"},
+			{
+				Role:               models.RoleAssistant,
+				Content:            "42.",
+				ReasoningContent:   "6*7 is 42.",
+				ReasoningSignature: "sig-abc",
+			},
+		},
+	}
+
+	result, err := converter.ConvertRequest(req, models.ProtocolOpenAI, models.ProtocolClaude)
+	if err != nil {
+		t.Fatalf("ConvertRequest failed: %v", err)
+	}
+
+	claudeReq := result.(*models.ClaudeRequest)
+	if claudeReq.Thinking == nil || claudeReq.Thinking.BudgetTokens != 32768 {
+		t.Fatalf("expected reasoning_effort=high to set a thinking budget, got %#v", claudeReq.Thinking)
+	}
+
+	assistantMsg := claudeReq.Messages[1]
+	if len(assistantMsg.Content) != 2 {
+		t.Fatalf("expected a leading thinking block plus the text block, got %#v", assistantMsg.Content)
+	}
+	block := assistantMsg.Content[0]
+	if block.Type != "thinking" || block.Thinking != "6*7 is 42." || block.Signature != "sig-abc" {
+		t.Fatalf("expected the original thinking block replayed with its signature, got %#v", block)
+	}
+	if assistantMsg.Content[1].Type != "text" || assistantMsg.Content[1].Text != "42." {
+		t.Fatalf("expected the visible text to follow the thinking block, got %#v", assistantMsg.Content[1])
+	}
+}
+
+func TestConverter_ConvertRequest_GeminiToOpenAI_SeparatesThoughtPartsFromContent(t *testing.T) {
+	converter := NewConverter()
+
+	geminiReq := &models.GeminiRequest{
+		Contents: []models.GeminiContent{
+			{
+				Role: models.RoleModel,
+				Parts: []models.GeminiPart{
+					{Text: "Reasoning about the problem...", Thought: true},
+					{Text: "Final answer: 42."},
+				},
+			},
+		},
+		GenerationConfig: &models.GeminiGenerationConfig{
+			ThinkingConfig: &models.GeminiThinkingConfig{ThinkingBudget: 4096},
+		},
+	}
+
+	result, err := converter.ConvertRequest(geminiReq, models.ProtocolGemini, models.ProtocolOpenAI)
+	if err != nil {
+		t.Fatalf("ConvertRequest failed: %v", err)
+	}
+
+	openaiReq := result.(*models.OpenAIRequest)
+	if openaiReq.ReasoningEffort != "low" {
+		t.Fatalf("expected a 4096-token thinking budget to map to reasoning_effort=low, got %q", openaiReq.ReasoningEffort)
+	}
+
+	msg := openaiReq.Messages[0]
+	if msg.ReasoningContent != "Reasoning about the problem..." {
+		t.Fatalf("expected the thought part surfaced as reasoning_content, got %q", msg.ReasoningContent)
+	}
+	if msg.Content != "Final answer: 42." {
+		t.Fatalf("expected the thought part excluded from content, got %#v", msg.Content)
+	}
+}
+
 func BenchmarkConverter_ConvertRequest_OpenAIToClaude(b *testing.B) {
 	converter := NewConverter()
 
@@ -282,7 +1070,7 @@ func BenchmarkConverter_ConvertRequest_OpenAIToClaude(b *testing.B) {
 			{Role: "user", Content: "Hello!"},
 		},
 		MaxTokens:   100,
-		Temperature: 0.7,
+		Temperature: floatPtr(0.7),
 	}
 
 	b.ResetTimer()
@@ -0,0 +1,240 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+	"github.com/google/uuid"
+)
+
+// pendingToolCall accumulates the partial_json fragments of a tool call
+// that is still streaming, keyed by its content-block index.
+type pendingToolCall struct {
+	id   string
+	name string
+	args strings.Builder
+}
+
+// StreamConverter translates a stream of typed models.StreamEvent values
+// from one protocol's incremental shape to another's, carrying whatever
+// state that requires across calls. Unlike DefaultConverter, which is
+// stateless and shared across every request, a StreamConverter belongs to
+// a single in-flight stream: callers create one per request with
+// NewStreamConverter and feed it events in order.
+//
+// Gemini has no incremental tool-call form, so Convert buffers
+// InputJSONDelta fragments per index and only emits Gemini's single-shot
+// functionCall once the matching ContentBlockStop arrives. OpenAI and
+// Claude stream incrementally, so those targets get one chunk per event.
+type StreamConverter struct {
+	model   string
+	pending map[int]*pendingToolCall
+}
+
+// NewStreamConverter creates a StreamConverter for a single stream whose
+// response chunks should be labeled with model.
+func NewStreamConverter(model string) *StreamConverter {
+	return &StreamConverter{model: model, pending: make(map[int]*pendingToolCall)}
+}
+
+// Convert advances the converter's state with ev and returns the chunk(s)
+// ready to send to toProvider, or nil if ev only updated internal state
+// (e.g. an OpenAI/Claude-only event routed to Gemini, which still needs to
+// wait for ContentBlockStop).
+func (sc *StreamConverter) Convert(ev models.StreamEvent, toProvider models.ProtocolPrefix) (interface{}, error) {
+	switch ev.Type {
+	case models.StreamEventContentBlockDelta:
+		return sc.emitTextDelta(ev, toProvider), nil
+
+	case models.StreamEventToolUseStart:
+		sc.pending[ev.Index] = &pendingToolCall{id: ev.ToolCallID, name: ev.ToolName}
+		return sc.emitToolStart(ev, toProvider), nil
+
+	case models.StreamEventInputJSONDelta:
+		if p := sc.pending[ev.Index]; p != nil {
+			p.args.WriteString(ev.PartialJSON)
+		}
+		return sc.emitInputDelta(ev, toProvider), nil
+
+	case models.StreamEventContentBlockStop:
+		return sc.emitBlockStop(ev, toProvider)
+
+	case models.StreamEventMessageDelta:
+		return sc.emitMessageDelta(ev, toProvider), nil
+
+	case models.StreamEventMessageStop:
+		return sc.emitMessageStop(toProvider), nil
+
+	case models.StreamEventMessageStart:
+		return nil, nil
+
+	default:
+		return nil, fmt.Errorf("unknown stream event type %q", ev.Type)
+	}
+}
+
+func (sc *StreamConverter) emitTextDelta(ev models.StreamEvent, toProvider models.ProtocolPrefix) interface{} {
+	switch toProvider {
+	case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
+		return sc.openAIChunk(ev.Index, models.OpenAIMessage{Content: ev.Text}, "")
+	case models.ProtocolClaude:
+		return &models.ClaudeStreamEvent{
+			Type:  string(models.StreamEventContentBlockDelta),
+			Index: ev.Index,
+			Delta: &models.ClaudeStreamDelta{Type: "text_delta", Text: ev.Text},
+		}
+	case models.ProtocolGemini, models.ProtocolVertexAI:
+		return sc.geminiTextChunk(ev.Text)
+	}
+	return nil
+}
+
+func (sc *StreamConverter) emitToolStart(ev models.StreamEvent, toProvider models.ProtocolPrefix) interface{} {
+	switch toProvider {
+	case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
+		toolCall := models.ToolCall{
+			ID:       ev.ToolCallID,
+			Type:     "function",
+			Index:    ev.Index,
+			Function: models.ToolCallFunction{Name: ev.ToolName},
+		}
+		return sc.openAIChunk(ev.Index, models.OpenAIMessage{ToolCalls: []models.ToolCall{toolCall}}, "")
+	case models.ProtocolClaude:
+		return &models.ClaudeStreamEvent{
+			Type:  "content_block_start",
+			Index: ev.Index,
+			ContentBlock: &models.ClaudeStreamContentBlock{
+				Type: "tool_use",
+				ID:   ev.ToolCallID,
+				Name: ev.ToolName,
+			},
+		}
+	case models.ProtocolGemini, models.ProtocolVertexAI:
+		// Gemini has no incremental tool_use form; wait for ContentBlockStop.
+		return nil
+	}
+	return nil
+}
+
+func (sc *StreamConverter) emitInputDelta(ev models.StreamEvent, toProvider models.ProtocolPrefix) interface{} {
+	switch toProvider {
+	case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
+		toolCall := models.ToolCall{
+			Index:    ev.Index,
+			Function: models.ToolCallFunction{Arguments: ev.PartialJSON},
+		}
+		return sc.openAIChunk(ev.Index, models.OpenAIMessage{ToolCalls: []models.ToolCall{toolCall}}, "")
+	case models.ProtocolClaude:
+		return &models.ClaudeStreamEvent{
+			Type:  string(models.StreamEventInputJSONDelta),
+			Index: ev.Index,
+			Delta: &models.ClaudeStreamDelta{Type: "input_json_delta", PartialJSON: ev.PartialJSON},
+		}
+	case models.ProtocolGemini, models.ProtocolVertexAI:
+		// Buffered in sc.pending until ContentBlockStop.
+		return nil
+	}
+	return nil
+}
+
+func (sc *StreamConverter) emitBlockStop(ev models.StreamEvent, toProvider models.ProtocolPrefix) (interface{}, error) {
+	switch toProvider {
+	case models.ProtocolClaude:
+		return &models.ClaudeStreamEvent{Type: string(models.StreamEventContentBlockStop), Index: ev.Index}, nil
+	case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
+		return nil, nil
+	case models.ProtocolGemini, models.ProtocolVertexAI:
+		p, ok := sc.pending[ev.Index]
+		if !ok {
+			return nil, nil
+		}
+		delete(sc.pending, ev.Index)
+
+		var args map[string]interface{}
+		if p.args.Len() > 0 {
+			if err := json.Unmarshal([]byte(p.args.String()), &args); err != nil {
+				return nil, fmt.Errorf("parse buffered tool arguments for %s: %w", p.name, err)
+			}
+		}
+
+		return &models.GeminiResponse{
+			Candidates: []models.GeminiCandidate{
+				{
+					Content: models.GeminiContent{
+						Role: models.RoleModel,
+						Parts: []models.GeminiPart{
+							{FunctionCall: &models.GeminiFunctionCall{ID: geminiFunctionCallID(p.id, p.name), Name: p.name, Args: args}},
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	return nil, nil
+}
+
+// emitMessageDelta translates the terminal message_delta event (Claude's
+// stop_reason plus completion usage) into the target protocol's equivalent:
+// an OpenAI chunk carrying finish_reason, a Claude message_delta carrying
+// stop_reason/usage, or a Gemini candidate carrying finishReason/usageMetadata
+// (Gemini has no separate terminal event, so it's folded into one chunk).
+func (sc *StreamConverter) emitMessageDelta(ev models.StreamEvent, toProvider models.ProtocolPrefix) interface{} {
+	converter := &DefaultConverter{}
+	switch toProvider {
+	case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
+		return sc.openAIChunk(0, models.OpenAIMessage{}, converter.mapClaudeStopReason(ev.FinishReason))
+	case models.ProtocolClaude:
+		return &models.ClaudeStreamEvent{
+			Type:  string(models.StreamEventMessageDelta),
+			Delta: &models.ClaudeStreamDelta{StopReason: ev.FinishReason},
+			Usage: &models.ClaudeStreamUsage{OutputTokens: ev.OutputTokens},
+		}
+	case models.ProtocolGemini, models.ProtocolVertexAI:
+		return &models.GeminiResponse{
+			Candidates: []models.GeminiCandidate{
+				{
+					Content:      models.GeminiContent{Role: models.RoleModel},
+					FinishReason: converter.mapClaudeStopReasonToGemini(ev.FinishReason),
+				},
+			},
+			UsageMetadata: &models.GeminiUsage{
+				CandidatesTokenCount: ev.OutputTokens,
+				TotalTokenCount:      ev.OutputTokens,
+			},
+		}
+	}
+	return nil
+}
+
+// emitMessageStop closes out a Claude stream with its message_stop event.
+// OpenAI and Gemini have no equivalent terminal marker of their own; the
+// caller's loop appends OpenAI's [DONE] sentinel once the stream ends.
+func (sc *StreamConverter) emitMessageStop(toProvider models.ProtocolPrefix) interface{} {
+	if toProvider == models.ProtocolClaude {
+		return &models.ClaudeStreamEvent{Type: string(models.StreamEventMessageStop)}
+	}
+	return nil
+}
+
+func (sc *StreamConverter) openAIChunk(index int, delta models.OpenAIMessage, finishReason string) *models.StreamChunk {
+	return &models.StreamChunk{
+		ID:      fmt.Sprintf("chatcmpl-%s", uuid.New().String()),
+		Object:  "chat.completion.chunk",
+		Created: time.Now().Unix(),
+		Model:   sc.model,
+		Choices: []models.StreamChoice{
+			{Index: index, Delta: &delta, FinishReason: finishReason},
+		},
+	}
+}
+
+func (sc *StreamConverter) geminiTextChunk(text string) *models.GeminiResponse {
+	return &models.GeminiResponse{
+		Candidates: []models.GeminiCandidate{
+			{Content: models.GeminiContent{Role: models.RoleModel, Parts: []models.GeminiPart{{Text: text}}}},
+		},
+	}
+}
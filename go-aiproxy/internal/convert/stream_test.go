@@ -0,0 +1,156 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+func TestStreamConverter_ToolUseToOpenAI_StreamsIncrementally(t *testing.T) {
+	sc := NewStreamConverter("gpt-4o")
+
+	start, err := sc.Convert(models.StreamEvent{
+		Type: models.StreamEventToolUseStart, Index: 0, ToolCallID: "call_1", ToolName: "get_weather",
+	}, models.ProtocolOpenAI)
+	if err != nil {
+		t.Fatalf("ToolUseStart: %v", err)
+	}
+	chunk, ok := start.(*models.StreamChunk)
+	if !ok || chunk.Choices[0].Delta.ToolCalls[0].Function.Name != "get_weather" {
+		t.Fatalf("expected a StreamChunk naming get_weather, got %#v", start)
+	}
+
+	delta, err := sc.Convert(models.StreamEvent{
+		Type: models.StreamEventInputJSONDelta, Index: 0, PartialJSON: `{"location":"Tokyo"}`,
+	}, models.ProtocolOpenAI)
+	if err != nil {
+		t.Fatalf("InputJSONDelta: %v", err)
+	}
+	deltaChunk, ok := delta.(*models.StreamChunk)
+	if !ok || deltaChunk.Choices[0].Delta.ToolCalls[0].Function.Arguments != `{"location":"Tokyo"}` {
+		t.Fatalf("expected incremental arguments chunk, got %#v", delta)
+	}
+}
+
+func TestStreamConverter_ToolUseToGemini_BuffersUntilBlockStop(t *testing.T) {
+	sc := NewStreamConverter("gemini-1.5-pro")
+
+	if chunk, err := sc.Convert(models.StreamEvent{
+		Type: models.StreamEventToolUseStart, Index: 0, ToolCallID: "call_1", ToolName: "get_weather",
+	}, models.ProtocolGemini); err != nil || chunk != nil {
+		t.Fatalf("expected ToolUseStart to be buffered with no chunk, got %#v, err %v", chunk, err)
+	}
+
+	fragments := []string{`{"locat`, `ion":"T`, `okyo"}`}
+	for _, f := range fragments {
+		if chunk, err := sc.Convert(models.StreamEvent{
+			Type: models.StreamEventInputJSONDelta, Index: 0, PartialJSON: f,
+		}, models.ProtocolGemini); err != nil || chunk != nil {
+			t.Fatalf("expected InputJSONDelta to be buffered with no chunk, got %#v, err %v", chunk, err)
+		}
+	}
+
+	result, err := sc.Convert(models.StreamEvent{Type: models.StreamEventContentBlockStop, Index: 0}, models.ProtocolGemini)
+	if err != nil {
+		t.Fatalf("ContentBlockStop: %v", err)
+	}
+
+	geminiResp, ok := result.(*models.GeminiResponse)
+	if !ok {
+		t.Fatalf("expected *models.GeminiResponse, got %#v", result)
+	}
+
+	part := geminiResp.Candidates[0].Content.Parts[0]
+	if part.FunctionCall == nil || part.FunctionCall.Name != "get_weather" {
+		t.Fatalf("expected get_weather functionCall, got %#v", part.FunctionCall)
+	}
+	if part.FunctionCall.Args["location"] != "Tokyo" {
+		t.Fatalf("expected buffered args to parse to Tokyo, got %#v", part.FunctionCall.Args)
+	}
+}
+
+func TestStreamConverter_TextDeltaToClaude(t *testing.T) {
+	sc := NewStreamConverter("claude-3-opus")
+
+	result, err := sc.Convert(models.StreamEvent{Type: models.StreamEventContentBlockDelta, Index: 0, Text: "Hello"}, models.ProtocolClaude)
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	event, ok := result.(*models.ClaudeStreamEvent)
+	if !ok || event.Delta.Text != "Hello" {
+		t.Fatalf("expected a Claude text_delta event, got %#v", result)
+	}
+}
+
+func TestStreamConverter_MessageDelta_ToOpenAI_CarriesFinishReason(t *testing.T) {
+	sc := NewStreamConverter("gpt-4o")
+
+	result, err := sc.Convert(models.StreamEvent{
+		Type: models.StreamEventMessageDelta, FinishReason: "tool_use", OutputTokens: 12,
+	}, models.ProtocolOpenAI)
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	chunk, ok := result.(*models.StreamChunk)
+	if !ok || chunk.Choices[0].FinishReason != "tool_calls" {
+		t.Fatalf("expected finish_reason tool_calls, got %#v", result)
+	}
+}
+
+func TestStreamConverter_MessageDeltaAndStop_ToClaude(t *testing.T) {
+	sc := NewStreamConverter("claude-3-opus")
+
+	delta, err := sc.Convert(models.StreamEvent{
+		Type: models.StreamEventMessageDelta, FinishReason: "end_turn", OutputTokens: 42,
+	}, models.ProtocolClaude)
+	if err != nil {
+		t.Fatalf("MessageDelta: %v", err)
+	}
+	deltaEvent, ok := delta.(*models.ClaudeStreamEvent)
+	if !ok || deltaEvent.Type != "message_delta" || deltaEvent.Delta.StopReason != "end_turn" || deltaEvent.Usage.OutputTokens != 42 {
+		t.Fatalf("expected a message_delta event with stop_reason/usage, got %#v", delta)
+	}
+
+	stop, err := sc.Convert(models.StreamEvent{Type: models.StreamEventMessageStop}, models.ProtocolClaude)
+	if err != nil {
+		t.Fatalf("MessageStop: %v", err)
+	}
+	stopEvent, ok := stop.(*models.ClaudeStreamEvent)
+	if !ok || stopEvent.Type != "message_stop" {
+		t.Fatalf("expected a message_stop event, got %#v", stop)
+	}
+}
+
+func TestStreamConverter_MessageDelta_ToGemini_CarriesFinishReasonAndUsage(t *testing.T) {
+	sc := NewStreamConverter("gemini-1.5-pro")
+
+	result, err := sc.Convert(models.StreamEvent{
+		Type: models.StreamEventMessageDelta, FinishReason: "max_tokens", OutputTokens: 8,
+	}, models.ProtocolGemini)
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	geminiResp, ok := result.(*models.GeminiResponse)
+	if !ok || geminiResp.Candidates[0].FinishReason != "MAX_TOKENS" || geminiResp.UsageMetadata.CandidatesTokenCount != 8 {
+		t.Fatalf("expected finishReason MAX_TOKENS and usage 8, got %#v", result)
+	}
+}
+
+func TestStreamEvent_EncodeDecodeRoundTrip(t *testing.T) {
+	ev := models.StreamEvent{Type: models.StreamEventToolUseStart, Index: 2, ToolCallID: "call_9", ToolName: "get_weather"}
+
+	decoded, ok := models.DecodeStreamEvent(models.EncodeStreamEvent(ev))
+	if !ok {
+		t.Fatal("expected DecodeStreamEvent to recognize an encoded event")
+	}
+	if decoded != ev {
+		t.Fatalf("round trip mismatch: got %#v, want %#v", decoded, ev)
+	}
+
+	if _, ok := models.DecodeStreamEvent("plain text chunk"); ok {
+		t.Fatal("expected plain text chunk to not decode as a StreamEvent")
+	}
+}
@@ -0,0 +1,187 @@
+// Package grpcapi implements the AIProxyStream RPCs described in
+// proto/streaming.proto. This checkout has no protoc/buf/connect-go
+// toolchain to regenerate real gRPC bindings from that file (see its
+// NOTE), so each RPC is served as newline-delimited JSON StreamChunk
+// frames over h2c on a second port instead, reusing the same
+// convert.Converter the SSE-based HTTP API uses so both transports stay
+// semantically identical.
+package grpcapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/aiproxy/go-aiproxy/internal/convert"
+	"github.com/aiproxy/go-aiproxy/internal/providers"
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+// Service implements the AIProxyStream RPCs over plain HTTP/2 (h2c).
+type Service struct {
+	providers       map[string]providers.Provider
+	converter       convert.Converter
+	defaultProvider string
+}
+
+// NewService builds a Service sharing providers and converter with the
+// HTTP API's Server, so both transports stay consistent as providers are
+// added or reconfigured. defaultProvider is used when a request doesn't
+// set X-Model-Provider, matching handleChatCompletions's fallback.
+func NewService(providerMap map[string]providers.Provider, converter convert.Converter, defaultProvider string) *Service {
+	return &Service{
+		providers:       providerMap,
+		converter:       converter,
+		defaultProvider: defaultProvider,
+	}
+}
+
+// Handler returns the mux routing each RPC to its path, following the
+// gRPC/Connect convention of /<package>.<Service>/<Method>.
+func (s *Service) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/aiproxy.streaming.v1.AIProxyStream/ChatCompletions", s.handleChatCompletions)
+	mux.HandleFunc("/aiproxy.streaming.v1.AIProxyStream/GenerateContent", s.handleGenerateContent)
+	mux.HandleFunc("/aiproxy.streaming.v1.AIProxyStream/Messages", s.handleMessages)
+	return mux
+}
+
+// handleChatCompletions streams an OpenAI-shaped request, converting
+// each chunk into the backing provider's native protocol and back, the
+// same as Server.handleChatCompletions's streaming path.
+func (s *Service) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req models.OpenAIRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Stream = true
+
+	providerName := r.Header.Get("X-Model-Provider")
+	if providerName == "" {
+		providerName = s.defaultProvider
+	}
+	provider, ok := s.providers[providerName]
+	if !ok {
+		http.Error(w, "invalid provider", http.StatusBadRequest)
+		return
+	}
+
+	s.stream(w, r, provider, req.Model, &req, models.ProtocolOpenAI)
+}
+
+// handleGenerateContent streams a Gemini-shaped request directly against
+// the configured Gemini provider, mirroring handleGeminiStream. Gemini's
+// model lives in the URL path (.../models/<model>:streamGenerateContent),
+// not the request body - models.GeminiRequest has no Model field - so it's
+// read off the "model" query parameter instead, the way callers already
+// adapting HTTP Gemini requests to this RPC are expected to pass it through.
+func (s *Service) handleGenerateContent(w http.ResponseWriter, r *http.Request) {
+	var req models.GeminiRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	provider := s.providerByProtocol(models.ProtocolGemini)
+	if provider == nil {
+		http.Error(w, "Gemini provider not configured", http.StatusBadRequest)
+		return
+	}
+
+	model := r.URL.Query().Get("model")
+	if model == "" {
+		http.Error(w, "missing model query parameter", http.StatusBadRequest)
+		return
+	}
+
+	s.stream(w, r, provider, model, &req, models.ProtocolGemini)
+}
+
+// handleMessages streams a Claude-shaped request directly against the
+// configured Claude provider, mirroring handleClaudeMessages.
+func (s *Service) handleMessages(w http.ResponseWriter, r *http.Request) {
+	var req models.ClaudeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	provider := s.providerByProtocol(models.ProtocolClaude)
+	if provider == nil {
+		http.Error(w, "Claude provider not configured", http.StatusBadRequest)
+		return
+	}
+
+	s.stream(w, r, provider, req.Model, &req, models.ProtocolClaude)
+}
+
+// providerByProtocol returns the first configured provider whose native
+// protocol is protocol, or nil if none is configured.
+func (s *Service) providerByProtocol(protocol models.ProtocolPrefix) providers.Provider {
+	for _, p := range s.providers {
+		if p.GetProtocolPrefix() == protocol {
+			return p
+		}
+	}
+	return nil
+}
+
+// stream reads provider's raw stream for request, converts each chunk
+// from fromProtocol into the caller's native protocol (here always the
+// same as fromProtocol, since every RPC's request shape is already
+// native), and writes one StreamChunk JSON object per line, flushing
+// after each so the caller sees incremental progress the way gRPC
+// server-streaming would.
+func (s *Service) stream(w http.ResponseWriter, r *http.Request, provider providers.Provider, model string, request interface{}, fromProtocol models.ProtocolPrefix) {
+	ctx := r.Context()
+
+	respStream, err := provider.GenerateContentStream(ctx, model, request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer respStream.Close()
+
+	w.Header().Set("Content-Type", "application/json-seq")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	streamConv := convert.NewStreamConverter(model)
+
+	buffer := make([]byte, 4096)
+	for {
+		n, err := respStream.Read(buffer)
+		if n > 0 {
+			chunk := string(buffer[:n])
+
+			var converted interface{}
+			if se, ok := models.DecodeStreamEvent(chunk); ok {
+				var convErr error
+				converted, convErr = streamConv.Convert(se, fromProtocol)
+				if convErr != nil || converted == nil {
+					continue
+				}
+			} else {
+				converted = chunk
+			}
+
+			jsonData, marshalErr := json.Marshal(converted)
+			if marshalErr != nil {
+				continue
+			}
+			fmt.Fprintf(w, "%s\n", jsonData)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("grpcapi stream read error: %v", err)
+			}
+			break
+		}
+	}
+}
@@ -3,6 +3,7 @@ package loadbalancer
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,6 +21,18 @@ const (
 	AlgorithmWeighted      Algorithm = "weighted"
 	AlgorithmRandom        Algorithm = "random"
 	AlgorithmIPHash        Algorithm = "ip_hash"
+	AlgorithmAdaptive      Algorithm = "adaptive"
+)
+
+// ewmaAlpha/ewmaIdleDecay back AlgorithmAdaptive's per-instance latency
+// and error-rate tracking: each sample is blended into the running EWMA
+// with weight ewmaAlpha, and the EWMA is reset to zero once an instance
+// has gone ewmaIdleDecay without a sample, so an instance that recovers
+// after sitting unhealthy (or simply unused) is scored fresh rather than
+// carrying a stale penalty from before.
+const (
+	ewmaAlpha     = 0.3
+	ewmaIdleDecay = 30 * time.Second
 )
 
 // LoadBalancer manages multiple provider instances
@@ -30,20 +43,51 @@ type LoadBalancer struct {
 	currentIndex  uint64
 	healthChecker *HealthChecker
 	metrics       *BalancerMetrics
+	ring          atomic.Pointer[hashRing]
+
+	// reattach holds externally-managed provider backends discovered via
+	// AIPROXY_REATTACH_PROVIDERS, keyed "<providerType>/<uuid>". Instances
+	// whose config matches an entry attach to it instead of the normally
+	// configured upstream.
+	reattach map[string]providers.ReattachConfig
 }
 
 // Instance represents a provider instance
 type Instance struct {
-	ID             string
-	Provider       providers.Provider
-	Config         *models.ProviderConfig
-	Weight         int
-	ActiveRequests int64
-	TotalRequests  int64
-	FailedRequests int64
-	LastUsed       time.Time
-	IsHealthy      bool
-	HealthCheckURL string
+	ID                  string
+	Provider            providers.Provider
+	Config              *models.ProviderConfig
+	Weight              int
+	ActiveRequests      int64
+	TotalRequests       int64
+	FailedRequests      int64
+	LastUsed            time.Time
+	IsHealthy            bool
+	HealthCheckURL       string
+	ConsecutiveFailures  int
+	ConsecutiveSuccesses int
+	NextProbeAt          time.Time
+
+	// CurrentWeight/EffectiveWeight back the Nginx-style smooth weighted
+	// round robin selection in selectWeighted. EffectiveWeight starts equal
+	// to Weight and is reduced on failures, recovering gradually on
+	// success, so a flaky-but-not-yet-unhealthy instance naturally loses
+	// share without being removed outright.
+	CurrentWeight   int
+	EffectiveWeight int
+
+	// Reattached marks an instance backed by an externally-managed
+	// provider process (AIPROXY_REATTACH_PROVIDERS); the health checker
+	// skips it entirely and it is always considered healthy.
+	Reattached bool
+
+	// latEWMAMs/errEWMA back AlgorithmAdaptive: an exponentially-weighted
+	// moving average of observed latency in milliseconds and of the error
+	// rate (0..1), updated by recordSample on every ReleaseInstance call
+	// and reset to zero after ewmaIdleDecay of inactivity.
+	latEWMAMs    float64
+	errEWMA      float64
+	lastSampleAt time.Time
 }
 
 // BalancerMetrics tracks load balancer performance
@@ -57,13 +101,22 @@ type BalancerMetrics struct {
 
 // NewLoadBalancer creates a new load balancer
 func NewLoadBalancer(algorithm Algorithm) *LoadBalancer {
+	// A malformed AIPROXY_REATTACH_PROVIDERS shouldn't take down the proxy;
+	// fall back to normal provider dialing for every instance.
+	reattach, _ := providers.ParseReattachEnv()
+
 	lb := &LoadBalancer{
 		algorithm:     algorithm,
 		instances:     make([]*Instance, 0),
 		healthChecker: NewHealthChecker(30 * time.Second),
 		metrics:       &BalancerMetrics{},
+		reattach:      reattach,
 	}
 
+	// Register for health status changes so the balancer's own bookkeeping
+	// (IsHealthy, HealthyInstances) stays in sync with the checker.
+	lb.healthChecker.AddUpdateCallback(lb.UpdateInstanceHealth)
+
 	// Start health checking
 	go lb.healthChecker.Start()
 
@@ -82,13 +135,29 @@ func (lb *LoadBalancer) AddInstance(id string, provider providers.Provider, conf
 		}
 	}
 
+	if weight <= 0 {
+		weight = 1
+	}
+
+	reattached := false
+	if config != nil {
+		if cfg, ok := providers.LookupReattach(lb.reattach, string(config.Provider), config.UUID); ok {
+			// Skip normal credential/keepalive logic entirely and route to
+			// the externally-managed backend instead.
+			provider = providers.NewReattachProvider(cfg)
+			reattached = true
+		}
+	}
+
 	instance := &Instance{
-		ID:        id,
-		Provider:  provider,
-		Config:    config,
-		Weight:    weight,
-		IsHealthy: true,
-		LastUsed:  time.Now(),
+		ID:              id,
+		Provider:        provider,
+		Config:          config,
+		Weight:          weight,
+		EffectiveWeight: weight,
+		IsHealthy:       true,
+		LastUsed:        time.Now(),
+		Reattached:      reattached,
 	}
 
 	lb.instances = append(lb.instances, instance)
@@ -98,6 +167,8 @@ func (lb *LoadBalancer) AddInstance(id string, provider providers.Provider, conf
 	// Register with health checker
 	lb.healthChecker.Register(instance)
 
+	lb.rebuildRingLocked()
+
 	return nil
 }
 
@@ -117,6 +188,7 @@ func (lb *LoadBalancer) RemoveInstance(id string) error {
 			if inst.IsHealthy {
 				atomic.AddInt64(&lb.metrics.HealthyInstances, -1)
 			}
+			lb.rebuildRingLocked()
 			return nil
 		}
 	}
@@ -124,10 +196,102 @@ func (lb *LoadBalancer) RemoveInstance(id string) error {
 	return fmt.Errorf("instance %s not found", id)
 }
 
+// ReplaceInstance swaps id's provider/config in place: a fresh Instance is
+// registered under a new internal bookkeeping ID so in-flight and new
+// selections never observe a half-swapped id, while id itself keeps
+// resolving to the new provider immediately (ReplaceInstance exists for
+// credential rotation, where the caller wants "new requests use the new
+// token right away" without dropping whatever is still in flight on the
+// old one). The old instance is marked unhealthy so the ring stops routing
+// to it, then drained in the background: ReplaceInstance returns as soon
+// as the swap is visible, and the old instance is actually removed once
+// its ActiveRequests reaches zero or gracePeriod elapses, whichever comes
+// first.
+func (lb *LoadBalancer) ReplaceInstance(id string, provider providers.Provider, config *models.ProviderConfig, weight int, gracePeriod time.Duration) error {
+	lb.mu.Lock()
+
+	var old *Instance
+	for _, inst := range lb.instances {
+		if inst.ID == id {
+			old = inst
+			break
+		}
+	}
+	if old == nil {
+		lb.mu.Unlock()
+		return fmt.Errorf("instance %s not found", id)
+	}
+
+	// Retire the old instance's ID so it no longer collides with the
+	// replacement, and stop routing new selections to it.
+	drainingID := fmt.Sprintf("%s-draining-%d", id, time.Now().UnixNano())
+	old.ID = drainingID
+	if old.IsHealthy {
+		old.IsHealthy = false
+		atomic.AddInt64(&lb.metrics.HealthyInstances, -1)
+	}
+
+	if weight <= 0 {
+		weight = 1
+	}
+	next := &Instance{
+		ID:              id,
+		Provider:        provider,
+		Config:          config,
+		Weight:          weight,
+		EffectiveWeight: weight,
+		IsHealthy:       true,
+		LastUsed:        time.Now(),
+	}
+	lb.instances = append(lb.instances, next)
+	atomic.AddInt64(&lb.metrics.TotalInstances, 1)
+	atomic.AddInt64(&lb.metrics.HealthyInstances, 1)
+	lb.healthChecker.Register(next)
+	lb.rebuildRingLocked()
+
+	lb.mu.Unlock()
+
+	go lb.drainAndRemove(old, gracePeriod)
+
+	return nil
+}
+
+// drainAndRemove waits until old has no active requests or gracePeriod
+// elapses, then removes it from the load balancer. Called as the
+// background half of ReplaceInstance.
+func (lb *LoadBalancer) drainAndRemove(old *Instance, gracePeriod time.Duration) {
+	deadline := time.Now().Add(gracePeriod)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for atomic.LoadInt64(&old.ActiveRequests) > 0 && time.Now().Before(deadline) {
+		<-ticker.C
+	}
+
+	// Error ignored: old.ID not being found just means something else
+	// already removed it.
+	_ = lb.RemoveInstance(old.ID)
+}
+
+// rebuildRingLocked recomputes the consistent-hash ring from the current
+// healthy instance set and atomically swaps it in. Must be called with
+// lb.mu held.
+func (lb *LoadBalancer) rebuildRingLocked() {
+	ids := make([]string, 0, len(lb.instances))
+	for _, inst := range lb.instances {
+		if inst.IsHealthy {
+			ids = append(ids, inst.ID)
+		}
+	}
+	lb.ring.Store(buildHashRing(ids))
+}
+
 // SelectInstance selects a provider instance based on the algorithm
 func (lb *LoadBalancer) SelectInstance(ctx context.Context, clientIP string) (*Instance, error) {
-	lb.mu.RLock()
-	defer lb.mu.RUnlock()
+	// Full lock, not RLock: selectWeighted mutates CurrentWeight/
+	// EffectiveWeight on every pick.
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
 
 	// Get healthy instances
 	healthyInstances := lb.getHealthyInstances()
@@ -148,6 +312,8 @@ func (lb *LoadBalancer) SelectInstance(ctx context.Context, clientIP string) (*I
 		selected = lb.selectRandom(healthyInstances)
 	case AlgorithmIPHash:
 		selected = lb.selectIPHash(healthyInstances, clientIP)
+	case AlgorithmAdaptive:
+		selected = lb.selectAdaptive(healthyInstances)
 	default:
 		selected = lb.selectRoundRobin(healthyInstances)
 	}
@@ -163,15 +329,37 @@ func (lb *LoadBalancer) SelectInstance(ctx context.Context, clientIP string) (*I
 	return selected, nil
 }
 
-// ReleaseInstance releases an instance after request completion
-func (lb *LoadBalancer) ReleaseInstance(instance *Instance, failed bool) {
+// ReleaseInstance releases an instance after request completion. A failed
+// request reduces the instance's EffectiveWeight (down to a floor of 1) so
+// selectWeighted gives it less share going forward; a success recovers it
+// gradually back toward its configured Weight. latency also feeds
+// instance's EWMA latency/error-rate tracking used by AlgorithmAdaptive.
+func (lb *LoadBalancer) ReleaseInstance(instance *Instance, latency time.Duration, failed bool) {
 	atomic.AddInt64(&instance.ActiveRequests, -1)
 	atomic.AddInt64(&lb.metrics.ActiveRequests, -1)
 
+	lb.mu.Lock()
+	instance.recordSample(latency, failed)
+	lb.mu.Unlock()
+
 	if failed {
 		atomic.AddInt64(&instance.FailedRequests, 1)
 		atomic.AddInt64(&lb.metrics.FailedRequests, 1)
+
+		lb.mu.Lock()
+		instance.EffectiveWeight -= instance.EffectiveWeight / 2
+		if instance.EffectiveWeight < 1 {
+			instance.EffectiveWeight = 1
+		}
+		lb.mu.Unlock()
+		return
+	}
+
+	lb.mu.Lock()
+	if instance.EffectiveWeight < instance.Weight {
+		instance.EffectiveWeight++
 	}
+	lb.mu.Unlock()
 }
 
 // getHealthyInstances returns all healthy instances
@@ -215,34 +403,38 @@ func (lb *LoadBalancer) selectLeastRequests(instances []*Instance) *Instance {
 	return selected
 }
 
-// selectWeighted selects instance based on weights
+// selectWeighted selects an instance using Nginx-style smooth weighted
+// round robin: every pick adds each instance's EffectiveWeight to its
+// CurrentWeight, the instance with the highest CurrentWeight wins, and the
+// winner's CurrentWeight is reduced by the total effective weight. This
+// spreads picks proportionally to weight without bursting N requests in a
+// row onto the same heavy instance, unlike a plain modulo scheme.
 func (lb *LoadBalancer) selectWeighted(instances []*Instance) *Instance {
 	if len(instances) == 0 {
 		return nil
 	}
 
-	// Calculate total weight
-	totalWeight := 0
+	totalEffectiveWeight := 0
+	var best *Instance
+
 	for _, inst := range instances {
-		totalWeight += inst.Weight
-	}
+		if inst.EffectiveWeight <= 0 {
+			inst.EffectiveWeight = 1
+		}
+		inst.CurrentWeight += inst.EffectiveWeight
+		totalEffectiveWeight += inst.EffectiveWeight
 
-	if totalWeight == 0 {
-		return lb.selectRoundRobin(instances)
+		if best == nil || inst.CurrentWeight > best.CurrentWeight {
+			best = inst
+		}
 	}
 
-	// Select based on weight
-	index := int(atomic.AddUint64(&lb.currentIndex, 1)) % totalWeight
-	currentWeight := 0
-
-	for _, inst := range instances {
-		currentWeight += inst.Weight
-		if index < currentWeight {
-			return inst
-		}
+	if best == nil {
+		return lb.selectRoundRobin(instances)
 	}
 
-	return instances[len(instances)-1]
+	best.CurrentWeight -= totalEffectiveWeight
+	return best
 }
 
 // selectRandom selects a random instance
@@ -256,20 +448,113 @@ func (lb *LoadBalancer) selectRandom(instances []*Instance) *Instance {
 	return instances[index]
 }
 
-// selectIPHash selects instance based on client IP hash
+// selectIPHash selects an instance for clientIP using the consistent-hash
+// ring cached on lb.ring. Unlike hash(clientIP) % len(instances), adding or
+// removing an instance only remaps the vnodes adjacent to it on the ring,
+// so the vast majority of clients keep landing on the same instance.
 func (lb *LoadBalancer) selectIPHash(instances []*Instance, clientIP string) *Instance {
 	if len(instances) == 0 {
 		return nil
 	}
 
-	// Simple hash function
-	hash := uint32(0)
-	for _, b := range []byte(clientIP) {
-		hash = hash*31 + uint32(b)
+	ring := lb.ring.Load()
+	if ring != nil {
+		if ownerID, ok := ring.owner(clientIP); ok {
+			for _, inst := range instances {
+				if inst.ID == ownerID {
+					return inst
+				}
+			}
+		}
 	}
 
-	index := hash % uint32(len(instances))
-	return instances[index]
+	// Ring is stale (e.g. the owning instance just became unhealthy) or not
+	// yet built: fall back to round robin rather than returning nil.
+	return lb.selectRoundRobin(instances)
+}
+
+// selectAdaptive implements power-of-two-choices: sample two distinct
+// random healthy instances and pick the one with the lower AdaptiveScore,
+// falling back to the lone instance in the degenerate single-instance
+// case. Sampling two rather than scoring every instance keeps a pick O(1)
+// regardless of how many instances are registered. Must be called with
+// lb.mu held (SelectInstance's caller already holds it).
+func (lb *LoadBalancer) selectAdaptive(instances []*Instance) *Instance {
+	if len(instances) == 0 {
+		return nil
+	}
+	if len(instances) == 1 {
+		return instances[0]
+	}
+
+	i := rand.Intn(len(instances))
+	j := rand.Intn(len(instances) - 1)
+	if j >= i {
+		j++
+	}
+
+	a, b := instances[i], instances[j]
+	a.decayIfIdleLocked()
+	b.decayIfIdleLocked()
+
+	if a.AdaptiveScore() <= b.AdaptiveScore() {
+		return a
+	}
+	return b
+}
+
+// recordSample blends one observed request outcome into latEWMAMs/
+// errEWMA, decaying first if the instance has been idle long enough that
+// its prior samples shouldn't dominate a fresh one. Must be called with
+// the owning LoadBalancer's mu held.
+func (inst *Instance) recordSample(latency time.Duration, failed bool) {
+	inst.decayIfIdleLocked()
+
+	sampleMs := float64(latency.Milliseconds())
+	errSample := 0.0
+	if failed {
+		errSample = 1.0
+	}
+
+	if inst.lastSampleAt.IsZero() {
+		inst.latEWMAMs = sampleMs
+		inst.errEWMA = errSample
+	} else {
+		inst.latEWMAMs = ewmaAlpha*sampleMs + (1-ewmaAlpha)*inst.latEWMAMs
+		inst.errEWMA = ewmaAlpha*errSample + (1-ewmaAlpha)*inst.errEWMA
+	}
+	inst.lastSampleAt = time.Now()
+}
+
+// decayIfIdleLocked resets latEWMAMs/errEWMA to zero once ewmaIdleDecay
+// has passed since the last sample, so an instance that recovered while
+// idle gets probed on a clean slate instead of staying penalized by
+// history from before it went unhealthy. Must be called with the owning
+// LoadBalancer's mu held.
+func (inst *Instance) decayIfIdleLocked() {
+	if !inst.lastSampleAt.IsZero() && time.Since(inst.lastSampleAt) > ewmaIdleDecay {
+		inst.latEWMAMs = 0
+		inst.errEWMA = 0
+	}
+}
+
+// AdaptiveScore is the cost AlgorithmAdaptive minimizes: EWMA latency
+// scaled up by error rate and by current load relative to weight, so a
+// slow, error-prone, or already-busy instance scores worse than an idle
+// one with a good track record. A cold-start instance (no samples yet,
+// latEWMAMs == 0) has nothing to score on besides weight, so a small
+// weight-proportional term breaks that tie in favor of the more heavily
+// weighted instance instead of leaving it to whichever was sampled first.
+func (inst *Instance) AdaptiveScore() float64 {
+	weight := inst.Weight
+	if weight <= 0 {
+		weight = 1
+	}
+	active := float64(atomic.LoadInt64(&inst.ActiveRequests))
+
+	score := inst.latEWMAMs * (1 + inst.errEWMA) * (1 + active/float64(weight))
+	score -= float64(weight) * 1e-6
+	return score
 }
 
 // GetMetrics returns current metrics
@@ -283,6 +568,18 @@ func (lb *LoadBalancer) GetMetrics() BalancerMetrics {
 	}
 }
 
+// HealthStats returns per-instance health stats (last latency, success
+// ratio, last error) from the underlying HealthChecker, for the
+// GET /cluster/health endpoint.
+func (lb *LoadBalancer) HealthStats() map[string]InstanceStats {
+	return lb.healthChecker.Stats()
+}
+
+// SetHealthThresholds configures the health checker's hysteresis.
+func (lb *LoadBalancer) SetHealthThresholds(unhealthy, healthy int) {
+	lb.healthChecker.SetThresholds(unhealthy, healthy)
+}
+
 // UpdateInstanceHealth updates the health status of an instance
 func (lb *LoadBalancer) UpdateInstanceHealth(instanceID string, healthy bool) {
 	lb.mu.Lock()
@@ -297,6 +594,7 @@ func (lb *LoadBalancer) UpdateInstanceHealth(instanceID string, healthy bool) {
 				} else {
 					atomic.AddInt64(&lb.metrics.HealthyInstances, -1)
 				}
+				lb.rebuildRingLocked()
 			}
 			break
 		}
@@ -0,0 +1,200 @@
+package loadbalancer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newTestInstance(id string, weight int) *Instance {
+	return &Instance{
+		ID:              id,
+		Weight:          weight,
+		EffectiveWeight: weight,
+		IsHealthy:       true,
+	}
+}
+
+func TestSelectWeighted_Distribution(t *testing.T) {
+	lb := &LoadBalancer{algorithm: AlgorithmWeighted, metrics: &BalancerMetrics{}}
+	instances := []*Instance{
+		newTestInstance("a", 5),
+		newTestInstance("b", 1),
+		newTestInstance("c", 1),
+	}
+
+	counts := make(map[string]int)
+	const picks = 700
+	for i := 0; i < picks; i++ {
+		selected := lb.selectWeighted(instances)
+		counts[selected.ID]++
+	}
+
+	total := 7
+	expectedA := picks * 5 / total
+	if diff := counts["a"] - expectedA; diff < -10 || diff > 10 {
+		t.Errorf("instance a got %d picks, expected close to %d", counts["a"], expectedA)
+	}
+
+	// No more than weight+1 consecutive picks for the heaviest instance,
+	// i.e. smooth distribution rather than bursty runs of the same weight.
+	run := 0
+	maxRun := 0
+	for i := 0; i < picks; i++ {
+		selected := lb.selectWeighted(instances)
+		if selected.ID == "a" {
+			run++
+			if run > maxRun {
+				maxRun = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	if maxRun > 6 {
+		t.Errorf("smooth weighted round robin produced a burst of %d consecutive picks for the heaviest instance", maxRun)
+	}
+}
+
+func TestSelectIPHash_MinimalRemapping(t *testing.T) {
+	lb := &LoadBalancer{algorithm: AlgorithmIPHash, metrics: &BalancerMetrics{}}
+
+	var instances []*Instance
+	for i := 0; i < 5; i++ {
+		instances = append(instances, newTestInstance(fmt.Sprintf("inst-%d", i), 1))
+	}
+	lb.instances = instances
+	lb.rebuildRingLocked()
+
+	clientIPs := make([]string, 200)
+	for i := range clientIPs {
+		clientIPs[i] = fmt.Sprintf("10.0.0.%d", i)
+	}
+
+	before := make(map[string]string, len(clientIPs))
+	for _, ip := range clientIPs {
+		selected := lb.selectIPHash(lb.instances, ip)
+		before[ip] = selected.ID
+	}
+
+	// Remove one instance and rebuild the ring, simulating a failover.
+	lb.instances = instances[:4]
+	lb.rebuildRingLocked()
+
+	remapped := 0
+	for _, ip := range clientIPs {
+		selected := lb.selectIPHash(lb.instances, ip)
+		if selected.ID != before[ip] {
+			remapped++
+		}
+	}
+
+	// Only clients that were mapped to the removed instance should move;
+	// with 5 instances that is roughly 1/5th, well under the ~4/5ths a
+	// naive hash % N scheme would reshuffle.
+	if remapped > len(clientIPs)/3 {
+		t.Errorf("removing one of 5 instances remapped %d/%d clients, expected far fewer", remapped, len(clientIPs))
+	}
+}
+
+func TestSelectIPHash_Consistent(t *testing.T) {
+	lb := &LoadBalancer{algorithm: AlgorithmIPHash, metrics: &BalancerMetrics{}}
+	lb.instances = []*Instance{newTestInstance("a", 1), newTestInstance("b", 1)}
+	lb.rebuildRingLocked()
+
+	first := lb.selectIPHash(lb.instances, "203.0.113.7")
+	for i := 0; i < 20; i++ {
+		got := lb.selectIPHash(lb.instances, "203.0.113.7")
+		if got.ID != first.ID {
+			t.Fatalf("same client IP mapped to different instances: %s then %s", first.ID, got.ID)
+		}
+	}
+}
+
+func TestReleaseInstance_ReducesEffectiveWeightOnFailure(t *testing.T) {
+	lb := &LoadBalancer{metrics: &BalancerMetrics{}}
+	inst := newTestInstance("a", 10)
+	lb.instances = []*Instance{inst}
+
+	lb.ReleaseInstance(inst, 10*time.Millisecond, true)
+	if inst.EffectiveWeight >= 10 {
+		t.Errorf("expected EffectiveWeight to drop below Weight after a failure, got %d", inst.EffectiveWeight)
+	}
+
+	for i := 0; i < 20; i++ {
+		lb.ReleaseInstance(inst, 10*time.Millisecond, false)
+	}
+	if inst.EffectiveWeight != inst.Weight {
+		t.Errorf("expected EffectiveWeight to recover to Weight=%d after repeated successes, got %d", inst.Weight, inst.EffectiveWeight)
+	}
+}
+
+func TestSelectAdaptive_ColdStartUsesWeightOnly(t *testing.T) {
+	lb := &LoadBalancer{algorithm: AlgorithmAdaptive, metrics: &BalancerMetrics{}}
+	light := newTestInstance("light", 1)
+	heavy := newTestInstance("heavy", 10)
+	instances := []*Instance{light, heavy}
+
+	counts := map[string]int{}
+	for i := 0; i < 200; i++ {
+		counts[lb.selectAdaptive(instances).ID]++
+	}
+
+	if counts["heavy"] <= counts["light"] {
+		t.Errorf("expected cold-start (no samples) to favor the heavier-weighted instance, got counts %v", counts)
+	}
+}
+
+func TestSelectAdaptive_SingleInstanceDegenerateCase(t *testing.T) {
+	lb := &LoadBalancer{algorithm: AlgorithmAdaptive, metrics: &BalancerMetrics{}}
+	only := newTestInstance("only", 1)
+
+	for i := 0; i < 5; i++ {
+		if got := lb.selectAdaptive([]*Instance{only}); got != only {
+			t.Fatalf("expected the lone instance to always be selected, got %v", got)
+		}
+	}
+}
+
+func TestSelectAdaptive_PrefersLowerLatencyAndErrorRate(t *testing.T) {
+	lb := &LoadBalancer{algorithm: AlgorithmAdaptive, metrics: &BalancerMetrics{}}
+	fast := newTestInstance("fast", 1)
+	slow := newTestInstance("slow", 1)
+
+	lb.mu.Lock()
+	fast.recordSample(5*time.Millisecond, false)
+	slow.recordSample(200*time.Millisecond, true)
+	lb.mu.Unlock()
+
+	if fast.AdaptiveScore() >= slow.AdaptiveScore() {
+		t.Errorf("expected fast, error-free instance to score lower than slow, erroring one: fast=%v slow=%v", fast.AdaptiveScore(), slow.AdaptiveScore())
+	}
+}
+
+func TestSelectAdaptive_RecoveryProbeAfterIdleDecay(t *testing.T) {
+	lb := &LoadBalancer{algorithm: AlgorithmAdaptive, metrics: &BalancerMetrics{}}
+	recovered := newTestInstance("recovered", 1)
+
+	lb.mu.Lock()
+	recovered.recordSample(500*time.Millisecond, true)
+	// Simulate having gone unhealthy and idle past ewmaIdleDecay: back-date
+	// lastSampleAt instead of sleeping in the test.
+	recovered.lastSampleAt = time.Now().Add(-2 * ewmaIdleDecay)
+	recovered.decayIfIdleLocked()
+	lb.mu.Unlock()
+
+	if recovered.latEWMAMs != 0 || recovered.errEWMA != 0 {
+		t.Errorf("expected an instance idle past ewmaIdleDecay to decay back to zero, got latEWMAMs=%v errEWMA=%v", recovered.latEWMAMs, recovered.errEWMA)
+	}
+}
+
+func TestSelectInstance_NoHealthyInstances(t *testing.T) {
+	lb := NewLoadBalancer(AlgorithmRoundRobin)
+	defer lb.Close()
+
+	_, err := lb.SelectInstance(context.Background(), "127.0.0.1")
+	if err == nil {
+		t.Error("expected error when no instances are registered")
+	}
+}
@@ -4,13 +4,24 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
 )
 
+// leadershipTransferRetries caps how many times LeadershipTransfer retries
+// raft.LeadershipTransfer before giving up, mirroring Consul's leader
+// handoff loop so a graceful restart hands off cleanly instead of forcing
+// a fresh election that leaves the cluster leaderless for a full timeout.
+const leadershipTransferRetries = 3
+
 // ClusterNode represents a node in the cluster
 type ClusterNode struct {
 	ID           string    `json:"id"`
@@ -20,54 +31,133 @@ type ClusterNode struct {
 	LoadBalancer *LoadBalancer
 }
 
-// Cluster manages multiple AI proxy nodes
+// Cluster manages multiple AI proxy nodes. Membership and shared state
+// (node registry, leader, the GuaranteedUpdate config store) are replicated
+// via Raft: every mutation is proposed as a log entry to the leader and
+// applied to ClusterFSM on every node once committed, instead of being
+// mutated directly by whichever node happens to receive the HTTP request.
 type Cluster struct {
-	mu          sync.RWMutex
-	nodeID      string
-	nodes       map[string]*ClusterNode
-	isLeader    bool
-	leaderID    string
-	httpClient  *http.Client
+	mu                sync.RWMutex
+	nodeID            string
+	address           string
+	nodes             map[string]*ClusterNode
+	httpClient        *http.Client
 	heartbeatInterval time.Duration
-	stopChan    chan struct{}
+	stopChan          chan struct{}
+
+	fsm    *ClusterFSM
+	raft   *raft.Raft
+	logger hclog.Logger
 }
 
 // NewCluster creates a new cluster manager
 func NewCluster(nodeID, address string) *Cluster {
 	return &Cluster{
-		nodeID:   nodeID,
-		nodes:    make(map[string]*ClusterNode),
+		nodeID:  nodeID,
+		address: address,
+		nodes:   make(map[string]*ClusterNode),
 		httpClient: &http.Client{
 			Timeout: 5 * time.Second,
 		},
 		heartbeatInterval: 10 * time.Second,
-		stopChan:         make(chan struct{}),
+		stopChan:          make(chan struct{}),
+		fsm:               NewClusterFSM(),
+		logger: hclog.New(&hclog.LoggerOptions{
+			Name:  "cluster",
+			Level: hclog.Info,
+		}),
+	}
+}
+
+// SetLogger overrides the structured logger used for election, membership,
+// and GuaranteedUpdate events. Intended for callers that want the cluster's
+// log lines to share one named-sub-logger hierarchy with the rest of the
+// process (e.g. ProviderConfig.LogLevel driving every subsystem's level).
+func (c *Cluster) SetLogger(logger hclog.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger = logger
+}
+
+// bootstrapRaft stands up this node's *raft.Raft instance. It always uses
+// an in-memory log/stable/snapshot store: the node registry and config
+// store are small and already durably reconstructible from a Join/replay,
+// so there's no need to manage a raft data directory on disk for this
+// proxy's use case.
+func (c *Cluster) bootstrapRaft(bootstrap bool) error {
+	raftConfig := raft.DefaultConfig()
+	raftConfig.LocalID = raft.ServerID(c.nodeID)
+	raftConfig.Logger = c.logger.Named("raft")
+
+	addr, err := net.ResolveTCPAddr("tcp", c.address)
+	if err != nil {
+		return fmt.Errorf("cluster: failed to resolve raft address %s: %w", c.address, err)
+	}
+	transport, err := raft.NewTCPTransport(c.address, addr, 3, 10*time.Second, os.Stderr)
+	if err != nil {
+		return fmt.Errorf("cluster: failed to create raft transport: %w", err)
+	}
+
+	logStore := raft.NewInmemStore()
+	stableStore := raft.NewInmemStore()
+	snapshotStore := raft.NewInmemSnapshotStore()
+
+	r, err := raft.NewRaft(raftConfig, c.fsm, logStore, stableStore, snapshotStore, transport)
+	if err != nil {
+		return fmt.Errorf("cluster: failed to create raft node: %w", err)
+	}
+	c.raft = r
+
+	if bootstrap {
+		cfg := raft.Configuration{
+			Servers: []raft.Server{
+				{ID: raftConfig.LocalID, Address: transport.LocalAddr()},
+			},
+		}
+		if f := r.BootstrapCluster(cfg); f.Error() != nil {
+			return fmt.Errorf("cluster: failed to bootstrap raft cluster: %w", f.Error())
+		}
 	}
+
+	return nil
 }
 
-// Join joins a cluster
+// Join joins a cluster. seedNodes is the list of already-running peers to
+// contact; an empty list bootstraps a brand-new single-node Raft cluster
+// that later joiners are added to via handleJoin's AddVoter call.
 func (c *Cluster) Join(seedNodes []string) error {
-	// Try to connect to seed nodes
+	if err := c.bootstrapRaft(len(seedNodes) == 0); err != nil {
+		return err
+	}
+
+	joined := false
 	for _, seed := range seedNodes {
 		if err := c.connectToNode(seed); err == nil {
+			joined = true
 			break
 		}
 	}
+	if len(seedNodes) > 0 && !joined {
+		c.logger.Warn("failed to reach any seed node, starting isolated", "seeds", seedNodes)
+	}
 
-	// Start heartbeat
 	go c.heartbeatLoop()
 
-	// Start leader election
-	go c.leaderElectionLoop()
-
 	return nil
 }
 
-// Leave leaves the cluster
+// Leave gracefully removes this node from the cluster. If it is currently
+// the Raft leader, it transfers leadership first so the cluster doesn't
+// sit leaderless for a full election timeout while this node shuts down.
 func (c *Cluster) Leave() {
+	if c.raft != nil && c.raft.State() == raft.Leader {
+		if err := c.LeadershipTransfer(); err != nil {
+			c.logger.Warn("leadership transfer failed during leave, shutting down anyway", "error", err)
+		}
+	}
+
 	close(c.stopChan)
-	
-	// Notify other nodes
+
 	c.mu.RLock()
 	nodes := make([]*ClusterNode, 0, len(c.nodes))
 	for _, node := range c.nodes {
@@ -78,17 +168,94 @@ func (c *Cluster) Leave() {
 	for _, node := range nodes {
 		c.notifyNodeLeave(node.Address)
 	}
+
+	if c.raft != nil {
+		c.raft.Shutdown()
+	}
+}
+
+// LeadershipTransfer hands leadership to another voter, retrying up to
+// leadershipTransferRetries times and logging each attempt. Modeled on
+// Consul's establishLeadership/graceful-shutdown handoff: without this, a
+// planned restart of the leader forces a fresh election and the cluster
+// goes leaderless for up to a full election timeout.
+func (c *Cluster) LeadershipTransfer() error {
+	if c.raft == nil {
+		return fmt.Errorf("cluster: raft not initialized")
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= leadershipTransferRetries; attempt++ {
+		future := c.raft.LeadershipTransfer()
+		if err := future.Error(); err != nil {
+			lastErr = err
+			c.logger.Warn("leadership transfer attempt failed", "attempt", attempt, "error", err)
+			continue
+		}
+		c.logger.Info("leadership transfer succeeded", "attempt", attempt)
+		return nil
+	}
+
+	return fmt.Errorf("cluster: leadership transfer failed after %d attempts: %w", leadershipTransferRetries, lastErr)
+}
+
+// OnConfigUpdate registers fn to run on every node (leader and followers
+// alike) whenever a Store().GuaranteedUpdate commits, so callers outside
+// this package can keep local in-memory state — the load balancer's
+// algorithm, the cache's enable/disable flag — in sync with the
+// Raft-replicated config store instead of mutating it only on whichever
+// node received the admin request.
+func (c *Cluster) OnConfigUpdate(fn func(key string, data json.RawMessage)) {
+	c.fsm.SetOnConfigUpdate(fn)
+}
+
+// IsLeader reports whether this node currently holds Raft leadership.
+func (c *Cluster) IsLeader() bool {
+	return c.raft != nil && c.raft.State() == raft.Leader
+}
+
+// LeaderID returns the node ID of the current Raft leader, if known.
+func (c *Cluster) LeaderID() string {
+	if c.raft == nil {
+		return ""
+	}
+	_, id := c.raft.LeaderWithID()
+	return string(id)
+}
+
+// applyCommand proposes cmd to the Raft log. Only the leader can commit a
+// log entry; callers on a follower should forward the originating HTTP
+// request to the leader instead of calling this directly (see handleJoin).
+func (c *Cluster) applyCommand(cmd logCommand) (interface{}, error) {
+	if c.raft == nil {
+		return nil, fmt.Errorf("cluster: raft not initialized")
+	}
+
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("cluster: failed to marshal command: %w", err)
+	}
+
+	future := c.raft.Apply(data, 5*time.Second)
+	if err := future.Error(); err != nil {
+		return nil, fmt.Errorf("cluster: failed to apply command: %w", err)
+	}
+
+	if applyErr, ok := future.Response().(error); ok && applyErr != nil {
+		return nil, applyErr
+	}
+	return future.Response(), nil
 }
 
 // connectToNode connects to a cluster node
 func (c *Cluster) connectToNode(address string) error {
 	url := fmt.Sprintf("http://%s/cluster/join", address)
-	
+
 	nodeInfo := map[string]interface{}{
 		"id":      c.nodeID,
-		"address": address,
+		"address": c.address,
 	}
-	
+
 	data, _ := json.Marshal(nodeInfo)
 	resp, err := c.httpClient.Post(url, "application/json", bytes.NewReader(data))
 	if err != nil {
@@ -100,22 +267,20 @@ func (c *Cluster) connectToNode(address string) error {
 		return fmt.Errorf("failed to join cluster: status %d", resp.StatusCode)
 	}
 
-	// Parse cluster info
 	var clusterInfo struct {
 		Nodes    []ClusterNode `json:"nodes"`
 		LeaderID string        `json:"leader_id"`
 	}
-	
+
 	if err := json.NewDecoder(resp.Body).Decode(&clusterInfo); err != nil {
 		return err
 	}
 
-	// Update nodes
 	c.mu.Lock()
 	for _, node := range clusterInfo.Nodes {
+		node := node
 		c.nodes[node.ID] = &node
 	}
-	c.leaderID = clusterInfo.LeaderID
 	c.mu.Unlock()
 
 	return nil
@@ -155,17 +320,16 @@ func (c *Cluster) sendHeartbeats() {
 // sendHeartbeat sends a heartbeat to a specific node
 func (c *Cluster) sendHeartbeat(node *ClusterNode) {
 	url := fmt.Sprintf("http://%s/cluster/heartbeat", node.Address)
-	
+
 	heartbeat := map[string]interface{}{
 		"id":        c.nodeID,
 		"timestamp": time.Now().Unix(),
 	}
-	
+
 	data, _ := json.Marshal(heartbeat)
 	resp, err := c.httpClient.Post(url, "application/json", bytes.NewReader(data))
 	if err != nil {
-		// Mark node as potentially failed
-		c.markNodeFailed(node.ID)
+		c.logger.Warn("heartbeat failed", "node_id", node.ID, "error", err)
 		return
 	}
 	defer resp.Body.Close()
@@ -179,80 +343,14 @@ func (c *Cluster) sendHeartbeat(node *ClusterNode) {
 	}
 }
 
-// leaderElectionLoop performs leader election
-func (c *Cluster) leaderElectionLoop() {
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			c.checkLeader()
-		case <-c.stopChan:
-			return
-		}
-	}
-}
-
-// checkLeader checks if current leader is alive and elects new if needed
-func (c *Cluster) checkLeader() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	// Check if current leader is alive
-	if c.leaderID != "" {
-		if leader, ok := c.nodes[c.leaderID]; ok {
-			if time.Since(leader.LastSeen) < 30*time.Second {
-				return // Leader is alive
-			}
-		}
-	}
-
-	// Leader election: node with lowest ID becomes leader
-	lowestID := c.nodeID
-	for id := range c.nodes {
-		if id < lowestID {
-			lowestID = id
-		}
-	}
-
-	c.leaderID = lowestID
-	c.isLeader = (lowestID == c.nodeID)
-
-	if c.isLeader {
-		c.broadcastLeaderElection()
-	}
-}
-
-// broadcastLeaderElection broadcasts leader election result
-func (c *Cluster) broadcastLeaderElection() {
-	// Implementation would broadcast to all nodes
-}
-
-// markNodeFailed marks a node as failed
-func (c *Cluster) markNodeFailed(nodeID string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if _, ok := c.nodes[nodeID]; ok {
-		delete(c.nodes, nodeID)
-		
-		// If failed node was leader, trigger new election
-		if nodeID == c.leaderID {
-			c.leaderID = ""
-			go c.checkLeader()
-		}
-	}
-}
-
 // notifyNodeLeave notifies a node about leaving
 func (c *Cluster) notifyNodeLeave(address string) {
 	url := fmt.Sprintf("http://%s/cluster/leave", address)
-	
+
 	data, _ := json.Marshal(map[string]string{
 		"id": c.nodeID,
 	})
-	
+
 	c.httpClient.Post(url, "application/json", bytes.NewReader(data))
 }
 
@@ -261,10 +359,14 @@ func (c *Cluster) RegisterHandlers(router *gin.RouterGroup) {
 	router.POST("/join", c.handleJoin)
 	router.POST("/heartbeat", c.handleHeartbeat)
 	router.POST("/leave", c.handleLeave)
+	router.POST("/leadership-transfer", c.handleLeadershipTransfer)
 	router.GET("/status", c.handleStatus)
 }
 
-// handleJoin handles cluster join requests
+// handleJoin handles cluster join requests. It only mutates state on the
+// leader: a follower that receives a join forwards it, since an AddVoter
+// call (and the node-registry Apply it triggers) must go through Raft's
+// leader to be committed.
 func (c *Cluster) handleJoin(ctx *gin.Context) {
 	var nodeInfo ClusterNode
 	if err := ctx.ShouldBindJSON(&nodeInfo); err != nil {
@@ -272,33 +374,106 @@ func (c *Cluster) handleJoin(ctx *gin.Context) {
 		return
 	}
 
-	c.mu.Lock()
-	c.nodes[nodeInfo.ID] = &nodeInfo
+	if !c.IsLeader() {
+		c.forwardToLeader(ctx, "/cluster/join")
+		return
+	}
+
+	voterFuture := c.raft.AddVoter(raft.ServerID(nodeInfo.ID), raft.ServerAddress(nodeInfo.Address), 0, 5*time.Second)
+	if err := voterFuture.Error(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to add voter: %v", err)})
+		return
+	}
+
 	nodeInfo.LastSeen = time.Now()
-	c.mu.Unlock()
+	if _, err := c.applyCommand(logCommand{Type: commandJoin, Node: &nodeInfo}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.logger.Info("node joined cluster", "node_id", nodeInfo.ID, "address", nodeInfo.Address)
 
-	// Return cluster info
-	c.mu.RLock()
-	nodes := make([]ClusterNode, 0, len(c.nodes))
-	for _, node := range c.nodes {
-		nodes = append(nodes, *node)
+	nodes := c.fsm.nodesSnapshot()
+	nodeList := make([]ClusterNode, 0, len(nodes))
+	for _, n := range nodes {
+		nodeList = append(nodeList, *n)
 	}
-	leaderID := c.leaderID
-	c.mu.RUnlock()
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"nodes":     nodes,
-		"leader_id": leaderID,
+		"nodes":     nodeList,
+		"leader_id": c.LeaderID(),
 	})
 }
 
+// forwardToLeader proxies the in-flight request body to path on whichever
+// node is currently the Raft leader, preserving the original request's HTTP
+// method (ForwardToLeader is reused by PUT admin endpoints, not just this
+// package's own POST-only cluster routes), so callers don't need to know
+// (or keep retrying to find) the leader themselves.
+func (c *Cluster) forwardToLeader(ctx *gin.Context, path string) {
+	c.mu.RLock()
+	leaderID := c.LeaderID()
+	leaderNode, ok := c.nodes[leaderID]
+	c.mu.RUnlock()
+
+	if !ok {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "no leader available"})
+		return
+	}
+
+	url := fmt.Sprintf("http://%s%s", leaderNode.Address, path)
+	req, err := http.NewRequest(ctx.Request.Method, url, ctx.Request.Body)
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("failed to build leader request: %v", err)})
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("failed to forward to leader: %v", err)})
+		return
+	}
+	defer resp.Body.Close()
+
+	var body interface{}
+	json.NewDecoder(resp.Body).Decode(&body)
+	ctx.JSON(resp.StatusCode, body)
+}
+
+// ForwardToLeader proxies the in-flight request to path on the current Raft
+// leader. Exported so admin HTTP handlers outside this package (e.g. the
+// load balancer's algorithm endpoint and the cache enable/disable
+// endpoints) can redirect a follower's write using the same mechanism
+// handleJoin/handleLeave use internally.
+func (c *Cluster) ForwardToLeader(ctx *gin.Context, path string) {
+	c.forwardToLeader(ctx, path)
+}
+
+// handleLeadershipTransfer drains this node's Raft leadership onto another
+// voter, mirroring Consul's leave-and-handoff endpoint so an operator can
+// drain a node gracefully before restarting it instead of forcing a fresh
+// election that leaves the cluster leaderless for a full election timeout.
+func (c *Cluster) handleLeadershipTransfer(ctx *gin.Context) {
+	if !c.IsLeader() {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "not the leader"})
+		return
+	}
+
+	if err := c.LeadershipTransfer(); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "leadership transferred"})
+}
+
 // handleHeartbeat handles heartbeat requests
 func (c *Cluster) handleHeartbeat(ctx *gin.Context) {
 	var heartbeat struct {
 		ID        string `json:"id"`
 		Timestamp int64  `json:"timestamp"`
 	}
-	
+
 	if err := ctx.ShouldBindJSON(&heartbeat); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -318,35 +493,63 @@ func (c *Cluster) handleLeave(ctx *gin.Context) {
 	var req struct {
 		ID string `json:"id"`
 	}
-	
+
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.mu.Lock()
-	delete(c.nodes, req.ID)
-	c.mu.Unlock()
+	if !c.IsLeader() {
+		c.forwardToLeader(ctx, "/cluster/leave")
+		return
+	}
+
+	if future := c.raft.RemoveServer(raft.ServerID(req.ID), 0, 5*time.Second); future.Error() != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": future.Error().Error()})
+		return
+	}
+
+	if _, err := c.applyCommand(logCommand{Type: commandLeave, ID: req.ID}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.logger.Info("node left cluster", "node_id", req.ID)
 
 	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
-// handleStatus returns cluster status
+// handleStatus returns cluster status, including the Raft view (peers,
+// last log index, commit index) an operator needs to judge replication lag
+// before draining a node.
 func (c *Cluster) handleStatus(ctx *gin.Context) {
-	c.mu.RLock()
-	nodes := make([]ClusterNode, 0, len(c.nodes))
-	for _, node := range c.nodes {
-		nodes = append(nodes, *node)
+	nodes := c.fsm.nodesSnapshot()
+	nodeList := make([]ClusterNode, 0, len(nodes))
+	for _, n := range nodes {
+		nodeList = append(nodeList, *n)
+	}
+
+	var peers []string
+	var lastLogIndex, commitIndex uint64
+	if c.raft != nil {
+		stats := c.raft.Stats()
+		lastLogIndex, _ = strconv.ParseUint(stats["last_log_index"], 10, 64)
+		commitIndex, _ = strconv.ParseUint(stats["commit_index"], 10, 64)
+
+		if future := c.raft.GetConfiguration(); future.Error() == nil {
+			for _, server := range future.Configuration().Servers {
+				peers = append(peers, string(server.ID))
+			}
+		}
 	}
-	isLeader := c.isLeader
-	leaderID := c.leaderID
-	c.mu.RUnlock()
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"node_id":   c.nodeID,
-		"is_leader": isLeader,
-		"leader_id": leaderID,
-		"nodes":     nodes,
-		"total":     len(nodes),
+		"node_id":        c.nodeID,
+		"is_leader":      c.IsLeader(),
+		"leader_id":      c.LeaderID(),
+		"nodes":          nodeList,
+		"total":          len(nodeList),
+		"peers":          peers,
+		"last_log_index": lastLogIndex,
+		"commit_index":   commitIndex,
 	})
-}
\ No newline at end of file
+}
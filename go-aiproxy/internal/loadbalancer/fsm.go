@@ -0,0 +1,215 @@
+package loadbalancer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/hashicorp/raft"
+)
+
+// commandType identifies the kind of mutation a Raft log entry carries.
+type commandType string
+
+const (
+	commandJoin         commandType = "join"
+	commandLeave        commandType = "leave"
+	commandConfigUpdate commandType = "config_update"
+)
+
+// logCommand is the payload Apply()'d to every node's FSM through the Raft
+// log, so node membership and config changes are replicated consistently
+// instead of being mutated locally on whichever node happened to receive
+// the HTTP request.
+type logCommand struct {
+	Type commandType     `json:"type"`
+	Node *ClusterNode    `json:"node,omitempty"`
+	ID   string          `json:"id,omitempty"`
+	Key  string          `json:"key,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// ClusterFSM is the raft.FSM backing Cluster's replicated state: node
+// membership plus a small key/value store used for shared config (instance
+// weights, routing rules, per-provider quotas; see Store.GuaranteedUpdate).
+// Every node in the Raft group applies the same log in the same order, so
+// f.nodes and f.store converge identically everywhere without needing the
+// old "lowest ID wins" guess or the broadcastLeaderElection stub.
+type ClusterFSM struct {
+	mu    sync.RWMutex
+	nodes map[string]*ClusterNode
+	store map[string]storedValue
+
+	// onConfigUpdate, if set, is invoked on every node (leader and
+	// followers alike) after a commandConfigUpdate commits, so callers can
+	// keep local in-memory state (e.g. the load balancer's algorithm, the
+	// cache's enable/disable flag) in sync with the replicated store
+	// instead of only updating the raw KV value.
+	onConfigUpdate func(key string, data json.RawMessage)
+}
+
+// storedValue is one entry in the FSM's config store: the value plus a
+// monotonically increasing revision used for optimistic-concurrency
+// compare-and-swap in GuaranteedUpdate.
+type storedValue struct {
+	Value    json.RawMessage `json:"value"`
+	Revision uint64          `json:"revision"`
+}
+
+// NewClusterFSM creates an empty ClusterFSM.
+func NewClusterFSM() *ClusterFSM {
+	return &ClusterFSM{
+		nodes: make(map[string]*ClusterNode),
+		store: make(map[string]storedValue),
+	}
+}
+
+// Apply implements raft.FSM. It is invoked once per committed log entry, on
+// every node in the Raft group (leader included), so it must be a pure,
+// deterministic function of the command and current state.
+func (f *ClusterFSM) Apply(l *raft.Log) interface{} {
+	var cmd logCommand
+	if err := json.Unmarshal(l.Data, &cmd); err != nil {
+		return fmt.Errorf("fsm: failed to unmarshal log entry: %w", err)
+	}
+
+	switch cmd.Type {
+	case commandJoin:
+		f.mu.Lock()
+		if cmd.Node != nil {
+			f.nodes[cmd.Node.ID] = cmd.Node
+		}
+		f.mu.Unlock()
+		return nil
+
+	case commandLeave:
+		f.mu.Lock()
+		delete(f.nodes, cmd.ID)
+		f.mu.Unlock()
+		return nil
+
+	case commandConfigUpdate:
+		f.mu.Lock()
+		newRevision := f.store[cmd.Key].Revision + 1
+		f.store[cmd.Key] = storedValue{Value: cmd.Data, Revision: newRevision}
+		onConfigUpdate := f.onConfigUpdate
+		f.mu.Unlock()
+
+		// Run outside the lock: the callback may reach into other
+		// subsystems (load balancer, cache manager) and must not be able
+		// to deadlock against a concurrent FSM read.
+		if onConfigUpdate != nil {
+			onConfigUpdate(cmd.Key, cmd.Data)
+		}
+		return newRevision
+
+	default:
+		return fmt.Errorf("fsm: unknown command type %q", cmd.Type)
+	}
+}
+
+// SetOnConfigUpdate registers fn to run on every node whenever a
+// commandConfigUpdate commits through the Raft log (see Apply). Cluster
+// exposes this as OnConfigUpdate for callers outside this package.
+func (f *ClusterFSM) SetOnConfigUpdate(fn func(key string, data json.RawMessage)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onConfigUpdate = fn
+}
+
+// nodesSnapshot returns a deep-enough copy of the node registry for reads
+// outside the FSM's own lock (used by Cluster to serve /cluster/status).
+func (f *ClusterFSM) nodesSnapshot() map[string]*ClusterNode {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	nodes := make(map[string]*ClusterNode, len(f.nodes))
+	for id, n := range f.nodes {
+		cp := *n
+		nodes[id] = &cp
+	}
+	return nodes
+}
+
+// get reads a stored config value and its revision.
+func (f *ClusterFSM) get(key string) (json.RawMessage, uint64) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	v := f.store[key]
+	return v.Value, v.Revision
+}
+
+// Snapshot implements raft.FSM, capturing the node registry and config
+// store so a restarting or newly-joined node can restore state from a
+// snapshot instead of replaying the entire log.
+func (f *ClusterFSM) Snapshot() (raft.FSMSnapshot, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	nodes := make(map[string]*ClusterNode, len(f.nodes))
+	for id, n := range f.nodes {
+		cp := *n
+		nodes[id] = &cp
+	}
+	store := make(map[string]storedValue, len(f.store))
+	for k, v := range f.store {
+		store[k] = v
+	}
+
+	return &clusterSnapshot{nodes: nodes, store: store}, nil
+}
+
+// Restore implements raft.FSM, replacing the FSM's state wholesale from a
+// previously-taken snapshot.
+func (f *ClusterFSM) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+
+	var snap struct {
+		Nodes map[string]*ClusterNode `json:"nodes"`
+		Store map[string]storedValue `json:"store"`
+	}
+	if err := json.NewDecoder(rc).Decode(&snap); err != nil {
+		return fmt.Errorf("fsm: failed to decode snapshot: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nodes = snap.Nodes
+	if f.nodes == nil {
+		f.nodes = make(map[string]*ClusterNode)
+	}
+	f.store = snap.Store
+	if f.store == nil {
+		f.store = make(map[string]storedValue)
+	}
+	return nil
+}
+
+// clusterSnapshot implements raft.FSMSnapshot over a point-in-time copy of
+// ClusterFSM's state.
+type clusterSnapshot struct {
+	nodes map[string]*ClusterNode
+	store map[string]storedValue
+}
+
+func (s *clusterSnapshot) Persist(sink raft.SnapshotSink) error {
+	data, err := json.Marshal(struct {
+		Nodes map[string]*ClusterNode `json:"nodes"`
+		Store map[string]storedValue `json:"store"`
+	}{Nodes: s.nodes, Store: s.store})
+	if err != nil {
+		sink.Cancel()
+		return fmt.Errorf("fsm: failed to marshal snapshot: %w", err)
+	}
+
+	if _, err := sink.Write(data); err != nil {
+		sink.Cancel()
+		return fmt.Errorf("fsm: failed to write snapshot: %w", err)
+	}
+
+	return sink.Close()
+}
+
+func (s *clusterSnapshot) Release() {}
@@ -0,0 +1,83 @@
+package loadbalancer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/raft"
+)
+
+func applyLog(t *testing.T, fsm *ClusterFSM, cmd logCommand) interface{} {
+	t.Helper()
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		t.Fatalf("failed to marshal command: %v", err)
+	}
+	return fsm.Apply(&raft.Log{Data: data})
+}
+
+func TestClusterFSM_Apply_JoinAndLeave(t *testing.T) {
+	fsm := NewClusterFSM()
+
+	applyLog(t, fsm, logCommand{Type: commandJoin, Node: &ClusterNode{ID: "node-1", Address: "127.0.0.1:9001"}})
+
+	nodes := fsm.nodesSnapshot()
+	if _, ok := nodes["node-1"]; !ok {
+		t.Fatalf("expected node-1 to be present after join, got %#v", nodes)
+	}
+
+	applyLog(t, fsm, logCommand{Type: commandLeave, ID: "node-1"})
+
+	nodes = fsm.nodesSnapshot()
+	if _, ok := nodes["node-1"]; ok {
+		t.Fatalf("expected node-1 to be removed after leave, got %#v", nodes)
+	}
+}
+
+func TestClusterFSM_Apply_ConfigUpdateNotifiesCallback(t *testing.T) {
+	fsm := NewClusterFSM()
+
+	var gotKey string
+	var gotData json.RawMessage
+	fsm.SetOnConfigUpdate(func(key string, data json.RawMessage) {
+		gotKey = key
+		gotData = data
+	})
+
+	payload, _ := json.Marshal("least-connections")
+	result := applyLog(t, fsm, logCommand{Type: commandConfigUpdate, Key: "loadbalancer.algorithm", Data: payload})
+
+	if rev, ok := result.(uint64); !ok || rev != 1 {
+		t.Fatalf("expected first config update to return revision 1, got %#v", result)
+	}
+	if gotKey != "loadbalancer.algorithm" {
+		t.Fatalf("expected callback to see key %q, got %q", "loadbalancer.algorithm", gotKey)
+	}
+	if string(gotData) != string(payload) {
+		t.Fatalf("expected callback to see payload %s, got %s", payload, gotData)
+	}
+
+	value, revision := fsm.get("loadbalancer.algorithm")
+	if revision != 1 || string(value) != string(payload) {
+		t.Fatalf("expected stored value %s at revision 1, got %s at revision %d", payload, value, revision)
+	}
+
+	// A second update bumps the revision and fires the callback again.
+	payload2, _ := json.Marshal("round-robin")
+	applyLog(t, fsm, logCommand{Type: commandConfigUpdate, Key: "loadbalancer.algorithm", Data: payload2})
+	if gotKey != "loadbalancer.algorithm" || string(gotData) != string(payload2) {
+		t.Fatalf("expected callback to observe the second update, got key=%q data=%s", gotKey, gotData)
+	}
+	if _, revision := fsm.get("loadbalancer.algorithm"); revision != 2 {
+		t.Fatalf("expected revision 2 after second update, got %d", revision)
+	}
+}
+
+func TestClusterFSM_Apply_UnknownCommandReturnsError(t *testing.T) {
+	fsm := NewClusterFSM()
+
+	result := applyLog(t, fsm, logCommand{Type: commandType("bogus")})
+	if _, ok := result.(error); !ok {
+		t.Fatalf("expected an error for an unknown command type, got %#v", result)
+	}
+}
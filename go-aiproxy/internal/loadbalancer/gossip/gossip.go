@@ -0,0 +1,72 @@
+// Package gossip implements SWIM-style failure detection: every
+// ProbeInterval a node probes one random peer directly and, on timeout,
+// runs an indirect probe phase through other peers before declaring the
+// peer suspect. Membership deltas are not exchanged between nodes; each
+// node's view holds only the members added to it through Join. It
+// exists so Cluster's membership can scale past the handful of nodes the
+// O(N^2) heartbeat-to-every-peer mesh in sendHeartbeats was built for.
+package gossip
+
+import "time"
+
+// State is a member's believed liveness in the SWIM state machine.
+type State int
+
+const (
+	Alive State = iota
+	Suspect
+	Dead
+)
+
+func (s State) String() string {
+	switch s {
+	case Alive:
+		return "alive"
+	case Suspect:
+		return "suspect"
+	case Dead:
+		return "dead"
+	default:
+		return "unknown"
+	}
+}
+
+// Member is one node as known to the local gossip state. Incarnation is a
+// per-member logical clock the member itself bumps to refute stale
+// Suspect/Dead claims, the same role it plays in memberlist.
+type Member struct {
+	ID          string
+	Addr        string
+	Incarnation uint64
+	State       State
+}
+
+// Config tunes the probe cadence and failure-detection thresholds.
+type Config struct {
+	// ProbeInterval is how often a random peer is probed directly.
+	ProbeInterval time.Duration
+	// ProbeTimeout is how long a direct probe waits before falling back
+	// to indirect probes through IndirectProbes relays.
+	ProbeTimeout time.Duration
+	// IndirectProbes is the number (R) of random relays asked to probe a
+	// peer on the prober's behalf before it is marked Suspect.
+	IndirectProbes int
+	// SuspicionTimeout is how long a member stays Suspect, absent a
+	// refutation, before being declared Dead. Unlike the cluster's old
+	// hard-coded 30s leader timeout, this should scale with cluster size.
+	SuspicionTimeout time.Duration
+	// GossipFanout (K) is the fan-out for membership deltas. Membership
+	// does not read it, since it sends no deltas.
+	GossipFanout int
+}
+
+// DefaultConfig returns reasonable small-cluster defaults.
+func DefaultConfig() Config {
+	return Config{
+		ProbeInterval:    1 * time.Second,
+		ProbeTimeout:     500 * time.Millisecond,
+		IndirectProbes:   3,
+		SuspicionTimeout: 5 * time.Second,
+		GossipFanout:     3,
+	}
+}
@@ -0,0 +1,259 @@
+package gossip
+
+import (
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// Membership tracks this node's view of the cluster via SWIM-style direct
+// and indirect probing. It does not itself decide what a Dead member means
+// for the application (e.g. whether a Raft RemoveServer should be
+// proposed) — callers subscribe via OnStateChange and act on transitions.
+type Membership struct {
+	mu      sync.RWMutex
+	self    Member
+	members map[string]*Member
+	cfg     Config
+	logger  hclog.Logger
+	conn    *net.UDPConn
+	stop    chan struct{}
+
+	onChange []func(Member)
+}
+
+// NewMembership creates a Membership bound to a local UDP probe socket at
+// addr. The caller must call Start to begin probing.
+func NewMembership(self Member, addr string, cfg Config, logger hclog.Logger) (*Membership, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Membership{
+		self:    self,
+		members: map[string]*Member{self.ID: &self},
+		cfg:     cfg,
+		logger:  logger.Named("gossip"),
+		conn:    conn,
+		stop:    make(chan struct{}),
+	}
+	return m, nil
+}
+
+// OnStateChange registers a callback invoked whenever a member's State
+// transitions (Alive -> Suspect, Suspect -> Dead, Suspect -> Alive, ...).
+func (m *Membership) OnStateChange(fn func(Member)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onChange = append(m.onChange, fn)
+}
+
+// Join adds a peer to the local membership view as Alive. Only the peer
+// itself is added; the rest of the cluster's members are not learned from
+// it, so each member must be joined separately.
+func (m *Membership) Join(peer Member) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.members[peer.ID] = &peer
+}
+
+// Members returns a snapshot of the current membership view.
+func (m *Membership) Members() []Member {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]Member, 0, len(m.members))
+	for _, mem := range m.members {
+		out = append(out, *mem)
+	}
+	return out
+}
+
+// Start begins the periodic probe loop. Stop cancels it.
+func (m *Membership) Start() {
+	go m.probeLoop()
+}
+
+// Stop ends the probe loop and closes the UDP socket.
+func (m *Membership) Stop() {
+	close(m.stop)
+	m.conn.Close()
+}
+
+func (m *Membership) probeLoop() {
+	ticker := time.NewTicker(m.cfg.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.probeRandomMember()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// probeRandomMember picks one random peer and probes it directly; on
+// timeout it asks IndirectProbes random relays to probe on its behalf
+// before marking the peer Suspect, same two-phase structure as SWIM.
+func (m *Membership) probeRandomMember() {
+	target := m.randomPeer()
+	if target == nil {
+		return
+	}
+
+	if m.directProbe(*target) {
+		m.refute(target.ID)
+		return
+	}
+
+	if m.indirectProbe(*target) {
+		m.refute(target.ID)
+		return
+	}
+
+	m.transition(target.ID, Suspect)
+	time.AfterFunc(m.cfg.SuspicionTimeout, func() {
+		m.expireSuspicion(target.ID)
+	})
+}
+
+// directProbe sends a single UDP ping and waits up to ProbeTimeout for an
+// ack. A real wire protocol would also piggyback gossip deltas on this
+// packet; that encoding is left for when this subsystem is wired to an
+// actual transport rather than exercised via Join/probeRandomMember calls.
+func (m *Membership) directProbe(target Member) bool {
+	addr, err := net.ResolveUDPAddr("udp", target.Addr)
+	if err != nil {
+		return false
+	}
+
+	m.conn.SetDeadline(time.Now().Add(m.cfg.ProbeTimeout))
+	if _, err := m.conn.WriteToUDP([]byte("ping:"+m.self.ID), addr); err != nil {
+		return false
+	}
+
+	buf := make([]byte, 64)
+	_, _, err = m.conn.ReadFromUDP(buf)
+	return err == nil
+}
+
+// indirectProbe asks IndirectProbes random relays (other than target) to
+// probe target on this node's behalf, per SWIM's indirect-ping phase.
+func (m *Membership) indirectProbe(target Member) bool {
+	relays := m.randomPeers(m.cfg.IndirectProbes, target.ID)
+	if len(relays) == 0 {
+		return false
+	}
+
+	acked := make(chan bool, len(relays))
+	for _, relay := range relays {
+		relay := relay
+		go func() {
+			acked <- m.directProbe(relay)
+		}()
+	}
+
+	for range relays {
+		if <-acked {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Membership) randomPeer() *Member {
+	peers := m.randomPeers(1, "")
+	if len(peers) == 0 {
+		return nil
+	}
+	return &peers[0]
+}
+
+func (m *Membership) randomPeers(n int, exclude string) []Member {
+	m.mu.RLock()
+	candidates := make([]Member, 0, len(m.members))
+	for id, mem := range m.members {
+		if id == m.self.ID || id == exclude {
+			continue
+		}
+		candidates = append(candidates, *mem)
+	}
+	m.mu.RUnlock()
+
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if n > len(candidates) {
+		n = len(candidates)
+	}
+	return candidates[:n]
+}
+
+// refute marks id Alive again and bumps its incarnation, the SWIM
+// mechanism a falsely-suspected member uses (here, on its behalf, by
+// whoever successfully reached it) to stop a suspicion from progressing
+// to Dead.
+func (m *Membership) refute(id string) {
+	m.transitionWithIncarnationBump(id, Alive)
+}
+
+func (m *Membership) expireSuspicion(id string) {
+	m.mu.RLock()
+	mem, ok := m.members[id]
+	stillSuspect := ok && mem.State == Suspect
+	m.mu.RUnlock()
+
+	if stillSuspect {
+		m.transition(id, Dead)
+	}
+}
+
+func (m *Membership) transition(id string, state State) {
+	m.mu.Lock()
+	mem, ok := m.members[id]
+	if !ok || mem.State == state {
+		m.mu.Unlock()
+		return
+	}
+	mem.State = state
+	snapshot := *mem
+	callbacks := append([]func(Member){}, m.onChange...)
+	m.mu.Unlock()
+
+	m.logger.Info("member state changed", "member_id", id, "state", state.String())
+	for _, cb := range callbacks {
+		cb(snapshot)
+	}
+}
+
+func (m *Membership) transitionWithIncarnationBump(id string, state State) {
+	m.mu.Lock()
+	mem, ok := m.members[id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	mem.Incarnation++
+	changed := mem.State != state
+	mem.State = state
+	snapshot := *mem
+	callbacks := append([]func(Member){}, m.onChange...)
+	m.mu.Unlock()
+
+	if changed {
+		m.logger.Info("member state changed", "member_id", id, "state", state.String())
+		for _, cb := range callbacks {
+			cb(snapshot)
+		}
+	}
+}
@@ -4,34 +4,101 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"github.com/aiproxy/go-aiproxy/internal/backoff"
+	"github.com/hashicorp/go-hclog"
+)
+
+// defaultUnhealthyThreshold/defaultHealthyThreshold are the hysteresis
+// defaults: an instance must fail this many consecutive checks before
+// being marked unhealthy, and recover this many consecutive successful
+// checks before being marked healthy again, so a single transient network
+// blip doesn't flip it in and out of rotation.
+const (
+	defaultUnhealthyThreshold = 2
+	defaultHealthyThreshold   = 1
+	defaultDeepCheckEvery     = 3
 )
 
+// InstanceStats is the externally-visible health signal for one instance,
+// surfaced through GET /cluster/health so operators can see why an
+// instance is (or isn't) in rotation without reading logs.
+type InstanceStats struct {
+	LastLatency  int64         `json:"last_latency_ms"`
+	SuccessRatio float64       `json:"success_ratio"`
+	LastError    string        `json:"last_error,omitempty"`
+	checks       int
+	successes   int
+}
+
 // HealthChecker performs periodic health checks on instances
 type HealthChecker struct {
-	mu              sync.RWMutex
-	instances       map[string]*Instance
-	checkInterval   time.Duration
-	checkTimeout    time.Duration
-	stopChan        chan struct{}
-	updateCallbacks []func(instanceID string, healthy bool)
+	mu                  sync.RWMutex
+	instances           map[string]*Instance
+	checkInterval       time.Duration
+	checkTimeout        time.Duration
+	backoff             backoff.Backoff
+	stopChan            chan struct{}
+	updateCallbacks     []func(instanceID string, healthy bool)
+	unhealthyThreshold  int
+	healthyThreshold    int
+	deepCheckEvery       int
+	tickCount           int
+	stats               map[string]*InstanceStats
+	logger              hclog.Logger
 }
 
 // NewHealthChecker creates a new health checker
 func NewHealthChecker(checkInterval time.Duration) *HealthChecker {
 	return &HealthChecker{
-		instances:       make(map[string]*Instance),
-		checkInterval:   checkInterval,
-		checkTimeout:    5 * time.Second,
-		stopChan:        make(chan struct{}),
-		updateCallbacks: make([]func(string, bool), 0),
+		instances:          make(map[string]*Instance),
+		checkInterval:      checkInterval,
+		checkTimeout:       5 * time.Second,
+		backoff:            backoff.Default(),
+		stopChan:           make(chan struct{}),
+		updateCallbacks:    make([]func(string, bool), 0),
+		unhealthyThreshold: defaultUnhealthyThreshold,
+		healthyThreshold:   defaultHealthyThreshold,
+		deepCheckEvery:     defaultDeepCheckEvery,
+		stats:              make(map[string]*InstanceStats),
+		logger: hclog.New(&hclog.LoggerOptions{
+			Name:  "healthchecker",
+			Level: hclog.Info,
+		}),
 	}
 }
 
+// SetLogger overrides the structured logger used for instance health
+// transitions.
+func (hc *HealthChecker) SetLogger(logger hclog.Logger) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.logger = logger
+}
+
+// SetThresholds configures the hysteresis: unhealthy consecutive-failure
+// and healthy consecutive-success counts required before IsHealthy flips.
+func (hc *HealthChecker) SetThresholds(unhealthy, healthy int) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.unhealthyThreshold = unhealthy
+	hc.healthyThreshold = healthy
+}
+
+// SetBackoff overrides the exponential backoff policy used to schedule
+// retries for failing instances.
+func (hc *HealthChecker) SetBackoff(b backoff.Backoff) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.backoff = b
+}
+
 // Register registers an instance for health checking
 func (hc *HealthChecker) Register(instance *Instance) {
 	hc.mu.Lock()
 	defer hc.mu.Unlock()
 	hc.instances[instance.ID] = instance
+	hc.stats[instance.ID] = &InstanceStats{}
 }
 
 // Unregister removes an instance from health checking
@@ -39,6 +106,7 @@ func (hc *HealthChecker) Unregister(instance *Instance) {
 	hc.mu.Lock()
 	defer hc.mu.Unlock()
 	delete(hc.instances, instance.ID)
+	delete(hc.stats, instance.ID)
 }
 
 // AddUpdateCallback adds a callback for health status updates
@@ -71,14 +139,29 @@ func (hc *HealthChecker) Stop() {
 	close(hc.stopChan)
 }
 
-// checkAll performs health checks on all registered instances
+// checkAll performs health checks on all registered instances whose backoff
+// window has elapsed. Healthy instances are always re-checked; unhealthy
+// ones are skipped until their NextProbeAt passes, so a freshly-failing
+// instance isn't hammered on every tick.
 func (hc *HealthChecker) checkAll() {
-	hc.mu.RLock()
+	now := time.Now()
+
+	hc.mu.Lock()
+	hc.tickCount++
+	deep := hc.deepCheckEvery <= 1 || hc.tickCount%hc.deepCheckEvery == 0
 	instances := make([]*Instance, 0, len(hc.instances))
 	for _, inst := range hc.instances {
+		if inst.Reattached {
+			// Externally-managed: the health-check loop is disabled for
+			// this instance entirely, it is always considered healthy.
+			continue
+		}
+		if !inst.IsHealthy && !inst.NextProbeAt.IsZero() && now.Before(inst.NextProbeAt) {
+			continue
+		}
 		instances = append(instances, inst)
 	}
-	hc.mu.RUnlock()
+	hc.mu.Unlock()
 
 	// Check each instance concurrently
 	var wg sync.WaitGroup
@@ -86,42 +169,105 @@ func (hc *HealthChecker) checkAll() {
 		wg.Add(1)
 		go func(instance *Instance) {
 			defer wg.Done()
-			hc.checkInstance(instance)
+			hc.checkInstance(instance, deep)
 		}(inst)
 	}
 	wg.Wait()
 }
 
-// checkInstance performs a health check on a single instance
-func (hc *HealthChecker) checkInstance(instance *Instance) {
+// checkInstance performs a health check on a single instance. Every tick
+// runs the cheap IsHealthy probe; every deepCheckEvery-th tick additionally
+// runs the more expensive ListModels canary, so a flapping network doesn't
+// make every instance pay for a full model-list round trip every second.
+// IsHealthy only flips after unhealthyThreshold consecutive failures or
+// healthyThreshold consecutive successes, so a single bad probe can't take
+// an instance out of rotation on its own.
+func (hc *HealthChecker) checkInstance(instance *Instance, deep bool) {
 	ctx, cancel := context.WithTimeout(context.Background(), hc.checkTimeout)
 	defer cancel()
 
-	// Perform health check
-	healthy := instance.Provider.IsHealthy()
-
-	// Additional check: try to list models as a health check
-	if healthy {
+	start := time.Now()
+	probeOK := instance.Provider.IsHealthy()
+	if probeOK && deep {
 		_, err := instance.Provider.ListModels(ctx)
-		healthy = err == nil
+		probeOK = err == nil
+		if err != nil {
+			hc.recordError(instance.ID, err)
+		}
+	}
+	latency := time.Since(start)
+
+	hc.mu.Lock()
+	unhealthyThreshold := hc.unhealthyThreshold
+	healthyThreshold := hc.healthyThreshold
+	b := hc.backoff
+	if st, ok := hc.stats[instance.ID]; ok {
+		st.LastLatency = latency.Milliseconds()
+		st.checks++
+		if probeOK {
+			st.successes++
+		}
+		st.SuccessRatio = float64(st.successes) / float64(st.checks)
+	}
+	hc.mu.Unlock()
+
+	wasHealthy := instance.IsHealthy
+	if probeOK {
+		instance.ConsecutiveFailures = 0
+		instance.ConsecutiveSuccesses++
+		if !wasHealthy && instance.ConsecutiveSuccesses >= healthyThreshold {
+			instance.IsHealthy = true
+			instance.NextProbeAt = time.Time{}
+		}
+	} else {
+		instance.ConsecutiveSuccesses = 0
+		instance.ConsecutiveFailures++
+		instance.NextProbeAt = time.Now().Add(b.Next(instance.ConsecutiveFailures))
+		if wasHealthy && instance.ConsecutiveFailures >= unhealthyThreshold {
+			instance.IsHealthy = false
+		}
 	}
 
-	// Update health status if changed
-	if instance.IsHealthy != healthy {
-		instance.IsHealthy = healthy
+	if instance.IsHealthy != wasHealthy {
+		hc.logger.Info("instance health changed",
+			"instance_id", instance.ID,
+			"healthy", instance.IsHealthy,
+			"consecutive_failures", instance.ConsecutiveFailures,
+			"consecutive_successes", instance.ConsecutiveSuccesses,
+		)
 
-		// Notify callbacks
 		hc.mu.RLock()
 		callbacks := make([]func(string, bool), len(hc.updateCallbacks))
 		copy(callbacks, hc.updateCallbacks)
 		hc.mu.RUnlock()
 
 		for _, callback := range callbacks {
-			callback(instance.ID, healthy)
+			callback(instance.ID, instance.IsHealthy)
 		}
 	}
 }
 
+func (hc *HealthChecker) recordError(instanceID string, err error) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	if st, ok := hc.stats[instanceID]; ok {
+		st.LastError = err.Error()
+	}
+}
+
+// Stats returns a snapshot of every registered instance's health stats,
+// keyed by instance ID, for the GET /cluster/health endpoint.
+func (hc *HealthChecker) Stats() map[string]InstanceStats {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+
+	out := make(map[string]InstanceStats, len(hc.stats))
+	for id, st := range hc.stats {
+		out[id] = *st
+	}
+	return out
+}
+
 // ForceCheck forces an immediate health check on a specific instance
 func (hc *HealthChecker) ForceCheck(instanceID string) {
 	hc.mu.RLock()
@@ -129,6 +275,6 @@ func (hc *HealthChecker) ForceCheck(instanceID string) {
 	hc.mu.RUnlock()
 
 	if ok {
-		hc.checkInstance(instance)
+		hc.checkInstance(instance, true)
 	}
 }
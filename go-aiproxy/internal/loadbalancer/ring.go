@@ -0,0 +1,77 @@
+package loadbalancer
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// vnodesPerInstance controls how many virtual nodes each instance gets on
+// the consistent-hash ring. More vnodes smooth out the distribution at the
+// cost of a larger ring to search.
+const vnodesPerInstance = 160
+
+// hashRing is an immutable consistent-hash ring: a sorted list of virtual
+// node hashes, each pointing back at the instance ID that owns it. The
+// LoadBalancer holds the current ring in an atomic.Pointer; the ring is
+// rebuilt and swapped wholesale whenever membership changes.
+type hashRing struct {
+	hashes []uint32
+	owners []string // owners[i] owns hashes[i]
+}
+
+// buildHashRing constructs a ring from the given instance IDs. Only a small
+// minority of vnodes move when an instance is added or removed, unlike
+// hash(clientIP) % len(instances) which reshuffles almost everything.
+func buildHashRing(instanceIDs []string) *hashRing {
+	ring := &hashRing{
+		hashes: make([]uint32, 0, len(instanceIDs)*vnodesPerInstance),
+		owners: make([]string, 0, len(instanceIDs)*vnodesPerInstance),
+	}
+
+	type vnode struct {
+		hash  uint32
+		owner string
+	}
+	vnodes := make([]vnode, 0, len(instanceIDs)*vnodesPerInstance)
+
+	for _, id := range instanceIDs {
+		for i := 0; i < vnodesPerInstance; i++ {
+			key := id + "#" + strconv.Itoa(i)
+			vnodes = append(vnodes, vnode{hash: fnv1a(key), owner: id})
+		}
+	}
+
+	sort.Slice(vnodes, func(i, j int) bool { return vnodes[i].hash < vnodes[j].hash })
+
+	for _, v := range vnodes {
+		ring.hashes = append(ring.hashes, v.hash)
+		ring.owners = append(ring.owners, v.owner)
+	}
+
+	return ring
+}
+
+// owner returns the instance ID responsible for key, found by walking
+// clockwise from key's hash to the first vnode at or past it, wrapping
+// around to the start of the ring.
+func (r *hashRing) owner(key string) (string, bool) {
+	if len(r.hashes) == 0 {
+		return "", false
+	}
+
+	h := fnv1a(key)
+	idx := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if idx == len(r.hashes) {
+		idx = 0
+	}
+
+	return r.owners[idx], true
+}
+
+// fnv1a hashes a string with 32-bit FNV-1a.
+func fnv1a(s string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return h.Sum32()
+}
@@ -0,0 +1,78 @@
+package loadbalancer
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Store exposes optimistic-concurrency updates over the cluster's
+// Raft-replicated config key/value store (instance weights, routing rules,
+// per-provider quotas). It is the only supported way to mutate that
+// state: handleJoin/handleLeave route their node-registry changes through
+// Cluster.applyCommand directly, but arbitrary config keys go through
+// GuaranteedUpdate so concurrent admin API calls can't clobber each other.
+type Store struct {
+	cluster *Cluster
+}
+
+// Store returns the cluster's config store accessor.
+func (c *Cluster) Store() *Store {
+	return &Store{cluster: c}
+}
+
+// TryUpdateFunc mutates a key's current value, returning the new value to
+// store. origStateIsCurrent tells GuaranteedUpdate it may skip re-fetching
+// current on the first retry after a CAS conflict, because the caller
+// already knows its in-memory copy is stale in a way tryUpdate accounts
+// for (e.g. it's folding in a delta rather than replacing wholesale).
+type TryUpdateFunc func(current []byte) (newValue []byte, origStateIsCurrent bool, err error)
+
+// guaranteedUpdateRetries bounds how many compare-and-swap attempts
+// GuaranteedUpdate makes before giving up, mirroring the etcd3 store
+// pattern used by the Kubernetes apiserver's GuaranteedUpdate.
+const guaranteedUpdateRetries = 5
+
+// GuaranteedUpdate fetches key's current value and revision, invokes
+// tryUpdate, and applies the result as a compare-and-swap against that
+// revision via the Raft log. On a concurrent writer winning the race, it
+// refetches and retries — unless tryUpdate's own origStateIsCurrent hint
+// says the retry can reuse the value it just computed from.
+func (s *Store) GuaranteedUpdate(key string, tryUpdate TryUpdateFunc) error {
+	current, revision := s.cluster.fsm.get(key)
+
+	for attempt := 0; attempt < guaranteedUpdateRetries; attempt++ {
+		newValue, origStateIsCurrent, err := tryUpdate(current)
+		if err != nil {
+			return fmt.Errorf("store: tryUpdate failed: %w", err)
+		}
+
+		result, applyErr := s.cluster.applyCommand(logCommand{
+			Type: commandConfigUpdate,
+			Key:  key,
+			Data: json.RawMessage(newValue),
+		})
+		if applyErr == nil {
+			if newRevision, ok := result.(uint64); ok && newRevision == revision+1 {
+				return nil
+			}
+			// Someone else's write landed between our read and our apply.
+		}
+
+		// Refetch unless the caller already knows its computed value
+		// reflects the latest state (e.g. an idempotent merge).
+		if origStateIsCurrent {
+			revision++
+			current = newValue
+			continue
+		}
+		current, revision = s.cluster.fsm.get(key)
+	}
+
+	return fmt.Errorf("store: GuaranteedUpdate on %q failed after %d attempts", key, guaranteedUpdateRetries)
+}
+
+// Get returns key's current raw value.
+func (s *Store) Get(key string) []byte {
+	value, _ := s.cluster.fsm.get(key)
+	return value
+}
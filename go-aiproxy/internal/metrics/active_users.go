@@ -0,0 +1,252 @@
+package metrics
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Rolling bucket counts kept per window: 60 one-minute buckets for the
+// trailing hour, 24 one-hour buckets for the trailing day.
+const (
+	activeUsers1hBuckets  = 60
+	activeUsers24hBuckets = 24
+)
+
+// ActiveUserTracker maintains two rolling bucket sketches of distinct
+// caller identities (see Server.callerLabel) observed recently, exposing
+// their union sizes as the aiproxy_active_users_1h/24h gauges. Unlike
+// UserStats, which labels a Prometheus series per user and must therefore
+// bound cardinality, this tracker only ever produces two gauge values -
+// the identities themselves never become label values - so it can record
+// every caller, known or not, at no cardinality cost.
+type ActiveUserTracker struct {
+	gauge1h  prometheus.Gauge
+	gauge24h prometheus.Gauge
+
+	mu            sync.Mutex
+	minuteBuckets []map[string]struct{}
+	hourBuckets   []map[string]struct{}
+	minuteIdx     int
+	hourIdx       int
+	currentMinute time.Time
+	currentHour   time.Time
+
+	// persistPath, when non-empty, is where Advance saves bucket state
+	// and newActiveUserTracker restores it from, so a restart doesn't
+	// momentarily zero the gauges.
+	persistPath string
+}
+
+// newActiveUserTracker creates a tracker for the trailing 1h/24h
+// distinct-caller gauges, restoring prior state from persistPath if it is
+// non-empty and exists.
+func newActiveUserTracker(persistPath string) *ActiveUserTracker {
+	t := &ActiveUserTracker{
+		gauge1h: newGauge(prometheus.GaugeOpts{
+			Name: "aiproxy_active_users_1h",
+			Help: "Distinct caller identities with at least one request in the trailing hour.",
+		}),
+		gauge24h: newGauge(prometheus.GaugeOpts{
+			Name: "aiproxy_active_users_24h",
+			Help: "Distinct caller identities with at least one request in the trailing day.",
+		}),
+		minuteBuckets: make([]map[string]struct{}, activeUsers1hBuckets),
+		hourBuckets:   make([]map[string]struct{}, activeUsers24hBuckets),
+		persistPath:   persistPath,
+	}
+	for i := range t.minuteBuckets {
+		t.minuteBuckets[i] = make(map[string]struct{})
+	}
+	for i := range t.hourBuckets {
+		t.hourBuckets[i] = make(map[string]struct{})
+	}
+	if persistPath != "" {
+		t.load()
+	}
+	return t
+}
+
+// SetPersistPath configures (or, with an empty path, disables) on-disk
+// persistence for t and immediately loads any existing state at path, so
+// a late call (after the process already observed some activity this
+// run) doesn't clobber what's already tracked with a blank slate.
+func (t *ActiveUserTracker) SetPersistPath(path string) {
+	t.mu.Lock()
+	t.persistPath = path
+	t.mu.Unlock()
+	if path != "" {
+		t.load()
+	}
+}
+
+// rotateLocked advances the current minute/hour buckets to now, clearing
+// every bucket the rotation passes over. Must be called with t.mu held.
+func (t *ActiveUserTracker) rotateLocked(now time.Time) {
+	minute := now.Truncate(time.Minute)
+	if t.currentMinute.IsZero() {
+		t.currentMinute = minute
+	}
+	if gap := int64(minute.Sub(t.currentMinute) / time.Minute); gap > 0 {
+		if gap >= int64(len(t.minuteBuckets)) {
+			for i := range t.minuteBuckets {
+				t.minuteBuckets[i] = make(map[string]struct{})
+			}
+		} else {
+			for i := int64(0); i < gap; i++ {
+				t.minuteIdx = (t.minuteIdx + 1) % len(t.minuteBuckets)
+				t.minuteBuckets[t.minuteIdx] = make(map[string]struct{})
+			}
+		}
+		t.currentMinute = minute
+	}
+
+	hour := now.Truncate(time.Hour)
+	if t.currentHour.IsZero() {
+		t.currentHour = hour
+	}
+	if gap := int64(hour.Sub(t.currentHour) / time.Hour); gap > 0 {
+		if gap >= int64(len(t.hourBuckets)) {
+			for i := range t.hourBuckets {
+				t.hourBuckets[i] = make(map[string]struct{})
+			}
+		} else {
+			for i := int64(0); i < gap; i++ {
+				t.hourIdx = (t.hourIdx + 1) % len(t.hourBuckets)
+				t.hourBuckets[t.hourIdx] = make(map[string]struct{})
+			}
+		}
+		t.currentHour = hour
+	}
+}
+
+// Record marks user as active in the current minute and hour buckets.
+// Empty user is ignored, so anonymous requests are not counted as active
+// users.
+func (t *ActiveUserTracker) Record(user string) {
+	if user == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rotateLocked(time.Now())
+	t.minuteBuckets[t.minuteIdx][user] = struct{}{}
+	t.hourBuckets[t.hourIdx][user] = struct{}{}
+}
+
+// Advance rotates the rolling windows for the current time and refreshes
+// the gauges, so ActiveUsers1h/24h reflect the trailing window even
+// during a lull with no incoming requests to trigger Record. Called
+// periodically by CollectUserActivityMetrics.
+func (t *ActiveUserTracker) Advance() {
+	t.mu.Lock()
+	t.rotateLocked(time.Now())
+	count1h := len(unionOf(t.minuteBuckets))
+	count24h := len(unionOf(t.hourBuckets))
+	t.mu.Unlock()
+
+	t.gauge1h.Set(float64(count1h))
+	t.gauge24h.Set(float64(count24h))
+
+	if t.persistPath != "" {
+		t.save()
+	}
+}
+
+func unionOf(buckets []map[string]struct{}) map[string]struct{} {
+	union := make(map[string]struct{})
+	for _, b := range buckets {
+		for user := range b {
+			union[user] = struct{}{}
+		}
+	}
+	return union
+}
+
+// activeUserSnapshot is the JSON shape persisted to/restored from
+// persistPath.
+type activeUserSnapshot struct {
+	MinuteBuckets [][]string `json:"minute_buckets"`
+	HourBuckets   [][]string `json:"hour_buckets"`
+	MinuteIdx     int        `json:"minute_idx"`
+	HourIdx       int        `json:"hour_idx"`
+	CurrentMinute time.Time  `json:"current_minute"`
+	CurrentHour   time.Time  `json:"current_hour"`
+}
+
+// save writes the current bucket state to t.persistPath. Write failures
+// are swallowed: a restart without persisted state just starts the
+// gauges from zero rather than failing the request that triggered it.
+func (t *ActiveUserTracker) save() {
+	t.mu.Lock()
+	snap := activeUserSnapshot{
+		MinuteIdx:     t.minuteIdx,
+		HourIdx:       t.hourIdx,
+		CurrentMinute: t.currentMinute,
+		CurrentHour:   t.currentHour,
+	}
+	for _, b := range t.minuteBuckets {
+		snap.MinuteBuckets = append(snap.MinuteBuckets, setKeys(b))
+	}
+	for _, b := range t.hourBuckets {
+		snap.HourBuckets = append(snap.HourBuckets, setKeys(b))
+	}
+	t.mu.Unlock()
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(t.persistPath, data, 0o600)
+}
+
+// load restores bucket state from t.persistPath. Any read/parse error is
+// treated as "no prior state" rather than failing construction.
+func (t *ActiveUserTracker) load() {
+	data, err := os.ReadFile(t.persistPath)
+	if err != nil {
+		return
+	}
+	var snap activeUserSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, b := range snap.MinuteBuckets {
+		if i >= len(t.minuteBuckets) {
+			break
+		}
+		t.minuteBuckets[i] = toSet(b)
+	}
+	for i, b := range snap.HourBuckets {
+		if i >= len(t.hourBuckets) {
+			break
+		}
+		t.hourBuckets[i] = toSet(b)
+	}
+	t.minuteIdx = snap.MinuteIdx
+	t.hourIdx = snap.HourIdx
+	t.currentMinute = snap.CurrentMinute
+	t.currentHour = snap.CurrentHour
+}
+
+func setKeys(m map[string]struct{}) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+func toSet(keys []string) map[string]struct{} {
+	m := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		m[k] = struct{}{}
+	}
+	return m
+}
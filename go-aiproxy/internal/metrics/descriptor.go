@@ -0,0 +1,45 @@
+package metrics
+
+import "sync"
+
+// Descriptor is the stable, serializable shape of one registered metric:
+// its name, help text, Prometheus type, variable label names, and (for
+// histograms) bucket boundaries. DumpDescriptors and the metrics-dump.json
+// golden file test use this to catch accidental breaking changes - a
+// renamed/removed metric, changed help text, removed label, or changed
+// histogram buckets - in code review.
+type Descriptor struct {
+	Name    string    `json:"name"`
+	Help    string    `json:"help"`
+	Type    string    `json:"type"`
+	Labels  []string  `json:"labels,omitempty"`
+	Buckets []float64 `json:"buckets,omitempty"`
+}
+
+var (
+	catalogMu sync.Mutex
+	catalog   []Descriptor
+)
+
+// register appends d to the process-wide catalog DumpDescriptors reads
+// from. Called once per metric, from the same constructor call site that
+// registers it with Prometheus (see newCounterVec and friends below), so
+// the two can never drift apart.
+func register(d Descriptor) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	catalog = append(catalog, d)
+}
+
+// DumpDescriptors returns a Descriptor for every metric registered so far
+// in this process by NewMetrics and the metric groups it constructs
+// (UserStats, ActiveUserTracker). Call metrics.Default() (or
+// metrics.NewMetrics()) first if nothing has built the catalog yet - the
+// "metrics dump" CLI subcommand does this itself.
+func DumpDescriptors() ([]Descriptor, error) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	out := make([]Descriptor, len(catalog))
+	copy(out, catalog)
+	return out, nil
+}
@@ -1,6 +1,11 @@
 package metrics
 
 import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,16 +17,23 @@ import (
 // Metrics holds all Prometheus metrics
 type Metrics struct {
 	// HTTP metrics
-	RequestsTotal   *prometheus.CounterVec
-	RequestDuration *prometheus.HistogramVec
-	ResponseSize    *prometheus.HistogramVec
-	ActiveRequests  prometheus.Gauge
+	RequestsTotal      *prometheus.CounterVec
+	RequestDuration    *prometheus.HistogramVec
+	RequestSize        *prometheus.HistogramVec
+	ResponseSize       *prometheus.HistogramVec
+	ActiveRequests     prometheus.Gauge
+	RequestsInFlight   *prometheus.GaugeVec
+	RequestErrorsTotal *prometheus.CounterVec
 
 	// Provider metrics
 	ProviderRequestsTotal   *prometheus.CounterVec
 	ProviderRequestDuration *prometheus.HistogramVec
 	ProviderErrors          *prometheus.CounterVec
 	ProviderTokensUsed      *prometheus.CounterVec
+	ProviderStreamChunks    *prometheus.CounterVec
+
+	// OAuth metrics
+	OAuthTokenRefreshTotal *prometheus.CounterVec
 
 	// Cache metrics
 	CacheHits       *prometheus.CounterVec
@@ -43,95 +55,201 @@ type Metrics struct {
 	PoolProviders      *prometheus.GaugeVec
 	PoolHealthyProviders *prometheus.GaugeVec
 	PoolFailovers       *prometheus.CounterVec
+
+	// Circuit breaker metrics
+	ProviderCircuitState      *prometheus.GaugeVec
+	ProviderCircuitTripsTotal *prometheus.CounterVec
+
+	// Hot-reload metrics
+	ConfigReloadsTotal *prometheus.CounterVec
+
+	// Users is the per-caller usage/billing subsystem RecordUserMetrics
+	// records into.
+	Users *UserStats
+
+	// ActiveUsers is the rolling 1h/24h distinct-caller gauge subsystem;
+	// RecordUserMetrics feeds it the same caller identity it records into
+	// Users. Call CollectUserActivityMetrics once at startup to keep its
+	// gauges current even without a steady stream of requests.
+	ActiveUsers *ActiveUserTracker
+}
+
+var (
+	defaultMetrics     *Metrics
+	defaultMetricsOnce sync.Once
+)
+
+// Default returns the process-wide Metrics instance, constructing and
+// registering it against the default Prometheus registry on first call.
+// Callers instrumenting code outside of EnhancedServer's own setup (e.g.
+// the provider clients) should use this instead of NewMetrics directly,
+// since NewMetrics panics on a second promauto registration of the same
+// collector names.
+func Default() *Metrics {
+	defaultMetricsOnce.Do(func() {
+		defaultMetrics = NewMetrics()
+	})
+	return defaultMetrics
+}
+
+// newCounterVec, newGaugeVec, newHistogramVec, newGauge, and newCounter
+// wrap their promauto equivalents to additionally register a Descriptor
+// for DumpDescriptors, built from the exact same Opts/labels passed to
+// Prometheus, so the catalog can never drift from what's actually
+// registered.
+func newCounterVec(opts prometheus.CounterOpts, labels []string) *prometheus.CounterVec {
+	register(Descriptor{Name: opts.Name, Help: opts.Help, Type: "counter", Labels: labels})
+	return promauto.NewCounterVec(opts, labels)
+}
+
+func newGaugeVec(opts prometheus.GaugeOpts, labels []string) *prometheus.GaugeVec {
+	register(Descriptor{Name: opts.Name, Help: opts.Help, Type: "gauge", Labels: labels})
+	return promauto.NewGaugeVec(opts, labels)
+}
+
+func newHistogramVec(opts prometheus.HistogramOpts, labels []string) *prometheus.HistogramVec {
+	register(Descriptor{Name: opts.Name, Help: opts.Help, Type: "histogram", Labels: labels, Buckets: opts.Buckets})
+	return promauto.NewHistogramVec(opts, labels)
+}
+
+func newGauge(opts prometheus.GaugeOpts) prometheus.Gauge {
+	register(Descriptor{Name: opts.Name, Help: opts.Help, Type: "gauge"})
+	return promauto.NewGauge(opts)
+}
+
+func newCounter(opts prometheus.CounterOpts) prometheus.Counter {
+	register(Descriptor{Name: opts.Name, Help: opts.Help, Type: "counter"})
+	return promauto.NewCounter(opts)
 }
 
 // NewMetrics creates and registers all Prometheus metrics
 func NewMetrics() *Metrics {
 	return &Metrics{
 		// HTTP metrics
-		RequestsTotal: promauto.NewCounterVec(
+		RequestsTotal: newCounterVec(
 			prometheus.CounterOpts{
 				Name: "aiproxy_http_requests_total",
 				Help: "Total number of HTTP requests",
 			},
 			[]string{"method", "endpoint", "status"},
 		),
-		RequestDuration: promauto.NewHistogramVec(
+		RequestDuration: newHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "aiproxy_http_request_duration_seconds",
 				Help:    "HTTP request duration in seconds",
 				Buckets: prometheus.DefBuckets,
 			},
+			[]string{"method", "endpoint", "code"},
+		),
+		RequestSize: newHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "aiproxy_http_request_size_bytes",
+				Help:    "HTTP request body size in bytes",
+				Buckets: prometheus.ExponentialBuckets(256, 4, 12), // 256B to ~1GB
+			},
 			[]string{"method", "endpoint"},
 		),
-		ResponseSize: promauto.NewHistogramVec(
+		ResponseSize: newHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "aiproxy_http_response_size_bytes",
 				Help:    "HTTP response size in bytes",
 				Buckets: prometheus.ExponentialBuckets(100, 10, 7), // 100B to 100MB
 			},
-			[]string{"method", "endpoint"},
+			[]string{"method", "endpoint", "code"},
 		),
-		ActiveRequests: promauto.NewGauge(
+		ActiveRequests: newGauge(
 			prometheus.GaugeOpts{
 				Name: "aiproxy_http_active_requests",
 				Help: "Number of active HTTP requests",
 			},
 		),
+		RequestsInFlight: newGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "aiproxy_http_requests_in_flight",
+				Help: "Number of HTTP requests currently being handled, by endpoint.",
+			},
+			[]string{"endpoint"},
+		),
+		RequestErrorsTotal: newCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_http_request_errors_total",
+				Help: "Total HTTP requests that returned 5xx or recorded a gin context error, by endpoint and reason.",
+			},
+			[]string{"endpoint", "reason"},
+		),
 
 		// Provider metrics
-		ProviderRequestsTotal: promauto.NewCounterVec(
+		ProviderRequestsTotal: newCounterVec(
 			prometheus.CounterOpts{
 				Name: "aiproxy_provider_requests_total",
 				Help: "Total number of requests to providers",
 			},
 			[]string{"provider", "model", "status"},
 		),
-		ProviderRequestDuration: promauto.NewHistogramVec(
+		ProviderRequestDuration: newHistogramVec(
 			prometheus.HistogramOpts{
-				Name:    "aiproxy_provider_request_duration_seconds",
-				Help:    "Provider request duration in seconds",
-				Buckets: prometheus.DefBuckets,
+				Name: "aiproxy_provider_request_duration_seconds",
+				Help: "Provider request duration in seconds",
+				// Starts sub-millisecond (500us) so fast local/mocked
+				// provider calls land in a real bucket instead of all
+				// piling into +Inf at the low end of DefBuckets.
+				Buckets: prometheus.ExponentialBuckets(0.0005, 2, 20),
 			},
 			[]string{"provider", "model"},
 		),
-		ProviderErrors: promauto.NewCounterVec(
+		ProviderErrors: newCounterVec(
 			prometheus.CounterOpts{
 				Name: "aiproxy_provider_errors_total",
 				Help: "Total number of provider errors",
 			},
 			[]string{"provider", "error_type"},
 		),
-		ProviderTokensUsed: promauto.NewCounterVec(
+		ProviderTokensUsed: newCounterVec(
 			prometheus.CounterOpts{
 				Name: "aiproxy_provider_tokens_used_total",
 				Help: "Total number of tokens used by provider",
 			},
 			[]string{"provider", "model", "token_type"},
 		),
+		ProviderStreamChunks: newCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_provider_stream_chunks_total",
+				Help: "Total number of streaming chunks received from a provider",
+			},
+			[]string{"provider", "model"},
+		),
+
+		// OAuth metrics
+		OAuthTokenRefreshTotal: newCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_oauth_token_refresh_total",
+				Help: "Total number of OAuth token refresh attempts, by provider and result (success or error)",
+			},
+			[]string{"provider", "result"},
+		),
 
 		// Cache metrics
-		CacheHits: promauto.NewCounterVec(
+		CacheHits: newCounterVec(
 			prometheus.CounterOpts{
 				Name: "aiproxy_cache_hits_total",
 				Help: "Total number of cache hits",
 			},
 			[]string{"cache_type"},
 		),
-		CacheMisses: promauto.NewCounterVec(
+		CacheMisses: newCounterVec(
 			prometheus.CounterOpts{
 				Name: "aiproxy_cache_misses_total",
 				Help: "Total number of cache misses",
 			},
 			[]string{"cache_type"},
 		),
-		CacheEvictions: promauto.NewCounter(
+		CacheEvictions: newCounter(
 			prometheus.CounterOpts{
 				Name: "aiproxy_cache_evictions_total",
 				Help: "Total number of cache evictions",
 			},
 		),
-		CacheSizeBytes: promauto.NewGauge(
+		CacheSizeBytes: newGauge(
 			prometheus.GaugeOpts{
 				Name: "aiproxy_cache_size_bytes",
 				Help: "Current cache size in bytes",
@@ -139,19 +257,19 @@ func NewMetrics() *Metrics {
 		),
 
 		// System metrics
-		GoRoutines: promauto.NewGauge(
+		GoRoutines: newGauge(
 			prometheus.GaugeOpts{
 				Name: "aiproxy_goroutines",
 				Help: "Number of active goroutines",
 			},
 		),
-		MemoryUsageBytes: promauto.NewGauge(
+		MemoryUsageBytes: newGauge(
 			prometheus.GaugeOpts{
 				Name: "aiproxy_memory_usage_bytes",
 				Help: "Current memory usage in bytes",
 			},
 		),
-		CPUUsagePercent: promauto.NewGauge(
+		CPUUsagePercent: newGauge(
 			prometheus.GaugeOpts{
 				Name: "aiproxy_cpu_usage_percent",
 				Help: "Current CPU usage percentage",
@@ -159,20 +277,20 @@ func NewMetrics() *Metrics {
 		),
 
 		// WebSocket metrics
-		WSConnections: promauto.NewGauge(
+		WSConnections: newGauge(
 			prometheus.GaugeOpts{
 				Name: "aiproxy_websocket_connections",
 				Help: "Number of active WebSocket connections",
 			},
 		),
-		WSMessagesTotal: promauto.NewCounterVec(
+		WSMessagesTotal: newCounterVec(
 			prometheus.CounterOpts{
 				Name: "aiproxy_websocket_messages_total",
 				Help: "Total number of WebSocket messages",
 			},
 			[]string{"direction"}, // "sent" or "received"
 		),
-		WSBytesTotal: promauto.NewCounterVec(
+		WSBytesTotal: newCounterVec(
 			prometheus.CounterOpts{
 				Name: "aiproxy_websocket_bytes_total",
 				Help: "Total bytes transferred via WebSocket",
@@ -181,52 +299,110 @@ func NewMetrics() *Metrics {
 		),
 
 		// Pool metrics
-		PoolProviders: promauto.NewGaugeVec(
+		PoolProviders: newGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "aiproxy_pool_providers_total",
 				Help: "Total number of providers in pool",
 			},
 			[]string{"provider_type"},
 		),
-		PoolHealthyProviders: promauto.NewGaugeVec(
+		PoolHealthyProviders: newGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "aiproxy_pool_healthy_providers",
 				Help: "Number of healthy providers in pool",
 			},
 			[]string{"provider_type"},
 		),
-		PoolFailovers: promauto.NewCounterVec(
+		PoolFailovers: newCounterVec(
 			prometheus.CounterOpts{
 				Name: "aiproxy_pool_failovers_total",
 				Help: "Total number of provider failovers",
 			},
 			[]string{"from_provider", "to_provider"},
 		),
+
+		// Circuit breaker metrics
+		ProviderCircuitState: newGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "aiproxy_provider_circuit_state",
+				Help: "Current circuit breaker state per provider: 0=closed, 1=half-open, 2=open.",
+			},
+			[]string{"provider"},
+		),
+		ProviderCircuitTripsTotal: newCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_provider_circuit_trips_total",
+				Help: "Total number of times a provider's circuit breaker tripped open, by reason.",
+			},
+			[]string{"provider", "reason"},
+		),
+
+		ConfigReloadsTotal: newCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_config_reload_total",
+				Help: "Total number of hot-reload attempts for provider credentials, by provider and result (success or error)",
+			},
+			[]string{"provider", "result"},
+		),
+
+		Users:       newUserStats(),
+		ActiveUsers: newActiveUserTracker(""),
 	}
 }
 
-// PrometheusMiddleware creates a Gin middleware for Prometheus metrics
+// PrometheusMiddleware instruments every request with in-flight, size,
+// duration, and error counters, labeled by the matched route template
+// (endpointLabel) rather than the raw path so cardinality stays bounded.
 func PrometheusMiddleware(m *Metrics) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
-		path := c.Request.URL.Path
+		endpoint := endpointLabel(c)
 		method := c.Request.Method
 
-		// Increment active requests
 		m.ActiveRequests.Inc()
+		m.RequestsInFlight.WithLabelValues(endpoint).Inc()
 		defer m.ActiveRequests.Dec()
+		defer m.RequestsInFlight.WithLabelValues(endpoint).Dec()
+
+		if c.Request.ContentLength > 0 {
+			m.RequestSize.WithLabelValues(method, endpoint).Observe(float64(c.Request.ContentLength))
+		}
 
-		// Process request
 		c.Next()
 
-		// Record metrics
 		duration := time.Since(start).Seconds()
-		status := fmt.Sprintf("%d", c.Writer.Status())
-		
-		m.RequestsTotal.WithLabelValues(method, path, status).Inc()
-		m.RequestDuration.WithLabelValues(method, path).Observe(duration)
-		m.ResponseSize.WithLabelValues(method, path).Observe(float64(c.Writer.Size()))
+		code := fmt.Sprintf("%d", c.Writer.Status())
+
+		m.RequestsTotal.WithLabelValues(method, endpoint, code).Inc()
+		m.RequestDuration.WithLabelValues(method, endpoint, code).Observe(duration)
+		m.ResponseSize.WithLabelValues(method, endpoint, code).Observe(float64(c.Writer.Size()))
+
+		if c.Writer.Status() >= http.StatusInternalServerError || len(c.Errors) > 0 {
+			m.RequestErrorsTotal.WithLabelValues(endpoint, errorReason(c)).Inc()
+		}
+	}
+}
+
+// endpointLabel returns the matched route template (e.g.
+// "/v1beta/models/:model:generateContent"), not the raw request path, so
+// path parameters and unmatched 404s don't grow this metric's
+// cardinality unbounded; unmatched routes fall back to "unknown".
+func endpointLabel(c *gin.Context) string {
+	if path := c.FullPath(); path != "" {
+		return path
 	}
+	return "unknown"
+}
+
+// errorReason buckets why a request is counted in RequestErrorsTotal into
+// a small, bounded set of labels: the HTTP status for 5xx responses,
+// otherwise "handler_error" when the handler recorded a gin context error
+// without a 5xx status.
+func errorReason(c *gin.Context) string {
+	if status := c.Writer.Status(); status >= http.StatusInternalServerError {
+		return fmt.Sprintf("http_%d", status)
+	}
+	return "handler_error"
 }
 
 // RecordProviderMetrics records metrics for provider requests
@@ -254,6 +430,31 @@ func (m *Metrics) RecordProviderMetrics(provider, model string, duration time.Du
 	}
 }
 
+// RecordUserMetrics records one request's usage against user (the
+// caller's identity, e.g. a hashed API key or an OIDC CallerID) in
+// addition to the provider/model RecordProviderMetrics already tracks, so
+// usage and cost can be broken down per tenant for billing instead of
+// only aggregated globally. op identifies the operation/endpoint bucket
+// the request hit, e.g. "chat.completions" or "chat.completions.stream".
+// Cardinality is bounded by UserStats's LRU cap; an empty user is
+// recorded under a shared anonymous label instead of creating a label
+// per anonymous request.
+func (m *Metrics) RecordUserMetrics(user, provider, model, op string, tokensIn, tokensOut, bytesIn, bytesOut int) {
+	m.Users.Record(user, provider, model, op, tokensIn, tokensOut, bytesIn, bytesOut)
+	m.ActiveUsers.Record(user)
+}
+
+// RecordStreamChunk records one streamed chunk received from provider/model.
+func (m *Metrics) RecordStreamChunk(provider, model string) {
+	m.ProviderStreamChunks.WithLabelValues(provider, model).Inc()
+}
+
+// RecordOAuthTokenRefresh records an OAuth token refresh attempt for
+// provider, with result "success" or "error".
+func (m *Metrics) RecordOAuthTokenRefresh(provider, result string) {
+	m.OAuthTokenRefreshTotal.WithLabelValues(provider, result).Inc()
+}
+
 // RecordCacheMetrics records cache hit/miss metrics
 func (m *Metrics) RecordCacheMetrics(cacheType string, hit bool) {
 	if hit {
@@ -263,6 +464,25 @@ func (m *Metrics) RecordCacheMetrics(cacheType string, hit bool) {
 	}
 }
 
+// RecordConfigReload records a credential hot-reload attempt for provider,
+// with result "success" or "error".
+func (m *Metrics) RecordConfigReload(provider, result string) {
+	m.ConfigReloadsTotal.WithLabelValues(provider, result).Inc()
+}
+
+// RecordCircuitState sets provider's current circuit breaker state gauge:
+// 0=closed, 1=half-open, 2=open.
+func (m *Metrics) RecordCircuitState(provider string, state float64) {
+	m.ProviderCircuitState.WithLabelValues(provider).Set(state)
+}
+
+// RecordCircuitTrip records that provider's circuit breaker just tripped
+// open, with reason identifying why (e.g. "error_rate", "half_open_failure",
+// or "auth").
+func (m *Metrics) RecordCircuitTrip(provider, reason string) {
+	m.ProviderCircuitTripsTotal.WithLabelValues(provider, reason).Inc()
+}
+
 // UpdateSystemMetrics updates system resource metrics
 func (m *Metrics) UpdateSystemMetrics(goroutines int, memoryMB float64, cpuPercent float64) {
 	m.GoRoutines.Set(float64(goroutines))
@@ -275,6 +495,23 @@ func Handler() gin.HandlerFunc {
 	return gin.WrapH(promhttp.Handler())
 }
 
+// CollectUserActivityMetrics starts a goroutine that calls
+// m.ActiveUsers.Advance on every tick of interval, keeping
+// aiproxy_active_users_1h/24h current (and, if persistence is
+// configured, saved to disk) even during a lull with no requests to
+// drive RecordUserMetrics. A one-minute interval matches the tracker's
+// own minute-bucket granularity.
+func (m *Metrics) CollectUserActivityMetrics(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			m.ActiveUsers.Advance()
+		}
+	}()
+}
+
 // CollectSystemMetrics starts a goroutine to collect system metrics
 func (m *Metrics) CollectSystemMetrics(interval time.Duration) {
 	go func() {
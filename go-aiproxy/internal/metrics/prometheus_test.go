@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestDumpDescriptors_MatchesGoldenFile guards against accidentally
+// breaking the metrics contract: a renamed/removed metric, changed help
+// text, removed label, or changed histogram buckets will all show up here
+// as a diff against testdata/metrics-dump.json. Update the golden file
+// (see the "metrics dump" CLI subcommand) in the same commit as any
+// intentional change to what NewMetrics registers.
+func TestDumpDescriptors_MatchesGoldenFile(t *testing.T) {
+	Default()
+
+	got, err := DumpDescriptors()
+	if err != nil {
+		t.Fatalf("DumpDescriptors: %v", err)
+	}
+
+	data, err := os.ReadFile("testdata/metrics-dump.json")
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	var want []Descriptor
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatalf("parse golden file: %v", err)
+	}
+
+	sortDescriptors(got)
+	sortDescriptors(want)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("registered metrics drifted from testdata/metrics-dump.json (update the golden file if this is intentional):\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+func sortDescriptors(d []Descriptor) {
+	sort.Slice(d, func(i, j int) bool { return d[i].Name < d[j].Name })
+}
@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// maxTrackedUsers bounds the number of distinct "user" label values
+// UserStats will ever create on the default Prometheus registry. Without
+// a cap, a flood of one-off or spoofed API keys would grow label
+// cardinality without bound; callers seen after the cap is reached are
+// folded into overflowUserLabel instead of being dropped, so aggregate
+// totals (and the /admin/usage billing export) stay correct either way.
+const maxTrackedUsers = 2000
+
+// anonUserLabel is recorded for requests UserStats can't attribute to a
+// caller (no API key, no OIDC claims).
+const anonUserLabel = "_anon_"
+
+// overflowUserLabel absorbs callers seen only after maxTrackedUsers
+// distinct users are already tracked.
+const overflowUserLabel = "_overflow_"
+
+// UserStats tracks per-caller usage for billing: request counts broken
+// down by user and op, plus token and byte counters broken down by user,
+// provider, and model. It mirrors RecordProviderMetrics's Prometheus-first
+// design but additionally keeps its own running totals per user, since
+// Prometheus counters can't be read back cheaply for the /admin/usage
+// endpoint.
+type UserStats struct {
+	requestsTotal *prometheus.CounterVec
+	inputTokens   *prometheus.CounterVec
+	outputTokens  *prometheus.CounterVec
+	inBytes       *prometheus.CounterVec
+	outBytes      *prometheus.CounterVec
+
+	mu     sync.Mutex
+	lru    *list.List
+	index  map[string]*list.Element
+	totals map[string]*UserUsage
+}
+
+// UserUsage is one user's accumulated counters, as returned by Snapshot
+// for the /admin/usage endpoint.
+type UserUsage struct {
+	RequestsTotal int64 `json:"requests_total"`
+	InputTokens   int64 `json:"input_tokens"`
+	OutputTokens  int64 `json:"output_tokens"`
+	InBytes       int64 `json:"in_bytes"`
+	OutBytes      int64 `json:"out_bytes"`
+}
+
+// newUserStats registers and returns the per-user metrics. Called once
+// from NewMetrics, like every other metric group.
+func newUserStats() *UserStats {
+	return &UserStats{
+		requestsTotal: newCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_user_requests_total",
+				Help: "Total requests per caller, by user and operation.",
+			},
+			[]string{"user", "op"},
+		),
+		inputTokens: newCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_user_input_tokens_total",
+				Help: "Total input (prompt) tokens per caller, by user, provider, and model.",
+			},
+			[]string{"user", "provider", "model"},
+		),
+		outputTokens: newCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_user_output_tokens_total",
+				Help: "Total output (completion) tokens per caller, by user, provider, and model.",
+			},
+			[]string{"user", "provider", "model"},
+		),
+		inBytes: newCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_user_in_bytes_total",
+				Help: "Total request body bytes per caller, by user, provider, and model.",
+			},
+			[]string{"user", "provider", "model"},
+		),
+		outBytes: newCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_user_out_bytes_total",
+				Help: "Total response body bytes per caller, by user, provider, and model.",
+			},
+			[]string{"user", "provider", "model"},
+		),
+		lru:    list.New(),
+		index:  make(map[string]*list.Element),
+		totals: make(map[string]*UserUsage),
+	}
+}
+
+// label resolves user to the Prometheus/Snapshot label Record will use:
+// anonUserLabel when empty, user itself while under maxTrackedUsers
+// distinct callers (refreshing its LRU position), otherwise
+// overflowUserLabel. Must be called with s.mu held.
+func (s *UserStats) label(user string) string {
+	if user == "" {
+		return anonUserLabel
+	}
+	if el, ok := s.index[user]; ok {
+		s.lru.MoveToFront(el)
+		return user
+	}
+	if len(s.index) < maxTrackedUsers {
+		s.index[user] = s.lru.PushFront(user)
+		return user
+	}
+	return overflowUserLabel
+}
+
+// Record accounts one request for user against provider/model/op. user is
+// the caller's opaque identity (see Server.callerLabel); provider and
+// model label the token/byte counters the same way RecordProviderMetrics
+// already does.
+func (s *UserStats) Record(user, provider, model, op string, tokensIn, tokensOut, bytesIn, bytesOut int) {
+	s.mu.Lock()
+	label := s.label(user)
+	totals, ok := s.totals[label]
+	if !ok {
+		totals = &UserUsage{}
+		s.totals[label] = totals
+	}
+	totals.RequestsTotal++
+	totals.InputTokens += int64(tokensIn)
+	totals.OutputTokens += int64(tokensOut)
+	totals.InBytes += int64(bytesIn)
+	totals.OutBytes += int64(bytesOut)
+	s.mu.Unlock()
+
+	s.requestsTotal.WithLabelValues(label, op).Inc()
+	if tokensIn > 0 {
+		s.inputTokens.WithLabelValues(label, provider, model).Add(float64(tokensIn))
+	}
+	if tokensOut > 0 {
+		s.outputTokens.WithLabelValues(label, provider, model).Add(float64(tokensOut))
+	}
+	if bytesIn > 0 {
+		s.inBytes.WithLabelValues(label, provider, model).Add(float64(bytesIn))
+	}
+	if bytesOut > 0 {
+		s.outBytes.WithLabelValues(label, provider, model).Add(float64(bytesOut))
+	}
+}
+
+// Snapshot returns a copy of every tracked user's accumulated totals, for
+// the /admin/usage endpoint.
+func (s *UserStats) Snapshot() map[string]UserUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]UserUsage, len(s.totals))
+	for user, usage := range s.totals {
+		out[user] = *usage
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"crypto/x509"
+	"net/url"
+	"testing"
+)
+
+func TestSpiffeIDFromCert(t *testing.T) {
+	matching, _ := url.Parse("spiffe://example.org/ns/default/sa/worker")
+	other, _ := url.Parse("spiffe://other.org/ns/default/sa/worker")
+
+	tests := []struct {
+		name        string
+		uris        []*url.URL
+		trustDomain string
+		wantID      string
+		wantErr     bool
+	}{
+		{"matches", []*url.URL{matching}, "example.org", "spiffe://example.org/ns/default/sa/worker", false},
+		{"wrong domain", []*url.URL{other}, "example.org", "", true},
+		{"no uri sans", nil, "example.org", "", true},
+		{"trailing slash in trust domain", []*url.URL{matching}, "example.org/", "spiffe://example.org/ns/default/sa/worker", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := &x509.Certificate{URIs: tt.uris}
+			id, err := spiffeIDFromCert(cert, tt.trustDomain)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("spiffeIDFromCert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if id != tt.wantID {
+				t.Errorf("spiffeIDFromCert() = %v, want %v", id, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestMTLSAuth_NoPeerCertificate(t *testing.T) {
+	handler := MTLSAuth(MTLSConfig{})
+	if handler == nil {
+		t.Fatal("MTLSAuth() returned nil handler")
+	}
+}
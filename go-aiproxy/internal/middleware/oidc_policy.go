@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// CallerPolicy is what an authenticated OIDC caller is allowed to do:
+// their own rate limits (enforced via ratelimit.Limiter.AllowCaller,
+// separate from the provider-level limits Limiter.Allow already
+// enforces) and which providers/models they may route requests to. An
+// empty AllowedProviders/AllowedModels means "no restriction" in that
+// dimension, so a policy can restrict just one of the two.
+type CallerPolicy struct {
+	RateLimitRPM     int      `json:"rate_limit_rpm"`
+	RateLimitTPM     int      `json:"rate_limit_tpm"`
+	AllowedProviders []string `json:"allowed_providers,omitempty"`
+	AllowedModels    []string `json:"allowed_models,omitempty"`
+}
+
+// AllowsProvider reports whether provider is permitted by p.
+func (p CallerPolicy) AllowsProvider(provider string) bool {
+	return len(p.AllowedProviders) == 0 || contains(p.AllowedProviders, provider)
+}
+
+// AllowsModel reports whether model is permitted by p.
+func (p CallerPolicy) AllowsModel(model string) bool {
+	return len(p.AllowedModels) == 0 || contains(p.AllowedModels, model)
+}
+
+// CallerPolicies maps OIDC claims to a CallerPolicy. Resolve checks
+// BySubject first (the most specific, since "sub" is unique per caller),
+// then ByEmail, then the first matching entry in ByGroup, falling back to
+// Default when nothing matches.
+type CallerPolicies struct {
+	BySubject map[string]CallerPolicy `json:"by_subject,omitempty"`
+	ByEmail   map[string]CallerPolicy `json:"by_email,omitempty"`
+	ByGroup   map[string]CallerPolicy `json:"by_group,omitempty"`
+	Default   *CallerPolicy           `json:"default,omitempty"`
+}
+
+// Resolve looks up claims against p, returning ok=false only when nothing
+// matched and no Default policy is configured.
+func (p *CallerPolicies) Resolve(claims Claims) (CallerPolicy, bool) {
+	if policy, ok := p.BySubject[claims.Subject]; ok {
+		return policy, true
+	}
+	if claims.Email != "" {
+		if policy, ok := p.ByEmail[claims.Email]; ok {
+			return policy, true
+		}
+	}
+	for _, group := range claims.Groups {
+		if policy, ok := p.ByGroup[group]; ok {
+			return policy, true
+		}
+	}
+	if p.Default != nil {
+		return *p.Default, true
+	}
+	return CallerPolicy{}, false
+}
+
+// LoadCallerPolicies reads a CallerPolicies JSON document from path.
+func LoadCallerPolicies(path string) (*CallerPolicies, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read caller policies file: %w", err)
+	}
+	var policies CallerPolicies
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("failed to parse caller policies file: %w", err)
+	}
+	return &policies, nil
+}
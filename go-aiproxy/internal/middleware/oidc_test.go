@@ -0,0 +1,56 @@
+package middleware
+
+import "testing"
+
+func TestHasRequiredScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    string
+		required []string
+		want     bool
+	}{
+		{"no requirements", "read write", nil, true},
+		{"all present", "read write admin", []string{"read", "admin"}, true},
+		{"missing one", "read write", []string{"read", "admin"}, false},
+		{"empty scope claim", "", []string{"read"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasRequiredScopes(tt.scope, tt.required); got != tt.want {
+				t.Errorf("hasRequiredScopes(%q, %v) = %v, want %v", tt.scope, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnyContains(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		have    []string
+		want    bool
+	}{
+		{"match", []string{"admins", "ops"}, []string{"users", "ops"}, true},
+		{"no match", []string{"admins"}, []string{"users", "ops"}, false},
+		{"empty have", []string{"admins"}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := anyContains(tt.allowed, tt.have); got != tt.want {
+				t.Errorf("anyContains(%v, %v) = %v, want %v", tt.allowed, tt.have, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAuthMiddleware_FallsBackToAPIKey(t *testing.T) {
+	handler, err := NewAuthMiddleware("secret", nil)
+	if err != nil {
+		t.Fatalf("NewAuthMiddleware returned error: %v", err)
+	}
+	if handler == nil {
+		t.Fatal("expected a non-nil handler")
+	}
+}
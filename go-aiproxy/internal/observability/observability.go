@@ -0,0 +1,218 @@
+// Package observability instruments the request path with Prometheus
+// metrics and OpenTelemetry tracing: HTTP-level counters/histograms in
+// Metrics, and span/traceparent propagation to upstream providers via
+// StartSpan/Inject. It is intentionally separate from internal/metrics
+// (which backs EnhancedServer's dashboard-oriented metrics under different
+// names) since base Server has no dependency on EnhancedServer and the two
+// metric sets would otherwise collide on the default Prometheus registry.
+package observability
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation library name every span in this
+// package is recorded under.
+const tracerName = "github.com/aiproxy/go-aiproxy"
+
+// Metrics holds the Prometheus collectors instrumenting the request path.
+// Names are namespaced with aiproxy_ like every other metric this repo
+// registers.
+type Metrics struct {
+	RequestsTotal    *prometheus.CounterVec
+	RequestDuration  *prometheus.HistogramVec
+	StreamTTFB       *prometheus.HistogramVec
+	TokensTotal      *prometheus.CounterVec
+	CacheHitsTotal   *prometheus.CounterVec
+	ErrorsTotal      *prometheus.CounterVec
+}
+
+// NewMetrics registers and returns the request-path metrics. Call once per
+// process; promauto panics on a duplicate registration.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		RequestsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_requests_total",
+				Help: "Total completion requests handled, by provider, model, and outcome.",
+			},
+			[]string{"provider", "model", "status"},
+		),
+		RequestDuration: promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "aiproxy_request_duration_seconds",
+				Help:    "End-to-end completion request duration in seconds.",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"provider", "model"},
+		),
+		StreamTTFB: promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "aiproxy_stream_ttfb_seconds",
+				Help:    "Time to first streamed chunk, in seconds.",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"provider", "model"},
+		),
+		TokensTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_tokens_total",
+				Help: "Tokens processed, by direction (prompt or completion).",
+			},
+			[]string{"direction"},
+		),
+		CacheHitsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_cache_lookup_hits_total",
+				Help: "Cache lookups that were hits, by cache kind (exact, semantic, stream).",
+			},
+			[]string{"kind"},
+		),
+		ErrorsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_errors_total",
+				Help: "Errors surfaced to callers, by taxonomy code (see pkg/models.ErrorCode).",
+			},
+			[]string{"code"},
+		),
+	}
+}
+
+// RecordError increments ErrorsTotal for code, e.g. every time the HTTP
+// layer translates a *models.APIError into a response.
+func (m *Metrics) RecordError(code string) {
+	m.ErrorsTotal.WithLabelValues(code).Inc()
+}
+
+// Handler serves the Prometheus default registry, matching the rest of
+// this repo's /metrics endpoints.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// TracingOptions configures InitTracing's exporter, resource, and sampler.
+type TracingOptions struct {
+	// Endpoint is the OTLP/HTTP collector host:port (no scheme); empty
+	// leaves tracing as the package-level no-op otel defaults to.
+	Endpoint string
+
+	// ServiceName is the service.name resource attribute exported spans
+	// carry; defaults to "go-aiproxy" when empty.
+	ServiceName string
+
+	// Sampler selects the root sampler: "always_on" (default), "always_off",
+	// or a ratio like "0.1" for a TraceIDRatioBased sampler of that ratio.
+	Sampler string
+
+	// Headers are sent with every OTLP export request, e.g. a collector
+	// authentication token.
+	Headers map[string]string
+}
+
+// InitTracing configures the global OpenTelemetry tracer provider per opts
+// and installs the W3C traceparent propagator used by StartSpan/Inject.
+// When opts.Endpoint is empty, tracing is left as the package-level no-op
+// implementation otel defaults to, so calling StartSpan is always safe
+// even if the operator never configured an exporter. The returned shutdown
+// func flushes and closes the exporter; callers should defer it.
+func InitTracing(ctx context.Context, opts TracingOptions) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	serviceName := opts.ServiceName
+	if serviceName == "" {
+		serviceName = "go-aiproxy"
+	}
+
+	exporterOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(opts.Endpoint), otlptracehttp.WithInsecure()}
+	if len(opts.Headers) > 0 {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithHeaders(opts.Headers))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(samplerFromConfig(opts.Sampler))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+// samplerFromConfig parses the --otel-sampler value into a root Sampler:
+// "always_off" never samples, a bare ratio like "0.1" is TraceIDRatioBased,
+// and everything else (including "always_on" and "") always samples.
+func samplerFromConfig(sampler string) sdktrace.Sampler {
+	switch sampler {
+	case "always_off":
+		return sdktrace.NeverSample()
+	case "", "always_on":
+		return sdktrace.AlwaysSample()
+	default:
+		if ratio, err := strconv.ParseFloat(sampler, 64); err == nil {
+			return sdktrace.TraceIDRatioBased(ratio)
+		}
+		return sdktrace.AlwaysSample()
+	}
+}
+
+// StartSpan starts a span named name under this package's tracer, using
+// whatever tracer provider is currently installed (the real OTLP one after
+// InitTracing, or otel's no-op default otherwise).
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Inject writes ctx's span context into header as a traceparent (and
+// tracestate, if set), so an outgoing provider request continues the same
+// trace.
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// RecordTokens adds count to TokensTotal under direction ("prompt" or
+// "completion"). A nil Metrics is a no-op so callers can skip a nil check
+// when observability is disabled.
+func (m *Metrics) RecordTokens(direction string, count int) {
+	if m == nil || count <= 0 {
+		return
+	}
+	m.TokensTotal.WithLabelValues(direction).Add(float64(count))
+}
+
+// RecordCacheHit increments CacheHitsTotal for kind when hit is true. A nil
+// Metrics is a no-op.
+func (m *Metrics) RecordCacheHit(kind string, hit bool) {
+	if m == nil || !hit {
+		return
+	}
+	m.CacheHitsTotal.WithLabelValues(kind).Inc()
+}
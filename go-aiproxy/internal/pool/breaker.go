@@ -0,0 +1,356 @@
+package pool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+// BreakerState is one of the three classic circuit-breaker states.
+type BreakerState int
+
+const (
+	// BreakerClosed passes every request through and records outcomes.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects every request until the current cooldown elapses
+	// (OpenDuration, doubled on repeated trips, or AuthFailureCooldown).
+	BreakerOpen
+	// BreakerHalfOpen allows a single trial request through to decide
+	// whether to close (on success) or reopen (on failure).
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// outcome is one sliding-window sample.
+type outcome struct {
+	at      time.Time
+	failed  bool
+	latency time.Duration
+}
+
+// CircuitBreaker trips open when a provider's error rate over a sliding
+// time window (counting both request failures and responses that miss
+// LatencySLO as "bad") crosses ErrorRateThreshold, so server.handleChatCompletions
+// can stop sending traffic to a provider that's failing or degraded
+// without waiting for the pool's slower ErrorCount-based health check.
+type CircuitBreaker struct {
+	ErrorRateThreshold float64
+	MinRequests        int
+	Window             time.Duration
+	OpenDuration       time.Duration
+	LatencySLO         time.Duration
+
+	// MaxCooldown caps how long repeated trips can back the breaker off
+	// to: each trip without an intervening successful close doubles the
+	// previous cooldown, up to this ceiling. <= 0 disables both the
+	// doubling and the cap, keeping every trip at a flat OpenDuration -
+	// the original behavior, still exercised by tests that build a
+	// CircuitBreaker literal without setting it.
+	MaxCooldown time.Duration
+
+	// AuthFailureCooldown is the cooldown used when RecordFailure sees a
+	// non-retryable *models.APIError (e.g. rejected credentials) and
+	// trips immediately instead of waiting on the sliding window. <= 0
+	// falls back to OpenDuration. This is normally set much longer than
+	// OpenDuration, since retrying the same bad credentials a few
+	// seconds later is no more likely to succeed.
+	AuthFailureCooldown time.Duration
+
+	mu               sync.Mutex
+	state            BreakerState
+	samples          []outcome
+	openedAt         time.Time
+	cooldown         time.Duration
+	consecutiveOpens int
+	halfOpenInFlight bool
+	onStateChange    func(from, to BreakerState)
+	onTrip           func(reason string)
+}
+
+// DefaultCircuitBreaker returns a breaker that trips when at least 10
+// requests land in a 30s window with a >=50% bad rate (errors or
+// responses slower than a 5s SLO), staying open for 30s before probing
+// again with a single half-open trial, doubling its cooldown on each
+// repeated trip up to a 5 minute ceiling, and trapping a rejected-auth
+// failure into a 2 minute cooldown immediately rather than waiting on
+// the window.
+func DefaultCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		ErrorRateThreshold:  0.5,
+		MinRequests:         10,
+		Window:              30 * time.Second,
+		OpenDuration:        30 * time.Second,
+		LatencySLO:          5 * time.Second,
+		MaxCooldown:         5 * time.Minute,
+		AuthFailureCooldown: 2 * time.Minute,
+	}
+}
+
+// Allow reports whether a request may proceed. In BreakerOpen it returns
+// false until the cooldown set by the last trip (see nextCooldown and
+// tripTerminal) has elapsed, at which point it transitions to
+// BreakerHalfOpen and allows exactly one trial request through.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerHalfOpen:
+		if b.halfOpenInFlight {
+			return false
+		}
+		b.halfOpenInFlight = true
+		return true
+	default: // BreakerOpen
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.halfOpenInFlight = true
+		b.notify(BreakerOpen, BreakerHalfOpen)
+		return true
+	}
+}
+
+// RecordSuccess records a successful request's latency. Equivalent to
+// RecordResult(nil, latency).
+func (b *CircuitBreaker) RecordSuccess(latency time.Duration) {
+	b.RecordResult(nil, latency)
+}
+
+// RecordFailure records a failed request's outcome. A *models.APIError
+// with Retryable == false (e.g. rejected credentials) trips the breaker
+// immediately with AuthFailureCooldown rather than waiting for the sliding
+// window to accumulate enough bad samples - retrying the same credentials
+// against the same provider a dozen more times before giving up would just
+// multiply the failed auth calls. Any other error, including a
+// *models.APIError with Retryable == true such as a 5xx or timeout, counts
+// toward the window the same way RecordResult always has.
+func (b *CircuitBreaker) RecordFailure(err error, latency time.Duration) {
+	if apiErr, ok := err.(*models.APIError); ok && !apiErr.Retryable {
+		b.mu.Lock()
+		b.tripTerminal()
+		b.mu.Unlock()
+		return
+	}
+	b.RecordResult(err, latency)
+}
+
+// RecordResult records the outcome of a request this breaker allowed,
+// evaluating whether to trip open, close, or stay as-is.
+func (b *CircuitBreaker) RecordResult(err error, latency time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bad := err != nil || latency > b.LatencySLO
+
+	if b.state == BreakerHalfOpen {
+		b.halfOpenInFlight = false
+		if bad {
+			b.trip("half_open_failure")
+		} else {
+			b.reset()
+		}
+		return
+	}
+
+	now := time.Now()
+	b.samples = append(b.samples, outcome{at: now, failed: bad, latency: latency})
+	b.prune(now)
+
+	if len(b.samples) < b.MinRequests {
+		return
+	}
+
+	failures := 0
+	for _, s := range b.samples {
+		if s.failed {
+			failures++
+		}
+	}
+	if float64(failures)/float64(len(b.samples)) >= b.ErrorRateThreshold {
+		b.trip("error_rate")
+	}
+}
+
+// trip moves the breaker to BreakerOpen with the next exponential-backoff
+// cooldown. reason identifies why it tripped (e.g. "error_rate",
+// "half_open_failure") and is passed to onTrip. Callers must hold b.mu.
+func (b *CircuitBreaker) trip(reason string) {
+	from := b.state
+	b.consecutiveOpens++
+	b.cooldown = b.nextCooldown()
+	b.state = BreakerOpen
+	b.openedAt = time.Now()
+	b.samples = nil
+	b.halfOpenInFlight = false
+	b.notify(from, BreakerOpen)
+	b.notifyTrip(reason)
+}
+
+// tripTerminal moves the breaker to BreakerOpen immediately, bypassing the
+// sliding window, with AuthFailureCooldown (falling back to OpenDuration if
+// unset) instead of the usual exponential backoff. Callers must hold b.mu.
+func (b *CircuitBreaker) tripTerminal() {
+	from := b.state
+	b.consecutiveOpens++
+
+	d := b.AuthFailureCooldown
+	if d <= 0 {
+		d = b.OpenDuration
+	}
+	if b.MaxCooldown > 0 && d > b.MaxCooldown {
+		d = b.MaxCooldown
+	}
+
+	b.cooldown = d
+	b.state = BreakerOpen
+	b.openedAt = time.Now()
+	b.samples = nil
+	b.halfOpenInFlight = false
+	b.notify(from, BreakerOpen)
+	b.notifyTrip("auth")
+}
+
+// nextCooldown returns how long the breaker should stay open this time:
+// OpenDuration doubled once per trip since the last successful close,
+// capped at MaxCooldown. MaxCooldown <= 0 disables both the doubling and
+// the cap, keeping a flat OpenDuration every time. Callers must hold b.mu.
+func (b *CircuitBreaker) nextCooldown() time.Duration {
+	if b.MaxCooldown <= 0 {
+		return b.OpenDuration
+	}
+
+	d := b.OpenDuration
+	for i := 1; i < b.consecutiveOpens; i++ {
+		d *= 2
+		if d >= b.MaxCooldown {
+			return b.MaxCooldown
+		}
+	}
+	if d > b.MaxCooldown {
+		return b.MaxCooldown
+	}
+	return d
+}
+
+// reset moves the breaker back to BreakerClosed with a clean window and
+// resets the backoff that trip/tripTerminal accumulated. Callers must hold
+// b.mu.
+func (b *CircuitBreaker) reset() {
+	from := b.state
+	b.state = BreakerClosed
+	b.samples = nil
+	b.halfOpenInFlight = false
+	b.consecutiveOpens = 0
+	b.notify(from, BreakerClosed)
+}
+
+// notify invokes onStateChange if one is set and the state actually
+// changed. Called with b.mu held; the callback must not call back into b.
+func (b *CircuitBreaker) notify(from, to BreakerState) {
+	if from == to || b.onStateChange == nil {
+		return
+	}
+	b.onStateChange(from, to)
+}
+
+// notifyTrip invokes onTrip if one is set, with the reason the breaker just
+// tripped open. Called with b.mu held; the callback must not call back into
+// b.
+func (b *CircuitBreaker) notifyTrip(reason string) {
+	if b.onTrip == nil {
+		return
+	}
+	b.onTrip(reason)
+}
+
+// State reports the breaker's current state.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// SetOnStateChange installs fn to be called, synchronously and with b.mu
+// held, on every state transition - e.g. to increment a Prometheus counter
+// keyed by (from, to). fn must not call back into b.
+func (b *CircuitBreaker) SetOnStateChange(fn func(from, to BreakerState)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onStateChange = fn
+}
+
+// SetOnTrip installs fn to be called, synchronously and with b.mu held,
+// every time the breaker transitions to BreakerOpen, with reason
+// identifying why ("error_rate", "half_open_failure", or "auth") - e.g. to
+// increment a Prometheus counter keyed by (provider, reason). fn must not
+// call back into b.
+func (b *CircuitBreaker) SetOnTrip(fn func(reason string)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onTrip = fn
+}
+
+// prune drops samples older than Window. Callers must hold b.mu.
+func (b *CircuitBreaker) prune(now time.Time) {
+	cutoff := now.Add(-b.Window)
+	i := 0
+	for ; i < len(b.samples); i++ {
+		if b.samples[i].at.After(cutoff) {
+			break
+		}
+	}
+	b.samples = b.samples[i:]
+}
+
+// BreakerStats is a point-in-time snapshot of a CircuitBreaker, suitable
+// for JSON serialization by the /admin/providers endpoint.
+type BreakerStats struct {
+	State            string     `json:"state"`
+	Requests         int        `json:"requests_in_window"`
+	ErrorRate        float64    `json:"error_rate"`
+	OpenedAt         *time.Time `json:"opened_at,omitempty"`
+	ConsecutiveOpens int        `json:"consecutive_opens,omitempty"`
+}
+
+// Stats returns a snapshot of the breaker's current state.
+func (b *CircuitBreaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := BreakerStats{
+		State:            b.state.String(),
+		Requests:         len(b.samples),
+		ConsecutiveOpens: b.consecutiveOpens,
+	}
+	if len(b.samples) > 0 {
+		failures := 0
+		for _, s := range b.samples {
+			if s.failed {
+				failures++
+			}
+		}
+		stats.ErrorRate = float64(failures) / float64(len(b.samples))
+	}
+	if b.state == BreakerOpen || b.state == BreakerHalfOpen {
+		opened := b.openedAt
+		stats.OpenedAt = &opened
+	}
+	return stats
+}
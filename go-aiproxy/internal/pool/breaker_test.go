@@ -0,0 +1,297 @@
+package pool
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+func TestCircuitBreaker_TripsOnErrorRate(t *testing.T) {
+	b := &CircuitBreaker{
+		ErrorRateThreshold: 0.5,
+		MinRequests:        4,
+		Window:             time.Minute,
+		OpenDuration:       time.Minute,
+		LatencySLO:         time.Second,
+	}
+
+	results := []error{nil, nil, errors.New("boom"), errors.New("boom")}
+	for i, err := range results {
+		if !b.Allow() {
+			t.Fatalf("request %d should be allowed while closed", i)
+		}
+		b.RecordResult(err, 0)
+	}
+
+	if b.state != BreakerOpen {
+		t.Fatalf("state = %v, want BreakerOpen once 2/4 failures hits the 50%% threshold", b.state)
+	}
+}
+
+func TestCircuitBreaker_StaysClosedBelowThreshold(t *testing.T) {
+	b := &CircuitBreaker{
+		ErrorRateThreshold: 0.5,
+		MinRequests:        4,
+		Window:             time.Minute,
+		OpenDuration:       time.Minute,
+		LatencySLO:         time.Second,
+	}
+
+	for i := 0; i < 4; i++ {
+		b.Allow()
+		b.RecordResult(nil, 0)
+	}
+
+	if b.state != BreakerClosed {
+		t.Fatalf("state = %v, want BreakerClosed with 0%% error rate", b.state)
+	}
+}
+
+func TestCircuitBreaker_LatencyAboveSLOCountsAsBad(t *testing.T) {
+	b := &CircuitBreaker{
+		ErrorRateThreshold: 0.5,
+		MinRequests:        2,
+		Window:             time.Minute,
+		OpenDuration:       time.Minute,
+		LatencySLO:         10 * time.Millisecond,
+	}
+
+	b.Allow()
+	b.RecordResult(nil, 0)
+	b.Allow()
+	b.RecordResult(nil, 50*time.Millisecond)
+
+	if b.state != BreakerOpen {
+		t.Fatalf("state = %v, want BreakerOpen when latency exceeds SLO half the time", b.state)
+	}
+}
+
+func TestCircuitBreaker_OpenRejectsUntilOpenDurationElapses(t *testing.T) {
+	b := &CircuitBreaker{
+		ErrorRateThreshold: 0.5,
+		MinRequests:        1,
+		Window:             time.Minute,
+		OpenDuration:       20 * time.Millisecond,
+		LatencySLO:         time.Second,
+	}
+
+	b.Allow()
+	b.RecordResult(errors.New("boom"), 0)
+	if b.Allow() {
+		t.Fatal("breaker should reject immediately after tripping open")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("breaker should allow a half-open trial once OpenDuration elapses")
+	}
+	if b.state != BreakerHalfOpen {
+		t.Fatalf("state = %v, want BreakerHalfOpen after the trial is let through", b.state)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenSuccessCloses(t *testing.T) {
+	b := &CircuitBreaker{
+		ErrorRateThreshold: 0.5,
+		MinRequests:        1,
+		Window:             time.Minute,
+		OpenDuration:       time.Millisecond,
+		LatencySLO:         time.Second,
+	}
+
+	b.Allow()
+	b.RecordResult(errors.New("boom"), 0)
+	time.Sleep(5 * time.Millisecond)
+
+	if !b.Allow() {
+		t.Fatal("expected a half-open trial to be allowed")
+	}
+	b.RecordResult(nil, 0)
+
+	if b.state != BreakerClosed {
+		t.Fatalf("state = %v, want BreakerClosed after a successful half-open trial", b.state)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenFailureReopens(t *testing.T) {
+	b := &CircuitBreaker{
+		ErrorRateThreshold: 0.5,
+		MinRequests:        1,
+		Window:             time.Minute,
+		OpenDuration:       time.Millisecond,
+		LatencySLO:         time.Second,
+	}
+
+	b.Allow()
+	b.RecordResult(errors.New("boom"), 0)
+	time.Sleep(5 * time.Millisecond)
+
+	b.Allow()
+	b.RecordResult(errors.New("still failing"), 0)
+
+	if b.state != BreakerOpen {
+		t.Fatalf("state = %v, want BreakerOpen after a failed half-open trial", b.state)
+	}
+	if b.Allow() {
+		t.Fatal("breaker should reject again immediately after the half-open trial fails")
+	}
+}
+
+func TestCircuitBreaker_ExponentialBackoffCapsAtMaxCooldown(t *testing.T) {
+	b := &CircuitBreaker{
+		ErrorRateThreshold: 0.5,
+		MinRequests:        1,
+		Window:             time.Minute,
+		OpenDuration:       10 * time.Millisecond,
+		MaxCooldown:        35 * time.Millisecond,
+		LatencySLO:         time.Second,
+	}
+
+	b.Allow()
+	b.RecordResult(errors.New("boom"), 0) // 1st open: 10ms
+
+	time.Sleep(15 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("expected half-open trial after the first 10ms cooldown")
+	}
+	b.RecordResult(errors.New("still failing"), 0) // 2nd open: 20ms
+
+	time.Sleep(15 * time.Millisecond)
+	if b.Allow() {
+		t.Fatal("expected the doubled 20ms cooldown to still be in effect after 15ms")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("expected half-open trial once the doubled 20ms cooldown elapses")
+	}
+	b.RecordResult(errors.New("still failing"), 0) // 3rd open: would be 40ms, capped to 35ms
+
+	time.Sleep(37 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("expected cooldown capped at MaxCooldown (35ms), not left to grow to 40ms")
+	}
+}
+
+func TestCircuitBreaker_SuccessfulCloseResetsBackoff(t *testing.T) {
+	b := &CircuitBreaker{
+		ErrorRateThreshold: 0.5,
+		MinRequests:        1,
+		Window:             time.Minute,
+		OpenDuration:       10 * time.Millisecond,
+		MaxCooldown:        time.Minute,
+		LatencySLO:         time.Second,
+	}
+
+	b.Allow()
+	b.RecordResult(errors.New("boom"), 0)
+	time.Sleep(15 * time.Millisecond)
+	b.Allow()
+	b.RecordResult(nil, 0) // half-open success closes and resets consecutiveOpens
+
+	b.Allow()
+	b.RecordResult(errors.New("boom again"), 0)
+	if b.Stats().ConsecutiveOpens != 1 {
+		t.Fatalf("ConsecutiveOpens = %d, want 1 after backoff reset by the earlier successful close", b.Stats().ConsecutiveOpens)
+	}
+}
+
+func TestCircuitBreaker_RecordFailureTripsImmediatelyOnNonRetryableAPIError(t *testing.T) {
+	b := &CircuitBreaker{
+		ErrorRateThreshold:  0.5,
+		MinRequests:         100, // would never trip via the window within this test
+		Window:              time.Minute,
+		OpenDuration:        time.Millisecond,
+		AuthFailureCooldown: time.Hour,
+		LatencySLO:          time.Second,
+	}
+
+	b.Allow()
+	b.RecordFailure(&models.APIError{Code: models.ErrProviderAuth, Retryable: false}, 0)
+
+	if b.State() != BreakerOpen {
+		t.Fatalf("state = %v, want BreakerOpen immediately after a non-retryable auth error", b.State())
+	}
+	if b.Allow() {
+		t.Fatal("breaker should stay open for AuthFailureCooldown, not OpenDuration")
+	}
+}
+
+func TestCircuitBreaker_RecordFailureCountsRetryableErrorTowardWindow(t *testing.T) {
+	b := &CircuitBreaker{
+		ErrorRateThreshold: 0.5,
+		MinRequests:        2,
+		Window:             time.Minute,
+		OpenDuration:       time.Minute,
+		LatencySLO:         time.Second,
+	}
+
+	b.Allow()
+	b.RecordFailure(&models.APIError{Code: models.ErrUpstreamTimeout, Retryable: true}, 0)
+	if b.State() != BreakerClosed {
+		t.Fatalf("state = %v, want BreakerClosed below MinRequests", b.State())
+	}
+
+	b.Allow()
+	b.RecordFailure(&models.APIError{Code: models.ErrUpstreamTimeout, Retryable: true}, 0)
+	if b.State() != BreakerOpen {
+		t.Fatalf("state = %v, want BreakerOpen once 2/2 window failures hit the threshold", b.State())
+	}
+}
+
+func TestCircuitBreaker_OnStateChangeCallback(t *testing.T) {
+	b := &CircuitBreaker{
+		ErrorRateThreshold: 0.5,
+		MinRequests:        1,
+		Window:             time.Minute,
+		OpenDuration:       time.Minute,
+		LatencySLO:         time.Second,
+	}
+
+	var transitions []string
+	b.SetOnStateChange(func(from, to BreakerState) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	})
+
+	b.Allow()
+	b.RecordResult(errors.New("boom"), 0)
+
+	if len(transitions) != 1 || transitions[0] != "closed->open" {
+		t.Fatalf("transitions = %v, want [\"closed->open\"]", transitions)
+	}
+}
+
+func TestManager_BreakerStateAndRecordHelpers(t *testing.T) {
+	configFile := createTestConfig(t)
+	defer os.Remove(configFile)
+
+	var lastTransition string
+	manager, err := NewManager(
+		configFile,
+		WithBreakerCooldown(time.Millisecond),
+		WithBreakerMaxCooldown(time.Minute),
+		WithBreakerMinRequests(2),
+		WithBreakerStateChangeCallback(func(providerUUID string, from, to BreakerState) {
+			lastTransition = providerUUID + ":" + from.String() + "->" + to.String()
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	if manager.BreakerState("some-uuid") != BreakerClosed {
+		t.Error("expected a never-seen provider's breaker to start closed")
+	}
+
+	manager.RecordSuccess("some-uuid", time.Millisecond)
+	manager.RecordFailure("some-uuid", errors.New("boom"), 0)
+
+	if manager.BreakerState("some-uuid") != BreakerOpen {
+		t.Fatalf("expected breaker to trip open, got %v", manager.BreakerState("some-uuid"))
+	}
+	if lastTransition != "some-uuid:closed->open" {
+		t.Errorf("lastTransition = %q, want \"some-uuid:closed->open\"", lastTransition)
+	}
+}
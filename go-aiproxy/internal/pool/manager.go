@@ -1,31 +1,63 @@
 package pool
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"sync"
 	"time"
 
+	"github.com/aiproxy/go-aiproxy/internal/backoff"
+	"github.com/aiproxy/go-aiproxy/internal/providers"
 	"github.com/aiproxy/go-aiproxy/pkg/models"
+	"github.com/fsnotify/fsnotify"
 )
 
 // Manager manages pools of providers
 type Manager struct {
 	mu                  sync.RWMutex
 	pools               map[string][]*models.ProviderConfig
-	roundRobinIndex     map[string]int
+	strategy            SelectionStrategy
+	poolStrategies      map[string]SelectionStrategy
 	maxErrorCount       int
 	healthCheckInterval time.Duration
+	backoff             backoff.Backoff
+	instances           map[string]providers.Provider
+	reattached          map[string]bool
+	breakersMu          sync.Mutex
+	breakers            map[string]*CircuitBreaker
+
+	breakerCooldown      time.Duration
+	breakerMaxCooldown   time.Duration
+	breakerAuthCooldown  time.Duration
+	breakerMinRequests   int
+	onBreakerStateChange func(providerUUID string, from, to BreakerState)
+
+	// configFile is the path LoadConfig last read from, reused by Reload
+	// and startConfigWatcher.
+	configFile string
+	// watchConfig is set by WithConfigWatcher; startConfigWatcher only
+	// runs when it's true.
+	watchConfig bool
+	// configWatcher is non-nil once startConfigWatcher has wired up an
+	// fsnotify watcher on configFile.
+	configWatcher *fsnotify.Watcher
+	// onReload is the callback installed by OnReload, invoked after a
+	// Reload that actually changed the pool config.
+	onReload func(added, removed, changed []string)
 }
 
 // NewManager creates a new pool manager
 func NewManager(configFile string, options ...Option) (*Manager, error) {
 	m := &Manager{
 		pools:               make(map[string][]*models.ProviderConfig),
-		roundRobinIndex:     make(map[string]int),
+		strategy:            NewRoundRobinStrategy(),
+		poolStrategies:      make(map[string]SelectionStrategy),
 		maxErrorCount:       3,
 		healthCheckInterval: 30 * time.Minute,
+		backoff:             backoff.Default(),
+		instances:           make(map[string]providers.Provider),
+		reattached:          make(map[string]bool),
+		breakers:            make(map[string]*CircuitBreaker),
 	}
 
 	// Apply options
@@ -38,6 +70,19 @@ func NewManager(configFile string, options ...Option) (*Manager, error) {
 		return nil, err
 	}
 
+	// Attach to any externally-managed provider backends named via
+	// AIPROXY_REATTACH_PROVIDERS before starting health checks, so they
+	// never go through normal credential/keepalive setup.
+	if err := m.loadReattachFromEnv(); err != nil {
+		return nil, err
+	}
+
+	if m.watchConfig {
+		if err := m.startConfigWatcher(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Start health check routine
 	go m.healthCheckLoop()
 
@@ -61,32 +106,104 @@ func WithHealthCheckInterval(interval time.Duration) Option {
 	}
 }
 
-// LoadConfig loads provider pools from a JSON file
+// WithBackoff sets the exponential backoff policy used to schedule health
+// probes for unhealthy providers.
+func WithBackoff(b backoff.Backoff) Option {
+	return func(m *Manager) {
+		m.backoff = b
+	}
+}
+
+// WithStrategy sets the default SelectionStrategy used by SelectProvider
+// for any pool without a WithPoolStrategy override. Defaults to
+// NewRoundRobinStrategy(), matching Manager's original behavior.
+func WithStrategy(strategy SelectionStrategy) Option {
+	return func(m *Manager) {
+		m.strategy = strategy
+	}
+}
+
+// WithPoolStrategy overrides the selection strategy for a single pool name,
+// leaving every other pool on the default strategy.
+func WithPoolStrategy(name string, strategy SelectionStrategy) Option {
+	return func(m *Manager) {
+		m.poolStrategies[name] = strategy
+	}
+}
+
+// WithBreakerCooldown overrides how long a newly-created circuit breaker
+// stays open before its first half-open probe (CircuitBreaker.OpenDuration),
+// in place of DefaultCircuitBreaker's 30s.
+func WithBreakerCooldown(d time.Duration) Option {
+	return func(m *Manager) {
+		m.breakerCooldown = d
+	}
+}
+
+// WithBreakerMaxCooldown overrides the ceiling a newly-created circuit
+// breaker's exponential backoff can grow to (CircuitBreaker.MaxCooldown),
+// in place of DefaultCircuitBreaker's 5 minutes.
+func WithBreakerMaxCooldown(d time.Duration) Option {
+	return func(m *Manager) {
+		m.breakerMaxCooldown = d
+	}
+}
+
+// WithBreakerAuthFailureCooldown overrides the cooldown a newly-created
+// circuit breaker uses when RecordFailure sees a non-retryable
+// *models.APIError (CircuitBreaker.AuthFailureCooldown), in place of
+// DefaultCircuitBreaker's 2 minutes.
+func WithBreakerAuthFailureCooldown(d time.Duration) Option {
+	return func(m *Manager) {
+		m.breakerAuthCooldown = d
+	}
+}
+
+// WithBreakerMinRequests overrides how many samples a newly-created circuit
+// breaker's sliding window must accumulate before its error-rate trip logic
+// evaluates at all (CircuitBreaker.MinRequests), in place of
+// DefaultCircuitBreaker's 10. Mainly useful in tests that want to observe a
+// trip without recording ten requests first.
+func WithBreakerMinRequests(n int) Option {
+	return func(m *Manager) {
+		m.breakerMinRequests = n
+	}
+}
+
+// WithBreakerStateChangeCallback installs fn to be called on every circuit
+// breaker state transition, across every provider, keyed by providerUUID -
+// the hook an integrator wires into a Prometheus counter
+// (e.g. circuit_breaker_transitions_total{provider,from,to}).
+func WithBreakerStateChangeCallback(fn func(providerUUID string, from, to BreakerState)) Option {
+	return func(m *Manager) {
+		m.onBreakerStateChange = fn
+	}
+}
+
+// LoadConfig loads provider pools from a JSON or YAML file (format
+// detected from the extension, see parsePoolConfig).
 func (m *Manager) LoadConfig(filename string) error {
-	data, err := ioutil.ReadFile(filename)
+	config, err := parsePoolConfig(filename)
 	if err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
+		return err
 	}
-
-	var config map[string][]*models.ProviderConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		return fmt.Errorf("failed to parse config: %w", err)
+	if err := validatePoolConfig(config); err != nil {
+		return fmt.Errorf("invalid pool config: %w", err)
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.configFile = filename
 	m.pools = config
 
-	// Initialize round-robin indices
-	for providerType := range config {
-		m.roundRobinIndex[providerType] = 0
-	}
-
 	return nil
 }
 
-// SelectProvider selects a healthy provider using round-robin
+// SelectProvider selects a healthy provider for providerType using the
+// configured SelectionStrategy (WithPoolStrategy override if one is set for
+// providerType, otherwise the default from WithStrategy - round-robin if
+// neither was set).
 func (m *Manager) SelectProvider(providerType string) (*models.ProviderConfig, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -96,10 +213,12 @@ func (m *Manager) SelectProvider(providerType string) (*models.ProviderConfig, e
 		return nil, fmt.Errorf("no providers available for type: %s", providerType)
 	}
 
-	// Find healthy providers
+	// Find healthy providers whose circuit breaker isn't currently open.
+	// Calling Allow() here (rather than just inspecting state) is what
+	// lets a half-open breaker admit its single trial request.
 	var healthyProviders []*models.ProviderConfig
 	for _, p := range providers {
-		if p.IsHealthy {
+		if p.IsHealthy && m.breaker(p.UUID).Allow() {
 			healthyProviders = append(healthyProviders, p)
 		}
 	}
@@ -108,12 +227,10 @@ func (m *Manager) SelectProvider(providerType string) (*models.ProviderConfig, e
 		return nil, fmt.Errorf("no healthy providers available for type: %s", providerType)
 	}
 
-	// Round-robin selection
-	index := m.roundRobinIndex[providerType] % len(healthyProviders)
-	selected := healthyProviders[index]
-
-	// Update round-robin index
-	m.roundRobinIndex[providerType] = (index + 1) % len(healthyProviders)
+	selected, err := m.strategyFor(providerType).Select(healthyProviders, SelectionContext{PoolName: providerType})
+	if err != nil {
+		return nil, err
+	}
 
 	// Update usage statistics
 	selected.LastUsed = timePtr(time.Now())
@@ -122,6 +239,132 @@ func (m *Manager) SelectProvider(providerType string) (*models.ProviderConfig, e
 	return selected, nil
 }
 
+// strategyFor returns providerType's selection strategy: its
+// WithPoolStrategy override if one was configured, otherwise the Manager's
+// default. Callers must hold m.mu for at least reading.
+func (m *Manager) strategyFor(providerType string) SelectionStrategy {
+	if s, ok := m.poolStrategies[providerType]; ok {
+		return s
+	}
+	return m.strategy
+}
+
+// ReleaseProvider tells providerType's strategy that a request against
+// provider has finished, so strategies tracking in-flight load (currently
+// LeastLoadedStrategy) can free up the slot it reserved in Select. It is a
+// no-op for strategies that don't track in-flight state.
+func (m *Manager) ReleaseProvider(providerType string, provider *models.ProviderConfig) {
+	m.mu.RLock()
+	strategy := m.strategyFor(providerType)
+	m.mu.RUnlock()
+
+	if r, ok := strategy.(releaser); ok {
+		r.release(provider.UUID)
+	}
+}
+
+// ReportLatency feeds an observed response latency for provider into
+// providerType's strategy, so strategies tracking latency (currently
+// LatencyAwareStrategy) can fold it into their EWMA. It is a no-op for
+// strategies that don't track latency.
+func (m *Manager) ReportLatency(providerType string, provider *models.ProviderConfig, d time.Duration) {
+	m.mu.RLock()
+	strategy := m.strategyFor(providerType)
+	m.mu.RUnlock()
+
+	if r, ok := strategy.(latencyRecorder); ok {
+		r.recordLatency(provider.UUID, d)
+	}
+}
+
+// breaker returns providerUUID's circuit breaker, creating it with
+// DefaultCircuitBreaker settings (overridden by any WithBreakerCooldown /
+// WithBreakerMaxCooldown / WithBreakerAuthFailureCooldown /
+// WithBreakerMinRequests / WithBreakerStateChangeCallback options) on first
+// use. It has its own mutex, separate from m.mu, so it can be called while
+// m.mu is only read-locked (as SelectProvider does).
+func (m *Manager) breaker(providerUUID string) *CircuitBreaker {
+	m.breakersMu.Lock()
+	defer m.breakersMu.Unlock()
+
+	b, ok := m.breakers[providerUUID]
+	if !ok {
+		b = DefaultCircuitBreaker()
+		if m.breakerCooldown > 0 {
+			b.OpenDuration = m.breakerCooldown
+		}
+		if m.breakerMaxCooldown > 0 {
+			b.MaxCooldown = m.breakerMaxCooldown
+		}
+		if m.breakerAuthCooldown > 0 {
+			b.AuthFailureCooldown = m.breakerAuthCooldown
+		}
+		if m.breakerMinRequests > 0 {
+			b.MinRequests = m.breakerMinRequests
+		}
+		if m.onBreakerStateChange != nil {
+			b.SetOnStateChange(func(from, to BreakerState) {
+				m.onBreakerStateChange(providerUUID, from, to)
+			})
+		}
+		m.breakers[providerUUID] = b
+	}
+	return b
+}
+
+// RecordResult feeds a completed request's outcome into providerUUID's
+// circuit breaker, independent of (and faster-tripping than) the
+// ErrorCount-based MarkProviderUnhealthy path.
+func (m *Manager) RecordResult(providerUUID string, err error, latency time.Duration) {
+	m.breaker(providerUUID).RecordResult(err, latency)
+}
+
+// RecordSuccess records a successful request's latency against
+// providerUUID's circuit breaker.
+func (m *Manager) RecordSuccess(providerUUID string, latency time.Duration) {
+	m.breaker(providerUUID).RecordSuccess(latency)
+}
+
+// RecordFailure records a failed request's outcome against providerUUID's
+// circuit breaker, tripping it immediately on a non-retryable
+// *models.APIError (see CircuitBreaker.RecordFailure) instead of waiting
+// on the sliding window.
+func (m *Manager) RecordFailure(providerUUID string, err error, latency time.Duration) {
+	m.breaker(providerUUID).RecordFailure(err, latency)
+}
+
+// BreakerState reports providerUUID's current circuit breaker state, for
+// observability (e.g. an /admin/providers or /metrics endpoint).
+func (m *Manager) BreakerState(providerUUID string) BreakerState {
+	return m.breaker(providerUUID).State()
+}
+
+// BreakerStatus is one provider entry's circuit-breaker snapshot, keyed
+// by its pool type and UUID for the /admin/providers endpoint.
+type BreakerStatus struct {
+	ProviderType string `json:"provider_type"`
+	UUID         string `json:"uuid"`
+	BreakerStats
+}
+
+// ListBreakers snapshots every known provider entry's circuit breaker.
+func (m *Manager) ListBreakers() []BreakerStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var statuses []BreakerStatus
+	for providerType, pool := range m.pools {
+		for _, p := range pool {
+			statuses = append(statuses, BreakerStatus{
+				ProviderType: providerType,
+				UUID:         p.UUID,
+				BreakerStats: m.breaker(p.UUID).Stats(),
+			})
+		}
+	}
+	return statuses
+}
+
 // MarkProviderUnhealthy marks a provider as unhealthy
 func (m *Manager) MarkProviderUnhealthy(providerType string, providerUUID string) {
 	m.mu.Lock()
@@ -139,12 +382,23 @@ func (m *Manager) MarkProviderUnhealthy(providerType string, providerUUID string
 
 			if p.ErrorCount >= m.maxErrorCount {
 				p.IsHealthy = false
+				p.ConsecutiveFailures++
+				p.NextProbeAt = time.Now().Add(m.backoff.Next(p.ConsecutiveFailures))
 			}
 			break
 		}
 	}
 }
 
+// RegisterInstance associates a live provider instance with a pooled
+// provider config so that performHealthChecks can probe it directly instead
+// of relying on elapsed-time heuristics.
+func (m *Manager) RegisterInstance(providerUUID string, instance providers.Provider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.instances[providerUUID] = instance
+}
+
 // MarkProviderHealthy marks a provider as healthy
 func (m *Manager) MarkProviderHealthy(providerType string, providerUUID string) {
 	m.mu.Lock()
@@ -160,6 +414,8 @@ func (m *Manager) MarkProviderHealthy(providerType string, providerUUID string)
 			p.IsHealthy = true
 			p.ErrorCount = 0
 			p.LastErrorTime = nil
+			p.ConsecutiveFailures = 0
+			p.NextProbeAt = time.Time{}
 			break
 		}
 	}
@@ -175,31 +431,73 @@ func (m *Manager) healthCheckLoop() {
 	}
 }
 
-// performHealthChecks checks the health of all providers
+// performHealthChecks probes every unhealthy provider whose backoff window
+// (NextProbeAt) has elapsed. A provider with a registered instance is probed
+// with a real call through the providers.Provider interface; otherwise it
+// falls back to the elapsed-time heuristic used before backoff scheduling.
 func (m *Manager) performHealthChecks() {
 	m.mu.RLock()
 	allProviders := make(map[string][]*models.ProviderConfig)
 	for k, v := range m.pools {
 		allProviders[k] = v
 	}
+	instances := make(map[string]providers.Provider, len(m.instances))
+	for k, v := range m.instances {
+		instances[k] = v
+	}
+	reattached := make(map[string]bool, len(m.reattached))
+	for k, v := range m.reattached {
+		reattached[k] = v
+	}
 	m.mu.RUnlock()
 
-	// Perform health checks (implementation would depend on specific provider types)
-	// For now, this is a placeholder
-	for providerType, providers := range allProviders {
-		for _, provider := range providers {
-			if !provider.IsHealthy && provider.LastErrorTime != nil {
-				// Check if enough time has passed since last error
-				if time.Since(*provider.LastErrorTime) > m.healthCheckInterval {
-					// In a real implementation, we would test the provider here
-					// For now, we'll just mark it as healthy
+	now := time.Now()
+	for providerType, pool := range allProviders {
+		for _, provider := range pool {
+			if reattached[provider.UUID] {
+				// Externally-managed: the health-check loop is disabled for
+				// this entry entirely, it is always considered healthy.
+				continue
+			}
+			if provider.IsHealthy {
+				continue
+			}
+			if !provider.NextProbeAt.IsZero() && now.Before(provider.NextProbeAt) {
+				continue
+			}
+
+			if instance, ok := instances[provider.UUID]; ok {
+				if m.probeInstance(instance) {
 					m.MarkProviderHealthy(providerType, provider.UUID)
+				} else {
+					m.MarkProviderUnhealthy(providerType, provider.UUID)
 				}
+				continue
+			}
+
+			// No live instance registered: fall back to the elapsed-time
+			// heuristic so providers configured without RegisterInstance
+			// still recover eventually.
+			if provider.LastErrorTime != nil && time.Since(*provider.LastErrorTime) > m.healthCheckInterval {
+				m.MarkProviderHealthy(providerType, provider.UUID)
 			}
 		}
 	}
 }
 
+// probeInstance performs a lightweight health probe against a live provider.
+func (m *Manager) probeInstance(instance providers.Provider) bool {
+	if !instance.IsHealthy() {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := instance.ListModels(ctx)
+	return err == nil
+}
+
 // timePtr is a helper function to get a pointer to a time
 func timePtr(t time.Time) *time.Time {
 	return &t
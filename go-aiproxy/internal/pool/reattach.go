@@ -0,0 +1,66 @@
+package pool
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/internal/providers"
+)
+
+// loadReattachFromEnv reattaches every entry named in
+// AIPROXY_REATTACH_PROVIDERS, keyed "<providerType>/<uuid>".
+func (m *Manager) loadReattachFromEnv() error {
+	entries, err := providers.ParseReattachEnv()
+	if err != nil {
+		return err
+	}
+
+	for key, cfg := range entries {
+		parts := strings.SplitN(key, "/", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid AIPROXY_REATTACH_PROVIDERS key %q, expected <providerType>/<uuid>", key)
+		}
+
+		if err := m.Reattach(parts[1], cfg); err != nil {
+			return fmt.Errorf("failed to reattach %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// Reattach attaches to an externally-managed provider backend instead of
+// the normally configured upstream, borrowing Terraform's "unmanaged
+// providers" pattern: it skips credential/keepalive setup, marks the
+// provider unconditionally healthy, disables the health-check loop for it,
+// and routes requests through a thin reattach wrapper. Exposed for
+// programmatic use by tests and debuggers (e.g. a `dlv attach` workflow
+// against a real provider binary).
+func (m *Manager) Reattach(providerUUID string, cfg providers.ReattachConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var found bool
+	for _, pool := range m.pools {
+		for _, p := range pool {
+			if p.UUID != providerUUID {
+				continue
+			}
+
+			p.IsHealthy = true
+			p.ErrorCount = 0
+			p.ConsecutiveFailures = 0
+			p.NextProbeAt = time.Time{}
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("no provider config with UUID %s", providerUUID)
+	}
+
+	m.reattached[providerUUID] = true
+	m.instances[providerUUID] = providers.NewReattachProvider(cfg)
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package pool
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// parsePoolConfig reads filename into a fresh pools map, without touching
+// any Manager state - callers validate and apply the result themselves.
+// The format (JSON or YAML) is detected from the file's extension by
+// viper, the same library internal/config uses for the main server config.
+// mapstructure.StringToTimeHookFunc is required so models.ProviderConfig's
+// NextProbeAt round-trips correctly: viper/mapstructure otherwise has no
+// decode path from the RFC3339 string encoding/json.Marshal produces for
+// time.Time to the struct field itself.
+func parsePoolConfig(filename string) (map[string][]*models.ProviderConfig, error) {
+	v := viper.New()
+	v.SetConfigFile(filename)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config map[string][]*models.ProviderConfig
+	decodeHook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeHookFunc(time.RFC3339),
+		mapstructure.StringToTimeDurationHookFunc(),
+	))
+	if err := v.Unmarshal(&config, decodeHook); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	// Selection strategies and per-provider circuit breakers are keyed by
+	// UUID, so two providers that both leave it unset (older configs
+	// predate UUID, see providerKey below) would otherwise share one
+	// in-flight counter and one breaker - a failing provider silently
+	// tripping out its healthy sibling too. Backfilling it here, rather
+	// than falling back to another key at every call site, keeps UUID the
+	// one true provider identity everywhere else in this package.
+	for _, providers := range config {
+		for _, p := range providers {
+			if p.UUID == "" {
+				p.UUID = uuid.New().String()
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// validatePoolConfig rejects an empty config outright and any pool with no
+// providers. It deliberately stays this permissive (not, say, requiring
+// BaseURL or UUID) since those are filled in by some deployments'
+// provider-specific defaults rather than the pool config file itself;
+// catching a genuinely malformed edit before it reaches m.pools matters
+// more here than being exhaustive.
+func validatePoolConfig(pools map[string][]*models.ProviderConfig) error {
+	if len(pools) == 0 {
+		return fmt.Errorf("pool config has no provider pools")
+	}
+
+	for poolName, providers := range pools {
+		if len(providers) == 0 {
+			return fmt.Errorf("pool %q has no providers", poolName)
+		}
+	}
+	return nil
+}
+
+// providerKey identifies a provider entry across reloads so Reload can tell
+// a mutated entry (same identity, changed fields) apart from a removed one
+// followed by an unrelated addition. UUID is the normal identity; entries
+// without one fall back to BaseURL+APIKey. Entries read by
+// parsePoolConfig always have a UUID, since it backfills a random one, so
+// an entry whose file sets no UUID gets a new identity (and a fresh
+// breaker) on every reload.
+func providerKey(p *models.ProviderConfig) string {
+	if p.UUID != "" {
+		return p.UUID
+	}
+	return p.BaseURL + "|" + p.APIKey
+}
+
+// diffPools compares oldPools against newPools by providerKey and reports
+// which identities were added, removed, or kept but with BaseURL, Weight,
+// or IsHealthy changed. Any other field changing is not currently reported,
+// matching what Reload's doc comment promises to track.
+func diffPools(oldPools, newPools map[string][]*models.ProviderConfig) (added, removed, changed []string) {
+	old := make(map[string]*models.ProviderConfig)
+	for _, pool := range oldPools {
+		for _, p := range pool {
+			old[providerKey(p)] = p
+		}
+	}
+
+	seen := make(map[string]bool, len(old))
+	for _, pool := range newPools {
+		for _, p := range pool {
+			key := providerKey(p)
+			seen[key] = true
+
+			prev, ok := old[key]
+			if !ok {
+				added = append(added, key)
+				continue
+			}
+			if prev.BaseURL != p.BaseURL || prev.Weight != p.Weight || prev.IsHealthy != p.IsHealthy {
+				changed = append(changed, key)
+			}
+		}
+	}
+
+	for key := range old {
+		if !seen[key] {
+			removed = append(removed, key)
+		}
+	}
+	return added, removed, changed
+}
+
+// OnReload installs fn to be called after every Reload that actually
+// changes the pool config (added/removed/changed providerKeys, see
+// diffPools). Only the most recently installed fn is kept, matching
+// WithBreakerStateChangeCallback's single-callback convention.
+func (m *Manager) OnReload(fn func(added, removed, changed []string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onReload = fn
+}
+
+// Reload re-parses m's config file, validates the result, and - only if
+// that succeeds - swaps it in atomically under mu.Lock(). A parse or
+// validation error leaves the running config untouched, so a bad edit
+// can't brick a running server.
+//
+// Providers whose providerKey is unchanged keep their existing circuit
+// breaker and selection-strategy state, both of which are keyed by UUID
+// rather than by *models.ProviderConfig pointer identity, so mutating
+// BaseURL/Weight/IsHealthy on an existing entry doesn't reset them and
+// round-robin cursors (which track a position within the pool slice, not a
+// particular provider) are undisturbed either way. A provider dropped from
+// the file is "drained" simply by no longer being handed out by
+// SelectProvider from this point on: any request that already holds a
+// pointer to it via an earlier SelectProvider call runs to completion
+// normally, since Reload never mutates a *models.ProviderConfig in place,
+// only the map of which ones are current.
+func (m *Manager) Reload() error {
+	m.mu.RLock()
+	filename := m.configFile
+	oldPools := m.pools
+	onReload := m.onReload
+	m.mu.RUnlock()
+
+	newPools, err := parsePoolConfig(filename)
+	if err != nil {
+		return err
+	}
+	if err := validatePoolConfig(newPools); err != nil {
+		return fmt.Errorf("invalid pool config, keeping previous config: %w", err)
+	}
+
+	added, removed, changed := diffPools(oldPools, newPools)
+
+	m.mu.Lock()
+	m.pools = newPools
+	m.mu.Unlock()
+
+	if len(removed) > 0 {
+		oldByKey := make(map[string]*models.ProviderConfig, len(removed))
+		for _, pool := range oldPools {
+			for _, p := range pool {
+				oldByKey[providerKey(p)] = p
+			}
+		}
+
+		m.breakersMu.Lock()
+		for _, key := range removed {
+			if p, ok := oldByKey[key]; ok {
+				delete(m.breakers, p.UUID)
+			}
+		}
+		m.breakersMu.Unlock()
+	}
+
+	if onReload != nil && (len(added) > 0 || len(removed) > 0 || len(changed) > 0) {
+		onReload(added, removed, changed)
+	}
+
+	return nil
+}
+
+// WithConfigWatcher enables a background fsnotify watcher on the config
+// file passed to NewManager, calling Reload on every write/create event
+// once the Manager is constructed. A reload that fails is logged, not
+// propagated, since there is no caller left to return the error to by
+// then; the previous config stays in effect.
+func WithConfigWatcher(enabled bool) Option {
+	return func(m *Manager) {
+		m.watchConfig = enabled
+	}
+}
+
+// startConfigWatcher wires up the fsnotify watcher requested by
+// WithConfigWatcher. Editors and ConfigMap-style deployments both rotate
+// config files via rename-into-place, which fsnotify reports as Create on
+// the destination path rather than Write, so both are treated the same way
+// (matching tlsReloader and config.CredentialWatcher). The watch is on the
+// file's directory, filtered to the file's own path, so it survives the
+// file being replaced.
+func (m *Manager) startConfigWatcher() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create pool config watcher: %w", err)
+	}
+	path := filepath.Clean(m.configFile)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch %s: %w", m.configFile, err)
+	}
+	m.configWatcher = watcher
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != path || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				if err := m.Reload(); err != nil {
+					log.Printf("pool: config reload failed, keeping previous config: %v", err)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("pool: config watcher error: %v", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close stops the background config watcher started by WithConfigWatcher,
+// if one is running. Safe to call on a Manager constructed without it.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	watcher := m.configWatcher
+	m.configWatcher = nil
+	m.mu.Unlock()
+
+	if watcher == nil {
+		return nil
+	}
+	return watcher.Close()
+}
@@ -0,0 +1,187 @@
+package pool
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+// writePoolConfig marshals config as JSON to a fresh temp file and returns
+// its path, mirroring createTestConfig's layout so it can be rewritten
+// in-place to simulate an operator editing it.
+func writePoolConfig(t *testing.T, config map[string][]*models.ProviderConfig) string {
+	t.Helper()
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+
+	tmpfile, err := ioutil.TempFile("", "pool-reload-*.json")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	if _, err := tmpfile.Write(data); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	tmpfile.Close()
+	return tmpfile.Name()
+}
+
+func TestManager_Reload_AddsRemovesAndChangesProviders(t *testing.T) {
+	configFile := writePoolConfig(t, map[string][]*models.ProviderConfig{
+		"openai": {
+			{Provider: models.ProviderOpenAI, UUID: "stays", BaseURL: "https://api.openai.com/v1", IsHealthy: true},
+			{Provider: models.ProviderOpenAI, UUID: "goes-away", BaseURL: "https://api.openai.com/v1", IsHealthy: true},
+		},
+	})
+	defer os.Remove(configFile)
+
+	manager, err := NewManager(configFile)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	var added, removed, changed []string
+	manager.OnReload(func(a, r, c []string) {
+		added, removed, changed = a, r, c
+	})
+
+	// Trip "stays"'s breaker so we can assert its state survives the
+	// reload, then rewrite the file: "goes-away" is dropped, "stays" has
+	// its BaseURL mutated, and "new-arrival" is added. A non-retryable
+	// *models.APIError trips immediately instead of needing
+	// DefaultCircuitBreaker's MinRequests: 10 window to fill first.
+	manager.RecordFailure("stays", &models.APIError{Code: models.ErrProviderAuth, Retryable: false}, 0)
+	if manager.BreakerState("stays") != BreakerOpen {
+		t.Fatalf("expected breaker to trip before reload, got %v", manager.BreakerState("stays"))
+	}
+
+	data, err := json.Marshal(map[string][]*models.ProviderConfig{
+		"openai": {
+			{Provider: models.ProviderOpenAI, UUID: "stays", BaseURL: "https://api.openai.com/v2", IsHealthy: true},
+			{Provider: models.ProviderOpenAI, UUID: "new-arrival", BaseURL: "https://api.openai.com/v1", IsHealthy: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal updated config: %v", err)
+	}
+	if err := ioutil.WriteFile(configFile, data, 0o644); err != nil {
+		t.Fatalf("Failed to rewrite config: %v", err)
+	}
+
+	if err := manager.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	manager.mu.RLock()
+	pool := manager.pools["openai"]
+	manager.mu.RUnlock()
+	if len(pool) != 2 {
+		t.Fatalf("expected 2 providers after reload, got %d", len(pool))
+	}
+	var gotBaseURL string
+	for _, p := range pool {
+		if p.UUID == "stays" {
+			gotBaseURL = p.BaseURL
+		}
+	}
+	if gotBaseURL != "https://api.openai.com/v2" {
+		t.Fatalf("BaseURL for \"stays\" = %q, want the mutated v2 URL", gotBaseURL)
+	}
+
+	if manager.BreakerState("stays") != BreakerOpen {
+		t.Error("reload should not have reset \"stays\"'s breaker state")
+	}
+
+	if len(added) != 1 || added[0] != "new-arrival" {
+		t.Errorf("added = %v, want [\"new-arrival\"]", added)
+	}
+	if len(removed) != 1 || removed[0] != "goes-away" {
+		t.Errorf("removed = %v, want [\"goes-away\"]", removed)
+	}
+	if len(changed) != 1 || changed[0] != "stays" {
+		t.Errorf("changed = %v, want [\"stays\"]", changed)
+	}
+}
+
+func TestManager_Reload_InvalidConfigIsRejectedAndPreviousKept(t *testing.T) {
+	configFile := createTestConfig(t)
+	defer os.Remove(configFile)
+
+	manager, err := NewManager(configFile)
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	manager.mu.RLock()
+	before := len(manager.pools)
+	manager.mu.RUnlock()
+
+	if err := ioutil.WriteFile(configFile, []byte(`{"openai": []}`), 0o644); err != nil {
+		t.Fatalf("Failed to write invalid config: %v", err)
+	}
+
+	if err := manager.Reload(); err == nil {
+		t.Fatal("expected Reload to reject a pool with no providers")
+	}
+
+	manager.mu.RLock()
+	after := len(manager.pools)
+	afterOpenAI := len(manager.pools["openai"])
+	manager.mu.RUnlock()
+
+	if after != before {
+		t.Fatalf("pool count changed after a rejected reload: before=%d after=%d", before, after)
+	}
+	if afterOpenAI != 2 {
+		t.Errorf("expected the previous 2 openai providers to survive a rejected reload, got %d", afterOpenAI)
+	}
+}
+
+func TestManager_ConfigWatcher_ReloadsOnFileWrite(t *testing.T) {
+	configFile := writePoolConfig(t, map[string][]*models.ProviderConfig{
+		"openai": {
+			{Provider: models.ProviderOpenAI, UUID: "p1", BaseURL: "https://api.openai.com/v1", IsHealthy: true},
+		},
+	})
+	defer os.Remove(configFile)
+
+	manager, err := NewManager(configFile, WithConfigWatcher(true))
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+	defer manager.Close()
+
+	data, err := json.Marshal(map[string][]*models.ProviderConfig{
+		"openai": {
+			{Provider: models.ProviderOpenAI, UUID: "p1", BaseURL: "https://api.openai.com/v1", IsHealthy: true},
+			{Provider: models.ProviderOpenAI, UUID: "p2", BaseURL: "https://api.openai.com/v1", IsHealthy: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal updated config: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if err := ioutil.WriteFile(configFile, data, 0o644); err != nil {
+			t.Fatalf("Failed to rewrite config: %v", err)
+		}
+
+		manager.mu.RLock()
+		n := len(manager.pools["openai"])
+		manager.mu.RUnlock()
+		if n == 2 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("config watcher did not pick up the file change within 2s")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,354 @@
+package pool
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+// SelectionContext carries the per-request information a SelectionStrategy
+// may need beyond the candidate list itself.
+type SelectionContext struct {
+	// PoolName is the pool type being selected from (the key Manager.pools
+	// is indexed by). RoundRobinStrategy keys its per-pool rotation index by
+	// this rather than by the candidate slice's identity, since the healthy subset
+	// changes shape every time a provider flips health state.
+	PoolName string
+
+	// SessionID identifies the conversation/session a request belongs to.
+	// ConsistentHashStrategy hashes it to keep a session pinned to one
+	// provider for prompt-cache locality; other strategies ignore it.
+	SessionID string
+}
+
+// SelectionStrategy picks one provider out of a pool's already-filtered
+// healthy candidates. Implementations must be safe for concurrent use, since
+// Manager.SelectProvider only holds m.mu for reading while calling Select.
+type SelectionStrategy interface {
+	Select(providers []*models.ProviderConfig, ctx SelectionContext) (*models.ProviderConfig, error)
+}
+
+// releaser is implemented by strategies that need to know when a request
+// against a previously-selected provider has finished (currently only
+// LeastLoadedStrategy, which decrements the in-flight counter it
+// incremented in Select). Manager.ReleaseProvider calls it when present,
+// mirroring how loadbalancer.ReleaseInstance takes the instance back
+// rather than an opaque token.
+type releaser interface {
+	release(providerUUID string)
+}
+
+// latencyRecorder is implemented by strategies that maintain their own
+// per-provider latency EWMA (currently only LatencyAwareStrategy).
+// Manager.ReportLatency calls it when present.
+type latencyRecorder interface {
+	recordLatency(providerUUID string, d time.Duration)
+}
+
+// RoundRobinStrategy cycles through candidates in order, one index per
+// pool name. It is the default strategy, replicating Manager's original
+// inline round-robin behavior.
+type RoundRobinStrategy struct {
+	mu      sync.Mutex
+	indices map[string]int
+}
+
+// NewRoundRobinStrategy creates a RoundRobinStrategy with no rotation
+// history.
+func NewRoundRobinStrategy() *RoundRobinStrategy {
+	return &RoundRobinStrategy{indices: make(map[string]int)}
+}
+
+func (s *RoundRobinStrategy) Select(providers []*models.ProviderConfig, ctx SelectionContext) (*models.ProviderConfig, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("pool: no providers to select from")
+	}
+
+	key := ctx.PoolName
+	if key == "" {
+		key = poolKey(providers)
+	}
+
+	s.mu.Lock()
+	idx := s.indices[key] % len(providers)
+	s.indices[key] = (idx + 1) % len(providers)
+	s.mu.Unlock()
+
+	return providers[idx], nil
+}
+
+// poolKey derives a stable identity for a candidate slice from its
+// providers' UUIDs, for callers that don't supply SelectionContext.PoolName.
+func poolKey(providers []*models.ProviderConfig) string {
+	uuids := make([]string, len(providers))
+	for i, p := range providers {
+		uuids[i] = p.UUID
+	}
+	sort.Strings(uuids)
+	return fmt.Sprintf("%v", uuids)
+}
+
+// WeightedRandomStrategy draws a candidate with probability proportional to
+// its Weight (unset or non-positive treated as 1), using Vose's
+// linear-time construction of Walker's alias method so each draw after the
+// table is built is O(1).
+type WeightedRandomStrategy struct{}
+
+// NewWeightedRandomStrategy creates a WeightedRandomStrategy. It is
+// stateless: the alias table is rebuilt every call since the candidate set
+// (and therefore the weight distribution) can change between requests.
+func NewWeightedRandomStrategy() *WeightedRandomStrategy {
+	return &WeightedRandomStrategy{}
+}
+
+func (s *WeightedRandomStrategy) Select(providers []*models.ProviderConfig, _ SelectionContext) (*models.ProviderConfig, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("pool: no providers to select from")
+	}
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+
+	prob, alias := buildAliasTable(providers)
+	i := rand.Intn(len(providers))
+	if rand.Float64() < prob[i] {
+		return providers[i], nil
+	}
+	return providers[alias[i]], nil
+}
+
+// buildAliasTable constructs Walker's alias table for providers' weights
+// via Vose's algorithm.
+func buildAliasTable(providers []*models.ProviderConfig) ([]float64, []int) {
+	n := len(providers)
+	prob := make([]float64, n)
+	alias := make([]int, n)
+
+	weight := func(p *models.ProviderConfig) float64 {
+		if p.Weight <= 0 {
+			return 1
+		}
+		return float64(p.Weight)
+	}
+
+	var total float64
+	for _, p := range providers {
+		total += weight(p)
+	}
+
+	scaled := make([]float64, n)
+	for i, p := range providers {
+		scaled[i] = weight(p) / total * float64(n)
+	}
+
+	var small, large []int
+	for i, sc := range scaled {
+		if sc < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		prob[s] = scaled[s]
+		alias[s] = l
+
+		scaled[l] = scaled[l] + scaled[s] - 1
+		if scaled[l] < 1 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+
+	for _, l := range large {
+		prob[l] = 1
+	}
+	for _, s := range small {
+		prob[s] = 1
+	}
+
+	return prob, alias
+}
+
+// LeastLoadedStrategy prefers whichever candidate currently has the fewest
+// requests in flight, tracked via per-provider atomic counters that Select
+// increments and Manager.ReleaseProvider decrements.
+type LeastLoadedStrategy struct {
+	mu       sync.Mutex
+	inFlight map[string]*int64
+}
+
+// NewLeastLoadedStrategy creates a LeastLoadedStrategy with every
+// provider's in-flight count starting at zero.
+func NewLeastLoadedStrategy() *LeastLoadedStrategy {
+	return &LeastLoadedStrategy{inFlight: make(map[string]*int64)}
+}
+
+func (s *LeastLoadedStrategy) counter(providerUUID string) *int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.inFlight[providerUUID]
+	if !ok {
+		c = new(int64)
+		s.inFlight[providerUUID] = c
+	}
+	return c
+}
+
+func (s *LeastLoadedStrategy) Select(providers []*models.ProviderConfig, _ SelectionContext) (*models.ProviderConfig, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("pool: no providers to select from")
+	}
+
+	best := providers[0]
+	bestLoad := atomic.LoadInt64(s.counter(best.UUID))
+	for _, p := range providers[1:] {
+		load := atomic.LoadInt64(s.counter(p.UUID))
+		if load < bestLoad {
+			best = p
+			bestLoad = load
+		}
+	}
+
+	atomic.AddInt64(s.counter(best.UUID), 1)
+	return best, nil
+}
+
+// release decrements providerUUID's in-flight counter, floored at zero so a
+// stray extra release (e.g. after the provider config's UUID changed
+// underneath it) can't push the count negative.
+func (s *LeastLoadedStrategy) release(providerUUID string) {
+	c := s.counter(providerUUID)
+	if atomic.AddInt64(c, -1) < 0 {
+		atomic.StoreInt64(c, 0)
+	}
+}
+
+// consistentHashVnodes is the number of virtual nodes ConsistentHashStrategy
+// places on the ring per candidate provider.
+const consistentHashVnodes = 150
+
+// ConsistentHashStrategy routes every request for a given
+// SelectionContext.SessionID to the same provider via a hash ring with
+// consistentHashVnodes virtual nodes per candidate, so a conversation keeps
+// hitting a backend that already has its prompt cached instead of bouncing
+// between providers on every turn.
+type ConsistentHashStrategy struct{}
+
+// NewConsistentHashStrategy creates a ConsistentHashStrategy. It is
+// stateless: the ring is rebuilt every call from the current candidate set.
+func NewConsistentHashStrategy() *ConsistentHashStrategy {
+	return &ConsistentHashStrategy{}
+}
+
+func (s *ConsistentHashStrategy) Select(providers []*models.ProviderConfig, ctx SelectionContext) (*models.ProviderConfig, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("pool: no providers to select from")
+	}
+	if len(providers) == 1 || ctx.SessionID == "" {
+		return providers[0], nil
+	}
+
+	type vnode struct {
+		hash     uint32
+		provider *models.ProviderConfig
+	}
+
+	ring := make([]vnode, 0, len(providers)*consistentHashVnodes)
+	for _, p := range providers {
+		for i := 0; i < consistentHashVnodes; i++ {
+			ring = append(ring, vnode{hash: hashString(fmt.Sprintf("%s#%d", p.UUID, i)), provider: p})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i].hash < ring[j].hash })
+
+	target := hashString(ctx.SessionID)
+	idx := sort.Search(len(ring), func(i int) bool { return ring[i].hash >= target })
+	if idx == len(ring) {
+		idx = 0
+	}
+	return ring[idx].provider, nil
+}
+
+// hashString hashes s into the ring's key space.
+func hashString(s string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum32()
+}
+
+// latencyEWMAAlpha weights the most recent sample against
+// LatencyAwareStrategy's running average, matching the smoothing factor
+// loadbalancer.Instance uses for its own latency EWMA.
+const latencyEWMAAlpha = 0.3
+
+// LatencyAwareStrategy maintains an EWMA of response latency per provider,
+// fed out-of-band via Manager.ReportLatency, and picks with
+// power-of-two-choices: sample two candidates uniformly and return whichever
+// has the lower EWMA. P2C avoids the herd effect of always routing to the
+// single best-looking provider (which would overload it before its latency
+// caught up), while still beating pure random.
+type LatencyAwareStrategy struct {
+	mu   sync.Mutex
+	ewma map[string]float64 // milliseconds
+}
+
+// NewLatencyAwareStrategy creates a LatencyAwareStrategy with no latency
+// history; until ReportLatency observes a sample for a provider, its EWMA
+// is treated as zero.
+func NewLatencyAwareStrategy() *LatencyAwareStrategy {
+	return &LatencyAwareStrategy{ewma: make(map[string]float64)}
+}
+
+func (s *LatencyAwareStrategy) recordLatency(providerUUID string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms := float64(d.Milliseconds())
+	if prev, ok := s.ewma[providerUUID]; ok {
+		s.ewma[providerUUID] = latencyEWMAAlpha*ms + (1-latencyEWMAAlpha)*prev
+	} else {
+		s.ewma[providerUUID] = ms
+	}
+}
+
+func (s *LatencyAwareStrategy) latency(providerUUID string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ewma[providerUUID]
+}
+
+func (s *LatencyAwareStrategy) Select(providers []*models.ProviderConfig, _ SelectionContext) (*models.ProviderConfig, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("pool: no providers to select from")
+	}
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+
+	i := rand.Intn(len(providers))
+	j := rand.Intn(len(providers) - 1)
+	if j >= i {
+		j++
+	}
+
+	a, b := providers[i], providers[j]
+	if s.latency(a.UUID) <= s.latency(b.UUID) {
+		return a, nil
+	}
+	return b, nil
+}
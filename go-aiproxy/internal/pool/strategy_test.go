@@ -0,0 +1,197 @@
+package pool
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+func newTestProviders(uuids ...string) []*models.ProviderConfig {
+	providers := make([]*models.ProviderConfig, len(uuids))
+	for i, uuid := range uuids {
+		providers[i] = &models.ProviderConfig{Provider: models.ProviderOpenAI, UUID: uuid, IsHealthy: true}
+	}
+	return providers
+}
+
+func TestRoundRobinStrategy_CyclesThroughCandidates(t *testing.T) {
+	s := NewRoundRobinStrategy()
+	providers := newTestProviders("a", "b")
+	ctx := SelectionContext{PoolName: "openai"}
+
+	first, err := s.Select(providers, ctx)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	second, err := s.Select(providers, ctx)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	third, err := s.Select(providers, ctx)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+
+	if first.UUID == second.UUID {
+		t.Errorf("expected round-robin to alternate, got %s then %s", first.UUID, second.UUID)
+	}
+	if first.UUID != third.UUID {
+		t.Errorf("expected rotation to wrap back to %s, got %s", first.UUID, third.UUID)
+	}
+}
+
+func TestRoundRobinStrategy_NoProviders(t *testing.T) {
+	s := NewRoundRobinStrategy()
+	if _, err := s.Select(nil, SelectionContext{}); err == nil {
+		t.Error("expected error selecting from an empty candidate list")
+	}
+}
+
+func TestWeightedRandomStrategy_PrefersHeavierWeight(t *testing.T) {
+	s := NewWeightedRandomStrategy()
+	providers := newTestProviders("heavy", "light")
+	providers[0].Weight = 99
+	providers[1].Weight = 1
+
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		p, err := s.Select(providers, SelectionContext{})
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		counts[p.UUID]++
+	}
+
+	if counts["heavy"] <= counts["light"] {
+		t.Errorf("expected heavy (weight 99) to be drawn far more often than light (weight 1), got heavy=%d light=%d", counts["heavy"], counts["light"])
+	}
+}
+
+func TestLeastLoadedStrategy_AvoidsBusyProvider(t *testing.T) {
+	s := NewLeastLoadedStrategy()
+	providers := newTestProviders("busy", "idle")
+
+	// Saturate "busy" with in-flight requests that never release.
+	for i := 0; i < 5; i++ {
+		p, err := s.Select(providers, SelectionContext{})
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		if p.UUID != "busy" && p.UUID != "idle" {
+			t.Fatalf("unexpected provider %s", p.UUID)
+		}
+	}
+
+	// Drain "idle" back down so every future pick must avoid "busy".
+	for i := 0; i < 10; i++ {
+		s.release("idle")
+	}
+
+	p, err := s.Select(providers, SelectionContext{})
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if p.UUID != "idle" {
+		t.Errorf("expected least-loaded to pick idle, got %s", p.UUID)
+	}
+}
+
+func TestLeastLoadedStrategy_ReleaseFreesSlot(t *testing.T) {
+	s := NewLeastLoadedStrategy()
+	providers := newTestProviders("a", "b")
+
+	first, err := s.Select(providers, SelectionContext{})
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	s.release(first.UUID)
+
+	second, err := s.Select(providers, SelectionContext{})
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if second.UUID != first.UUID {
+		t.Errorf("expected released provider %s to be eligible again, got %s", first.UUID, second.UUID)
+	}
+}
+
+func TestConsistentHashStrategy_SameSessionSticksToSameProvider(t *testing.T) {
+	s := NewConsistentHashStrategy()
+	providers := newTestProviders("a", "b", "c")
+	ctx := SelectionContext{SessionID: "session-42"}
+
+	first, err := s.Select(providers, ctx)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		again, err := s.Select(providers, ctx)
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		if again.UUID != first.UUID {
+			t.Errorf("expected session %q to stick to %s, got %s", ctx.SessionID, first.UUID, again.UUID)
+		}
+	}
+}
+
+func TestConsistentHashStrategy_EmptySessionFallsBackToFirst(t *testing.T) {
+	s := NewConsistentHashStrategy()
+	providers := newTestProviders("a", "b")
+
+	p, err := s.Select(providers, SelectionContext{})
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if p.UUID != "a" {
+		t.Errorf("expected empty SessionID to fall back to first candidate, got %s", p.UUID)
+	}
+}
+
+func TestLatencyAwareStrategy_PrefersLowerLatency(t *testing.T) {
+	s := NewLatencyAwareStrategy()
+	providers := newTestProviders("fast", "slow")
+
+	s.recordLatency("fast", 5*time.Millisecond)
+	s.recordLatency("slow", 500*time.Millisecond)
+
+	counts := map[string]int{}
+	for i := 0; i < 200; i++ {
+		p, err := s.Select(providers, SelectionContext{})
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		counts[p.UUID]++
+	}
+
+	if counts["fast"] <= counts["slow"] {
+		t.Errorf("expected P2C to prefer the lower-latency provider, got fast=%d slow=%d", counts["fast"], counts["slow"])
+	}
+}
+
+func TestManager_SelectProvider_UsesConfiguredStrategy(t *testing.T) {
+	configFile := createTestConfig(t)
+	defer os.Remove(configFile)
+
+	manager, err := NewManager(configFile, WithPoolStrategy("openai", NewLeastLoadedStrategy()))
+	if err != nil {
+		t.Fatalf("Failed to create manager: %v", err)
+	}
+
+	p1, err := manager.SelectProvider("openai")
+	if err != nil {
+		t.Fatalf("SelectProvider: %v", err)
+	}
+	p2, err := manager.SelectProvider("openai")
+	if err != nil {
+		t.Fatalf("SelectProvider: %v", err)
+	}
+	if p1.UUID == p2.UUID {
+		t.Errorf("expected least-loaded override to spread load across both providers, got %s twice", p1.UUID)
+	}
+
+	manager.ReleaseProvider("openai", p1)
+	manager.ReportLatency("openai", p1, 10*time.Millisecond)
+}
@@ -0,0 +1,299 @@
+package providers
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/internal/cache"
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+// CacheMode selects how CachingProvider serves GenerateContent calls.
+type CacheMode string
+
+const (
+	// CacheModeOff makes CachingProvider a pure passthrough.
+	CacheModeOff CacheMode = "off"
+	// CacheModeExact only serves a response for a byte-identical
+	// previously cached request.
+	CacheModeExact CacheMode = "exact"
+	// CacheModeSemantic falls back to a RediSearch KNN similarity match
+	// against previously cached prompts when no exact entry exists.
+	CacheModeSemantic CacheMode = "semantic"
+)
+
+// CachingOptions configures a CachingProvider. TTL and MaxBodyBytes can be
+// overridden per provider via models.ProviderConfig's CacheTTLSeconds/
+// CacheMaxBodyBytes; Mode, SimilarityThreshold, Embedder, and IndexName are
+// shared across every wrapped provider.
+type CachingOptions struct {
+	Mode                CacheMode
+	TTL                 time.Duration
+	MaxBodyBytes        int
+	SimilarityThreshold float64
+	Embedder            cache.Embedder
+	// IndexName is the RediSearch index CacheModeSemantic searches;
+	// distinct providers should use distinct index names (or distinct
+	// RedisCache prefixes) so one provider's cached prompts never surface
+	// as a similarity match for another's unrelated request.
+	IndexName string
+}
+
+// CachingProvider decorates a Provider with a response cache for
+// GenerateContent, backed by cache.RedisCache. Streaming requests always
+// bypass the cache: GenerateContentStream is passed straight through to
+// the wrapped Provider, since there's no response to key a lookup on until
+// the stream has been fully read. A caller that wants a streamed response
+// cached can still populate it afterward, once it has buffered the
+// assembled completion, via StoreAssembled.
+type CachingProvider struct {
+	Provider
+	providerName string
+	redis        *cache.RedisCache
+	opts         CachingOptions
+}
+
+// NewCachingProvider wraps inner so its non-streaming GenerateContent
+// calls are served from redis when possible. A nil redis, or
+// opts.Mode == CacheModeOff, makes every call a pure passthrough. In
+// CacheModeSemantic, the opts.IndexName index is created (idempotently) up front so the
+// first SearchVectors call doesn't race index creation; cfg may be nil,
+// in which case the global TTL/MaxBodyBytes apply unconditionally.
+func NewCachingProvider(inner Provider, providerName string, redis *cache.RedisCache, cfg *models.ProviderConfig, opts CachingOptions) *CachingProvider {
+	cp := &CachingProvider{Provider: inner, providerName: providerName, redis: redis, opts: opts}
+	if cfg != nil {
+		if cfg.CacheTTLSeconds > 0 {
+			cp.opts.TTL = time.Duration(cfg.CacheTTLSeconds) * time.Second
+		}
+		if cfg.CacheMaxBodyBytes > 0 {
+			cp.opts.MaxBodyBytes = cfg.CacheMaxBodyBytes
+		}
+	}
+	if redis != nil && opts.Mode == CacheModeSemantic && opts.Embedder != nil {
+		// Best-effort: a failure here just means the first SearchVectors
+		// call below hits "no such index" and falls through to the
+		// provider, same as a genuine cache miss.
+		_ = redis.EnsureVectorIndex(context.Background(), cp.opts.IndexName, providerName+":", embeddingDim)
+	}
+	return cp
+}
+
+// embeddingDim is the dimensionality EnsureVectorIndex declares its vector
+// field with: 1536, the size of text-embedding-3-small vectors from an
+// OpenAI-compatible /embeddings endpoint (cache.HTTPEmbedder). Vectors are
+// stored as the Embedder returns them, with no padding or truncation, so
+// an Embedder returning another size (text-embedding-3-large returns 3072)
+// does not match the index.
+const embeddingDim = 1536
+
+// GenerateContent implements Provider, serving a cached response when
+// opts.Mode and the request allow it, and storing the wrapped Provider's
+// response for next time otherwise.
+func (p *CachingProvider) GenerateContent(ctx context.Context, model string, request interface{}) (interface{}, error) {
+	if p.redis == nil || p.opts.Mode == CacheModeOff {
+		return p.Provider.GenerateContent(ctx, model, request)
+	}
+
+	key, prompt, err := p.cacheKey(model, request)
+	if err != nil {
+		return p.Provider.GenerateContent(ctx, model, request)
+	}
+
+	if cached, found := p.lookup(ctx, key, prompt); found {
+		return cached, nil
+	}
+
+	resp, err := p.Provider.GenerateContent(ctx, model, request)
+	if err != nil {
+		return nil, err
+	}
+
+	p.store(ctx, key, prompt, resp)
+	return resp, nil
+}
+
+// StoreAssembled caches resp for request as if it had come back from a
+// non-streaming GenerateContent call. A streaming handler that buffers the
+// assembled completion can call this once the stream finishes so a later
+// identical/similar request still gets a cache hit, even though
+// GenerateContentStream itself never consults the cache.
+func (p *CachingProvider) StoreAssembled(ctx context.Context, model string, request interface{}, resp interface{}) {
+	if p.redis == nil || p.opts.Mode == CacheModeOff {
+		return
+	}
+	key, prompt, err := p.cacheKey(model, request)
+	if err != nil {
+		return
+	}
+	p.store(ctx, key, prompt, resp)
+}
+
+// lookup tries an exact key match first, then (in CacheModeSemantic) a
+// RediSearch KNN similarity search against prompt's embedding.
+func (p *CachingProvider) lookup(ctx context.Context, key, prompt string) (interface{}, bool) {
+	if resp, err := p.redis.Get(ctx, key); err == nil && resp != nil {
+		return resp, true
+	}
+
+	if p.opts.Mode != CacheModeSemantic || p.opts.Embedder == nil {
+		return nil, false
+	}
+
+	vec, err := p.opts.Embedder.Embed(ctx, prompt)
+	if err != nil {
+		return nil, false
+	}
+
+	matches, err := p.redis.SearchVectors(ctx, p.opts.IndexName, vec, 1)
+	if err != nil || len(matches) == 0 {
+		return nil, false
+	}
+	if matches[0].Similarity < p.opts.SimilarityThreshold {
+		return nil, false
+	}
+
+	var resp interface{}
+	if err := json.Unmarshal(matches[0].Payload, &resp); err != nil {
+		return nil, false
+	}
+	return resp, true
+}
+
+// store writes resp under key, and (in CacheModeSemantic) also indexes
+// prompt's embedding so a future similar-enough prompt can find it via
+// SearchVectors. Bodies over opts.MaxBodyBytes are skipped entirely: a
+// response too large to be worth caching shouldn't also cost a Redis
+// round trip.
+func (p *CachingProvider) store(ctx context.Context, key, prompt string, resp interface{}) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	if p.opts.MaxBodyBytes > 0 && len(data) > p.opts.MaxBodyBytes {
+		return
+	}
+
+	_ = p.redis.Set(ctx, key, resp, p.opts.TTL)
+
+	if p.opts.Mode != CacheModeSemantic || p.opts.Embedder == nil {
+		return
+	}
+	vec, err := p.opts.Embedder.Embed(ctx, prompt)
+	if err != nil {
+		return
+	}
+	_ = p.redis.StoreVector(ctx, key, vec, data, p.opts.TTL)
+}
+
+// cacheKeyFields is hashed to produce the cache key, and its Prompt field
+// (the flattened message text) doubles as the semantic index's embedding
+// input. Hashing provider+model+these fields, rather than the raw request,
+// means the key is stable across equivalent requests that differ only in
+// field ordering or in fields this proxy doesn't otherwise care about.
+type cacheKeyFields struct {
+	Provider    string      `json:"provider"`
+	Model       string      `json:"model"`
+	Prompt      string      `json:"prompt"`
+	System      string      `json:"system,omitempty"`
+	Temperature float64     `json:"temperature"`
+	TopP        float64     `json:"top_p"`
+	MaxTokens   int         `json:"max_tokens"`
+	Tools       interface{} `json:"tools,omitempty"`
+}
+
+// cacheKey hashes the request's cacheKeyFields into a stable key, and
+// returns the flattened prompt text alongside it for semantic embedding.
+// request is whatever wire-format struct the wrapped Provider's own
+// GenerateContent expects (OpenAIRequest, ClaudeRequest, or GeminiRequest);
+// an unrecognized type falls back to hashing its raw JSON encoding, which
+// is still stable but not normalized across equivalent requests.
+func (p *CachingProvider) cacheKey(model string, request interface{}) (key, prompt string, err error) {
+	fields := cacheKeyFields{Provider: p.providerName, Model: model}
+
+	switch req := request.(type) {
+	case *models.OpenAIRequest:
+		fields.Prompt = promptFromOpenAIMessages(req.Messages)
+		fields.Temperature = models.TemperatureOrDefault(req.Temperature, 0)
+		fields.TopP = req.TopP
+		fields.MaxTokens = req.MaxTokens
+		fields.Tools = req.Tools
+	case *models.ClaudeRequest:
+		fields.Prompt = promptFromClaudeMessages(req.Messages)
+		fields.System = req.System
+		fields.Temperature = req.Temperature
+		fields.TopP = req.TopP
+		fields.MaxTokens = req.MaxTokens
+		fields.Tools = req.Tools
+	case *models.GeminiRequest:
+		fields.Prompt = promptFromGeminiContents(req.Contents)
+		if req.SystemInstruction != nil {
+			fields.System = promptFromGeminiParts(req.SystemInstruction.Parts)
+		}
+		if req.GenerationConfig != nil {
+			fields.Temperature = req.GenerationConfig.Temperature
+			fields.TopP = req.GenerationConfig.TopP
+		}
+		fields.Tools = req.Tools
+	default:
+		data, marshalErr := json.Marshal(request)
+		if marshalErr != nil {
+			return "", "", fmt.Errorf("failed to marshal request for cache key: %w", marshalErr)
+		}
+		fields.Prompt = string(data)
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal cache key fields: %w", err)
+	}
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:]), fields.Prompt, nil
+}
+
+func promptFromOpenAIMessages(messages []models.OpenAIMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		b.WriteString(m.Role)
+		b.WriteString(": ")
+		b.WriteString(m.GetContentAsString())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func promptFromClaudeMessages(messages []models.ClaudeMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		b.WriteString(m.Role)
+		b.WriteString(": ")
+		for _, part := range m.Content {
+			b.WriteString(part.Text)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func promptFromGeminiContents(contents []models.GeminiContent) string {
+	var b strings.Builder
+	for _, c := range contents {
+		b.WriteString(c.Role)
+		b.WriteString(": ")
+		b.WriteString(promptFromGeminiParts(c.Parts))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func promptFromGeminiParts(parts []models.GeminiPart) string {
+	var b strings.Builder
+	for _, part := range parts {
+		b.WriteString(part.Text)
+	}
+	return b.String()
+}
@@ -11,7 +11,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aiproxy/go-aiproxy/internal/observability"
 	"github.com/aiproxy/go-aiproxy/internal/providers"
+	"github.com/aiproxy/go-aiproxy/internal/ratelimit"
 	"github.com/aiproxy/go-aiproxy/pkg/models"
 )
 
@@ -74,8 +76,45 @@ func (c *Client) GenerateContent(ctx context.Context, model string, request inte
 	return &claudeResp, nil
 }
 
-// GenerateContentStream implements streaming for Claude
+// GenerateContentStream implements streaming for Claude, returning a thin
+// io.ReadCloser adapter over GenerateContentStreamEvents for callers (the
+// websocket handler, convert.StreamConverter) that only know the older
+// plain-byte view: each StreamEvent is re-encoded as a chunk using
+// models.EncodeStreamEvent's marker protocol, the same wire format every
+// other provider's reader already emits. A caller that needs tool_use,
+// thinking, or usage data in full fidelity should call
+// GenerateContentStreamEvents directly instead.
 func (c *Client) GenerateContentStream(ctx context.Context, model string, request interface{}) (io.ReadCloser, error) {
+	resp, err := c.startStream(ctx, model, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &claudeStreamReader{
+		events: parseClaudeEventStream(resp.Body),
+		closer: resp.Body,
+	}, nil
+}
+
+// GenerateContentStreamEvents streams a Claude completion as a channel of
+// fully-typed StreamEvents, preserving tool_use, thinking, and usage
+// information that GenerateContentStream's io.ReadCloser view collapses
+// into plain text. The channel is closed once the stream ends, or ctx is
+// canceled, whichever comes first. The last value sent is a MessageStop
+// event on a complete stream and an Error event after a failed read; a
+// body that ends without message_stop closes the channel after neither.
+func (c *Client) GenerateContentStreamEvents(ctx context.Context, model string, request interface{}) (<-chan StreamEvent, error) {
+	resp, err := c.startStream(ctx, model, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseClaudeEventStream(resp.Body), nil
+}
+
+// startStream issues the shared streaming POST /v1/messages request behind
+// both GenerateContentStream and GenerateContentStreamEvents.
+func (c *Client) startStream(ctx context.Context, model string, request interface{}) (*http.Response, error) {
 	claudeReq, ok := request.(*models.ClaudeRequest)
 	if !ok {
 		return nil, fmt.Errorf("invalid request type for Claude provider")
@@ -87,19 +126,8 @@ func (c *Client) GenerateContentStream(ctx context.Context, model string, reques
 	}
 	claudeReq.Stream = true
 
-	// Make streaming request
 	url := fmt.Sprintf("%s/v1/messages", c.baseURL)
-	resp, err := c.makeRequest(ctx, "POST", url, claudeReq)
-	if err != nil {
-		return nil, err
-	}
-
-	// Return a custom reader that handles SSE parsing
-	return &claudeStreamReader{
-		reader:  bufio.NewReader(resp.Body),
-		closer:  resp.Body,
-		model:   model,
-	}, nil
+	return c.makeRequest(ctx, "POST", url, claudeReq)
 }
 
 // ListModels implements the Provider interface
@@ -140,99 +168,297 @@ func (c *Client) ListModels(ctx context.Context) (interface{}, error) {
 
 // makeRequest is a helper method to make HTTP requests
 func (c *Client) makeRequest(ctx context.Context, method, url string, body interface{}) (*http.Response, error) {
-	var reqBody io.Reader
+	ctx, span := observability.StartSpan(ctx, "provider.claude.request")
+	defer span.End()
+
+	var jsonData []byte
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		var err error
+		jsonData, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request: %w", err)
 		}
-		reqBody = bytes.NewReader(jsonData)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
-	if err != nil {
-		return nil, err
-	}
+	policy := ratelimit.BackoffPolicyFromConfig(c.Config)
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		var reqBody io.Reader
+		if jsonData != nil {
+			reqBody = bytes.NewReader(jsonData)
+		}
 
-	// Set headers
-	req.Header.Set("x-api-key", c.apiKey)
-	req.Header.Set("anthropic-version", c.version)
-	req.Header.Set("Content-Type", "application/json")
+		req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+		if err != nil {
+			return nil, err
+		}
 
-	// Make request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
+		// Set headers
+		req.Header.Set("x-api-key", c.apiKey)
+		req.Header.Set("anthropic-version", c.version)
+		req.Header.Set("Content-Type", "application/json")
+		observability.Inject(ctx, req.Header)
+
+		// Make request
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			if !policy.ShouldRetry(0, attempt) {
+				return nil, err
+			}
+			time.Sleep(policy.Delay(attempt, 0))
+			continue
+		}
+
+		if resp.StatusCode < 400 {
+			return resp, nil
+		}
 
-	// Check status code
-	if resp.StatusCode >= 400 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(bodyBytes))
-	}
+		lastErr = fmt.Errorf("API error %d: %s", resp.StatusCode, string(bodyBytes))
 
-	return resp, nil
+		if !policy.ShouldRetry(resp.StatusCode, attempt) {
+			if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+				c.Config.IsHealthy = false
+			}
+			return nil, lastErr
+		}
+		time.Sleep(policy.Delay(attempt, ratelimit.ParseRetryAfter(resp.Header.Get("Retry-After"))))
+	}
 }
 
-// claudeStreamReader handles SSE stream parsing for Claude
-type claudeStreamReader struct {
-	reader  *bufio.Reader
-	closer  io.Closer
-	model   string
-	buffer  []byte
+// StreamEventType identifies which Claude SSE event a StreamEvent carries.
+type StreamEventType string
+
+const (
+	EventMessageStart      StreamEventType = "message_start"
+	EventContentBlockStart StreamEventType = "content_block_start"
+	EventContentBlockDelta StreamEventType = "content_block_delta"
+	EventContentBlockStop  StreamEventType = "content_block_stop"
+	EventMessageDelta      StreamEventType = "message_delta"
+	EventMessageStop       StreamEventType = "message_stop"
+	EventPing              StreamEventType = "ping"
+	EventError             StreamEventType = "error"
+)
+
+// ContentBlockType is the type of content block a ContentBlockStart event
+// opens.
+type ContentBlockType string
+
+const (
+	BlockText     ContentBlockType = "text"
+	BlockToolUse  ContentBlockType = "tool_use"
+	BlockThinking ContentBlockType = "thinking"
+)
+
+// StreamEvent is one fully-typed event from Claude's streaming Messages
+// API, as emitted by GenerateContentStreamEvents. Only the fields relevant
+// to Type are populated; the rest are left zero.
+type StreamEvent struct {
+	Type  StreamEventType
+	Index int
+
+	// BlockType, ToolCallID, ToolName are set on ContentBlockStart.
+	BlockType  ContentBlockType
+	ToolCallID string
+	ToolName   string
+
+	// Exactly one of these is set on a ContentBlockDelta, matching the
+	// underlying delta's type.
+	TextDelta      string
+	InputJSONDelta string
+	ThinkingDelta  string
+
+	// ToolInput is the fully-accumulated, parsed arguments of the tool_use
+	// block Index identifies. Only set on the ContentBlockStop event that
+	// closes such a block - Claude streams a tool call's arguments as
+	// arbitrary input_json_delta byte fragments that individually don't
+	// parse as JSON, so parsing only happens once all fragments are in
+	// (see parseClaudeEventStream).
+	ToolInput json.RawMessage
+
+	// StopReason and OutputTokens are set on MessageDelta.
+	StopReason   string
+	OutputTokens int
+
+	// Err is set on an Error event (the underlying SSE read failed); the
+	// channel is closed immediately afterwards. A clean end of stream
+	// closes the channel without an Error event.
+	Err error
 }
 
-func (r *claudeStreamReader) Read(p []byte) (n int, err error) {
-	// If we have buffered data, return it first
-	if len(r.buffer) > 0 {
-		n = copy(p, r.buffer)
-		r.buffer = r.buffer[n:]
-		return n, nil
-	}
+// parseClaudeEventStream reads body as Claude's SSE stream format and
+// emits a StreamEvent per event on the returned channel, closing it (and
+// body) once the stream ends, body.Read fails, or ctx passed to
+// GenerateContentStreamEvents/GenerateContentStream is canceled (which
+// aborts the underlying HTTP read the same way).
+func parseClaudeEventStream(body io.ReadCloser) <-chan StreamEvent {
+	events := make(chan StreamEvent)
 
-	// Read next SSE event
-	for {
-		line, err := r.reader.ReadString('\n')
-		if err != nil {
-			if err == io.EOF {
-				return 0, io.EOF
-			}
-			return 0, err
-		}
+	go func() {
+		defer close(events)
+		defer body.Close()
 
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
+		reader := bufio.NewReader(body)
+		// pendingToolInput accumulates input_json_delta fragments per
+		// content-block Index until that block's content_block_stop.
+		pendingToolInput := make(map[int]*bytes.Buffer)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					events <- StreamEvent{Type: EventError, Err: err}
+				}
+				return
+			}
 
-		if strings.HasPrefix(line, "data: ") {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
 			data := strings.TrimPrefix(line, "data: ")
-			
-			// Parse the event
-			var event map[string]interface{}
-			if err := json.Unmarshal([]byte(data), &event); err != nil {
+
+			var raw models.ClaudeStreamEvent
+			if err := json.Unmarshal([]byte(data), &raw); err != nil {
 				continue // Skip malformed events
 			}
 
-			// Handle different event types
-			eventType, _ := event["type"].(string)
-			switch eventType {
+			switch raw.Type {
+			case "message_start":
+				events <- StreamEvent{Type: EventMessageStart}
+			case "ping":
+				events <- StreamEvent{Type: EventPing}
+			case "content_block_start":
+				if raw.ContentBlock == nil {
+					continue
+				}
+				blockType := ContentBlockType(raw.ContentBlock.Type)
+				if blockType == BlockToolUse {
+					pendingToolInput[raw.Index] = &bytes.Buffer{}
+				}
+				events <- StreamEvent{
+					Type:       EventContentBlockStart,
+					Index:      raw.Index,
+					BlockType:  blockType,
+					ToolCallID: raw.ContentBlock.ID,
+					ToolName:   raw.ContentBlock.Name,
+				}
 			case "content_block_delta":
-				if delta, ok := event["delta"].(map[string]interface{}); ok {
-					if deltaType, _ := delta["type"].(string); deltaType == "text_delta" {
-						if text, ok := delta["text"].(string); ok && text != "" {
-							r.buffer = []byte(text)
-							n = copy(p, r.buffer)
-							r.buffer = r.buffer[n:]
-							return n, nil
-						}
+				if raw.Delta == nil {
+					continue
+				}
+				switch raw.Delta.Type {
+				case "text_delta":
+					events <- StreamEvent{Type: EventContentBlockDelta, Index: raw.Index, TextDelta: raw.Delta.Text}
+				case "input_json_delta":
+					if buf, ok := pendingToolInput[raw.Index]; ok {
+						buf.WriteString(raw.Delta.PartialJSON)
 					}
+					events <- StreamEvent{Type: EventContentBlockDelta, Index: raw.Index, InputJSONDelta: raw.Delta.PartialJSON}
+				case "thinking_delta":
+					events <- StreamEvent{Type: EventContentBlockDelta, Index: raw.Index, ThinkingDelta: raw.Delta.Thinking}
+				}
+			case "content_block_stop":
+				ev := StreamEvent{Type: EventContentBlockStop, Index: raw.Index}
+				if buf, ok := pendingToolInput[raw.Index]; ok {
+					delete(pendingToolInput, raw.Index)
+					if buf.Len() == 0 {
+						ev.ToolInput = json.RawMessage("{}")
+					} else if json.Valid(buf.Bytes()) {
+						ev.ToolInput = json.RawMessage(append([]byte(nil), buf.Bytes()...))
+					}
+				}
+				events <- ev
+			case "message_delta":
+				ev := StreamEvent{Type: EventMessageDelta}
+				if raw.Delta != nil {
+					ev.StopReason = raw.Delta.StopReason
 				}
+				if raw.Usage != nil {
+					ev.OutputTokens = raw.Usage.OutputTokens
+				}
+				events <- ev
 			case "message_stop":
-				return 0, io.EOF
+				events <- StreamEvent{Type: EventMessageStop}
+				return
 			}
 		}
+	}()
+
+	return events
+}
+
+// claudeStreamReader adapts a StreamEvent channel back into the
+// models.EncodeStreamEvent marker protocol, for GenerateContentStream's
+// io.ReadCloser callers.
+type claudeStreamReader struct {
+	events <-chan StreamEvent
+	closer io.Closer
+	buffer []byte
+}
+
+func (r *claudeStreamReader) Read(p []byte) (n int, err error) {
+	if len(r.buffer) > 0 {
+		n = copy(p, r.buffer)
+		r.buffer = r.buffer[n:]
+		return n, nil
+	}
+
+	for ev := range r.events {
+		chunk := encodeAsStreamChunk(ev)
+		if chunk == "" {
+			continue
+		}
+		r.buffer = []byte(chunk)
+		n = copy(p, r.buffer)
+		r.buffer = r.buffer[n:]
+		return n, nil
+	}
+	return 0, io.EOF
+}
+
+// encodeAsStreamChunk re-encodes ev as a chunk string using
+// models.EncodeStreamEvent's marker protocol (plain text for a TextDelta,
+// everything else wrapped so convert.StreamConverter can decode it
+// losslessly). ThinkingDelta, Ping, and Err have no representation in that
+// older protocol and are dropped, matching how the previous reader silently
+// ignored event types it didn't recognize.
+func encodeAsStreamChunk(ev StreamEvent) string {
+	switch ev.Type {
+	case EventContentBlockStart:
+		if ev.BlockType != BlockToolUse {
+			return ""
+		}
+		return models.EncodeStreamEvent(models.StreamEvent{
+			Type: models.StreamEventToolUseStart, Index: ev.Index,
+			ToolCallID: ev.ToolCallID, ToolName: ev.ToolName,
+		})
+	case EventContentBlockDelta:
+		if ev.TextDelta != "" {
+			return ev.TextDelta
+		}
+		if ev.InputJSONDelta != "" {
+			return models.EncodeStreamEvent(models.StreamEvent{
+				Type: models.StreamEventInputJSONDelta, Index: ev.Index,
+				PartialJSON: ev.InputJSONDelta,
+			})
+		}
+		return ""
+	case EventContentBlockStop:
+		return models.EncodeStreamEvent(models.StreamEvent{Type: models.StreamEventContentBlockStop, Index: ev.Index})
+	case EventMessageDelta:
+		return models.EncodeStreamEvent(models.StreamEvent{
+			Type:         models.StreamEventMessageDelta,
+			FinishReason: ev.StopReason,
+			OutputTokens: ev.OutputTokens,
+		})
+	case EventMessageStop:
+		return models.EncodeStreamEvent(models.StreamEvent{Type: models.StreamEventMessageStop})
+	default:
+		return ""
 	}
 }
 
@@ -8,13 +8,20 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/aiproxy/go-aiproxy/internal/auth"
+	"github.com/aiproxy/go-aiproxy/internal/metrics"
+	"github.com/aiproxy/go-aiproxy/internal/observability"
 	"github.com/aiproxy/go-aiproxy/internal/providers"
+	"github.com/aiproxy/go-aiproxy/internal/ratelimit"
 	"github.com/aiproxy/go-aiproxy/pkg/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 )
 
 // Client implements the Gemini provider
@@ -24,9 +31,15 @@ type Client struct {
 	baseURL       string
 	projectID     string
 	apiKey        string
-	authClient    *http.Client
-	tokenSource   oauth2.TokenSource
+	tokenManager  *auth.TokenManager
 	isInitialized bool
+
+	// credsFileModTime is the OAuthCredsFile mtime observed when
+	// tokenManager was last built, used by RefreshToken to notice the
+	// file was rotated out from under us (e.g. by an external `gcloud
+	// auth` re-login) and rebuild tokenManager from the new contents
+	// instead of refreshing a token for credentials that no longer exist.
+	credsFileModTime time.Time
 }
 
 // NewClient creates a new Gemini client
@@ -59,30 +72,37 @@ func NewClient(config *models.ProviderConfig) (*Client, error) {
 	return client, nil
 }
 
-// initializeAuth sets up OAuth authentication
+// initializeAuth builds c.tokenManager from the service-account or
+// user-authorized-user credentials JSON in Config.OAuthCredsBase64/File
+// (auth.TokenManager's google.CredentialsFromJSON dispatches on the
+// blob's "type" field, so both cases — and Workload Identity Federation's
+// external_account credentials — share the same code path) and wraps
+// c.httpClient so every request authenticates and refreshes through it.
 func (c *Client) initializeAuth(ctx context.Context) error {
-	var creds []byte
-	var err error
-
-	if c.Config.OAuthCredsBase64 != "" {
-		// Decode base64 credentials
-		creds = []byte(c.Config.OAuthCredsBase64) // In real implementation, decode from base64
-	} else if c.Config.OAuthCredsFile != "" {
-		// Read from file - implementation would read the actual file
-		return fmt.Errorf("file-based OAuth not implemented yet")
+	if c.Config.OAuthCredsFile != "" {
+		if info, err := os.Stat(c.Config.OAuthCredsFile); err == nil {
+			c.credsFileModTime = info.ModTime()
+		}
 	}
 
-	// Create OAuth2 config
-	config, err := google.JWTConfigFromJSON(creds, "https://www.googleapis.com/auth/cloud-platform")
+	tokenStore, err := auth.NewTokenStoreFromConfig(c.Config)
+	if err != nil {
+		return fmt.Errorf("failed to create token store: %w", err)
+	}
+	tokenManager, err := auth.NewTokenManager(c.Config, auth.WithTokenStore(tokenStore))
 	if err != nil {
-		return fmt.Errorf("failed to create JWT config: %w", err)
+		return fmt.Errorf("failed to create token manager: %w", err)
+	}
+	if _, err := tokenManager.Token(); err != nil {
+		return fmt.Errorf("failed to get token: %w", err)
 	}
 
-	// Create token source and HTTP client
-	c.tokenSource = config.TokenSource(ctx)
-	c.authClient = oauth2.NewClient(ctx, c.tokenSource)
+	c.tokenManager = tokenManager
+	c.httpClient = oauth2.NewClient(ctx, c.tokenManager)
 	c.isInitialized = true
 
+	c.tokenManager.StartAutoRefresh(ctx)
+
 	return nil
 }
 
@@ -93,12 +113,20 @@ func (c *Client) GenerateContent(ctx context.Context, model string, request inte
 		return nil, fmt.Errorf("invalid request type for Gemini provider")
 	}
 
+	ctx, span := observability.StartSpan(ctx, "gemini.GenerateContent",
+		attribute.String("provider", "gemini"),
+		attribute.String("model", model),
+		attribute.Bool("stream", false),
+	)
+	defer span.End()
+
 	// Build URL
 	url := c.buildURL(model, "generateContent", false)
 
 	// Make API request
-	resp, err := c.makeRequest(ctx, "POST", url, geminiReq)
+	resp, err := c.makeRequest(ctx, "POST", url, model, geminiReq)
 	if err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -106,9 +134,17 @@ func (c *Client) GenerateContent(ctx context.Context, model string, request inte
 	// Parse response
 	var geminiResp models.GeminiResponse
 	if err := json.NewDecoder(resp.Body).Decode(&geminiResp); err != nil {
+		span.RecordError(err)
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	if geminiResp.UsageMetadata != nil {
+		span.SetAttributes(
+			attribute.Int("tokens.prompt", geminiResp.UsageMetadata.PromptTokenCount),
+			attribute.Int("tokens.completion", geminiResp.UsageMetadata.CandidatesTokenCount),
+		)
+	}
+
 	return &geminiResp, nil
 }
 
@@ -119,29 +155,45 @@ func (c *Client) GenerateContentStream(ctx context.Context, model string, reques
 		return nil, fmt.Errorf("invalid request type for Gemini provider")
 	}
 
+	ctx, span := observability.StartSpan(ctx, "gemini.GenerateContentStream",
+		attribute.String("provider", "gemini"),
+		attribute.String("model", model),
+		attribute.Bool("stream", true),
+	)
+
 	// Build URL for streaming
 	url := c.buildURL(model, "streamGenerateContent", true)
 
 	// Make streaming request
-	resp, err := c.makeRequest(ctx, "POST", url, geminiReq)
+	resp, err := c.makeRequest(ctx, "POST", url, model, geminiReq)
 	if err != nil {
+		span.RecordError(err)
+		span.End()
 		return nil, err
 	}
 
-	// Return a custom reader that handles streaming response
+	// Return a custom reader that handles streaming response. span stays
+	// open for the reader's lifetime (Read records a per-chunk event; Close
+	// or the first EOF ends it) instead of closing here, so it covers the
+	// whole stream rather than just the initial request.
 	return &geminiStreamReader{
 		reader: bufio.NewReader(resp.Body),
 		closer: resp.Body,
 		model:  model,
+		span:   span,
 	}, nil
 }
 
 // ListModels implements the Provider interface
 func (c *Client) ListModels(ctx context.Context) (interface{}, error) {
+	ctx, span := observability.StartSpan(ctx, "gemini.ListModels", attribute.String("provider", "gemini"))
+	defer span.End()
+
 	url := fmt.Sprintf("%s/v1beta/models", c.baseURL)
-	
-	resp, err := c.makeRequest(ctx, "GET", url, nil)
+
+	resp, err := c.makeRequest(ctx, "GET", url, "", nil)
 	if err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -177,73 +229,148 @@ func (c *Client) ListModels(ctx context.Context) (interface{}, error) {
 	}, nil
 }
 
-// RefreshToken refreshes the OAuth token if needed
+// RefreshToken refreshes the OAuth token if needed. If Config.OAuthCredsFile
+// is in use and its mtime has moved since tokenManager was built (e.g. an
+// external `gcloud auth application-default login` rotated the file),
+// initializeAuth is re-run first so the refresh authenticates with the
+// current on-disk credentials instead of erroring against stale ones.
 func (c *Client) RefreshToken(ctx context.Context) error {
-	if c.tokenSource == nil {
+	if c.tokenManager == nil {
 		return nil // No OAuth configured
 	}
 
-	// Force token refresh
-	_, err := c.tokenSource.Token()
-	return err
+	if c.Config.OAuthCredsFile != "" {
+		if info, err := os.Stat(c.Config.OAuthCredsFile); err == nil && info.ModTime().After(c.credsFileModTime) {
+			if err := c.initializeAuth(ctx); err != nil {
+				metrics.Default().RecordOAuthTokenRefresh("gemini", "error")
+				return fmt.Errorf("failed to reload rotated credentials file: %w", err)
+			}
+		}
+	}
+
+	// c.httpClient's Transport already calls back into c.tokenManager on
+	// every request, so forcing a refresh here only needs to hit the
+	// token endpoint; there's no separate client to swap out.
+	if _, err := c.tokenManager.RefreshToken(ctx); err != nil {
+		metrics.Default().RecordOAuthTokenRefresh("gemini", "error")
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	metrics.Default().RecordOAuthTokenRefresh("gemini", "success")
+	return nil
 }
 
-// buildURL constructs the API URL
+// buildURL constructs the API URL. For OAuth/service-account requests it
+// targets Vertex AI in Config.Location (default us-central1), or, if
+// Config.VertexEndpointOverride is set, that host instead — e.g. for a
+// regional endpoint like europe-west4-aiplatform.googleapis.com or a
+// private Service Connect endpoint that doesn't follow the
+// "<location>-aiplatform.googleapis.com" naming convention at all.
 func (c *Client) buildURL(model string, action string, isStream bool) string {
 	if c.projectID != "" && c.isInitialized {
-		// Use vertex AI endpoint for OAuth
-		return fmt.Sprintf("https://%s-aiplatform.googleapis.com/v1/projects/%s/locations/%s/publishers/google/models/%s:%s",
-			"us-central1", c.projectID, "us-central1", model, action)
+		location := c.Config.Location
+		if location == "" {
+			location = "us-central1"
+		}
+		host := c.Config.VertexEndpointOverride
+		if host == "" {
+			host = fmt.Sprintf("%s-aiplatform.googleapis.com", location)
+		}
+		return fmt.Sprintf("https://%s/v1/projects/%s/locations/%s/publishers/google/models/%s:%s",
+			host, c.projectID, location, model, action)
 	}
 
 	// Use public API with API key
 	return fmt.Sprintf("%s/v1beta/models/%s:%s", c.baseURL, model, action)
 }
 
-// makeRequest is a helper method to make HTTP requests
-func (c *Client) makeRequest(ctx context.Context, method, url string, body interface{}) (*http.Response, error) {
-	var reqBody io.Reader
+// makeRequest opens the span covering doRequest (including its own internal
+// retries, which count as one provider request) and records provider
+// metrics around it.
+func (c *Client) makeRequest(ctx context.Context, method, url, model string, body interface{}) (*http.Response, error) {
+	ctx, span := observability.StartSpan(ctx, "provider.gemini.request",
+		attribute.String("provider", "gemini"),
+		attribute.String("model", model),
+	)
+	defer span.End()
+
+	start := time.Now()
+	resp, err := c.doRequest(ctx, method, url, body)
+	metrics.Default().RecordProviderMetrics("gemini", model, time.Since(start), err, nil)
+	if err != nil {
+		span.RecordError(err)
+	} else {
+		span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	}
+	return resp, err
+}
+
+// doRequest is a helper method to make HTTP requests
+func (c *Client) doRequest(ctx context.Context, method, url string, body interface{}) (*http.Response, error) {
+	var jsonData []byte
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		var err error
+		jsonData, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request: %w", err)
 		}
-		reqBody = bytes.NewReader(jsonData)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
-	if err != nil {
-		return nil, err
-	}
+	policy := ratelimit.BackoffPolicyFromConfig(c.Config)
 
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-
-	// Use appropriate client and auth
-	client := c.httpClient
-	if c.authClient != nil {
-		client = c.authClient
-	} else if c.apiKey != "" {
-		// Add API key to URL
-		q := req.URL.Query()
-		q.Add("key", c.apiKey)
-		req.URL.RawQuery = q.Encode()
-	}
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		var reqBody io.Reader
+		if jsonData != nil {
+			reqBody = bytes.NewReader(jsonData)
+		}
 
-	// Make request
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
+		req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+		if err != nil {
+			return nil, err
+		}
+
+		// Set headers
+		req.Header.Set("Content-Type", "application/json")
+		observability.Inject(ctx, req.Header)
+
+		// c.httpClient is already the OAuth-wrapping client built by
+		// initializeAuth when one was configured; otherwise fall back to
+		// an API key on the URL.
+		client := c.httpClient
+		if !c.isInitialized && c.apiKey != "" {
+			q := req.URL.Query()
+			q.Add("key", c.apiKey)
+			req.URL.RawQuery = q.Encode()
+		}
+
+		// Make request
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			if !policy.ShouldRetry(0, attempt) {
+				return nil, err
+			}
+			time.Sleep(policy.Delay(attempt, 0))
+			continue
+		}
+
+		if resp.StatusCode < 400 {
+			return resp, nil
+		}
 
-	// Check status code
-	if resp.StatusCode >= 400 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(bodyBytes))
-	}
+		lastErr = fmt.Errorf("API error %d: %s", resp.StatusCode, string(bodyBytes))
 
-	return resp, nil
+		if !policy.ShouldRetry(resp.StatusCode, attempt) {
+			if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+				c.Config.IsHealthy = false
+			}
+			return nil, lastErr
+		}
+		time.Sleep(policy.Delay(attempt, ratelimit.ParseRetryAfter(resp.Header.Get("Retry-After"))))
+	}
 }
 
 // geminiStreamReader handles streaming response parsing
@@ -252,6 +379,18 @@ type geminiStreamReader struct {
 	closer io.Closer
 	model  string
 	buffer []byte
+
+	// span covers the stream's whole lifetime, not just the initial
+	// request; endSpan ends it exactly once whether that's triggered by
+	// the first EOF or by an explicit Close.
+	span    trace.Span
+	endOnce sync.Once
+}
+
+func (r *geminiStreamReader) endSpan() {
+	r.endOnce.Do(func() {
+		r.span.End()
+	})
 }
 
 func (r *geminiStreamReader) Read(p []byte) (n int, err error) {
@@ -267,8 +406,11 @@ func (r *geminiStreamReader) Read(p []byte) (n int, err error) {
 		line, err := r.reader.ReadString('\n')
 		if err != nil {
 			if err == io.EOF {
+				r.endSpan()
 				return 0, io.EOF
 			}
+			r.span.RecordError(err)
+			r.endSpan()
 			return 0, err
 		}
 
@@ -287,6 +429,8 @@ func (r *geminiStreamReader) Read(p []byte) (n int, err error) {
 		for _, candidate := range response.Candidates {
 			for _, part := range candidate.Content.Parts {
 				if part.Text != "" {
+					metrics.Default().RecordStreamChunk("gemini", r.model)
+					r.span.AddEvent("chunk", trace.WithAttributes(attribute.Int("bytes", len(part.Text))))
 					r.buffer = []byte(part.Text)
 					n = copy(p, r.buffer)
 					r.buffer = r.buffer[n:]
@@ -298,5 +442,6 @@ func (r *geminiStreamReader) Read(p []byte) (n int, err error) {
 }
 
 func (r *geminiStreamReader) Close() error {
+	r.endSpan()
 	return r.closer.Close()
 }
\ No newline at end of file
@@ -9,11 +9,16 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aiproxy/go-aiproxy/internal/auth"
+	"github.com/aiproxy/go-aiproxy/internal/metrics"
+	"github.com/aiproxy/go-aiproxy/internal/observability"
 	"github.com/aiproxy/go-aiproxy/internal/providers"
 	"github.com/aiproxy/go-aiproxy/pkg/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/oauth2"
 )
 
@@ -46,7 +51,11 @@ func NewClient(config *models.ProviderConfig) (*Client, error) {
 
 	// Initialize OAuth if credentials are provided
 	if config.OAuthCredsBase64 != "" || config.OAuthCredsFile != "" {
-		tokenManager, err := auth.NewTokenManager(config)
+		tokenStore, err := auth.NewTokenStoreFromConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create token store: %w", err)
+		}
+		tokenManager, err := auth.NewTokenManager(config, auth.WithTokenStore(tokenStore))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create token manager: %w", err)
 		}
@@ -61,20 +70,31 @@ func NewClient(config *models.ProviderConfig) (*Client, error) {
 	return client, nil
 }
 
-// initialize sets up OAuth authentication
+// initialize sets up OAuth authentication. c.tokenManager is itself an
+// oauth2.TokenSource, so the Transport oauth2.NewClient builds calls back
+// into it (and its ReuseTokenSource/refresh-lock machinery) on every
+// request instead of this client holding a token that goes stale the
+// moment it expires.
 func (c *Client) initialize(ctx context.Context) error {
-	token, err := c.tokenManager.GetToken(ctx)
-	if err != nil {
+	if _, err := c.tokenManager.Token(); err != nil {
 		return fmt.Errorf("failed to get token: %w", err)
 	}
 
-	// Create OAuth2 client
-	c.httpClient = oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
+	c.httpClient = oauth2.NewClient(ctx, c.tokenManager)
 	c.isInitialized = true
 
+	c.tokenManager.StartAutoRefresh(ctx)
+
 	return nil
 }
 
+// Stop stops the token manager's background auto-refresh goroutine.
+func (c *Client) Stop() {
+	if c.tokenManager != nil {
+		c.tokenManager.Close()
+	}
+}
+
 // GenerateContent implements the Provider interface
 func (c *Client) GenerateContent(ctx context.Context, model string, request interface{}) (interface{}, error) {
 	// Ensure initialized
@@ -94,10 +114,18 @@ func (c *Client) GenerateContent(ctx context.Context, model string, request inte
 		claudeReq.Model = model
 	}
 
+	ctx, span := observability.StartSpan(ctx, "kiro.GenerateContent",
+		attribute.String("provider", "kiro"),
+		attribute.String("model", claudeReq.Model),
+		attribute.Bool("stream", false),
+	)
+	defer span.End()
+
 	// Make API request
 	url := fmt.Sprintf("%s/v1/messages", c.baseURL)
-	resp, err := c.makeRequest(ctx, "POST", url, claudeReq)
+	resp, err := c.makeRequest(ctx, "POST", url, model, claudeReq)
 	if err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -105,9 +133,17 @@ func (c *Client) GenerateContent(ctx context.Context, model string, request inte
 	// Parse response
 	var claudeResp models.ClaudeResponse
 	if err := json.NewDecoder(resp.Body).Decode(&claudeResp); err != nil {
+		span.RecordError(err)
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	if claudeResp.Usage != nil {
+		span.SetAttributes(
+			attribute.Int("tokens.prompt", claudeResp.Usage.InputTokens),
+			attribute.Int("tokens.completion", claudeResp.Usage.OutputTokens),
+		)
+	}
+
 	return &claudeResp, nil
 }
 
@@ -131,23 +167,37 @@ func (c *Client) GenerateContentStream(ctx context.Context, model string, reques
 	}
 	claudeReq.Stream = true
 
+	ctx, span := observability.StartSpan(ctx, "kiro.GenerateContentStream",
+		attribute.String("provider", "kiro"),
+		attribute.String("model", claudeReq.Model),
+		attribute.Bool("stream", true),
+	)
+
 	// Make streaming request
 	url := fmt.Sprintf("%s/v1/messages", c.baseURL)
-	resp, err := c.makeRequest(ctx, "POST", url, claudeReq)
+	resp, err := c.makeRequest(ctx, "POST", url, model, claudeReq)
 	if err != nil {
+		span.RecordError(err)
+		span.End()
 		return nil, err
 	}
 
-	// Return a custom reader that handles SSE parsing
+	// Return a custom reader that handles SSE parsing. span stays open for
+	// the reader's lifetime (Read records a per-chunk event; Close or the
+	// first EOF ends it).
 	return &kiroStreamReader{
-		reader:  bufio.NewReader(resp.Body),
-		closer:  resp.Body,
-		model:   model,
+		reader: bufio.NewReader(resp.Body),
+		closer: resp.Body,
+		model:  model,
+		span:   span,
 	}, nil
 }
 
 // ListModels implements the Provider interface
 func (c *Client) ListModels(ctx context.Context) (interface{}, error) {
+	_, span := observability.StartSpan(ctx, "kiro.ListModels", attribute.String("provider", "kiro"))
+	defer span.End()
+
 	// Kiro supports Claude models
 	modelList := []models.ModelInfo{
 		{
@@ -188,18 +238,27 @@ func (c *Client) RefreshToken(ctx context.Context) error {
 		return nil // No OAuth configured
 	}
 
-	// Force token refresh
-	token, err := c.tokenManager.RefreshToken(ctx)
-	if err != nil {
+	// c.httpClient's Transport already calls back into c.tokenManager on
+	// every request, so forcing a refresh here only needs to hit the
+	// token endpoint; there's no separate client to swap out.
+	if _, err := c.tokenManager.RefreshToken(ctx); err != nil {
+		metrics.Default().RecordOAuthTokenRefresh("kiro", "error")
 		return fmt.Errorf("failed to refresh token: %w", err)
 	}
 
-	// Update HTTP client with new token
-	c.httpClient = oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
-	
+	metrics.Default().RecordOAuthTokenRefresh("kiro", "success")
 	return nil
 }
 
+// RevokeToken implements providers.TokenRevoker by invalidating token in
+// c.tokenManager, so a compromised token stops being served from cache.
+func (c *Client) RevokeToken(ctx context.Context, token string) error {
+	if c.tokenManager == nil {
+		return fmt.Errorf("kiro: no token manager configured")
+	}
+	return c.tokenManager.RevokeToken(ctx, token)
+}
+
 // IsHealthy checks if the provider is healthy including token validity
 func (c *Client) IsHealthy() bool {
 	if !c.BaseProvider.IsHealthy() {
@@ -214,8 +273,29 @@ func (c *Client) IsHealthy() bool {
 	return true
 }
 
-// makeRequest is a helper method to make HTTP requests
-func (c *Client) makeRequest(ctx context.Context, method, url string, body interface{}) (*http.Response, error) {
+// makeRequest opens the span covering doRequest (including its own
+// 401-triggered retry, which counts as one provider request) and records
+// provider metrics around it.
+func (c *Client) makeRequest(ctx context.Context, method, url, model string, body interface{}) (*http.Response, error) {
+	ctx, span := observability.StartSpan(ctx, "provider.kiro.request",
+		attribute.String("provider", "kiro"),
+		attribute.String("model", model),
+	)
+	defer span.End()
+
+	start := time.Now()
+	resp, err := c.doRequest(ctx, method, url, body)
+	metrics.Default().RecordProviderMetrics("kiro", model, time.Since(start), err, nil)
+	if err != nil {
+		span.RecordError(err)
+	} else {
+		span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	}
+	return resp, err
+}
+
+// doRequest is a helper method to make HTTP requests
+func (c *Client) doRequest(ctx context.Context, method, url string, body interface{}) (*http.Response, error) {
 	var reqBody io.Reader
 	if body != nil {
 		jsonData, err := json.Marshal(body)
@@ -233,6 +313,7 @@ func (c *Client) makeRequest(ctx context.Context, method, url string, body inter
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("anthropic-version", "2023-06-01")
+	observability.Inject(ctx, req.Header)
 
 	// Make request
 	resp, err := c.httpClient.Do(req)
@@ -250,11 +331,11 @@ func (c *Client) makeRequest(ctx context.Context, method, url string, body inter
 			// Try to refresh token
 			if err := c.RefreshToken(ctx); err == nil {
 				// Retry request with new token
-				return c.makeRequest(ctx, method, url, body)
+				return c.doRequest(ctx, method, url, body)
 			}
 		}
 		
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, models.ClassifyHTTPError("kiro", resp.StatusCode, bodyBytes)
 	}
 
 	return resp, nil
@@ -262,10 +343,22 @@ func (c *Client) makeRequest(ctx context.Context, method, url string, body inter
 
 // kiroStreamReader handles SSE stream parsing for Kiro
 type kiroStreamReader struct {
-	reader  *bufio.Reader
-	closer  io.Closer
-	model   string
-	buffer  []byte
+	reader *bufio.Reader
+	closer io.Closer
+	model  string
+	buffer []byte
+
+	// span covers the stream's whole lifetime, not just the initial
+	// request; endSpan ends it exactly once whether that's triggered by
+	// the first EOF or by an explicit Close.
+	span    trace.Span
+	endOnce sync.Once
+}
+
+func (r *kiroStreamReader) endSpan() {
+	r.endOnce.Do(func() {
+		r.span.End()
+	})
 }
 
 func (r *kiroStreamReader) Read(p []byte) (n int, err error) {
@@ -281,8 +374,11 @@ func (r *kiroStreamReader) Read(p []byte) (n int, err error) {
 		line, err := r.reader.ReadString('\n')
 		if err != nil {
 			if err == io.EOF {
+				r.endSpan()
 				return 0, io.EOF
 			}
+			r.span.RecordError(err)
+			r.endSpan()
 			return 0, err
 		}
 
@@ -307,6 +403,8 @@ func (r *kiroStreamReader) Read(p []byte) (n int, err error) {
 				if delta, ok := event["delta"].(map[string]interface{}); ok {
 					if deltaType, _ := delta["type"].(string); deltaType == "text_delta" {
 						if text, ok := delta["text"].(string); ok && text != "" {
+							metrics.Default().RecordStreamChunk("kiro", r.model)
+							r.span.AddEvent("chunk", trace.WithAttributes(attribute.Int("bytes", len(text))))
 							r.buffer = []byte(text)
 							n = copy(p, r.buffer)
 							r.buffer = r.buffer[n:]
@@ -315,6 +413,7 @@ func (r *kiroStreamReader) Read(p []byte) (n int, err error) {
 					}
 				}
 			case "message_stop":
+				r.endSpan()
 				return 0, io.EOF
 			}
 		}
@@ -322,5 +421,6 @@ func (r *kiroStreamReader) Read(p []byte) (n int, err error) {
 }
 
 func (r *kiroStreamReader) Close() error {
+	r.endSpan()
 	return r.closer.Close()
 }
\ No newline at end of file
@@ -66,6 +66,10 @@ func (c *MockClient) GenerateContentStream(ctx context.Context, model string, re
 		return nil, fmt.Errorf("invalid request type for Kiro mock provider")
 	}
 
+	if c.wantsToolCall(claudeReq) {
+		return newMockToolCallStreamReader(), nil
+	}
+
 	// Create mock stream
 	response := c.generateMockResponse(claudeReq)
 	words := strings.Split(response, " ")
@@ -77,6 +81,24 @@ func (c *MockClient) GenerateContentStream(ctx context.Context, model string, re
 	}, nil
 }
 
+// wantsToolCall reports whether req's last message should drive the mock
+// get_weather tool call, exercising multi-step tool-call streaming
+// end-to-end the same way a real Claude tool_use stream would.
+func (c *MockClient) wantsToolCall(req *models.ClaudeRequest) bool {
+	if len(req.Messages) == 0 {
+		return false
+	}
+
+	lastMessage := req.Messages[len(req.Messages)-1]
+	for _, content := range lastMessage.Content {
+		if content.Type == "text" && strings.Contains(strings.ToLower(content.Text), "weather") {
+			return true
+		}
+	}
+
+	return false
+}
+
 // ListModels returns mock models
 func (c *MockClient) ListModels(ctx context.Context) (interface{}, error) {
 	return &models.ModelList{
@@ -181,4 +203,64 @@ func (r *mockStreamReader) Read(p []byte) (n int, err error) {
 
 func (r *mockStreamReader) Close() error {
 	return nil
+}
+
+// mockToolCallStreamReader emits a canned get_weather tool_use stream as a
+// sequence of models.StreamEvent-encoded chunks, the same shape a real
+// Claude tool_use stream produces: a ToolUseStart, several
+// InputJSONDelta fragments of the arguments JSON, and a ContentBlockStop.
+// It exists so the converter's multi-step tool-call reconstruction can be
+// exercised end-to-end without a live Claude API key.
+type mockToolCallStreamReader struct {
+	chunks   []string
+	position int
+	delay    time.Duration
+	buffer   []byte
+}
+
+func newMockToolCallStreamReader() *mockToolCallStreamReader {
+	return &mockToolCallStreamReader{
+		chunks: []string{
+			models.EncodeStreamEvent(models.StreamEvent{
+				Type: models.StreamEventToolUseStart, Index: 0,
+				ToolCallID: "toolu_01mock", ToolName: "get_weather",
+			}),
+			models.EncodeStreamEvent(models.StreamEvent{
+				Type: models.StreamEventInputJSONDelta, Index: 0, PartialJSON: `{"locat`,
+			}),
+			models.EncodeStreamEvent(models.StreamEvent{
+				Type: models.StreamEventInputJSONDelta, Index: 0, PartialJSON: `ion":"T`,
+			}),
+			models.EncodeStreamEvent(models.StreamEvent{
+				Type: models.StreamEventInputJSONDelta, Index: 0, PartialJSON: `okyo"}`,
+			}),
+			models.EncodeStreamEvent(models.StreamEvent{Type: models.StreamEventContentBlockStop, Index: 0}),
+		},
+		delay: 50 * time.Millisecond,
+	}
+}
+
+func (r *mockToolCallStreamReader) Read(p []byte) (n int, err error) {
+	if len(r.buffer) > 0 {
+		n = copy(p, r.buffer)
+		r.buffer = r.buffer[n:]
+		return n, nil
+	}
+
+	if r.position >= len(r.chunks) {
+		return 0, io.EOF
+	}
+
+	time.Sleep(r.delay)
+
+	r.buffer = []byte(r.chunks[r.position])
+	r.position++
+
+	n = copy(p, r.buffer)
+	r.buffer = r.buffer[n:]
+	return n, nil
+}
+
+func (r *mockToolCallStreamReader) Close() error {
+	return nil
 }
\ No newline at end of file
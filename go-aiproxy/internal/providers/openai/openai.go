@@ -11,7 +11,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aiproxy/go-aiproxy/internal/observability"
 	"github.com/aiproxy/go-aiproxy/internal/providers"
+	"github.com/aiproxy/go-aiproxy/internal/ratelimit"
 	"github.com/aiproxy/go-aiproxy/pkg/models"
 )
 
@@ -120,38 +122,64 @@ func (c *Client) ListModels(ctx context.Context) (interface{}, error) {
 
 // makeRequest is a helper method to make HTTP requests
 func (c *Client) makeRequest(ctx context.Context, method, url string, body interface{}) (*http.Response, error) {
-	var reqBody io.Reader
+	ctx, span := observability.StartSpan(ctx, "provider.openai.request")
+	defer span.End()
+
+	var jsonData []byte
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		var err error
+		jsonData, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request: %w", err)
 		}
-		reqBody = bytes.NewReader(jsonData)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
-	if err != nil {
-		return nil, err
-	}
+	policy := ratelimit.BackoffPolicyFromConfig(c.Config)
 
-	// Set headers
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-	req.Header.Set("Content-Type", "application/json")
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		var reqBody io.Reader
+		if jsonData != nil {
+			reqBody = bytes.NewReader(jsonData)
+		}
 
-	// Make request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
+		req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+		if err != nil {
+			return nil, err
+		}
+
+		// Set headers
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+		req.Header.Set("Content-Type", "application/json")
+		observability.Inject(ctx, req.Header)
+
+		// Make request
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			if !policy.ShouldRetry(0, attempt) {
+				return nil, err
+			}
+			time.Sleep(policy.Delay(attempt, 0))
+			continue
+		}
+
+		if resp.StatusCode < 400 {
+			return resp, nil
+		}
 
-	// Check status code
-	if resp.StatusCode >= 400 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(bodyBytes))
-	}
+		lastErr = models.ClassifyHTTPError("openai", resp.StatusCode, bodyBytes)
 
-	return resp, nil
+		if !policy.ShouldRetry(resp.StatusCode, attempt) {
+			if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+				c.Config.IsHealthy = false
+			}
+			return nil, lastErr
+		}
+		time.Sleep(policy.Delay(attempt, ratelimit.ParseRetryAfter(resp.Header.Get("Retry-After"))))
+	}
 }
 
 // openAIStreamReader handles SSE stream parsing
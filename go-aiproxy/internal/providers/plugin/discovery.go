@@ -0,0 +1,72 @@
+// Package plugin lets the proxy load model backends as out-of-process
+// plugins instead of compiling them in. A plugin is any aiproxy-plugin-*
+// executable dropped into the configured plugins directory; it is spawned
+// as a child process, checks the magic-cookie env var Launch sets to
+// confirm it was started by the host rather than run directly, reports
+// back how to reach it (and the name to register it under) over a
+// handshake line on stdout, and is then driven through the same Provider
+// interface as any in-process adapter. A Supervisor keeps each plugin
+// alive across crashes and kills one that outgrows its memory limit. See
+// proto/provider.proto for the wire contract.
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pluginFilePrefix is the filename prefix Discover looks for, so the
+// plugins directory can be shared with other executables (helper scripts,
+// a README) without them being mistaken for plugin binaries.
+const pluginFilePrefix = "aiproxy-plugin-"
+
+// Config describes one discovered plugin binary. Name is initially the
+// filename with pluginFilePrefix stripped, but Launch prefers whatever
+// name the plugin advertises in its handshake once it's running, since
+// that's the name the request asked for it to be registered under.
+type Config struct {
+	Name        string
+	Path        string
+	Args        []string
+	Env         []string
+	MaxMemoryMB int
+}
+
+// Discover scans dir for executable regular files named aiproxy-plugin-*
+// and returns one Config per plugin found. A missing directory is not an
+// error: plugins are optional, so callers can pass a default path that
+// doesn't exist and get back an empty list.
+func Discover(dir string) ([]Config, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read plugins directory %s: %w", dir, err)
+	}
+
+	var configs []Config
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), pluginFilePrefix) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if info.Mode()&0111 == 0 {
+			// Not executable, e.g. a README dropped alongside the binaries.
+			continue
+		}
+
+		configs = append(configs, Config{
+			Name: strings.TrimPrefix(entry.Name(), pluginFilePrefix),
+			Path: filepath.Join(dir, entry.Name()),
+		})
+	}
+
+	return configs, nil
+}
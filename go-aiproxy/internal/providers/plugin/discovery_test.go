@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscover_FindsPrefixedExecutablesOnly(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, "aiproxy-plugin-vllm"), 0755)
+	writeFile(t, filepath.Join(dir, "vllm-backend"), 0755)
+	writeFile(t, filepath.Join(dir, "aiproxy-plugin-notes.md"), 0644)
+	writeFile(t, filepath.Join(dir, "README.md"), 0644)
+
+	configs, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover returned error: %v", err)
+	}
+
+	if len(configs) != 1 {
+		t.Fatalf("expected 1 plugin, got %d: %#v", len(configs), configs)
+	}
+	if configs[0].Name != "vllm" {
+		t.Fatalf("expected name vllm with the aiproxy-plugin- prefix stripped, got %s", configs[0].Name)
+	}
+}
+
+func TestDiscover_MissingDirIsNotAnError(t *testing.T) {
+	configs, err := Discover(filepath.Join(t.TempDir(), "does-not-exist"))
+	if err != nil {
+		t.Fatalf("Discover returned error for missing dir: %v", err)
+	}
+	if configs != nil {
+		t.Fatalf("expected no plugins, got %#v", configs)
+	}
+}
+
+func writeFile(t *testing.T, path string, mode os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), mode); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,53 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+// Loaded pairs a launched plugin's Supervisor with the Provider adapter
+// built on top of it, so callers can register the Provider with the pool
+// and still reach Close when the proxy shuts down.
+type Loaded struct {
+	Config     Config
+	Supervisor *Supervisor
+	Provider   *Provider
+}
+
+// LoadAll discovers every plugin binary in dir, launches each under a
+// Supervisor (so a crash is restarted with backoff instead of taking the
+// provider down permanently), and wraps it in a Provider. maxMemoryMB, if
+// non-zero, is applied to every discovered plugin as its resource limit.
+// A plugin that fails to launch is skipped with its error returned
+// alongside the plugins that did start, so one broken binary doesn't
+// prevent the others from loading.
+func LoadAll(dir string, maxMemoryMB int) ([]Loaded, []error) {
+	configs, err := Discover(dir)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var loaded []Loaded
+	var errs []error
+
+	for _, cfg := range configs {
+		cfg.MaxMemoryMB = maxMemoryMB
+
+		sup, err := NewSupervisor(cfg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("launch plugin %s: %w", cfg.Name, err))
+			continue
+		}
+
+		name := sup.Current().Name
+		config := &models.ProviderConfig{
+			Provider:  models.Provider(name),
+			UUID:      name,
+			IsHealthy: true,
+		}
+		loaded = append(loaded, Loaded{Config: cfg, Supervisor: sup, Provider: NewProvider(config, sup)})
+	}
+
+	return loaded, errs
+}
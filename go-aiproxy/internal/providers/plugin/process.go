@@ -0,0 +1,168 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// handshakeTimeout bounds how long Launch waits for a plugin to print
+// its handshake line before giving up.
+const handshakeTimeout = 10 * time.Second
+
+// shutdownGrace bounds how long Shutdown waits for a plugin to exit
+// after SIGTERM before it is force-killed.
+const shutdownGrace = 5 * time.Second
+
+// handshakeProtocolVersion is the wire version Launch expects a plugin's
+// handshake line to report. Bumping this is a breaking change for plugin
+// authors, same as go-plugin's CoreProtocolVersion.
+const handshakeProtocolVersion = "1"
+
+// MagicCookieKey and MagicCookieValue are set as an environment variable
+// on every plugin subprocess. A well-behaved plugin checks that this
+// variable is set to this exact value before serving, and refuses to run
+// otherwise — mirroring go-plugin's magic cookie, whose entire purpose is
+// giving a user who runs the plugin binary directly (instead of through
+// the proxy) a clear error instead of a confusing hang. The host does not
+// validate anything back; the check exists entirely for the plugin's own
+// benefit.
+const (
+	MagicCookieKey   = "AIPROXY_PLUGIN_MAGIC_COOKIE"
+	MagicCookieValue = "aiproxy-plugin-v1"
+)
+
+// Process is a running plugin child process, reachable over the unix
+// socket it reported on startup.
+type Process struct {
+	cfg      Config
+	cmd      *exec.Cmd
+	SockPath string
+
+	// Name is the name the plugin advertised in its handshake, which may
+	// differ from cfg.Name (the filename) if the plugin wants to be
+	// registered under a different identity.
+	Name string
+
+	done    chan struct{}
+	exitErr error
+}
+
+// Launch starts the plugin binary and waits for its handshake line on
+// stdout: "1|name|unix|/path/to/socket.sock\n" (protocol version, the name
+// to register the plugin under, network type, network address). This
+// mirrors the handshake convention HashiCorp's go-plugin uses for
+// out-of-process backends, so existing plugin authors will find it
+// familiar. A single goroutine started here owns the process's cmd.Wait,
+// so Shutdown and a Supervisor can both observe the exit via Done/ExitErr
+// without racing each other over exec.Cmd's single-call Wait.
+func Launch(cfg Config) (*Process, error) {
+	cmd := exec.Command(cfg.Path, cfg.Args...)
+	cmd.Env = append(append(os.Environ(), cfg.Env...), fmt.Sprintf("%s=%s", MagicCookieKey, MagicCookieValue))
+	cmd.Stderr = os.Stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: stdout pipe: %w", cfg.Name, err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %s: start: %w", cfg.Name, err)
+	}
+
+	name, sockPath, err := readHandshake(stdout, handshakeTimeout)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, fmt.Errorf("plugin %s: %w", cfg.Name, err)
+	}
+	if name == "" {
+		name = cfg.Name
+	}
+
+	p := &Process{cfg: cfg, cmd: cmd, SockPath: sockPath, Name: name, done: make(chan struct{})}
+	go func() {
+		p.exitErr = cmd.Wait()
+		close(p.done)
+	}()
+
+	return p, nil
+}
+
+// readHandshake reads the plugin's single handshake line and extracts the
+// name it wants to register under and the unix socket path it reports.
+func readHandshake(r io.Reader, timeout time.Duration) (name, sockPath string, err error) {
+	lineCh := make(chan string, 1)
+	errCh := make(chan error, 1)
+
+	go func() {
+		scanner := bufio.NewScanner(r)
+		if scanner.Scan() {
+			lineCh <- scanner.Text()
+			return
+		}
+		if err := scanner.Err(); err != nil {
+			errCh <- err
+			return
+		}
+		errCh <- fmt.Errorf("plugin closed stdout before handshaking")
+	}()
+
+	select {
+	case line := <-lineCh:
+		parts := strings.SplitN(strings.TrimSpace(line), "|", 4)
+		if len(parts) != 4 || parts[0] != handshakeProtocolVersion || parts[2] != "unix" {
+			return "", "", fmt.Errorf("unexpected handshake %q", line)
+		}
+		return parts[1], parts[3], nil
+	case err := <-errCh:
+		return "", "", err
+	case <-time.After(timeout):
+		return "", "", fmt.Errorf("timed out waiting for handshake")
+	}
+}
+
+// Done returns a channel closed once the plugin process has exited, for a
+// Supervisor to distinguish a deliberate Shutdown from a crash. ExitErr is
+// only meaningful after Done is closed.
+func (p *Process) Done() <-chan struct{} { return p.done }
+
+// ExitErr is the error (if any) cmd.Wait returned when the process exited.
+// Only meaningful after Done is closed.
+func (p *Process) ExitErr() error { return p.exitErr }
+
+// Pid returns the plugin process's OS pid, or 0 if it never started.
+func (p *Process) Pid() int {
+	if p.cmd.Process == nil {
+		return 0
+	}
+	return p.cmd.Process.Pid
+}
+
+// Shutdown gracefully stops the plugin: it sends SIGTERM and gives the
+// process shutdownGrace to exit on its own before forcing a kill.
+func (p *Process) Shutdown(ctx context.Context) error {
+	if p.cmd.Process == nil {
+		return nil
+	}
+
+	p.cmd.Process.Signal(syscall.SIGTERM)
+
+	graceCtx, cancel := context.WithTimeout(ctx, shutdownGrace)
+	defer cancel()
+
+	select {
+	case <-p.done:
+		return p.exitErr
+	case <-graceCtx.Done():
+		p.cmd.Process.Kill()
+		<-p.done
+		return p.exitErr
+	}
+}
@@ -0,0 +1,146 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/internal/providers"
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+// Provider adapts a supervised plugin process to the providers.Provider
+// interface, so a vLLM/llama.cpp/custom backend spawned from the plugins
+// directory is indistinguishable from an in-process provider everywhere
+// else in the proxy. It dials through sup.Current() on every call rather
+// than a fixed socket path, so a crash-and-relaunch by the Supervisor is
+// transparent to callers.
+type Provider struct {
+	providers.BaseProvider
+	sup        *Supervisor
+	httpClient *http.Client
+}
+
+// NewProvider builds a Provider bound to sup's supervised plugin process.
+func NewProvider(config *models.ProviderConfig, sup *Supervisor) *Provider {
+	return &Provider{
+		BaseProvider: providers.BaseProvider{
+			Config:   config,
+			Protocol: models.ProtocolClaude,
+		},
+		sup: sup,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "unix", sup.Current().SockPath)
+				},
+			},
+		},
+	}
+}
+
+// GenerateContent sends request (a *models.ClaudeRequest) to the plugin
+// and returns its *models.ClaudeResponse.
+func (p *Provider) GenerateContent(ctx context.Context, model string, request interface{}) (interface{}, error) {
+	var resp models.ClaudeResponse
+	if err := p.call(ctx, http.MethodPost, "/v1/messages", request, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GenerateContentStream streams the plugin's SSE response body back
+// unmodified, so the existing ConvertStreamChunk pipeline keeps working.
+func (p *Provider) GenerateContentStream(ctx context.Context, model string, request interface{}) (io.ReadCloser, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("marshal plugin stream request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://plugin/v1/messages/stream", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build plugin stream request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s stream: %w", p.sup.Current().Name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("plugin %s stream: status %d", p.sup.Current().Name, resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+// ListModels returns the models the plugin reports serving.
+func (p *Provider) ListModels(ctx context.Context) (interface{}, error) {
+	var list models.ModelList
+	if err := p.call(ctx, http.MethodGet, "/v1/models", nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// IsHealthy probes the plugin's health endpoint directly, rather than
+// relying on Config.IsHealthy alone, since a plugin process can die
+// without the pool's health-check loop having run yet.
+func (p *Provider) IsHealthy() bool {
+	req, err := http.NewRequest(http.MethodGet, "http://plugin/healthz", nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK
+}
+
+// Close stops the underlying Supervisor, ending crash recovery and
+// shutting down the currently-running plugin process.
+func (p *Provider) Close(ctx context.Context) error {
+	return p.sup.Stop(ctx)
+}
+
+func (p *Provider) call(ctx context.Context, method, path string, in, out interface{}) error {
+	var bodyReader io.Reader
+	if in != nil {
+		body, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshal plugin request: %w", err)
+		}
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, "http://plugin"+path, bodyReader)
+	if err != nil {
+		return fmt.Errorf("build plugin request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("plugin %s: %w", p.sup.Current().Name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("plugin %s: status %d", p.sup.Current().Name, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
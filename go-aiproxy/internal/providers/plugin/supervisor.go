@@ -0,0 +1,174 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// restartBackoffMin and restartBackoffMax bound the exponential backoff
+// Supervisor applies between relaunch attempts after a crash, so a plugin
+// stuck in a crash loop doesn't pin a CPU core forking it repeatedly.
+const (
+	restartBackoffMin = 1 * time.Second
+	restartBackoffMax = 30 * time.Second
+)
+
+// resourceCheckInterval is how often Supervisor polls a plugin's resident
+// memory when MaxMemoryMB is set.
+const resourceCheckInterval = 5 * time.Second
+
+// Supervisor keeps one plugin process alive: it relaunches the process
+// with exponential backoff whenever it exits unexpectedly, and kills (for
+// the same restart loop to relaunch) a process that outgrows its
+// configured memory limit. This is the crash-recovery and per-plugin
+// resource-limit behavior a plugin subsystem needs to avoid one
+// misbehaving backend taking the whole proxy down with it.
+type Supervisor struct {
+	cfg Config
+
+	mu   sync.RWMutex
+	proc *Process
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSupervisor launches cfg's plugin and starts supervising it.
+func NewSupervisor(cfg Config) (*Supervisor, error) {
+	proc, err := Launch(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Supervisor{cfg: cfg, proc: proc, stopChan: make(chan struct{})}
+	go s.watchExit()
+	if cfg.MaxMemoryMB > 0 {
+		go s.watchMemory()
+	}
+	return s, nil
+}
+
+// Current returns the plugin's currently-running Process. The returned
+// pointer is replaced (never mutated) on every relaunch, so callers
+// should call Current again rather than caching the result across calls
+// that might span a restart.
+func (s *Supervisor) Current() *Process {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.proc
+}
+
+// Stop ends supervision and shuts down the currently-running plugin
+// process.
+func (s *Supervisor) Stop(ctx context.Context) error {
+	var err error
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+		err = s.Current().Shutdown(ctx)
+	})
+	return err
+}
+
+// watchExit relaunches the plugin with exponential backoff every time it
+// exits, until Stop is called. Stop's own Shutdown also makes the process
+// exit, so watchExit always checks stopChan before treating an exit as a
+// crash to recover from.
+func (s *Supervisor) watchExit() {
+	backoff := restartBackoffMin
+	for {
+		proc := s.Current()
+
+		select {
+		case <-proc.Done():
+		case <-s.stopChan:
+			return
+		}
+
+		select {
+		case <-s.stopChan:
+			return
+		default:
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-s.stopChan:
+			return
+		}
+
+		newProc, err := Launch(s.cfg)
+		if err != nil {
+			backoff = nextBackoff(backoff)
+			continue
+		}
+
+		s.mu.Lock()
+		s.proc = newProc
+		s.mu.Unlock()
+		backoff = restartBackoffMin
+	}
+}
+
+// watchMemory polls the supervised process's resident memory and kills it
+// if it exceeds cfg.MaxMemoryMB; watchExit then relaunches it through the
+// normal crash-recovery path.
+func (s *Supervisor) watchMemory() {
+	ticker := time.NewTicker(resourceCheckInterval)
+	defer ticker.Stop()
+
+	limitBytes := int64(s.cfg.MaxMemoryMB) * 1024 * 1024
+
+	for {
+		select {
+		case <-ticker.C:
+			proc := s.Current()
+			rss, err := readRSSBytes(proc.Pid())
+			if err == nil && rss > limitBytes {
+				proc.cmd.Process.Kill()
+			}
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// nextBackoff doubles d, capped at restartBackoffMax.
+func nextBackoff(d time.Duration) time.Duration {
+	d *= 2
+	if d > restartBackoffMax {
+		return restartBackoffMax
+	}
+	return d
+}
+
+// readRSSBytes reads a process's resident set size from /proc, the same
+// best-effort source /proc-based tools like ps and top use. It only works
+// on Linux; elsewhere (and if the process has already exited) it returns
+// an error, which watchMemory treats as "skip this check".
+func readRSSBytes(pid int) (int64, error) {
+	if pid == 0 {
+		return 0, fmt.Errorf("plugin: process has no pid")
+	}
+
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "VmRSS:" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return kb * 1024, nil
+		}
+	}
+	return 0, fmt.Errorf("plugin: VmRSS not found in /proc/%d/status", pid)
+}
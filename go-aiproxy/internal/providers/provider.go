@@ -5,6 +5,7 @@ import (
 	"io"
 
 	"github.com/aiproxy/go-aiproxy/pkg/models"
+	"github.com/hashicorp/go-hclog"
 )
 
 // Provider defines the interface for all AI service providers
@@ -28,10 +29,54 @@ type Provider interface {
 	IsHealthy() bool
 }
 
+// TokenRevoker is implemented by providers whose authentication goes
+// through an auth.TokenManager that supports revocation (the OAuth-backed
+// providers: Kiro, Qwen). Providers authenticating with a plain static API
+// key (OpenAI, Claude) have nothing to revoke and don't implement it, so
+// callers should type-assert rather than adding a no-op to Provider
+// itself.
+type TokenRevoker interface {
+	// RevokeToken invalidates token so a future GetToken call on the
+	// underlying auth.TokenManager won't serve it from cache.
+	RevokeToken(ctx context.Context, token string) error
+}
+
 // BaseProvider provides common functionality for all providers
 type BaseProvider struct {
 	Config   *models.ProviderConfig
 	Protocol models.ProtocolPrefix
+	log      hclog.Logger
+}
+
+// SetLogger overrides the structured logger used by Logger(), for callers
+// that want this provider's log lines under a shared named-logger
+// hierarchy rather than the lazily-created default.
+func (p *BaseProvider) SetLogger(logger hclog.Logger) {
+	p.log = logger
+}
+
+// Logger returns the provider's structured logger, lazily creating a
+// named sub-logger at the level from p.Config.LogLevel (default Info) for
+// providers that never called SetLogger, so every call site can just use
+// p.Logger() without nil-checking.
+func (p *BaseProvider) Logger() hclog.Logger {
+	if p.log != nil {
+		return p.log
+	}
+
+	name := "provider"
+	level := hclog.Info
+	if p.Config != nil {
+		if p.Config.Provider != "" {
+			name = "provider." + string(p.Config.Provider)
+		}
+		if p.Config.LogLevel != "" {
+			level = hclog.LevelFromString(p.Config.LogLevel)
+		}
+	}
+
+	p.log = hclog.New(&hclog.LoggerOptions{Name: name, Level: level})
+	return p.log
 }
 
 // GetProtocolPrefix returns the protocol prefix
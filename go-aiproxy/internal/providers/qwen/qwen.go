@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/aiproxy/go-aiproxy/internal/auth"
+	"github.com/aiproxy/go-aiproxy/internal/observability"
 	"github.com/aiproxy/go-aiproxy/internal/providers"
 	"github.com/aiproxy/go-aiproxy/pkg/models"
 	"golang.org/x/oauth2"
@@ -46,7 +47,11 @@ func NewClient(config *models.ProviderConfig) (*Client, error) {
 
 	// Initialize OAuth if credentials are provided
 	if config.OAuthCredsFile != "" {
-		tokenManager, err := auth.NewTokenManager(config)
+		tokenStore, err := auth.NewTokenStoreFromConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create token store: %w", err)
+		}
+		tokenManager, err := auth.NewTokenManager(config, auth.WithTokenStore(tokenStore))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create token manager: %w", err)
 		}
@@ -61,20 +66,35 @@ func NewClient(config *models.ProviderConfig) (*Client, error) {
 	return client, nil
 }
 
-// initialize sets up OAuth authentication
+// initialize sets up OAuth authentication. c.tokenManager itself is an
+// oauth2.TokenSource (it wraps its refresh logic, including the
+// cross-process lock from WithTokenStore, in an oauth2.ReuseTokenSource),
+// so oauth2.NewClient's Transport calls back into it on every request
+// instead of this client swapping out a StaticTokenSource by hand each
+// time the token rotates.
 func (c *Client) initialize(ctx context.Context) error {
-	token, err := c.tokenManager.GetToken(ctx)
-	if err != nil {
+	if _, err := c.tokenManager.Token(); err != nil {
 		return fmt.Errorf("failed to get token: %w", err)
 	}
 
-	// Create OAuth2 client
-	c.httpClient = oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
+	c.httpClient = oauth2.NewClient(ctx, c.tokenManager)
 	c.isInitialized = true
 
+	c.tokenManager.StartAutoRefresh(ctx)
+
 	return nil
 }
 
+// Stop stops the token manager's background auto-refresh goroutine.
+// Providers don't have a generic shutdown hook today, so callers that
+// create a Qwen Client directly (rather than through the pool manager's
+// lifecycle) should call this themselves when done with it.
+func (c *Client) Stop() {
+	if c.tokenManager != nil {
+		c.tokenManager.Close()
+	}
+}
+
 // GenerateContent implements the Provider interface
 func (c *Client) GenerateContent(ctx context.Context, model string, request interface{}) (interface{}, error) {
 	// Ensure initialized
@@ -204,18 +224,25 @@ func (c *Client) RefreshToken(ctx context.Context) error {
 		return nil // No OAuth configured
 	}
 
-	// Force token refresh
-	token, err := c.tokenManager.RefreshToken(ctx)
-	if err != nil {
+	// c.httpClient's Transport already calls back into c.tokenManager on
+	// every request, so forcing a refresh here only needs to hit the
+	// token endpoint; there's no separate client to swap out.
+	if _, err := c.tokenManager.RefreshToken(ctx); err != nil {
 		return fmt.Errorf("failed to refresh token: %w", err)
 	}
 
-	// Update HTTP client with new token
-	c.httpClient = oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
-	
 	return nil
 }
 
+// RevokeToken implements providers.TokenRevoker by invalidating token in
+// c.tokenManager, so a compromised token stops being served from cache.
+func (c *Client) RevokeToken(ctx context.Context, token string) error {
+	if c.tokenManager == nil {
+		return fmt.Errorf("qwen: no token manager configured")
+	}
+	return c.tokenManager.RevokeToken(ctx, token)
+}
+
 // IsHealthy checks if the provider is healthy including token validity
 func (c *Client) IsHealthy() bool {
 	if !c.BaseProvider.IsHealthy() {
@@ -293,6 +320,9 @@ func (c *Client) enhanceWithBuiltinTools(req *models.OpenAIRequest) *models.Open
 
 // makeRequest is a helper method to make HTTP requests
 func (c *Client) makeRequest(ctx context.Context, method, url string, body interface{}) (*http.Response, error) {
+	ctx, span := observability.StartSpan(ctx, "provider.qwen.request")
+	defer span.End()
+
 	var reqBody io.Reader
 	if body != nil {
 		jsonData, err := json.Marshal(body)
@@ -309,6 +339,7 @@ func (c *Client) makeRequest(ctx context.Context, method, url string, body inter
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
+	observability.Inject(ctx, req.Header)
 
 	// Make request
 	resp, err := c.httpClient.Do(req)
@@ -330,7 +361,7 @@ func (c *Client) makeRequest(ctx context.Context, method, url string, body inter
 			}
 		}
 		
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, models.ClassifyHTTPError("qwen", resp.StatusCode, bodyBytes)
 	}
 
 	return resp, nil
@@ -0,0 +1,210 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+	"github.com/gorilla/websocket"
+)
+
+// reattachEnvVar names the environment variable read at startup to discover
+// externally-managed provider backends, borrowing Terraform's "unmanaged
+// providers" pattern: operators run the backend out-of-band (a local CLI
+// shim, a debug build under `dlv attach`) and the proxy attaches to it
+// instead of spawning or dialing the normally configured upstream.
+const reattachEnvVar = "AIPROXY_REATTACH_PROVIDERS"
+
+// ReattachConfig describes how to reach an externally-managed provider
+// backend.
+type ReattachConfig struct {
+	Protocol       string `json:"protocol"` // "unix", "tcp", or "ws"
+	Addr           string `json:"addr"`
+	ProtocolPrefix string `json:"protocolPrefix"`
+}
+
+// ParseReattachEnv reads AIPROXY_REATTACH_PROVIDERS as JSON of the form
+// {"<providerType>/<uuid>": {"protocol":"unix|tcp|ws","addr":"...","protocolPrefix":"openai"}}.
+// An unset or empty variable returns a nil map and no error.
+func ParseReattachEnv() (map[string]ReattachConfig, error) {
+	raw := os.Getenv(reattachEnvVar)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var entries map[string]ReattachConfig
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", reattachEnvVar, err)
+	}
+	return entries, nil
+}
+
+// LookupReattach looks up the reattach config for a provider type + UUID
+// pair in a map returned by ParseReattachEnv.
+func LookupReattach(entries map[string]ReattachConfig, providerType, uuid string) (ReattachConfig, bool) {
+	cfg, ok := entries[providerType+"/"+uuid]
+	return cfg, ok
+}
+
+// ReattachProvider is a thin Provider wrapper that forwards every call to an
+// out-of-band process over the configured transport instead of talking to
+// the provider's normal upstream API. It reports itself as always healthy:
+// the operator is assumed to be actively managing the backend process.
+type ReattachProvider struct {
+	BaseProvider
+	cfg        ReattachConfig
+	httpClient *http.Client
+}
+
+// NewReattachProvider builds a Provider that routes requests to an
+// externally-managed backend described by cfg.
+func NewReattachProvider(cfg ReattachConfig) *ReattachProvider {
+	p := &ReattachProvider{
+		cfg: cfg,
+		BaseProvider: BaseProvider{
+			Protocol: models.ProtocolPrefix(cfg.ProtocolPrefix),
+			Config:   &models.ProviderConfig{IsHealthy: true},
+		},
+	}
+
+	transport := &http.Transport{}
+	switch cfg.Protocol {
+	case "unix":
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", cfg.Addr)
+		}
+	default:
+		transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "tcp", cfg.Addr)
+		}
+	}
+
+	p.httpClient = &http.Client{Transport: transport, Timeout: 60 * time.Second}
+
+	return p
+}
+
+func (p *ReattachProvider) requestURL(path string) string {
+	if p.cfg.Protocol == "unix" {
+		return "http://unix" + path
+	}
+	return "http://" + p.cfg.Addr + path
+}
+
+// GenerateContent forwards a non-streaming completion request to the
+// reattached backend.
+func (p *ReattachProvider) GenerateContent(ctx context.Context, model string, request interface{}) (interface{}, error) {
+	if p.cfg.Protocol == "ws" {
+		return p.generateContentWS(ctx, model, request)
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal reattach request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.requestURL("/v1/chat/completions"), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reattach request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode reattach response: %w", err)
+	}
+
+	return result, nil
+}
+
+// generateContentWS forwards a request over a WebSocket connection to the
+// reattached backend and waits for a single response frame.
+func (p *ReattachProvider) generateContentWS(ctx context.Context, model string, request interface{}) (interface{}, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, "ws://"+p.cfg.Addr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial reattach websocket: %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteJSON(map[string]interface{}{"model": model, "request": request}); err != nil {
+		return nil, fmt.Errorf("failed to write reattach websocket request: %w", err)
+	}
+
+	var result interface{}
+	if err := conn.ReadJSON(&result); err != nil {
+		return nil, fmt.Errorf("failed to read reattach websocket response: %w", err)
+	}
+
+	return result, nil
+}
+
+// GenerateContentStream forwards a streaming completion request to the
+// reattached backend and returns its raw response body for the caller to
+// read.
+func (p *ReattachProvider) GenerateContentStream(ctx context.Context, model string, request interface{}) (io.ReadCloser, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal reattach request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.requestURL("/v1/chat/completions"), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reattach stream request failed: %w", err)
+	}
+
+	return resp.Body, nil
+}
+
+// ListModels asks the reattached backend for its model list.
+func (p *ReattachProvider) ListModels(ctx context.Context) (interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.requestURL("/v1/models"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reattach list models failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode reattach model list: %w", err)
+	}
+
+	return result, nil
+}
+
+// IsHealthy always reports healthy for a reattached provider.
+func (p *ReattachProvider) IsHealthy() bool {
+	return true
+}
+
+// RefreshToken is a no-op: reattached backends manage their own
+// credentials out-of-band.
+func (p *ReattachProvider) RefreshToken(ctx context.Context) error {
+	return nil
+}
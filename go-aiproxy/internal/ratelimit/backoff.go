@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+// BackoffPolicy governs how a provider client retries a 429/503 response:
+// honor the provider's own Retry-After header when present, otherwise
+// wait min(Cap, Base*2^attempt) scaled by a random factor in [0.5, 1) so
+// that many replicas retrying the same outage don't all hammer the
+// provider in lockstep.
+type BackoffPolicy struct {
+	Base        time.Duration
+	Cap         time.Duration
+	MaxAttempts int
+}
+
+// DefaultBackoffPolicy returns sensible defaults: a 500ms base, a 30s cap,
+// and up to 5 attempts total (the initial request plus 4 retries).
+func DefaultBackoffPolicy() BackoffPolicy {
+	return BackoffPolicy{
+		Base:        500 * time.Millisecond,
+		Cap:         30 * time.Second,
+		MaxAttempts: 5,
+	}
+}
+
+// BackoffPolicyFromConfig builds a BackoffPolicy from a provider's
+// configured retry settings, falling back to DefaultBackoffPolicy's
+// values for any field left at its zero value.
+func BackoffPolicyFromConfig(cfg *models.ProviderConfig) BackoffPolicy {
+	policy := DefaultBackoffPolicy()
+	if cfg == nil {
+		return policy
+	}
+	if cfg.RetryBaseDelayMs > 0 {
+		policy.Base = time.Duration(cfg.RetryBaseDelayMs) * time.Millisecond
+	}
+	if cfg.RetryMaxDelayMs > 0 {
+		policy.Cap = time.Duration(cfg.RetryMaxDelayMs) * time.Millisecond
+	}
+	if cfg.RetryMaxAttempts > 0 {
+		policy.MaxAttempts = cfg.RetryMaxAttempts
+	}
+	return policy
+}
+
+// ShouldRetry reports whether statusCode warrants a retry given that
+// attempt (0-indexed) has already been made. statusCode 0 signals a
+// transport-level error (no response was received at all), which is
+// retried the same as a 429/503.
+func (b BackoffPolicy) ShouldRetry(statusCode, attempt int) bool {
+	if attempt+1 >= b.MaxAttempts {
+		return false
+	}
+	return statusCode == 0 || statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+// Delay returns how long to wait before the next attempt. retryAfter is
+// the provider's parsed Retry-After duration, or 0 if it didn't send one
+// (or it couldn't be parsed), in which case the exponential backoff with
+// jitter is used instead.
+func (b BackoffPolicy) Delay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	backoff := b.Base << uint(attempt)
+	if backoff <= 0 || backoff > b.Cap {
+		backoff = b.Cap
+	}
+
+	jitter := 0.5 + rand.Float64()*0.5
+	return time.Duration(float64(backoff) * jitter)
+}
+
+// ParseRetryAfter parses an HTTP Retry-After header, which is either a
+// number of seconds or an HTTP-date. Returns 0 if header is empty or
+// unparseable as either form.
+func ParseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(header); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a classic token bucket sized by a per-minute capacity:
+// it refills continuously at capacity/60 tokens per second rather than in
+// discrete per-minute steps, so a caller spreading requests evenly across
+// the minute never gets throttled by an artificial window boundary.
+type tokenBucket struct {
+	mu           sync.Mutex
+	capacity     float64
+	tokens       float64
+	refillPerSec float64
+	last         time.Time
+}
+
+func newTokenBucket(capacityPerMinute float64) *tokenBucket {
+	return &tokenBucket{
+		capacity:     capacityPerMinute,
+		tokens:       capacityPerMinute,
+		refillPerSec: capacityPerMinute / 60,
+		last:         time.Now(),
+	}
+}
+
+// allow reports whether n tokens are available and, if so, consumes them.
+func (b *tokenBucket) allow(n float64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.refillPerSec)
+
+	if b.tokens >= n {
+		b.tokens -= n
+		return true
+	}
+	return false
+}
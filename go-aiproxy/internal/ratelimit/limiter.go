@@ -0,0 +1,152 @@
+package ratelimit
+
+import "sync"
+
+// providerBuckets holds the token buckets enforcing one provider's RPM/TPM
+// limits, both at the provider level (shared across every caller) and per
+// API key. Each key's buckets are sized by the same rpmCap/tpmCap as the
+// provider-level ones, so a single key can use up the shared provider
+// budget on its own; the per-key buckets never deny a request the
+// provider-level buckets would allow.
+type providerBuckets struct {
+	rpmCap int
+	tpmCap int
+
+	rpm *tokenBucket
+	tpm *tokenBucket
+
+	mu     sync.Mutex
+	keyRPM map[string]*tokenBucket
+	keyTPM map[string]*tokenBucket
+}
+
+// Limiter enforces per-provider and per-API-key token-bucket rate limits
+// sized by requests-per-minute and tokens-per-minute. A provider with no
+// configured limit (rpm and tpm both <= 0) is never throttled.
+type Limiter struct {
+	mu        sync.Mutex
+	providers map[string]*providerBuckets
+
+	// callers holds buckets keyed directly by caller identity (e.g. an
+	// OIDC subject or email) rather than by provider, for callers whose
+	// own limit differs from their provider's shared per-key cap — see
+	// AllowCaller.
+	callersMu sync.Mutex
+	callers   map[string]*providerBuckets
+}
+
+// NewLimiter creates an empty Limiter. Call Configure for each provider
+// that should be rate limited before calling Allow.
+func NewLimiter() *Limiter {
+	return &Limiter{
+		providers: make(map[string]*providerBuckets),
+		callers:   make(map[string]*providerBuckets),
+	}
+}
+
+// Configure sets (or replaces) the RPM/TPM limits for provider. rpm or tpm
+// <= 0 disables that dimension's limit for the provider.
+func (l *Limiter) Configure(provider string, rpm, tpm int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	pb := &providerBuckets{
+		rpmCap: rpm,
+		tpmCap: tpm,
+		keyRPM: make(map[string]*tokenBucket),
+		keyTPM: make(map[string]*tokenBucket),
+	}
+	if rpm > 0 {
+		pb.rpm = newTokenBucket(float64(rpm))
+	}
+	if tpm > 0 {
+		pb.tpm = newTokenBucket(float64(tpm))
+	}
+	l.providers[provider] = pb
+}
+
+// Allow reports whether a request for apiKey against provider estimated
+// to use estimatedTokens tokens may proceed, consuming from the relevant
+// buckets if so. A provider with no Configure call is always allowed; an
+// apiKey of "" is only checked at the provider level.
+func (l *Limiter) Allow(provider, apiKey string, estimatedTokens int) bool {
+	l.mu.Lock()
+	pb, ok := l.providers[provider]
+	l.mu.Unlock()
+	if !ok {
+		return true
+	}
+
+	if pb.rpm != nil && !pb.rpm.allow(1) {
+		return false
+	}
+	if pb.tpm != nil && !pb.tpm.allow(float64(estimatedTokens)) {
+		return false
+	}
+
+	if apiKey == "" {
+		return true
+	}
+
+	if pb.rpmCap > 0 {
+		if !pb.keyBucket(&pb.keyRPM, apiKey, float64(pb.rpmCap)).allow(1) {
+			return false
+		}
+	}
+	if pb.tpmCap > 0 {
+		if !pb.keyBucket(&pb.keyTPM, apiKey, float64(pb.tpmCap)).allow(float64(estimatedTokens)) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllowCaller reports whether a request from caller, estimated to use
+// estimatedTokens tokens, may proceed against its own rpm/tpm budget,
+// independent of any provider-level limit Allow also enforces. rpm or tpm
+// <= 0 disables that dimension for caller. This is meant for per-caller
+// limits resolved from something other than provider configuration (e.g.
+// middleware.CallerPolicy for an OIDC-authenticated caller), where the
+// cap can differ from the provider's own per-key cap that Allow applies
+// uniformly to every caller.
+func (l *Limiter) AllowCaller(caller string, rpm, tpm int, estimatedTokens int) bool {
+	if caller == "" || (rpm <= 0 && tpm <= 0) {
+		return true
+	}
+
+	l.callersMu.Lock()
+	pb, ok := l.callers[caller]
+	if !ok {
+		pb = &providerBuckets{rpmCap: rpm, tpmCap: tpm}
+		if rpm > 0 {
+			pb.rpm = newTokenBucket(float64(rpm))
+		}
+		if tpm > 0 {
+			pb.tpm = newTokenBucket(float64(tpm))
+		}
+		l.callers[caller] = pb
+	}
+	l.callersMu.Unlock()
+
+	if pb.rpm != nil && !pb.rpm.allow(1) {
+		return false
+	}
+	if pb.tpm != nil && !pb.tpm.allow(float64(estimatedTokens)) {
+		return false
+	}
+	return true
+}
+
+// keyBucket returns the bucket for apiKey in buckets, creating it with
+// capacity if it doesn't exist yet.
+func (pb *providerBuckets) keyBucket(buckets *map[string]*tokenBucket, apiKey string, capacity float64) *tokenBucket {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	b, ok := (*buckets)[apiKey]
+	if !ok {
+		b = newTokenBucket(capacity)
+		(*buckets)[apiKey] = b
+	}
+	return b
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLimiter_UnconfiguredProviderAlwaysAllows(t *testing.T) {
+	l := NewLimiter()
+	for i := 0; i < 100; i++ {
+		if !l.Allow("openai-custom", "key1", 1000) {
+			t.Fatal("Allow() denied a request for an unconfigured provider")
+		}
+	}
+}
+
+func TestLimiter_ProviderRPMCapEnforced(t *testing.T) {
+	l := NewLimiter()
+	l.Configure("openai-custom", 2, 0)
+
+	if !l.Allow("openai-custom", "", 0) {
+		t.Fatal("first request should be allowed")
+	}
+	if !l.Allow("openai-custom", "", 0) {
+		t.Fatal("second request should be allowed")
+	}
+	if l.Allow("openai-custom", "", 0) {
+		t.Fatal("third request should be denied by the RPM cap")
+	}
+}
+
+func TestLimiter_ProviderCapSharedAcrossKeys(t *testing.T) {
+	l := NewLimiter()
+	l.Configure("openai-custom", 1, 0)
+
+	if !l.Allow("openai-custom", "key-a", 0) {
+		t.Fatal("key-a's first request should be allowed")
+	}
+	// key-a's own bucket is now empty, but the provider-level bucket is
+	// also exhausted at cap 1, so key-b is denied too.
+	if l.Allow("openai-custom", "key-b", 0) {
+		t.Fatal("key-b should be denied once the shared provider bucket is exhausted")
+	}
+}
+
+func TestLimiter_TPMCapEnforced(t *testing.T) {
+	l := NewLimiter()
+	l.Configure("openai-custom", 0, 100)
+
+	if !l.Allow("openai-custom", "", 60) {
+		t.Fatal("60 tokens should fit in a 100 TPM budget")
+	}
+	if l.Allow("openai-custom", "", 60) {
+		t.Fatal("a second 60-token request should exceed the 100 TPM budget")
+	}
+}
+
+func TestBackoffPolicy_ShouldRetry(t *testing.T) {
+	b := BackoffPolicy{MaxAttempts: 3}
+
+	if !b.ShouldRetry(http.StatusTooManyRequests, 0) {
+		t.Error("attempt 0 with 429 should retry")
+	}
+	if !b.ShouldRetry(http.StatusServiceUnavailable, 1) {
+		t.Error("attempt 1 with 503 should retry")
+	}
+	if b.ShouldRetry(http.StatusTooManyRequests, 2) {
+		t.Error("attempt 2 (3rd try) should not retry when MaxAttempts=3")
+	}
+	if b.ShouldRetry(http.StatusBadRequest, 0) {
+		t.Error("400 should never be retried by this policy")
+	}
+	if !b.ShouldRetry(0, 0) {
+		t.Error("statusCode 0 (transport error) should be retried like a 429/503")
+	}
+}
+
+func TestBackoffPolicy_DelayHonorsRetryAfter(t *testing.T) {
+	b := DefaultBackoffPolicy()
+	if got := b.Delay(0, 7*time.Second); got != 7*time.Second {
+		t.Errorf("Delay() = %v, want 7s when Retry-After is set", got)
+	}
+}
+
+func TestBackoffPolicy_DelayCapsExponentialGrowth(t *testing.T) {
+	b := BackoffPolicy{Base: time.Second, Cap: 4 * time.Second, MaxAttempts: 10}
+	for attempt := 0; attempt < 10; attempt++ {
+		d := b.Delay(attempt, 0)
+		if d > b.Cap {
+			t.Errorf("Delay(%d) = %v, exceeds cap %v", attempt, d, b.Cap)
+		}
+	}
+}
+
+func TestParseRetryAfter_Seconds(t *testing.T) {
+	if got := ParseRetryAfter("5"); got != 5*time.Second {
+		t.Errorf("ParseRetryAfter(\"5\") = %v, want 5s", got)
+	}
+	if got := ParseRetryAfter(""); got != 0 {
+		t.Errorf("ParseRetryAfter(\"\") = %v, want 0", got)
+	}
+	if got := ParseRetryAfter("not-a-duration"); got != 0 {
+		t.Errorf("ParseRetryAfter(garbage) = %v, want 0", got)
+	}
+}
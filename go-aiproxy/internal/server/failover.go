@@ -0,0 +1,344 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/internal/metrics"
+	"github.com/aiproxy/go-aiproxy/internal/pool"
+	"github.com/aiproxy/go-aiproxy/internal/providers"
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// hedgeStats counts, per provider name, how many times that provider's
+// response was hedged against and whether the primary or the hedge won,
+// surfaced read-only through handleAdminProviders.
+type hedgeStats struct {
+	mu    sync.Mutex
+	stats map[string]*hedgeCounts
+}
+
+type hedgeCounts struct {
+	Fired      int `json:"fired"`
+	PrimaryWon int `json:"primary_won"`
+	HedgeWon   int `json:"hedge_won"`
+}
+
+func newHedgeStats() *hedgeStats {
+	return &hedgeStats{stats: make(map[string]*hedgeCounts)}
+}
+
+// counts returns provider's counters, creating them on first use. Callers
+// must hold h.mu.
+func (h *hedgeStats) counts(provider string) *hedgeCounts {
+	c, ok := h.stats[provider]
+	if !ok {
+		c = &hedgeCounts{}
+		h.stats[provider] = c
+	}
+	return c
+}
+
+func (h *hedgeStats) fired(provider string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts(provider).Fired++
+}
+
+func (h *hedgeStats) primaryWon(provider string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts(provider).PrimaryWon++
+}
+
+func (h *hedgeStats) hedgeWon(provider string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts(provider).HedgeWon++
+}
+
+func (h *hedgeStats) snapshot() map[string]hedgeCounts {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := make(map[string]hedgeCounts, len(h.stats))
+	for k, v := range h.stats {
+		out[k] = *v
+	}
+	return out
+}
+
+// breaker returns name's circuit breaker, creating it with
+// pool.DefaultCircuitBreaker settings on first use and wiring its state
+// changes into aiproxy_provider_circuit_state/aiproxy_provider_circuit_trips_total.
+func (s *Server) breaker(name string) *pool.CircuitBreaker {
+	s.breakersMu.Lock()
+	defer s.breakersMu.Unlock()
+
+	b, ok := s.breakers[name]
+	if !ok {
+		b = pool.DefaultCircuitBreaker()
+		b.SetOnStateChange(func(from, to pool.BreakerState) {
+			metrics.Default().RecordCircuitState(name, circuitStateValue(to))
+		})
+		b.SetOnTrip(func(reason string) {
+			metrics.Default().RecordCircuitTrip(name, reason)
+		})
+		s.breakers[name] = b
+	}
+	return b
+}
+
+// circuitStateValue maps a pool.BreakerState to the numbering
+// aiproxy_provider_circuit_state documents (0=closed, 1=half-open,
+// 2=open), which intentionally does not match BreakerState's own iota
+// order.
+func circuitStateValue(state pool.BreakerState) float64 {
+	switch state {
+	case pool.BreakerHalfOpen:
+		return 1
+	case pool.BreakerOpen:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// failoverChain orders primary first, then every other configured
+// provider in s.config.ModelProviders order, so a tripped breaker on
+// primary falls through to the next provider that's still closed.
+func (s *Server) failoverChain(primary string) []string {
+	chain := []string{primary}
+	for _, name := range s.config.ModelProviders {
+		if name != primary {
+			chain = append(chain, name)
+		}
+	}
+	return chain
+}
+
+// generateWithFailover walks chain in order, skipping any provider whose
+// circuit breaker is currently open, converting req (always in
+// fromProtocol) into each candidate's native protocol before calling it.
+// It returns the first successful response along with the protocol it
+// came back in, so the caller can convert it back to fromProtocol itself.
+//
+// A provider error that classifies as a *models.APIError with
+// Retryable == false (e.g. rejected credentials) stops the walk
+// immediately instead of falling through to the next provider: another
+// instance of the same provider, or a different provider entirely, isn't
+// expected to succeed where this one failed for a non-retryable reason,
+// so trying it would just add latency before surfacing the same kind of
+// error anyway.
+func (s *Server) generateWithFailover(ctx context.Context, chain []string, model string, req interface{}, fromProtocol models.ProtocolPrefix) (interface{}, models.ProtocolPrefix, error) {
+	var lastErr error
+	for i, name := range chain {
+		provider, ok := s.providers[name]
+		if !ok {
+			continue
+		}
+
+		breaker := s.breaker(name)
+		if !breaker.Allow() {
+			lastErr = models.NewAPIError(models.ErrCircuitOpen, name, "circuit breaker open")
+			continue
+		}
+
+		if i > 0 {
+			metrics.Default().PoolFailovers.WithLabelValues(chain[0], name).Inc()
+		}
+
+		toProtocol := provider.GetProtocolPrefix()
+		candidateReq := req
+		if fromProtocol != toProtocol {
+			converted, err := s.converter.ConvertRequest(req, fromProtocol, toProtocol)
+			if err != nil {
+				lastErr = err
+				breaker.RecordResult(err, 0)
+				continue
+			}
+			candidateReq = converted
+		}
+
+		start := time.Now()
+		resp, err := provider.GenerateContent(ctx, model, candidateReq)
+		breaker.RecordResult(err, time.Since(start))
+		if err != nil {
+			lastErr = err
+			if apiErr, ok := err.(*models.APIError); ok && !apiErr.Retryable {
+				break
+			}
+			continue
+		}
+
+		return resp, toProtocol, nil
+	}
+
+	if lastErr == nil {
+		lastErr = models.NewAPIError(models.ErrNoHealthyInstance, "", "no healthy provider available")
+	}
+	return nil, "", lastErr
+}
+
+// writeProviderError translates err into an HTTP response. A
+// *models.APIError carries its own status code and renders as OpenAI's
+// {"error": {...}} shape (type/code included, so clients built against the
+// OpenAI SDK can branch on them the same way they would for OpenAI
+// itself); any other error is wrapped as an ErrUpstream APIError, so it
+// renders in the same shape with a 502 status. Either way, the error is
+// counted in aiproxy_errors_total by its taxonomy code ("upstream_error"
+// for the wrapped case).
+func (s *Server) writeProviderError(c *gin.Context, err error) {
+	apiErr, ok := err.(*models.APIError)
+	if !ok {
+		apiErr = models.NewAPIError(models.ErrUpstream, "", err.Error())
+	}
+	s.obsMetrics.RecordError(string(apiErr.Code))
+	c.JSON(apiErr.HTTPStatus(), apiErr.ToOpenAIResponse())
+}
+
+// hedgedResult is one of the two racing generateWithFailover outcomes.
+type hedgedResult struct {
+	resp     interface{}
+	protocol models.ProtocolPrefix
+	err      error
+}
+
+// generateWithHedging wraps generateWithFailover with hedged requests:
+// if chain[0] hasn't completed within HedgeAfterMs, a duplicate request
+// is fired against the rest of chain, and whichever finishes first wins,
+// cancelling the other via ctx. Falls back to plain failover when
+// hedging is disabled or there's nowhere to hedge to.
+func (s *Server) generateWithHedging(ctx context.Context, chain []string, model string, req interface{}, fromProtocol models.ProtocolPrefix) (interface{}, models.ProtocolPrefix, error) {
+	if !s.config.HedgeEnabled || len(chain) < 2 {
+		return s.generateWithFailover(ctx, chain, model, req, fromProtocol)
+	}
+
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	primary := make(chan hedgedResult, 1)
+	go func() {
+		resp, proto, err := s.generateWithFailover(hedgeCtx, chain[:1], model, req, fromProtocol)
+		primary <- hedgedResult{resp, proto, err}
+	}()
+
+	// primaryFailed is set when chain[0] comes back with an error before
+	// hedgeAfter even elapses, so the select below knows not to wait on
+	// the (already drained) primary channel again.
+	var primaryFailed *hedgedResult
+	hedgeAfter := time.Duration(s.config.HedgeAfterMs) * time.Millisecond
+	select {
+	case r := <-primary:
+		if r.err == nil {
+			return r.resp, r.protocol, r.err
+		}
+		primaryFailed = &r
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	case <-time.After(hedgeAfter):
+	}
+
+	s.hedges.fired(chain[0])
+	hedge := make(chan hedgedResult, 1)
+	go func() {
+		resp, proto, err := s.generateWithFailover(hedgeCtx, chain[1:], model, req, fromProtocol)
+		hedge <- hedgedResult{resp, proto, err}
+	}()
+
+	if primaryFailed != nil {
+		// chain[0] already lost the race outright (not just slow), so the
+		// rest of the chain is the only attempt left to wait on.
+		select {
+		case r := <-hedge:
+			cancel()
+			s.hedges.hedgeWon(chain[0])
+			return r.resp, r.protocol, r.err
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		}
+	}
+
+	select {
+	case r := <-primary:
+		cancel()
+		s.hedges.primaryWon(chain[0])
+		return r.resp, r.protocol, r.err
+	case r := <-hedge:
+		cancel()
+		s.hedges.hedgeWon(chain[0])
+		return r.resp, r.protocol, r.err
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	}
+}
+
+// handleAdminProviders reports each configured provider's circuit-breaker
+// state and hedge-request counters.
+func (s *Server) handleAdminProviders(c *gin.Context) {
+	hedgeSnapshot := s.hedges.snapshot()
+
+	type providerStatus struct {
+		Breaker pool.BreakerStats `json:"breaker"`
+		Hedge   hedgeCounts       `json:"hedge"`
+	}
+	result := make(map[string]providerStatus, len(s.providers))
+	for name := range s.providers {
+		result[name] = providerStatus{
+			Breaker: s.breaker(name).Stats(),
+			Hedge:   hedgeSnapshot[name],
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"providers": result})
+}
+
+// handleAdminUsage reports accumulated per-caller request/token/byte
+// totals from metrics.Default().Users, for downstream billing.
+func (s *Server) handleAdminUsage(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"users": metrics.Default().Users.Snapshot()})
+}
+
+// revokeTokenRequest is the body handleAuthRevoke expects. Both fields are
+// required: provider identifies which configured provider's TokenManager
+// to revoke against, and token is the exact access token to invalidate.
+type revokeTokenRequest struct {
+	Provider string `json:"provider" binding:"required"`
+	Token    string `json:"token" binding:"required"`
+}
+
+// handleAuthRevoke invalidates an OAuth access token for one of the
+// configured OAuth-backed providers (Kiro, Qwen), so a token known to be
+// compromised in the field stops being served from cache before its
+// natural expiry. Providers that don't implement providers.TokenRevoker
+// (OpenAI, Claude's static API-key auth) reject the request with 400.
+func (s *Server) handleAuthRevoke(c *gin.Context) {
+	var req revokeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	provider, ok := s.providers[req.Provider]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid provider"})
+		return
+	}
+
+	revoker, ok := provider.(providers.TokenRevoker)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("provider %q does not support token revocation", req.Provider)})
+		return
+	}
+
+	if err := revoker.RevokeToken(c.Request.Context(), req.Token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revoked": true})
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/internal/config"
+	"github.com/aiproxy/go-aiproxy/internal/pool"
+	"github.com/aiproxy/go-aiproxy/internal/providers"
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+// stubProvider is a minimal providers.Provider that blocks GenerateContent
+// for delay before returning resp/err, so tests can control which of the
+// hedged requests finishes first.
+type stubProvider struct {
+	delay time.Duration
+	resp  interface{}
+	err   error
+}
+
+func (p *stubProvider) GenerateContent(ctx context.Context, model string, request interface{}) (interface{}, error) {
+	select {
+	case <-time.After(p.delay):
+		return p.resp, p.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *stubProvider) GenerateContentStream(ctx context.Context, model string, request interface{}) (io.ReadCloser, error) {
+	return nil, nil
+}
+
+func (p *stubProvider) ListModels(ctx context.Context) (interface{}, error) { return nil, nil }
+func (p *stubProvider) RefreshToken(ctx context.Context) error              { return nil }
+func (p *stubProvider) GetProtocolPrefix() models.ProtocolPrefix            { return models.ProtocolOpenAI }
+func (p *stubProvider) IsHealthy() bool                                     { return true }
+
+// TestHedgeStatsNoDeadlock exercises fired/primaryWon/hedgeWon directly,
+// which is enough to reproduce the original deadlock: counts() used to
+// take h.mu itself while fired/primaryWon/hedgeWon already held it,
+// hanging forever on the second Lock(). counts() now expects its caller
+// to hold h.mu.
+func TestHedgeStatsNoDeadlock(t *testing.T) {
+	h := newHedgeStats()
+
+	done := make(chan struct{})
+	go func() {
+		h.fired("primary")
+		h.primaryWon("primary")
+		h.hedgeWon("secondary")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("hedgeStats recorder methods deadlocked")
+	}
+
+	snap := h.snapshot()
+	if snap["primary"].Fired != 1 || snap["primary"].PrimaryWon != 1 {
+		t.Fatalf("unexpected primary counts: %+v", snap["primary"])
+	}
+	if snap["secondary"].HedgeWon != 1 {
+		t.Fatalf("unexpected secondary counts: %+v", snap["secondary"])
+	}
+}
+
+// TestGenerateWithHedgingEndToEnd drives generateWithHedging with real
+// stub providers so a hedge actually fires, reproducing the deadlock this
+// review comment was filed against: once hedging fires and a winner is
+// recorded via s.hedges.primaryWon/hedgeWon, the request goroutine used to
+// wedge forever on hedgeStats' self-deadlocking recorder methods.
+func TestGenerateWithHedgingEndToEnd(t *testing.T) {
+	s := &Server{
+		config: &config.Config{
+			ModelProviders: []string{"primary", "secondary"},
+			HedgeEnabled:   true,
+			HedgeAfterMs:   10,
+		},
+		providers: map[string]providers.Provider{
+			"primary":   &stubProvider{delay: 200 * time.Millisecond, resp: "primary-resp"},
+			"secondary": &stubProvider{delay: 5 * time.Millisecond, resp: "secondary-resp"},
+		},
+		breakers: make(map[string]*pool.CircuitBreaker),
+		hedges:   newHedgeStats(),
+	}
+
+	done := make(chan struct{})
+	var resp interface{}
+	var err error
+	go func() {
+		resp, _, err = s.generateWithHedging(context.Background(), s.failoverChain("primary"), "model", "req", models.ProtocolOpenAI)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("generateWithHedging deadlocked")
+	}
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp != "secondary-resp" {
+		t.Fatalf("expected hedge (secondary) to win, got %v", resp)
+	}
+
+	snap := s.hedges.snapshot()
+	if snap["primary"].Fired != 1 {
+		t.Fatalf("expected hedge fired for primary, got %+v", snap["primary"])
+	}
+	if snap["primary"].HedgeWon != 1 {
+		t.Fatalf("expected hedge win recorded for primary, got %+v", snap["primary"])
+	}
+}
+
+// TestGenerateWithHedgingFailsOverOnFastPrimaryError covers the case where
+// chain[0] errors out before HedgeAfterMs even elapses: the first select in
+// generateWithHedging used to return that error straight to the caller,
+// never trying the rest of chain. It must instead fall through and wait for
+// the hedge attempt like a slow (rather than failed) primary would.
+func TestGenerateWithHedgingFailsOverOnFastPrimaryError(t *testing.T) {
+	s := &Server{
+		config: &config.Config{
+			ModelProviders: []string{"primary", "secondary"},
+			HedgeEnabled:   true,
+			HedgeAfterMs:   50,
+		},
+		providers: map[string]providers.Provider{
+			"primary":   &stubProvider{delay: 5 * time.Millisecond, err: context.DeadlineExceeded},
+			"secondary": &stubProvider{delay: 5 * time.Millisecond, resp: "secondary-resp"},
+		},
+		breakers: make(map[string]*pool.CircuitBreaker),
+		hedges:   newHedgeStats(),
+	}
+
+	done := make(chan struct{})
+	var resp interface{}
+	var err error
+	go func() {
+		resp, _, err = s.generateWithHedging(context.Background(), s.failoverChain("primary"), "model", "req", models.ProtocolOpenAI)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("generateWithHedging deadlocked")
+	}
+
+	if err != nil {
+		t.Fatalf("expected failover to secondary to succeed, got error: %v", err)
+	}
+	if resp != "secondary-resp" {
+		t.Fatalf("expected secondary's response after primary's fast failure, got %v", resp)
+	}
+}
@@ -0,0 +1,308 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/internal/convert"
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// realtimeUpgrader upgrades /v1/realtime connections to WebSocket. Origin
+// checking is left permissive, same as internal/websocket's upgrader,
+// since middleware.APIKeyAuth already authenticates the HTTP request on
+// the route group before the upgrade happens.
+var realtimeUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// realtimeInbound is one envelope a /v1/realtime client sends.
+type realtimeInbound struct {
+	Type string `json:"type"` // "user_message", "cancel", or "tool_result"
+
+	// user_message
+	Model    string                 `json:"model,omitempty"`
+	Messages []models.OpenAIMessage `json:"messages,omitempty"`
+
+	// tool_result
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	Content    string `json:"content,omitempty"`
+}
+
+// realtimeOutbound is one envelope sent to a /v1/realtime client.
+type realtimeOutbound struct {
+	Type     string            `json:"type"` // "delta", "tool_call", "done", or "error"
+	Delta    string            `json:"delta,omitempty"`
+	ToolCall *realtimeToolCall `json:"tool_call,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+type realtimeToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// handleRealtime upgrades the connection to a WebSocket and bridges it to
+// a provider's streaming API for the life of the session. Unlike the
+// SSE-based /v1/chat/completions, it exchanges typed JSON envelopes so a
+// client can cancel in-flight generation ("cancel") or feed a tool result
+// back into the conversation ("tool_result") without reopening the
+// connection.
+func (s *Server) handleRealtime(c *gin.Context) {
+	conn, err := realtimeUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("realtime upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	newRealtimeSession(s, conn).run()
+}
+
+// realtimeSession owns one /v1/realtime connection's conversation state
+// and in-flight generation.
+type realtimeSession struct {
+	s    *Server
+	conn *websocket.Conn
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	messages []models.OpenAIMessage
+	model    string
+	cancel   context.CancelFunc
+}
+
+func newRealtimeSession(s *Server, conn *websocket.Conn) *realtimeSession {
+	return &realtimeSession{s: s, conn: conn}
+}
+
+// run reads envelopes off the connection until it closes, dispatching each
+// one to the matching handler.
+func (rs *realtimeSession) run() {
+	rs.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	rs.conn.SetPongHandler(func(string) error {
+		rs.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go rs.keepalive(stop)
+
+	for {
+		_, data, err := rs.conn.ReadMessage()
+		if err != nil {
+			rs.cancelInFlight()
+			return
+		}
+
+		var msg realtimeInbound
+		if err := json.Unmarshal(data, &msg); err != nil {
+			rs.send(realtimeOutbound{Type: "error", Error: fmt.Sprintf("invalid envelope: %v", err)})
+			continue
+		}
+
+		switch msg.Type {
+		case "user_message":
+			rs.handleUserMessage(&msg)
+		case "cancel":
+			rs.cancelInFlight()
+		case "tool_result":
+			rs.handleToolResult(&msg)
+		default:
+			rs.send(realtimeOutbound{Type: "error", Error: fmt.Sprintf("unknown envelope type %q", msg.Type)})
+		}
+	}
+}
+
+// keepalive pings the client every 30s so intermediate proxies don't time
+// out the connection during a long silence between turns.
+func (rs *realtimeSession) keepalive(stop <-chan struct{}) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rs.writeMu.Lock()
+			rs.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			err := rs.conn.WriteMessage(websocket.PingMessage, nil)
+			rs.writeMu.Unlock()
+			if err != nil {
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (rs *realtimeSession) send(msg realtimeOutbound) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	rs.writeMu.Lock()
+	defer rs.writeMu.Unlock()
+	rs.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	rs.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// cancelInFlight stops the current generateTurn, if one is running. It is
+// safe to call whether or not a turn is in flight.
+func (rs *realtimeSession) cancelInFlight() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.cancel != nil {
+		rs.cancel()
+		rs.cancel = nil
+	}
+}
+
+func (rs *realtimeSession) handleUserMessage(msg *realtimeInbound) {
+	rs.mu.Lock()
+	if msg.Model != "" {
+		rs.model = msg.Model
+	}
+	rs.messages = append(rs.messages, msg.Messages...)
+	rs.mu.Unlock()
+
+	rs.generateTurn()
+}
+
+// handleToolResult appends a tool-role message for msg.ToolCallID and
+// immediately generates the next turn, the same way a normal chat-completions
+// tool-calling loop resumes once the caller's tool has run.
+func (rs *realtimeSession) handleToolResult(msg *realtimeInbound) {
+	rs.mu.Lock()
+	rs.messages = append(rs.messages, models.OpenAIMessage{
+		Role:       "tool",
+		Content:    msg.Content,
+		ToolCallID: msg.ToolCallID,
+	})
+	rs.mu.Unlock()
+
+	rs.generateTurn()
+}
+
+// generateTurn runs one streaming generation against the session's default
+// provider, forwarding each text delta and tool call as a typed envelope,
+// then appends the assistant's turn to rs.messages once the stream ends.
+// Its context is cancellable via rs.cancel, so a "cancel" envelope or a new
+// user_message always targets whichever turn is currently in flight.
+func (rs *realtimeSession) generateTurn() {
+	s := rs.s
+
+	providerName := s.config.ModelProviders[0]
+	provider, ok := s.providers[providerName]
+	if !ok {
+		rs.send(realtimeOutbound{Type: "error", Error: fmt.Sprintf("provider %q not configured", providerName)})
+		return
+	}
+
+	rs.mu.Lock()
+	model := rs.model
+	req := &models.OpenAIRequest{Model: model, Messages: rs.messages, Stream: true}
+	ctx, cancel := context.WithCancel(context.Background())
+	rs.cancel = cancel
+	rs.mu.Unlock()
+	defer rs.cancelInFlight()
+
+	fromProtocol := models.ProtocolOpenAI
+	toProtocol := provider.GetProtocolPrefix()
+
+	var convertedReq interface{} = req
+	if fromProtocol != toProtocol {
+		converted, err := s.converter.ConvertRequest(req, fromProtocol, toProtocol)
+		if err != nil {
+			rs.send(realtimeOutbound{Type: "error", Error: fmt.Sprintf("conversion error: %v", err)})
+			return
+		}
+		convertedReq = converted
+	}
+
+	stream, err := provider.GenerateContentStream(ctx, model, convertedReq)
+	if err != nil {
+		rs.send(realtimeOutbound{Type: "error", Error: err.Error()})
+		return
+	}
+	defer stream.Close()
+
+	// streamConv always targets OpenAI here regardless of the provider's
+	// native protocol, since realtimeOutbound is its own wire format rather
+	// than a mirror of any one provider's stream shape; OpenAI's
+	// StreamChunk/ToolCall types just happen to be the most convenient
+	// intermediate to read text/tool-call deltas back out of.
+	streamConv := convert.NewStreamConverter(model)
+	var assistantText strings.Builder
+
+	buffer := make([]byte, 4096)
+	for {
+		n, err := stream.Read(buffer)
+		if err != nil {
+			if err != io.EOF && ctx.Err() == nil {
+				rs.send(realtimeOutbound{Type: "error", Error: err.Error()})
+			}
+			break
+		}
+		if n == 0 {
+			continue
+		}
+
+		chunk := string(buffer[:n])
+		var openAIChunk *models.StreamChunk
+
+		if se, ok := models.DecodeStreamEvent(chunk); ok {
+			converted, err := streamConv.Convert(se, models.ProtocolOpenAI)
+			if err != nil || converted == nil {
+				continue
+			}
+			openAIChunk, _ = converted.(*models.StreamChunk)
+		} else if fromProtocol != toProtocol {
+			converted, err := s.converter.ConvertStreamChunk(chunk, toProtocol, fromProtocol, model)
+			if err != nil || converted == nil {
+				continue
+			}
+			openAIChunk, _ = converted.(*models.StreamChunk)
+		}
+
+		if openAIChunk == nil || len(openAIChunk.Choices) == 0 || openAIChunk.Choices[0].Delta == nil {
+			continue
+		}
+		delta := openAIChunk.Choices[0].Delta
+
+		if text, ok := delta.Content.(string); ok && text != "" {
+			assistantText.WriteString(text)
+			rs.send(realtimeOutbound{Type: "delta", Delta: text})
+		}
+		for _, tc := range delta.ToolCalls {
+			rs.send(realtimeOutbound{Type: "tool_call", ToolCall: &realtimeToolCall{
+				ID:        tc.ID,
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			}})
+		}
+	}
+
+	rs.mu.Lock()
+	if assistantText.Len() > 0 {
+		rs.messages = append(rs.messages, models.OpenAIMessage{Role: "assistant", Content: assistantText.String()})
+	}
+	rs.mu.Unlock()
+
+	rs.send(realtimeOutbound{Type: "done"})
+}
@@ -2,32 +2,59 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/aiproxy/go-aiproxy/internal/cache"
 	"github.com/aiproxy/go-aiproxy/internal/config"
 	"github.com/aiproxy/go-aiproxy/internal/convert"
+	"github.com/aiproxy/go-aiproxy/internal/grpcapi"
+	"github.com/aiproxy/go-aiproxy/internal/metrics"
 	"github.com/aiproxy/go-aiproxy/internal/middleware"
+	"github.com/aiproxy/go-aiproxy/internal/observability"
 	"github.com/aiproxy/go-aiproxy/internal/pool"
 	"github.com/aiproxy/go-aiproxy/internal/providers"
 	"github.com/aiproxy/go-aiproxy/internal/providers/claude"
 	"github.com/aiproxy/go-aiproxy/internal/providers/gemini"
 	"github.com/aiproxy/go-aiproxy/internal/providers/openai"
+	"github.com/aiproxy/go-aiproxy/internal/ratelimit"
 	"github.com/aiproxy/go-aiproxy/pkg/models"
+	"github.com/aiproxy/go-aiproxy/pkg/usage"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // Server represents the HTTP server
 type Server struct {
-	config      *config.Config
-	router      *gin.Engine
-	providers   map[string]providers.Provider
-	poolManager *pool.Manager
-	converter   convert.Converter
+	config        *config.Config
+	router        *gin.Engine
+	providers     map[string]providers.Provider
+	poolManager   *pool.Manager
+	converter     convert.Converter
+	usage         *usage.Recorder
+	quota         *usage.QuotaEnforcer
+	cacheMgr      *cache.CacheManager
+	providerCache *cache.RedisCache
+	rateLimiter   *ratelimit.Limiter
+	grpcSvc       *grpcapi.Service
+	obsMetrics    *observability.Metrics
+
+	breakersMu sync.Mutex
+	breakers   map[string]*pool.CircuitBreaker
+	hedges     *hedgeStats
+
+	tracingShutdown func(context.Context) error
 }
 
 // New creates a new server instance
@@ -37,11 +64,85 @@ func New(cfg *config.Config) (*Server, error) {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
+	budgetWindow := time.Duration(cfg.UsageBudgetWindowMins) * time.Minute
+	if budgetWindow <= 0 {
+		budgetWindow = time.Hour
+	}
+
 	// Create server instance
 	s := &Server{
-		config:    cfg,
-		providers: make(map[string]providers.Provider),
-		converter: convert.NewConverter(),
+		config:      cfg,
+		providers:   make(map[string]providers.Provider),
+		converter:   convert.NewConverter(),
+		usage:       usage.NewRecorder(usage.NewPriceTable()),
+		quota:       usage.NewQuotaEnforcer(cfg.UsageBudgetUSD, budgetWindow),
+		rateLimiter: ratelimit.NewLimiter(),
+		breakers:    make(map[string]*pool.CircuitBreaker),
+		hedges:      newHedgeStats(),
+		obsMetrics:  observability.NewMetrics(),
+	}
+
+	shutdown, err := observability.InitTracing(context.Background(), observability.TracingOptions{
+		Endpoint:    cfg.OTLPEndpoint,
+		ServiceName: cfg.OTelServiceName,
+		Sampler:     cfg.OTelSampler,
+		Headers:     cfg.OTelHeaderMap(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to init tracing: %w", err)
+	}
+	s.tracingShutdown = shutdown
+
+	if cfg.ActiveUsersStateFile != "" {
+		metrics.Default().ActiveUsers.SetPersistPath(cfg.ActiveUsersStateFile)
+	}
+	metrics.Default().CollectUserActivityMetrics(time.Minute)
+
+	for name, providerCfg := range cfg.ProviderConfigs {
+		if providerCfg.RateLimitRPM > 0 || providerCfg.RateLimitTPM > 0 {
+			s.rateLimiter.Configure(name, providerCfg.RateLimitRPM, providerCfg.RateLimitTPM)
+		}
+	}
+
+	if cfg.CacheEnabled {
+		cacheTTL := time.Duration(cfg.CacheTTLMins) * time.Minute
+		if cacheTTL <= 0 {
+			cacheTTL = 5 * time.Minute
+		}
+		maxSizeMB := cfg.CacheMaxSizeMB
+		if maxSizeMB <= 0 {
+			maxSizeMB = 256
+		}
+		s.cacheMgr = cache.NewCacheManager(cacheTTL, 10*time.Minute, maxSizeMB)
+		s.cacheMgr.SetMetricsHook(s.obsMetrics.RecordCacheHit)
+
+		if cfg.SemanticCacheEnabled {
+			embedder := cache.NewHTTPEmbedder(cfg.SemanticCacheEmbeddingURL, cfg.SemanticCacheEmbeddingKey, cfg.SemanticCacheEmbeddingModel)
+			s.cacheMgr.EnableSemanticCache(embedder, cfg.SemanticCacheThreshold, cfg.SemanticCacheTemperatureMax)
+		}
+
+		for _, model := range cfg.StreamCacheModels {
+			s.cacheMgr.EnableStreamCacheForModel(model)
+		}
+	}
+
+	if cfg.ProviderCacheMode != "" && cfg.ProviderCacheMode != string(providers.CacheModeOff) &&
+		(cfg.RedisAddr != "" || cfg.RedisMode == string(cache.RedisModeCluster) || cfg.RedisMode == string(cache.RedisModeSentinel)) {
+		providerCache, err := cache.NewRedisCache(cache.RedisConfig{
+			Mode:             cache.RedisMode(cfg.RedisMode),
+			Addr:             cfg.RedisAddr,
+			Password:         cfg.RedisPassword,
+			DB:               cfg.RedisDB,
+			Prefix:           "aiproxy:providercache:",
+			ClusterAddrs:     cfg.RedisClusterAddrs,
+			SentinelAddrs:    cfg.RedisSentinelAddrs,
+			SentinelMaster:   cfg.RedisSentinelMaster,
+			SentinelPassword: cfg.RedisSentinelPassword,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect provider cache to Redis: %w", err)
+		}
+		s.providerCache = providerCache
 	}
 
 	// Initialize providers
@@ -49,6 +150,10 @@ func New(cfg *config.Config) (*Server, error) {
 		return nil, fmt.Errorf("failed to initialize providers: %w", err)
 	}
 
+	if cfg.GRPCEnabled {
+		s.grpcSvc = grpcapi.NewService(s.providers, s.converter, cfg.ModelProviders[0])
+	}
+
 	// Initialize pool manager if configured
 	if cfg.ProviderPoolsFile != "" {
 		// Pool manager would be initialized here
@@ -56,7 +161,9 @@ func New(cfg *config.Config) (*Server, error) {
 	}
 
 	// Setup router
-	s.setupRouter()
+	if err := s.setupRouter(); err != nil {
+		return nil, fmt.Errorf("failed to set up router: %w", err)
+	}
 
 	return s, nil
 }
@@ -82,30 +189,94 @@ func (s *Server) initializeProviders() error {
 			return fmt.Errorf("failed to create provider %s: %w", name, err)
 		}
 
+		if s.providerCache != nil {
+			provider = s.newCachingProvider(provider, name, cfg)
+		}
+
 		s.providers[name] = provider
 	}
 
 	return nil
 }
 
+// newCachingProvider wraps provider with providers.CachingProvider per
+// s.config's global --cache-mode/--cache-ttl/--cache-similarity-threshold/
+// --cache-embedding-model flags, which cfg (the per-provider config) may
+// override via CacheTTLSeconds/CacheMaxBodyBytes. Each provider gets its
+// own RediSearch index name so one provider's cached prompts never surface
+// as a similarity match for another's unrelated request.
+func (s *Server) newCachingProvider(provider providers.Provider, name string, cfg *models.ProviderConfig) providers.Provider {
+	opts := providers.CachingOptions{
+		Mode:                providers.CacheMode(s.config.ProviderCacheMode),
+		TTL:                 time.Duration(s.config.ProviderCacheTTLSeconds) * time.Second,
+		SimilarityThreshold: s.config.ProviderCacheSimilarityThreshold,
+		IndexName:           "aiproxy:providercache:" + name + ":idx",
+	}
+	if opts.Mode == providers.CacheModeSemantic {
+		opts.Embedder = cache.NewHTTPEmbedder(s.config.SemanticCacheEmbeddingURL, s.config.SemanticCacheEmbeddingKey, s.config.ProviderCacheEmbeddingModel)
+	}
+	return providers.NewCachingProvider(provider, name, s.providerCache, cfg, opts)
+}
+
 // setupRouter configures the HTTP routes
-func (s *Server) setupRouter() {
+func (s *Server) setupRouter() error {
 	// Set Gin to release mode for production
 	gin.SetMode(gin.ReleaseMode)
 
 	s.router = gin.New()
-	
+
 	// Add middleware
 	s.router.Use(gin.Recovery())
 	s.router.Use(middleware.Logger())
 	s.router.Use(middleware.CORS())
+	s.router.Use(metrics.PrometheusMiddleware(metrics.Default()))
 
 	// Health check endpoint
 	s.router.GET("/health", s.handleHealth)
 
-	// API routes with authentication
+	// Prometheus scrape endpoint, unauthenticated like /health. Skipped
+	// here when MetricsAddr requests a dedicated listener instead (see
+	// Start/startMetrics), or when metrics are disabled outright.
+	if s.config.MetricsEnabled && s.config.MetricsAddr == "" {
+		s.router.GET(s.metricsPath(), observability.Handler())
+	}
+
+	// API routes with authentication. When OIDCIssuerURL is configured,
+	// requests are authenticated against that provider instead of the
+	// static APIKey.
+	var oidcCfg *middleware.OIDCConfig
+	if s.config.OIDCIssuerURL != "" {
+		oidcCfg = &middleware.OIDCConfig{
+			IssuerURL:       s.config.OIDCIssuerURL,
+			Audience:        s.config.OIDCAudience,
+			RequiredScopes:  s.config.OIDCRequiredScopes,
+			AllowedSubjects: s.config.OIDCAllowedSubjects,
+			AllowedGroups:   s.config.OIDCAllowedGroups,
+		}
+		if s.config.OIDCCallerPoliciesFile != "" {
+			policies, err := middleware.LoadCallerPolicies(s.config.OIDCCallerPoliciesFile)
+			if err != nil {
+				return fmt.Errorf("failed to load OIDC caller policies: %w", err)
+			}
+			oidcCfg.Policies = policies
+		}
+	}
+	authMiddleware, err := middleware.NewAuthMiddleware(s.config.APIKey, oidcCfg)
+	if err != nil {
+		return fmt.Errorf("failed to set up auth middleware: %w", err)
+	}
+
 	api := s.router.Group("/")
-	api.Use(middleware.APIKeyAuth(s.config.APIKey))
+	// mTLS authenticates the transport-level client identity (the
+	// certificate was already verified against TLSClientCAFile by
+	// crypto/tls before the request reached gin); it runs in addition to,
+	// not instead of, the bearer-token auth below.
+	if s.config.TLSClientCAFile != "" {
+		api.Use(middleware.MTLSAuth(middleware.MTLSConfig{
+			SPIFFETrustDomain: s.config.SPIFFETrustDomain,
+		}))
+	}
+	api.Use(authMiddleware)
 
 	// OpenAI compatible endpoints
 	api.POST("/v1/chat/completions", s.handleChatCompletions)
@@ -118,11 +289,95 @@ func (s *Server) setupRouter() {
 
 	// Claude native endpoints (if needed)
 	api.POST("/v1/messages", s.handleClaudeMessages)
+
+	// Realtime WebSocket bridge: typed delta/tool_call/done/error events
+	// instead of raw SSE, so a client can cancel in-flight generation or
+	// inject a tool result mid-conversation. Auth runs the same as every
+	// other route in this group, via authMiddleware above; WebSocket
+	// clients that can't set an Authorization header use the "key" query
+	// parameter middleware.APIKeyAuth already accepts.
+	api.GET("/v1/realtime", s.handleRealtime)
+
+	api.GET("/v1/cache/stats", s.handleCacheStats)
+
+	api.GET("/admin/providers", s.handleAdminProviders)
+	api.GET("/admin/usage", s.handleAdminUsage)
+	api.POST("/v1/auth/revoke", s.handleAuthRevoke)
+
+	return nil
 }
 
-// Start starts the HTTP server
+// Start starts the HTTP server. When TLSCertFile is configured, the server
+// terminates TLS itself (optionally requiring client certificates per
+// TLSClientCAFile) instead of running plain HTTP. When GRPCEnabled is
+// configured, the AIProxyStream RPCs are additionally served over h2c on
+// a second port, so backend-to-backend callers can stream over HTTP/2
+// without needing the main port's TLS.
 func (s *Server) Start(addr string) error {
-	return s.router.Run(addr)
+	if s.grpcSvc != nil {
+		go s.startGRPC()
+	}
+
+	if s.config.MetricsEnabled && s.config.MetricsAddr != "" {
+		go s.startMetrics()
+	}
+
+	if s.config.TLSCertFile == "" {
+		return s.router.Run(addr)
+	}
+
+	reloader, err := newTLSReloader(s.config.TLSCertFile, s.config.TLSKeyFile, s.config.TLSClientCAFile, clientAuthType(s.config.TLSClientCARequire))
+	if err != nil {
+		return fmt.Errorf("failed to set up TLS: %w", err)
+	}
+
+	httpServer := &http.Server{
+		Addr:      addr,
+		Handler:   s.router,
+		TLSConfig: reloader.config(),
+	}
+	// Cert/key are already baked into TLSConfig.GetCertificate, so the
+	// file paths below aren't read again by ListenAndServeTLS itself.
+	return httpServer.ListenAndServeTLS("", "")
+}
+
+// startGRPC runs the AIProxyStream h2c listener until it errors; Start
+// launches this in a goroutine since it listens on a separate port from
+// the main HTTP API and shouldn't block it.
+func (s *Server) startGRPC() {
+	h2s := &http2.Server{}
+	grpcServer := &http.Server{
+		Addr:    s.config.GRPCAddr,
+		Handler: h2c.NewHandler(s.grpcSvc.Handler(), h2s),
+	}
+	if err := grpcServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("grpcapi server error: %v", err)
+	}
+}
+
+// metricsPath returns the configured Prometheus scrape path, defaulting to
+// "/metrics" when MetricsPath is unset.
+func (s *Server) metricsPath() string {
+	if s.config.MetricsPath == "" {
+		return "/metrics"
+	}
+	return s.config.MetricsPath
+}
+
+// startMetrics runs a dedicated /metrics listener on MetricsAddr until it
+// errors; Start launches this in a goroutine, mirroring startGRPC, since
+// MetricsAddr is only set when the operator wants the scrape port separate
+// from the main API port.
+func (s *Server) startMetrics() {
+	mux := http.NewServeMux()
+	mux.Handle(s.metricsPath(), promhttp.Handler())
+	metricsServer := &http.Server{
+		Addr:    s.config.MetricsAddr,
+		Handler: mux,
+	}
+	if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("metrics server error: %v", err)
+	}
 }
 
 // handleHealth handles health check requests
@@ -133,6 +388,83 @@ func (s *Server) handleHealth(c *gin.Context) {
 	})
 }
 
+// claudePromptText flattens req into the text usage.EstimateTokens expects,
+// mirroring what cache.PromptFromMessages does for an OpenAIRequest - Claude
+// has no such helper of its own since req.Messages carries a content block
+// list rather than a plain string.
+func claudePromptText(req *models.ClaudeRequest) string {
+	var b strings.Builder
+	b.WriteString(req.System)
+	for _, m := range req.Messages {
+		for _, part := range m.Content {
+			b.WriteString(part.Text)
+		}
+	}
+	return b.String()
+}
+
+// geminiPromptText is claudePromptText's counterpart for a GeminiRequest.
+func geminiPromptText(req *models.GeminiRequest) string {
+	var b strings.Builder
+	if req.SystemInstruction != nil {
+		for _, part := range req.SystemInstruction.Parts {
+			b.WriteString(part.Text)
+		}
+	}
+	for _, content := range req.Contents {
+		for _, part := range content.Parts {
+			b.WriteString(part.Text)
+		}
+	}
+	return b.String()
+}
+
+// checkCallerAllowed enforces an OIDC-authenticated caller's CallerPolicy
+// provider/model allowlist, writing a 403 and returning false if either is
+// disallowed. model may be "" for endpoints that aren't routing to one
+// specific model (e.g. listing models), in which case only the provider is
+// checked. A caller with no resolved CallerPolicy (API-key auth, or OIDC
+// auth with no matching policy) is unrestricted, so this always returns
+// true in that case. Every protocol entrypoint (OpenAI, Claude, Gemini)
+// must call this right after resolving its provider and model - checking
+// it in only one of them would let a restricted caller bypass their policy
+// simply by switching protocols.
+func (s *Server) checkCallerAllowed(c *gin.Context, providerName, model string) bool {
+	policy, hasPolicy := c.Get(middleware.AuthPolicyKey)
+	if !hasPolicy {
+		return true
+	}
+	p := policy.(middleware.CallerPolicy)
+	if !p.AllowsProvider(providerName) || (model != "" && !p.AllowsModel(model)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "caller is not permitted to use this provider/model"})
+		return false
+	}
+	return true
+}
+
+// checkCallerRateLimit enforces an OIDC-authenticated caller's own
+// CallerPolicy RPM/TPM budget (independent of the provider-level limits
+// s.rateLimiter.Allow already enforces), writing a 429 and returning false
+// if it's exceeded. A caller with no resolved CallerPolicy, or no AuthClaimsKey
+// (API-key auth), is unrestricted here, so this always returns true in that
+// case.
+func (s *Server) checkCallerRateLimit(c *gin.Context, estimatedTokens int) bool {
+	policy, hasPolicy := c.Get(middleware.AuthPolicyKey)
+	if !hasPolicy {
+		return true
+	}
+	p := policy.(middleware.CallerPolicy)
+	claims, ok := c.Get(middleware.AuthClaimsKey)
+	if !ok {
+		return true
+	}
+	if !s.rateLimiter.AllowCaller(claims.(middleware.Claims).CallerID(), p.RateLimitRPM, p.RateLimitTPM, estimatedTokens) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+		return false
+	}
+	return true
+}
+
 // handleChatCompletions handles OpenAI-style chat completion requests
 func (s *Server) handleChatCompletions(c *gin.Context) {
 	// Parse request
@@ -154,54 +486,272 @@ func (s *Server) handleChatCompletions(c *gin.Context) {
 		return
 	}
 
+	// For an OIDC-authenticated caller with a resolved CallerPolicy,
+	// restrict which providers/models they may reach.
+	if !s.checkCallerAllowed(c, providerName, req.Model) {
+		return
+	}
+
+	apiKey := apiKeyFromRequest(c)
+	if !s.quota.Allow(apiKey) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "usage budget exceeded"})
+		return
+	}
+
+	// reqCtx carries the request's span everywhere c.Request.Context()
+	// would otherwise be used directly, so its traceparent propagates to
+	// whichever provider ends up handling the request.
+	spanAttrs := []attribute.KeyValue{
+		attribute.String("provider", providerName),
+		attribute.String("model", req.Model),
+		attribute.Bool("stream", req.Stream),
+	}
+	if claims, ok := c.Get(middleware.AuthClaimsKey); ok {
+		claims := claims.(middleware.Claims)
+		spanAttrs = append(spanAttrs, attribute.String("caller.subject", claims.Subject))
+		if claims.Email != "" {
+			spanAttrs = append(spanAttrs, attribute.String("caller.email", claims.Email))
+		}
+	}
+	reqCtx, span := observability.StartSpan(c.Request.Context(), "chat.completions", spanAttrs...)
+	defer span.End()
+	start := time.Now()
+
+	estimatedTokens := usage.EstimateTokens(cache.PromptFromMessages(req.Messages))
+	if !s.rateLimiter.Allow(providerName, apiKey, estimatedTokens) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+		return
+	}
+	if !s.checkCallerRateLimit(c, estimatedTokens) {
+		return
+	}
+	s.obsMetrics.RecordTokens("prompt", estimatedTokens)
+
 	// Get protocol prefixes
 	fromProtocol := models.ProtocolOpenAI
 	toProtocol := provider.GetProtocolPrefix()
 
-	// Convert request if needed
-	var convertedReq interface{} = &req
-	if fromProtocol != toProtocol {
-		var err error
-		convertedReq, err = s.converter.ConvertRequest(&req, fromProtocol, toProtocol)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("conversion error: %v", err)})
-			return
+	// Handle streaming. Failover/hedging across providers only applies to
+	// the non-streaming path below; a streaming response has already
+	// committed to toProtocol by the time a mid-stream error could be
+	// detected, so there's no clean point to retry against another
+	// provider without replaying output the client already received.
+	if req.Stream {
+		// A streaming request can also hit the response cache, via
+		// StreamRecorder: a model opted in to StreamCacheModels gets its
+		// chunks recorded the first time, then replayed verbatim (with
+		// pacing) on a later request with the same canonical cache key,
+		// instead of calling the provider again.
+		var streamCacheKey string
+		if s.cacheMgr != nil && s.cacheMgr.StreamCacheEnabledFor(req.Model) {
+			if key, err := s.cacheMgr.GenerateCanonicalCacheKey(s.converter, &req, fromProtocol); err == nil {
+				streamCacheKey = key
+				if chunks, found := s.cacheMgr.GetStream(key); found {
+					s.replayStreamFromCache(c, chunks)
+					return
+				}
+			}
 		}
-	}
 
-	// Handle streaming
-	if req.Stream {
-		s.handleStreamingResponse(c, provider, req.Model, convertedReq, fromProtocol, toProtocol)
+		var convertedReq interface{} = &req
+		if fromProtocol != toProtocol {
+			var err error
+			convertedReq, err = s.converter.ConvertRequest(&req, fromProtocol, toProtocol)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("conversion error: %v", err)})
+				return
+			}
+		}
+		s.handleStreamingResponseWithUsage(reqCtx, c, provider, req.Model, convertedReq, fromProtocol, toProtocol, providerName, apiKey, streamCacheKey, estimatedTokens, start)
 		return
 	}
 
-	// Make non-streaming request
-	ctx := c.Request.Context()
-	resp, err := provider.GenerateContent(ctx, req.Model, convertedReq)
+	// Look up the response cache keyed on the canonicalized request, so the
+	// same conversation hits the same entry regardless of which protocol
+	// shape originally carried it. When no exact entry exists, GetSemantic
+	// falls back to an embedding-similarity match against other cached
+	// prompts for the same provider+model+tool-set+response_format.
+	// X-Cache-Mode lets a single request opt out of the semantic fallback
+	// (exact) or out of the cache entirely (bypass).
+	cacheMode := cache.ParseMode(c.GetHeader("X-Cache-Mode"))
+	var cacheKey string
+	prompt := cache.PromptFromMessages(req.Messages)
+	toolsFormat := cache.FingerprintToolsFormat(req.Tools, req.ResponseFormat)
+	if s.cacheMgr != nil && cacheMode != cache.ModeBypass && cache.ShouldUseSemanticKey(req.Temperature, c.GetHeader("X-Cache")) {
+		if key, err := s.cacheMgr.GenerateCanonicalCacheKey(s.converter, &req, fromProtocol); err == nil {
+			cacheKey = key
+
+			var cached interface{}
+			var found bool
+			if cacheMode == cache.ModeExact {
+				cached, found = s.cacheMgr.Get(cacheKey)
+			} else {
+				cached, found = s.cacheMgr.GetSemantic(reqCtx, cacheKey, providerName, req.Model, models.TemperatureOrDefault(req.Temperature, convert.DefaultTemperature), toolsFormat, prompt)
+			}
+			span.SetAttributes(attribute.Bool("cache.hit", found))
+			if found {
+				c.JSON(http.StatusOK, cached)
+				return
+			}
+		}
+	}
+
+	// Make non-streaming request, transparently failing over to the next
+	// provider in s.config.ModelProviders whose circuit breaker isn't
+	// open, and hedging against it early if HedgeEnabled.
+	chain := s.failoverChain(providerName)
+	resp, usedProtocol, err := s.generateWithHedging(reqCtx, chain, req.Model, &req, fromProtocol)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		s.obsMetrics.RequestsTotal.WithLabelValues(providerName, req.Model, "error").Inc()
+		s.obsMetrics.RequestDuration.WithLabelValues(providerName, req.Model).Observe(time.Since(start).Seconds())
+		s.writeProviderError(c, err)
 		return
 	}
 
+	var promptTokens, completionTokens int
+	if usg, ok := usage.ExtractUsage(usedProtocol, resp); ok {
+		cost := s.usage.Record(models.Provider(providerName), req.Model, usedProtocol, usg)
+		s.quota.Charge(apiKey, cost)
+		s.obsMetrics.RecordTokens("completion", usg.CompletionTokens)
+		promptTokens, completionTokens = usg.PromptTokens, usg.CompletionTokens
+	}
+
 	// Convert response if needed
-	if fromProtocol != toProtocol {
-		resp, err = s.converter.ConvertResponse(resp, toProtocol, fromProtocol, req.Model)
+	if fromProtocol != usedProtocol {
+		resp, err = s.converter.ConvertResponse(resp, usedProtocol, fromProtocol, req.Model)
 		if err != nil {
+			s.obsMetrics.RequestsTotal.WithLabelValues(providerName, req.Model, "error").Inc()
+			s.obsMetrics.RequestDuration.WithLabelValues(providerName, req.Model).Observe(time.Since(start).Seconds())
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("response conversion error: %v", err)})
 			return
 		}
 	}
 
+	if cacheKey != "" && cacheMode != cache.ModeBypass {
+		if cacheMode == cache.ModeExact {
+			s.cacheMgr.Set(cacheKey, resp, s.cacheMgr.TTLForModel(req.Model))
+		} else {
+			s.cacheMgr.SetSemantic(reqCtx, cacheKey, providerName, req.Model, models.TemperatureOrDefault(req.Temperature, convert.DefaultTemperature), toolsFormat, prompt, resp, s.cacheMgr.TTLForModel(req.Model))
+		}
+	}
+
+	s.obsMetrics.RequestsTotal.WithLabelValues(providerName, req.Model, "ok").Inc()
+	s.obsMetrics.RequestDuration.WithLabelValues(providerName, req.Model).Observe(time.Since(start).Seconds())
 	c.JSON(http.StatusOK, resp)
+	metrics.Default().RecordUserMetrics(callerLabel(c), providerName, req.Model, "chat.completions",
+		promptTokens, completionTokens, int(c.Request.ContentLength), c.Writer.Size())
+}
+
+// handleCacheStats reports exact and semantic cache hit/miss counters.
+func (s *Server) handleCacheStats(c *gin.Context) {
+	if s.cacheMgr == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+
+	stats := s.cacheMgr.GetStats()
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":       true,
+		"hits":          stats.Hits,
+		"semantic_hits": stats.SemanticHits,
+		"misses":        stats.Misses,
+		"evictions":     stats.Evictions,
+		"total_bytes":   stats.TotalBytes,
+	})
+}
+
+// apiKeyFromRequest identifies the caller for per-key usage accounting,
+// mirroring the precedence middleware.APIKeyAuth already uses to accept a
+// caller's key: Authorization bearer token, then the x-goog-api-key
+// header, then the "key" query param.
+func apiKeyFromRequest(c *gin.Context) string {
+	if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimPrefix(authHeader, "Bearer ")
+	}
+	if apiKey := c.GetHeader("x-goog-api-key"); apiKey != "" {
+		return apiKey
+	}
+	return c.Query("key")
+}
+
+// callerLabel identifies the caller for per-user metrics (see
+// metrics.Metrics.RecordUserMetrics), distinct from apiKeyFromRequest's
+// raw key used for quota/rate-limit bookkeeping: an OIDC caller's
+// CallerID() (already a stable, non-secret identity) is used verbatim,
+// while a static API key is hashed so the key itself never appears in an
+// exported Prometheus label. Empty means "couldn't identify the caller",
+// which RecordUserMetrics folds into its anonymous bucket.
+func callerLabel(c *gin.Context) string {
+	if claims, ok := c.Get(middleware.AuthClaimsKey); ok {
+		return claims.(middleware.Claims).CallerID()
+	}
+	if apiKey := apiKeyFromRequest(c); apiKey != "" {
+		sum := sha256.Sum256([]byte(apiKey))
+		return "key:" + hex.EncodeToString(sum[:])[:12]
+	}
+	return ""
 }
 
 // handleStreamingResponse handles streaming responses
 func (s *Server) handleStreamingResponse(c *gin.Context, provider providers.Provider, model string, request interface{}, fromProtocol, toProtocol models.ProtocolPrefix) {
+	s.handleStreamingResponseWithUsage(c.Request.Context(), c, provider, model, request, fromProtocol, toProtocol, "", "", "", 0, time.Now())
+}
+
+// replayStreamFromCache replays a StreamRecorder cache hit to the client,
+// reproducing each chunk's originally recorded delay (or, if
+// StreamCacheChunkDelayMs is set, a fixed delay instead) so the client sees
+// realistic pacing rather than the whole response arriving at once. It
+// honors ctx.Done() so an early client disconnect stops the replay.
+func (s *Server) replayStreamFromCache(c *gin.Context, chunks []cache.StreamChunk) {
 	ctx := c.Request.Context()
-	
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("Transfer-Encoding", "chunked")
+
+	i := 0
+	c.Stream(func(w io.Writer) bool {
+		if i >= len(chunks) {
+			return false
+		}
+
+		delay := time.Duration(chunks[i].DelayMs) * time.Millisecond
+		if s.config.StreamCacheChunkDelayMs > 0 {
+			delay = time.Duration(s.config.StreamCacheChunkDelayMs) * time.Millisecond
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return false
+		}
+
+		w.Write([]byte(chunks[i].Data))
+		i++
+		return true
+	})
+}
+
+// handleStreamingResponseWithUsage is handleStreamingResponse plus usage
+// accounting: since providers report exact token usage only in their
+// final chunk (which most of this repo's stream readers still discard),
+// it records an approximate completion-token count from usage.EstimateTokens
+// accumulated over the plain-text deltas actually seen. providerName and
+// apiKey are empty when the caller doesn't need usage recorded, in which
+// case promptTokens is ignored too. When cacheKey is non-empty, every
+// chunk is also teed into a StreamRecorder and stored under cacheKey once
+// the stream completes, so a later request with the same key can replay
+// it via replayStreamFromCache. ctx carries the span started by the
+// caller (chat.completions for OpenAI-compatible requests, or just
+// c.Request.Context() for the native Gemini/Claude handlers); start is
+// when that request began, for RequestDuration and StreamTTFB.
+func (s *Server) handleStreamingResponseWithUsage(ctx context.Context, c *gin.Context, provider providers.Provider, model string, request interface{}, fromProtocol, toProtocol models.ProtocolPrefix, providerName, apiKey, cacheKey string, promptTokens int, start time.Time) {
 	// Get stream from provider
 	stream, err := provider.GenerateContentStream(ctx, model, request)
 	if err != nil {
+		if providerName != "" {
+			s.obsMetrics.RequestsTotal.WithLabelValues(providerName, model, "error").Inc()
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -217,6 +767,25 @@ func (s *Server) handleStreamingResponse(c *gin.Context, provider providers.Prov
 	dataChan := make(chan string)
 	doneChan := make(chan bool)
 
+	// streamConv reconstructs multi-step tool calls (Claude's tool_use
+	// blocks stream as a series of partial_json fragments) into whatever
+	// shape toProtocol expects. It is scoped to this one request, since
+	// Gemini's targets need to buffer fragments across many chunks.
+	streamConv := convert.NewStreamConverter(model)
+
+	// completionEstimate accumulates usage.EstimateTokens across every
+	// plain-text delta actually forwarded, as a stand-in for the exact
+	// completion token count upstream only reports in its final chunk.
+	completionEstimate := 0
+
+	// recorder tees every chunk sent to the client so it can be replayed on
+	// a later cache hit; nil when this request isn't opted in to stream
+	// caching.
+	var recorder *cache.StreamRecorder
+	if cacheKey != "" {
+		recorder = cache.NewStreamRecorder()
+	}
+
 	// Start goroutine to read from stream
 	go func() {
 		defer close(dataChan)
@@ -234,16 +803,24 @@ func (s *Server) handleStreamingResponse(c *gin.Context, provider providers.Prov
 
 			if n > 0 {
 				chunk := string(buffer[:n])
-				
+
+				if se, ok := models.DecodeStreamEvent(chunk); ok {
+					convertedChunk, err := streamConv.Convert(se, toProtocol)
+					if err == nil && convertedChunk != nil {
+						jsonData, _ := json.Marshal(convertedChunk)
+						dataChan <- fmt.Sprintf("data: %s\n\n", string(jsonData))
+					}
+					continue
+				}
+
+				completionEstimate += usage.EstimateTokens(chunk)
+
 				// Convert chunk if needed
 				if fromProtocol != toProtocol {
 					convertedChunk, err := s.converter.ConvertStreamChunk(chunk, toProtocol, fromProtocol, model)
 					if err == nil && convertedChunk != nil {
-						if chunkData, ok := convertedChunk.(*models.StreamChunk); ok {
-							// Format as SSE
-							jsonData, _ := json.Marshal(chunkData)
-							dataChan <- fmt.Sprintf("data: %s\n\n", string(jsonData))
-						}
+						jsonData, _ := json.Marshal(convertedChunk)
+						dataChan <- fmt.Sprintf("data: %s\n\n", string(jsonData))
 					}
 				} else {
 					// Send raw chunk
@@ -251,24 +828,73 @@ func (s *Server) handleStreamingResponse(c *gin.Context, provider providers.Prov
 				}
 			}
 		}
-		
+
+		if providerName != "" {
+			estimated := models.Usage{CompletionTokens: completionEstimate, TotalTokens: completionEstimate}
+			cost := s.usage.Record(models.Provider(providerName), model, toProtocol, estimated)
+			s.quota.Charge(apiKey, cost)
+			s.obsMetrics.RecordTokens("completion", completionEstimate)
+		}
+
 		// Send done signal
 		dataChan <- "data: [DONE]\n\n"
 	}()
 
 	// Stream to client
+	completed := false
+	firstChunk := true
 	c.Stream(func(w io.Writer) bool {
 		select {
 		case data, ok := <-dataChan:
 			if !ok {
+				completed = true
 				return false
 			}
+			if firstChunk {
+				firstChunk = false
+				if providerName != "" {
+					s.obsMetrics.StreamTTFB.WithLabelValues(providerName, model).Observe(time.Since(start).Seconds())
+				}
+			}
+			if recorder != nil {
+				recorder.Record(data)
+			}
 			w.Write([]byte(data))
 			return true
 		case <-ctx.Done():
 			return false
 		}
 	})
+
+	if !completed {
+		// Drain until the reader goroutine closes dataChan, so it has
+		// finished with completionEstimate before it is read below.
+		for range dataChan {
+		}
+	}
+
+	if providerName != "" {
+		status := "ok"
+		if !completed {
+			status = "error"
+		}
+		s.obsMetrics.RequestsTotal.WithLabelValues(providerName, model, status).Inc()
+		s.obsMetrics.RequestDuration.WithLabelValues(providerName, model).Observe(time.Since(start).Seconds())
+		// completionEstimate and c.Writer.Size() are both stable here: the
+		// accounting goroutine above only closes dataChan (unblocking
+		// c.Stream) after it finishes writing to completionEstimate, and
+		// c.Writer.Size() reflects every byte Stream already wrote to the
+		// client.
+		metrics.Default().RecordUserMetrics(callerLabel(c), providerName, model, "chat.completions.stream",
+			promptTokens, completionEstimate, int(c.Request.ContentLength), c.Writer.Size())
+	}
+
+	// Only cache a stream that ran to completion: a client disconnecting
+	// mid-stream would otherwise poison the cache entry with a truncated
+	// recording that replays as a response cut off partway through.
+	if recorder != nil && completed {
+		s.cacheMgr.SetStream(cacheKey, recorder.Chunks(), s.cacheMgr.TTLForModel(model))
+	}
 }
 
 // handleListModels handles model listing requests
@@ -308,7 +934,7 @@ func (s *Server) handleListModels(c *gin.Context) {
 // handleGeminiGenerate handles Gemini-style generation requests
 func (s *Server) handleGeminiGenerate(c *gin.Context) {
 	modelName := c.Param("model")
-	
+
 	// Parse request
 	var req models.GeminiRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -318,9 +944,11 @@ func (s *Server) handleGeminiGenerate(c *gin.Context) {
 
 	// Find Gemini provider
 	var provider providers.Provider
+	var providerName string
 	for name, p := range s.providers {
 		if p.GetProtocolPrefix() == models.ProtocolGemini {
 			provider = p
+			providerName = name
 			break
 		}
 	}
@@ -330,6 +958,25 @@ func (s *Server) handleGeminiGenerate(c *gin.Context) {
 		return
 	}
 
+	if !s.checkCallerAllowed(c, providerName, modelName) {
+		return
+	}
+
+	apiKey := apiKeyFromRequest(c)
+	if !s.quota.Allow(apiKey) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "usage budget exceeded"})
+		return
+	}
+
+	estimatedTokens := usage.EstimateTokens(geminiPromptText(&req))
+	if !s.rateLimiter.Allow(providerName, apiKey, estimatedTokens) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+		return
+	}
+	if !s.checkCallerRateLimit(c, estimatedTokens) {
+		return
+	}
+
 	// Make request
 	ctx := c.Request.Context()
 	resp, err := provider.GenerateContent(ctx, modelName, &req)
@@ -338,13 +985,18 @@ func (s *Server) handleGeminiGenerate(c *gin.Context) {
 		return
 	}
 
+	if usg, ok := usage.ExtractUsage(models.ProtocolGemini, resp); ok {
+		cost := s.usage.Record(models.Provider(providerName), modelName, models.ProtocolGemini, usg)
+		s.quota.Charge(apiKey, cost)
+	}
+
 	c.JSON(http.StatusOK, resp)
 }
 
 // handleGeminiStream handles Gemini-style streaming requests
 func (s *Server) handleGeminiStream(c *gin.Context) {
 	modelName := c.Param("model")
-	
+
 	// Parse request
 	var req models.GeminiRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -354,9 +1006,11 @@ func (s *Server) handleGeminiStream(c *gin.Context) {
 
 	// Find Gemini provider
 	var provider providers.Provider
+	var providerName string
 	for name, p := range s.providers {
 		if p.GetProtocolPrefix() == models.ProtocolGemini {
 			provider = p
+			providerName = name
 			break
 		}
 	}
@@ -366,17 +1020,38 @@ func (s *Server) handleGeminiStream(c *gin.Context) {
 		return
 	}
 
+	if !s.checkCallerAllowed(c, providerName, modelName) {
+		return
+	}
+
+	apiKey := apiKeyFromRequest(c)
+	if !s.quota.Allow(apiKey) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "usage budget exceeded"})
+		return
+	}
+
+	estimatedTokens := usage.EstimateTokens(geminiPromptText(&req))
+	if !s.rateLimiter.Allow(providerName, apiKey, estimatedTokens) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+		return
+	}
+	if !s.checkCallerRateLimit(c, estimatedTokens) {
+		return
+	}
+
 	// Handle streaming
-	s.handleStreamingResponse(c, provider, modelName, &req, models.ProtocolGemini, models.ProtocolGemini)
+	s.handleStreamingResponseWithUsage(c.Request.Context(), c, provider, modelName, &req, models.ProtocolGemini, models.ProtocolGemini, providerName, apiKey, "", estimatedTokens, time.Now())
 }
 
 // handleGeminiListModels handles Gemini model listing
 func (s *Server) handleGeminiListModels(c *gin.Context) {
 	// Find Gemini provider
 	var provider providers.Provider
+	var providerName string
 	for name, p := range s.providers {
 		if p.GetProtocolPrefix() == models.ProtocolGemini {
 			provider = p
+			providerName = name
 			break
 		}
 	}
@@ -386,6 +1061,16 @@ func (s *Server) handleGeminiListModels(c *gin.Context) {
 		return
 	}
 
+	// No model to check here (it's a listing endpoint) and nothing to
+	// estimate tokens from, but the provider allowlist and per-caller RPM
+	// budget still apply.
+	if !s.checkCallerAllowed(c, providerName, "") {
+		return
+	}
+	if !s.checkCallerRateLimit(c, 0) {
+		return
+	}
+
 	ctx := c.Request.Context()
 	models, err := provider.ListModels(ctx)
 	if err != nil {
@@ -407,9 +1092,11 @@ func (s *Server) handleClaudeMessages(c *gin.Context) {
 
 	// Find Claude provider
 	var provider providers.Provider
+	var providerName string
 	for name, p := range s.providers {
 		if p.GetProtocolPrefix() == models.ProtocolClaude {
 			provider = p
+			providerName = name
 			break
 		}
 	}
@@ -419,9 +1106,28 @@ func (s *Server) handleClaudeMessages(c *gin.Context) {
 		return
 	}
 
+	if !s.checkCallerAllowed(c, providerName, req.Model) {
+		return
+	}
+
+	apiKey := apiKeyFromRequest(c)
+	if !s.quota.Allow(apiKey) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "usage budget exceeded"})
+		return
+	}
+
+	estimatedTokens := usage.EstimateTokens(claudePromptText(&req))
+	if !s.rateLimiter.Allow(providerName, apiKey, estimatedTokens) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+		return
+	}
+	if !s.checkCallerRateLimit(c, estimatedTokens) {
+		return
+	}
+
 	// Handle streaming
 	if req.Stream {
-		s.handleStreamingResponse(c, provider, req.Model, &req, models.ProtocolClaude, models.ProtocolClaude)
+		s.handleStreamingResponseWithUsage(c.Request.Context(), c, provider, req.Model, &req, models.ProtocolClaude, models.ProtocolClaude, providerName, apiKey, "", estimatedTokens, time.Now())
 		return
 	}
 
@@ -433,5 +1139,10 @@ func (s *Server) handleClaudeMessages(c *gin.Context) {
 		return
 	}
 
+	if usg, ok := usage.ExtractUsage(models.ProtocolClaude, resp); ok {
+		cost := s.usage.Record(models.Provider(providerName), req.Model, models.ProtocolClaude, usg)
+		s.quota.Charge(apiKey, cost)
+	}
+
 	c.JSON(http.StatusOK, resp)
-}
\ No newline at end of file
+}
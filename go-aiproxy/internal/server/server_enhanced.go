@@ -1,14 +1,25 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
 	"time"
 
 	"github.com/aiproxy/go-aiproxy/internal/cache"
+	"github.com/aiproxy/go-aiproxy/internal/config"
+	"github.com/aiproxy/go-aiproxy/internal/convert"
 	"github.com/aiproxy/go-aiproxy/internal/loadbalancer"
 	"github.com/aiproxy/go-aiproxy/internal/metrics"
+	"github.com/aiproxy/go-aiproxy/internal/middleware"
+	"github.com/aiproxy/go-aiproxy/internal/providers"
 	"github.com/aiproxy/go-aiproxy/internal/providers/kiro"
+	"github.com/aiproxy/go-aiproxy/internal/providers/plugin"
 	"github.com/aiproxy/go-aiproxy/internal/providers/qwen"
 	"github.com/aiproxy/go-aiproxy/internal/websocket"
 	"github.com/aiproxy/go-aiproxy/pkg/models"
@@ -26,6 +37,8 @@ type EnhancedServer struct {
 	dashboard    *metrics.MetricsDashboard
 	loadBalancer *loadbalancer.LoadBalancer
 	cluster      *loadbalancer.Cluster
+	plugins      []plugin.Loaded
+	credWatcher  *config.CredentialWatcher
 }
 
 // NewEnhancedServer creates a server with all advanced features
@@ -42,7 +55,7 @@ func NewEnhancedServer(cfg *config.Config) (*EnhancedServer, error) {
 
 	// Initialize cache
 	if err := s.initializeCache(cfg); err != nil {
-		return nil, fmt.Errorf("failed to initialize cache: %w", err)
+		return nil, models.NewAPIError(models.ErrCacheInit, "", fmt.Sprintf("failed to initialize cache: %v", err))
 	}
 
 	// Initialize metrics
@@ -61,6 +74,10 @@ func NewEnhancedServer(cfg *config.Config) (*EnhancedServer, error) {
 		return nil, fmt.Errorf("failed to add enhanced providers: %w", err)
 	}
 
+	// Watch OAuth credential files so a rotated Kiro/Qwen token is picked
+	// up without restarting the process.
+	s.initializeCredentialWatcher()
+
 	// Setup enhanced routes
 	s.setupEnhancedRoutes()
 
@@ -77,26 +94,36 @@ func (s *EnhancedServer) initializeCache(cfg *config.Config) error {
 	)
 
 	// Initialize Redis cache if configured
-	if cfg.RedisAddr != "" {
+	if cfg.RedisAddr != "" || cfg.RedisMode == string(cache.RedisModeCluster) || cfg.RedisMode == string(cache.RedisModeSentinel) {
 		redisCache, err := cache.NewRedisCache(cache.RedisConfig{
-			Addr:     cfg.RedisAddr,
-			Password: cfg.RedisPassword,
-			DB:       cfg.RedisDB,
-			Prefix:   "aiproxy:",
+			Mode:             cache.RedisMode(cfg.RedisMode),
+			Addr:             cfg.RedisAddr,
+			Password:         cfg.RedisPassword,
+			DB:               cfg.RedisDB,
+			Prefix:           "aiproxy:",
+			ClusterAddrs:     cfg.RedisClusterAddrs,
+			SentinelAddrs:    cfg.RedisSentinelAddrs,
+			SentinelMaster:   cfg.RedisSentinelMaster,
+			SentinelPassword: cfg.RedisSentinelPassword,
 		})
 		if err != nil {
-			return err
+			return models.NewAPIError(models.ErrCacheInit, "", fmt.Sprintf("failed to connect to Redis: %v", err))
 		}
 		s.redisCache = redisCache
 	}
 
+	if cfg.SemanticCacheEnabled {
+		embedder := cache.NewHTTPEmbedder(cfg.SemanticCacheEmbeddingURL, cfg.SemanticCacheEmbeddingKey, cfg.SemanticCacheEmbeddingModel)
+		s.cacheManager.EnableSemanticCache(embedder, cfg.SemanticCacheThreshold, cfg.SemanticCacheTemperatureMax)
+	}
+
 	return nil
 }
 
 // initializeMetrics sets up Prometheus metrics
 func (s *EnhancedServer) initializeMetrics() {
-	s.metrics = metrics.NewMetrics()
-	
+	s.metrics = metrics.Default()
+
 	// Start system metrics collector
 	s.metrics.CollectSystemMetrics(10 * time.Second)
 
@@ -123,6 +150,16 @@ func (s *EnhancedServer) initializeWebSocket() {
 	go s.wsHub.Run(context.Background())
 }
 
+// Cluster config-store keys used to replicate admin-API writes (load
+// balancer algorithm, cache enable/disable) via Cluster.Store().
+// GuaranteedUpdate instead of mutating local state on whichever node
+// received the HTTP request.
+const (
+	clusterKeyLBAlgorithm       = "loadbalancer.algorithm"
+	clusterKeyCacheEnabled      = "cache.enabled"
+	clusterKeySemanticThreshold = "cache.semantic.threshold"
+)
+
 // initializeLoadBalancer sets up load balancing
 func (s *EnhancedServer) initializeLoadBalancer(cfg *config.Config) error {
 	// Create load balancer
@@ -130,7 +167,7 @@ func (s *EnhancedServer) initializeLoadBalancer(cfg *config.Config) error {
 	if cfg.LoadBalancerAlgorithm != "" {
 		algorithm = loadbalancer.Algorithm(cfg.LoadBalancerAlgorithm)
 	}
-	
+
 	s.loadBalancer = loadbalancer.NewLoadBalancer(algorithm)
 
 	// Add instances from pool configuration
@@ -138,12 +175,12 @@ func (s *EnhancedServer) initializeLoadBalancer(cfg *config.Config) error {
 		for i, config := range configs {
 			instanceID := fmt.Sprintf("%s-%d", providerType, i)
 			provider := s.providers[providerType]
-			
+
 			weight := 1
 			if config.Weight > 0 {
 				weight = config.Weight
 			}
-			
+
 			s.loadBalancer.AddInstance(instanceID, provider, config, weight)
 		}
 	}
@@ -151,7 +188,7 @@ func (s *EnhancedServer) initializeLoadBalancer(cfg *config.Config) error {
 	// Set up health check callback
 	s.loadBalancer.healthChecker.AddUpdateCallback(func(instanceID string, healthy bool) {
 		s.loadBalancer.UpdateInstanceHealth(instanceID, healthy)
-		
+
 		// Update metrics
 		if healthy {
 			s.metrics.PoolHealthyProviders.WithLabelValues(instanceID).Inc()
@@ -163,6 +200,30 @@ func (s *EnhancedServer) initializeLoadBalancer(cfg *config.Config) error {
 	// Initialize cluster if configured
 	if cfg.ClusterEnabled {
 		s.cluster = loadbalancer.NewCluster(cfg.NodeID, cfg.NodeAddress)
+
+		// Keep this node's in-memory load balancer/cache in sync with
+		// writes replicated through the Raft log, whether they originated
+		// here (this node is the leader) or on another node.
+		s.cluster.OnConfigUpdate(func(key string, data json.RawMessage) {
+			switch key {
+			case clusterKeyLBAlgorithm:
+				var algorithm string
+				if err := json.Unmarshal(data, &algorithm); err == nil {
+					s.loadBalancer.SetAlgorithm(loadbalancer.Algorithm(algorithm))
+				}
+			case clusterKeyCacheEnabled:
+				var enabled bool
+				if err := json.Unmarshal(data, &enabled); err == nil {
+					s.cacheManager.SetEnabled(enabled)
+				}
+			case clusterKeySemanticThreshold:
+				var threshold float64
+				if err := json.Unmarshal(data, &threshold); err == nil {
+					s.cacheManager.SetSemanticThreshold(threshold)
+				}
+			}
+		})
+
 		if err := s.cluster.Join(cfg.SeedNodes); err != nil {
 			return fmt.Errorf("failed to join cluster: %w", err)
 		}
@@ -171,7 +232,8 @@ func (s *EnhancedServer) initializeLoadBalancer(cfg *config.Config) error {
 	return nil
 }
 
-// addEnhancedProviders adds Kiro and Qwen providers
+// addEnhancedProviders adds Kiro and Qwen providers, plus any
+// out-of-process plugin providers discovered in cfg.PluginDir.
 func (s *EnhancedServer) addEnhancedProviders() error {
 	// Add Kiro provider
 	if kiroConfig, ok := s.config.ProviderConfigs["kiro-api"]; ok {
@@ -191,14 +253,146 @@ func (s *EnhancedServer) addEnhancedProviders() error {
 		s.providers["qwen-api"] = qwenClient
 	}
 
+	s.addPluginProviders()
+
 	return nil
 }
 
+// addPluginProviders discovers and launches every aiproxy-plugin-*
+// binary in s.config.PluginDir, registers each one into s.providers
+// under the name its handshake advertised, and adds it to the load
+// balancer so it participates in the same instance selection and health
+// checking as any built-in provider. A plugin that fails to launch is
+// logged and skipped rather than failing startup, since plugins are
+// optional and one broken binary shouldn't take the whole proxy down.
+func (s *EnhancedServer) addPluginProviders() {
+	if s.config.PluginDir == "" {
+		return
+	}
+
+	loaded, errs := plugin.LoadAll(s.config.PluginDir, s.config.PluginMaxMemoryMB)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "plugin: %v\n", err)
+	}
+
+	for _, l := range loaded {
+		name := l.Supervisor.Current().Name
+		s.providers[name] = l.Provider
+		s.plugins = append(s.plugins, l)
+
+		if s.loadBalancer != nil {
+			config := &models.ProviderConfig{Provider: models.Provider(name), UUID: name, IsHealthy: true}
+			if err := s.loadBalancer.AddInstance(name, l.Provider, config, 1); err != nil {
+				fmt.Fprintf(os.Stderr, "plugin: failed to register %s with load balancer: %v\n", name, err)
+			}
+		}
+	}
+}
+
+// credentialReloadGrace bounds how long ReplaceInstance waits for a
+// provider's in-flight requests to finish on its old client before
+// forcibly cutting over to a freshly-rotated credential.
+const credentialReloadGrace = 30 * time.Second
+
+// initializeCredentialWatcher watches every configured OAuth credentials
+// file (Kiro, Qwen) and hot-swaps that provider's client whenever its file
+// changes, so a token rotated on disk by an external refresher takes
+// effect without restarting the process. A deployment with no OAuth
+// credential files configured gets no watcher at all.
+func (s *EnhancedServer) initializeCredentialWatcher() {
+	var paths []string
+	for _, id := range []string{"kiro-api", "qwen-api"} {
+		if cfg, ok := s.config.ProviderConfigs[id]; ok && cfg.OAuthCredsFile != "" {
+			paths = append(paths, cfg.OAuthCredsFile)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	watcher, err := config.NewCredentialWatcher(paths, s.reloadProviderForFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config: failed to start credential watcher: %v\n", err)
+		return
+	}
+	s.credWatcher = watcher
+}
+
+// reloadProviderForFile rebuilds whichever configured provider's
+// OAuthCredsFile matches path and hot-swaps it into both s.providers (the
+// live failover path) and the load balancer, if that provider was
+// registered there.
+func (s *EnhancedServer) reloadProviderForFile(path string) {
+	for id, cfg := range s.config.ProviderConfigs {
+		if cfg.OAuthCredsFile != path {
+			continue
+		}
+
+		provider, err := s.rebuildProvider(id, cfg)
+		if err != nil {
+			log.Printf("config: failed to reload %s credentials from %s: %v", id, path, err)
+			if s.metrics != nil {
+				s.metrics.RecordConfigReload(id, "error")
+			}
+			return
+		}
+
+		s.providers[id] = provider
+		if s.loadBalancer != nil {
+			// Not every provider is registered with the load balancer
+			// (addEnhancedProviders adds kiro/qwen only to s.providers);
+			// s.providers is already updated either way, so a missing
+			// instance here is not itself a reload failure.
+			if err := s.loadBalancer.ReplaceInstance(id, provider, cfg, 1, credentialReloadGrace); err != nil {
+				log.Printf("config: %s reloaded but not registered with load balancer: %v", id, err)
+			}
+		}
+
+		log.Printf("config: reloaded %s credentials from %s", id, path)
+		if s.metrics != nil {
+			s.metrics.RecordConfigReload(id, "success")
+		}
+		return
+	}
+}
+
+// rebuildProvider constructs a fresh client for id from cfg, the same way
+// addEnhancedProviders does on startup.
+func (s *EnhancedServer) rebuildProvider(id string, cfg *models.ProviderConfig) (providers.Provider, error) {
+	switch id {
+	case "kiro-api":
+		return kiro.NewClient(cfg)
+	case "qwen-api":
+		return qwen.NewClient(cfg)
+	default:
+		return nil, fmt.Errorf("hot-reload not supported for provider %q", id)
+	}
+}
+
+// handleAdminReload triggers an immediate, synchronous re-read of every
+// watched OAuth credentials file, instead of waiting for fsnotify to
+// notice the change - useful right after rotating a credential out of
+// band, or when the filesystem the credentials live on doesn't deliver
+// inotify events (e.g. some network mounts).
+func (s *EnhancedServer) handleAdminReload(c *gin.Context) {
+	reloaded := make([]string, 0)
+	for _, id := range []string{"kiro-api", "qwen-api"} {
+		cfg, ok := s.config.ProviderConfigs[id]
+		if !ok || cfg.OAuthCredsFile == "" {
+			continue
+		}
+		s.reloadProviderForFile(cfg.OAuthCredsFile)
+		reloaded = append(reloaded, id)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reloaded": reloaded})
+}
+
 // setupEnhancedRoutes adds routes for advanced features
 func (s *EnhancedServer) setupEnhancedRoutes() {
 	// Metrics endpoint
 	s.router.GET("/metrics", metrics.Handler())
-	
+
 	// Metrics dashboard
 	if s.dashboard != nil {
 		s.dashboard.RegisterRoutes(s.router.Group("/"))
@@ -215,6 +409,8 @@ func (s *EnhancedServer) setupEnhancedRoutes() {
 		cache.DELETE("/clear", s.handleCacheClear)
 		cache.PUT("/enable", s.handleCacheEnable)
 		cache.PUT("/disable", s.handleCacheDisable)
+		cache.GET("/semantic/stats", s.handleSemanticCacheStats)
+		cache.PUT("/semantic/threshold", s.handleSemanticCacheThreshold)
 	}
 
 	// Load balancer endpoints
@@ -226,46 +422,111 @@ func (s *EnhancedServer) setupEnhancedRoutes() {
 		lb.PUT("/algorithm", s.handleLBSetAlgorithm)
 	}
 
+	// Admin endpoints
+	admin := s.router.Group("/admin")
+	admin.Use(middleware.APIKeyAuth(s.config.APIKey))
+	{
+		admin.POST("/reload", s.handleAdminReload)
+	}
+
 	// Cluster endpoints
 	if s.cluster != nil {
-		s.cluster.RegisterHandlers(s.router.Group("/cluster"))
+		clusterGroup := s.router.Group("/cluster")
+		s.cluster.RegisterHandlers(clusterGroup)
+		clusterGroup.GET("/health", s.handleClusterHealth)
 	}
 }
 
-// Enhanced chat completions with caching
+// handleClusterHealth reports per-instance health stats (last latency,
+// success ratio, last error) from the load balancer's HealthChecker.
+func (s *EnhancedServer) handleClusterHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"instances": s.loadBalancer.HealthStats(),
+	})
+}
+
+// Enhanced chat completions with caching. Unlike the base Server's
+// handleChatCompletions, which keys its cache on the canonicalized request
+// and falls back to an embedding-similarity match, this path historically
+// only supported an exact, non-canonical key; it now shares that same
+// canonical-key + semantic-fallback behavior, scoped by
+// provider+model+tool-set+response_format and subject to X-Cache-Mode.
 func (s *EnhancedServer) handleChatCompletionsWithCache(c *gin.Context) {
-	// Check cache first
-	if s.cacheManager.IsEnabled() {
-		var req models.OpenAIRequest
-		if err := c.ShouldBindJSON(&req); err == nil && !req.Stream {
-			// Generate cache key
-			providerName := c.GetHeader("X-Model-Provider")
-			if providerName == "" {
-				providerName = s.config.ModelProviders[0]
-			}
-			
-			cacheKey, _ := s.cacheManager.GenerateCacheKey(providerName, req.Model, &req)
-			
-			// Check cache
-			if cached, found := s.cacheManager.Get(cacheKey); found {
-				s.metrics.RecordCacheMetrics("memory", true)
-				c.JSON(http.StatusOK, cached)
-				return
-			}
-			
-			// Cache miss - continue with normal processing
-			s.metrics.RecordCacheMetrics("memory", false)
-		}
+	var req models.OpenAIRequest
+	rawBody, _ := c.GetRawData()
+	c.Request.Body = io.NopCloser(bytes.NewReader(rawBody))
+	bindErr := c.ShouldBindJSON(&req)
+	// ShouldBindJSON consumed the body; restore it so handleChatCompletions
+	// can bind the same request again.
+	c.Request.Body = io.NopCloser(bytes.NewReader(rawBody))
+	cacheMode := cache.ParseMode(c.GetHeader("X-Cache-Mode"))
+	cacheable := s.cacheManager.IsEnabled() && bindErr == nil && !req.Stream && cacheMode != cache.ModeBypass
+
+	if !cacheable {
+		s.handleChatCompletions(c)
+		return
 	}
 
-	// Call original handler
+	providerName := c.GetHeader("X-Model-Provider")
+	if providerName == "" {
+		providerName = s.config.ModelProviders[0]
+	}
+	cacheKey, err := s.cacheManager.GenerateCanonicalCacheKey(s.converter, &req, models.ProtocolOpenAI)
+	if err != nil {
+		cacheKey, _ = s.cacheManager.GenerateCacheKey(providerName, req.Model, &req)
+	}
+	prompt := cache.PromptFromMessages(req.Messages)
+	toolsFormat := cache.FingerprintToolsFormat(req.Tools, req.ResponseFormat)
+
+	var cached interface{}
+	var found bool
+	if cacheMode == cache.ModeExact {
+		cached, found = s.cacheManager.Get(cacheKey)
+	} else {
+		cached, found = s.cacheManager.GetSemantic(c.Request.Context(), cacheKey, providerName, req.Model, models.TemperatureOrDefault(req.Temperature, convert.DefaultTemperature), toolsFormat, prompt)
+	}
+	if found {
+		s.metrics.RecordCacheMetrics("semantic", true)
+		c.JSON(http.StatusOK, cached)
+		return
+	}
+	s.metrics.RecordCacheMetrics("semantic", false)
+
+	// Call the original handler, capturing its body so a cacheable response
+	// can be written back into s.cacheManager - this handler's own cache
+	// would otherwise stay read-only, since handleChatCompletions writes
+	// through the base Server's separate cacheMgr instead.
+	capture := &responseCapture{ResponseWriter: c.Writer}
+	c.Writer = capture
 	s.handleChatCompletions(c)
+
+	if capture.Status() == http.StatusOK {
+		if cacheMode == cache.ModeExact {
+			s.cacheManager.Set(cacheKey, json.RawMessage(capture.body), s.cacheManager.TTLForModel(req.Model))
+		} else {
+			s.cacheManager.SetSemantic(c.Request.Context(), cacheKey, providerName, req.Model, models.TemperatureOrDefault(req.Temperature, convert.DefaultTemperature), toolsFormat, prompt, json.RawMessage(capture.body), s.cacheManager.TTLForModel(req.Model))
+		}
+	}
+}
+
+// responseCapture tees a handler's written body into an in-memory buffer
+// alongside the real ResponseWriter, so handleChatCompletionsWithCache can
+// populate its cache from whatever handleChatCompletions ends up writing
+// without changing handleChatCompletions itself.
+type responseCapture struct {
+	gin.ResponseWriter
+	body []byte
+}
+
+func (r *responseCapture) Write(data []byte) (int, error) {
+	r.body = append(r.body, data...)
+	return r.ResponseWriter.Write(data)
 }
 
 // Cache management handlers
 func (s *EnhancedServer) handleCacheStats(c *gin.Context) {
 	stats := s.cacheManager.GetStats()
-	
+
 	response := gin.H{
 		"enabled":     s.cacheManager.IsEnabled(),
 		"hits":        stats.Hits,
@@ -286,7 +547,7 @@ func (s *EnhancedServer) handleCacheStats(c *gin.Context) {
 
 func (s *EnhancedServer) handleCacheClear(c *gin.Context) {
 	s.cacheManager.Clear()
-	
+
 	if s.redisCache != nil {
 		s.redisCache.Clear(c.Request.Context())
 	}
@@ -295,19 +556,96 @@ func (s *EnhancedServer) handleCacheClear(c *gin.Context) {
 }
 
 func (s *EnhancedServer) handleCacheEnable(c *gin.Context) {
-	s.cacheManager.SetEnabled(true)
-	c.JSON(http.StatusOK, gin.H{"status": "cache enabled"})
+	s.setCacheEnabled(c, true, "/cache/enable")
 }
 
 func (s *EnhancedServer) handleCacheDisable(c *gin.Context) {
-	s.cacheManager.SetEnabled(false)
-	c.JSON(http.StatusOK, gin.H{"status": "cache disabled"})
+	s.setCacheEnabled(c, false, "/cache/disable")
+}
+
+// setCacheEnabled flips the cache's enable flag. When clustered, the write
+// is replicated through Cluster.Store() so every node's cacheManager stays
+// in sync; a follower forwards the request to the leader instead of
+// mutating only its own local state.
+func (s *EnhancedServer) setCacheEnabled(c *gin.Context, enabled bool, path string) {
+	status := "cache disabled"
+	if enabled {
+		status = "cache enabled"
+	}
+
+	if s.cluster != nil {
+		if !s.cluster.IsLeader() {
+			s.cluster.ForwardToLeader(c, path)
+			return
+		}
+
+		data, _ := json.Marshal(enabled)
+		err := s.cluster.Store().GuaranteedUpdate(clusterKeyCacheEnabled, func(current []byte) ([]byte, bool, error) {
+			return data, true, nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": status})
+		return
+	}
+
+	s.cacheManager.SetEnabled(enabled)
+	c.JSON(http.StatusOK, gin.H{"status": status})
+}
+
+// handleSemanticCacheStats reports whether semantic caching is enabled and,
+// if so, its current similarity threshold and live indexed-entry count.
+func (s *EnhancedServer) handleSemanticCacheStats(c *gin.Context) {
+	enabled, threshold, entries := s.cacheManager.SemanticStats()
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":   enabled,
+		"threshold": threshold,
+		"entries":   entries,
+	})
+}
+
+// handleSemanticCacheThreshold updates the minimum cosine similarity
+// required for a semantic cache lookup to count as a hit. Like
+// setCacheEnabled, the write is replicated through Cluster.Store() when
+// clustered, with a follower forwarding the request to the leader instead
+// of mutating only its own local state.
+func (s *EnhancedServer) handleSemanticCacheThreshold(c *gin.Context) {
+	var body struct {
+		Threshold float64 `json:"threshold"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if s.cluster != nil {
+		if !s.cluster.IsLeader() {
+			s.cluster.ForwardToLeader(c, "/cache/semantic/threshold")
+			return
+		}
+
+		data, _ := json.Marshal(body.Threshold)
+		err := s.cluster.Store().GuaranteedUpdate(clusterKeySemanticThreshold, func(current []byte) ([]byte, bool, error) {
+			return data, true, nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"threshold": body.Threshold})
+		return
+	}
+
+	s.cacheManager.SetSemanticThreshold(body.Threshold)
+	c.JSON(http.StatusOK, gin.H{"threshold": body.Threshold})
 }
 
 // Load balancer handlers
 func (s *EnhancedServer) handleLBInstances(c *gin.Context) {
 	instances := s.loadBalancer.GetInstances()
-	
+
 	response := make([]gin.H, len(instances))
 	for i, inst := range instances {
 		response[i] = gin.H{
@@ -318,6 +656,7 @@ func (s *EnhancedServer) handleLBInstances(c *gin.Context) {
 			"failed_requests": inst.FailedRequests,
 			"is_healthy":      inst.IsHealthy,
 			"last_used":       inst.LastUsed,
+			"adaptive_score":  inst.AdaptiveScore(),
 		}
 	}
 
@@ -326,7 +665,7 @@ func (s *EnhancedServer) handleLBInstances(c *gin.Context) {
 
 func (s *EnhancedServer) handleLBMetrics(c *gin.Context) {
 	metrics := s.loadBalancer.GetMetrics()
-	
+
 	c.JSON(http.StatusOK, gin.H{
 		"total_requests":    metrics.TotalRequests,
 		"failed_requests":   metrics.FailedRequests,
@@ -340,12 +679,33 @@ func (s *EnhancedServer) handleLBSetAlgorithm(c *gin.Context) {
 	var req struct {
 		Algorithm string `json:"algorithm"`
 	}
-	
+
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// When clustered, replicate the algorithm change through Raft so every
+	// node converges on the same value instead of only the node that
+	// happened to receive this request; a follower redirects to the leader.
+	if s.cluster != nil {
+		if !s.cluster.IsLeader() {
+			s.cluster.ForwardToLeader(c, "/loadbalancer/algorithm")
+			return
+		}
+
+		data, _ := json.Marshal(req.Algorithm)
+		err := s.cluster.Store().GuaranteedUpdate(clusterKeyLBAlgorithm, func(current []byte) ([]byte, bool, error) {
+			return data, true, nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "algorithm updated"})
+		return
+	}
+
 	s.loadBalancer.SetAlgorithm(loadbalancer.Algorithm(req.Algorithm))
 	c.JSON(http.StatusOK, gin.H{"status": "algorithm updated"})
-}
\ No newline at end of file
+}
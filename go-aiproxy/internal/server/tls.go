@@ -0,0 +1,164 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// tlsReloader watches the server certificate/key and client CA bundle on
+// disk and swaps them in atomically, so short-lived SPIFFE SVIDs issued by
+// a SPIRE agent (which rewrites these files in place every rotation) are
+// picked up without dropping the listener or restarting the process.
+type tlsReloader struct {
+	certFile   string
+	keyFile    string
+	clientCA   string
+	clientAuth tls.ClientAuthType
+
+	cert    atomic.Value // tls.Certificate
+	pool    atomic.Value // *x509.CertPool
+	watcher *fsnotify.Watcher
+}
+
+func newTLSReloader(certFile, keyFile, clientCAFile string, clientAuth tls.ClientAuthType) (*tlsReloader, error) {
+	r := &tlsReloader{
+		certFile:   certFile,
+		keyFile:    keyFile,
+		clientCA:   clientCAFile,
+		clientAuth: clientAuth,
+	}
+	if err := r.reloadCert(); err != nil {
+		return nil, err
+	}
+	if clientCAFile != "" {
+		if err := r.reloadClientCA(); err != nil {
+			return nil, err
+		}
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TLS file watcher: %w", err)
+	}
+	r.watcher = watcher
+	for _, f := range []string{certFile, keyFile, clientCAFile} {
+		if f == "" {
+			continue
+		}
+		if err := watcher.Add(f); err != nil {
+			return nil, fmt.Errorf("failed to watch %s: %w", f, err)
+		}
+	}
+	go r.watch()
+
+	return r, nil
+}
+
+func (r *tlsReloader) reloadCert() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	r.cert.Store(cert)
+	return nil
+}
+
+func (r *tlsReloader) reloadClientCA() error {
+	pem, err := os.ReadFile(r.clientCA)
+	if err != nil {
+		return fmt.Errorf("failed to read client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no valid certificates found in client CA file %s", r.clientCA)
+	}
+	r.pool.Store(pool)
+	return nil
+}
+
+// watch reacts to write/create events on the watched files. SPIRE and
+// cert-manager both rotate files via a rename-into-place, which fsnotify
+// reports as Create on the destination path rather than Write, so both
+// are handled the same way.
+func (r *tlsReloader) watch() {
+	for {
+		select {
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			if event.Name == r.clientCA {
+				if err := r.reloadClientCA(); err != nil {
+					log.Printf("tls: failed to reload client CA: %v", err)
+				}
+				continue
+			}
+			if err := r.reloadCert(); err != nil {
+				log.Printf("tls: failed to reload certificate: %v", err)
+			}
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("tls: watcher error: %v", err)
+		}
+	}
+}
+
+func (r *tlsReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := r.cert.Load().(tls.Certificate)
+	return &cert, nil
+}
+
+func (r *tlsReloader) config() *tls.Config {
+	cfg := &tls.Config{
+		GetCertificate: r.getCertificate,
+		ClientAuth:     r.clientAuth,
+	}
+	if r.clientCA != "" {
+		cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			clientCfg := cfg.Clone()
+			clientCfg.GetConfigForClient = nil
+			clientCfg.ClientCAs = r.pool.Load().(*x509.CertPool)
+			return clientCfg, nil
+		}
+	}
+	return cfg
+}
+
+// clientAuthType maps config.Config.TLSClientCARequire to the matching
+// crypto/tls mode. An unset/unrecognized value defaults to requiring and
+// verifying a client certificate, since TLSClientCAFile being set at all
+// signals the operator wants client-cert auth enforced.
+//
+// "require" and "verify" are deliberately both mapped to
+// RequireAndVerifyClientCert rather than RequireAnyClientCert: MTLSAuth
+// trusts PeerCertificates[0]'s SPIFFE URI SAN on the assumption that
+// crypto/tls already validated the chain against the CA pool, and
+// RequireAnyClientCert only checks that *some* certificate was presented,
+// not that it chains to a trusted root - accepting it here would let any
+// self-signed certificate with an arbitrary SPIFFE URI impersonate any
+// identity. "require" is kept as a distinct accepted value for operators
+// migrating an existing config rather than because it behaves differently
+// from "verify". "request" remains the one genuinely permissive mode
+// (requests but doesn't require a certificate at all) and must not be
+// combined with SPIFFETrustDomain.
+func clientAuthType(mode string) tls.ClientAuthType {
+	switch mode {
+	case "request":
+		return tls.RequestClientCert
+	case "require", "verify":
+		return tls.RequireAndVerifyClientCert
+	default:
+		return tls.RequireAndVerifyClientCert
+	}
+}
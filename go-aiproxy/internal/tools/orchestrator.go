@@ -0,0 +1,268 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aiproxy/go-aiproxy/internal/providers"
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+	"github.com/google/uuid"
+)
+
+const defaultMaxToolSteps = 8
+
+// ConfirmHook is consulted before dispatching a call to a tool registered
+// with the "may_" naming convention. Returning false skips execution and
+// feeds a denial back to the model instead.
+type ConfirmHook func(ctx context.Context, call ToolInvocation) bool
+
+// ResultCallback is notified with each tool's result as soon as it
+// finishes running, so a caller such as a TUI or API stream can surface
+// tool activity to the user without waiting for the orchestrator's next
+// round trip to the model.
+type ResultCallback func(call ToolInvocation, result ToolResult)
+
+// Option configures an Orchestrator.
+type Option func(*Orchestrator)
+
+// WithMaxToolSteps bounds how many tool_calls/tool_use round trips the
+// orchestrator will drive before giving up and returning the last
+// response as-is.
+func WithMaxToolSteps(n int) Option {
+	return func(o *Orchestrator) {
+		if n > 0 {
+			o.maxToolSteps = n
+		}
+	}
+}
+
+// WithConfirmHook sets the hook consulted before running "may_"-prefixed
+// tools. Without one, confirmation-required tools are always skipped.
+func WithConfirmHook(hook ConfirmHook) Option {
+	return func(o *Orchestrator) {
+		o.confirm = hook
+	}
+}
+
+// WithResultCallback sets the callback notified after each tool call
+// finishes, confirmed or not. Without one, results are only visible once
+// they are folded back into the next request.
+func WithResultCallback(cb ResultCallback) Option {
+	return func(o *Orchestrator) {
+		o.onResult = cb
+	}
+}
+
+// Orchestrator drives a Provider through repeated tool-call rounds: it
+// inspects each response for pending tool calls, executes them against
+// the Registry, appends the results to the conversation, and re-invokes
+// the provider until a terminal response comes back or MaxToolSteps is
+// reached.
+type Orchestrator struct {
+	registry     *Registry
+	maxToolSteps int
+	confirm      ConfirmHook
+	onResult     ResultCallback
+}
+
+// NewOrchestrator creates an Orchestrator backed by registry.
+func NewOrchestrator(registry *Registry, opts ...Option) *Orchestrator {
+	o := &Orchestrator{
+		registry:     registry,
+		maxToolSteps: defaultMaxToolSteps,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Run repeatedly invokes provider.GenerateContent, executing any tool
+// calls the model asks for and feeding the results back, until the model
+// returns a response with no pending tool calls or MaxToolSteps is hit.
+// request must be the protocol-native request type for protocol
+// (*models.OpenAIRequest, *models.ClaudeRequest, or *models.GeminiRequest).
+func (o *Orchestrator) Run(ctx context.Context, provider providers.Provider, model string, protocol models.ProtocolPrefix, request interface{}) (interface{}, error) {
+	for step := 0; ; step++ {
+		resp, err := provider.GenerateContent(ctx, model, request)
+		if err != nil {
+			return nil, fmt.Errorf("generate content: %w", err)
+		}
+
+		calls, extractErr := extractToolCalls(protocol, resp)
+		if extractErr != nil {
+			return nil, extractErr
+		}
+		if len(calls) == 0 || step >= o.maxToolSteps {
+			return resp, nil
+		}
+
+		results := make([]ToolResult, 0, len(calls))
+		for _, call := range calls {
+			result := o.dispatch(ctx, call)
+			if o.onResult != nil {
+				o.onResult(call, result)
+			}
+			results = append(results, result)
+		}
+
+		request, err = appendToolRound(protocol, request, resp, calls, results)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// dispatch executes a single tool call, honoring per-tool timeouts and
+// the "may_" confirmation convention.
+func (o *Orchestrator) dispatch(ctx context.Context, call ToolInvocation) ToolResult {
+	def, ok := o.registry.Get(call.Name)
+	if !ok {
+		return ToolResult{ToolCallID: call.ID, Content: fmt.Sprintf("unknown tool %q", call.Name), IsError: true}
+	}
+
+	if def.RequiresConfirmation() {
+		if o.confirm == nil || !o.confirm(ctx, call) {
+			return ToolResult{ToolCallID: call.ID, Content: fmt.Sprintf("tool %q requires confirmation and was not approved", call.Name), IsError: true}
+		}
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, def.Timeout)
+	defer cancel()
+
+	result, err := def.Executor.Execute(callCtx, call)
+	if err != nil {
+		return ToolResult{ToolCallID: call.ID, Content: err.Error(), IsError: true}
+	}
+	return result
+}
+
+// extractToolCalls normalizes the pending tool calls out of a protocol
+// response, returning none for a terminal (non-tool) response.
+func extractToolCalls(protocol models.ProtocolPrefix, resp interface{}) ([]ToolInvocation, error) {
+	switch protocol {
+	case models.ProtocolOpenAI:
+		r, ok := resp.(*models.OpenAIResponse)
+		if !ok || len(r.Choices) == 0 || r.Choices[0].Message == nil {
+			return nil, nil
+		}
+		var calls []ToolInvocation
+		for _, tc := range r.Choices[0].Message.ToolCalls {
+			calls = append(calls, ToolInvocation{ID: tc.ID, Name: tc.Function.Name, Arguments: json.RawMessage(tc.Function.Arguments)})
+		}
+		return calls, nil
+
+	case models.ProtocolClaude:
+		r, ok := resp.(*models.ClaudeResponse)
+		if !ok || r.StopReason != "tool_use" {
+			return nil, nil
+		}
+		var calls []ToolInvocation
+		for _, block := range r.Content {
+			if block.Type != "tool_use" {
+				continue
+			}
+			args, err := json.Marshal(block.Input)
+			if err != nil {
+				return nil, fmt.Errorf("marshal tool_use input for %s: %w", block.Name, err)
+			}
+			calls = append(calls, ToolInvocation{ID: block.ID, Name: block.Name, Arguments: args})
+		}
+		return calls, nil
+
+	case models.ProtocolGemini:
+		r, ok := resp.(*models.GeminiResponse)
+		if !ok || len(r.Candidates) == 0 {
+			return nil, nil
+		}
+		var calls []ToolInvocation
+		for _, part := range r.Candidates[0].Content.Parts {
+			if part.FunctionCall == nil {
+				continue
+			}
+			args, err := json.Marshal(part.FunctionCall.Args)
+			if err != nil {
+				return nil, fmt.Errorf("marshal functionCall args for %s: %w", part.FunctionCall.Name, err)
+			}
+			id := part.FunctionCall.ID
+			if id == "" {
+				id = "call_" + uuid.New().String()
+			}
+			calls = append(calls, ToolInvocation{ID: id, Name: part.FunctionCall.Name, Arguments: args})
+		}
+		return calls, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported protocol for tool execution: %s", protocol)
+	}
+}
+
+// appendToolRound appends the assistant's tool-call message and each
+// tool's result to request, ready for the next GenerateContent call.
+func appendToolRound(protocol models.ProtocolPrefix, request interface{}, resp interface{}, calls []ToolInvocation, results []ToolResult) (interface{}, error) {
+	switch protocol {
+	case models.ProtocolOpenAI:
+		req, ok := request.(*models.OpenAIRequest)
+		if !ok {
+			return nil, fmt.Errorf("expected *models.OpenAIRequest, got %T", request)
+		}
+		assistant := resp.(*models.OpenAIResponse).Choices[0].Message
+		req.Messages = append(req.Messages, *assistant)
+		for _, res := range results {
+			req.Messages = append(req.Messages, models.OpenAIMessage{
+				Role:       models.RoleTool,
+				ToolCallID: res.ToolCallID,
+				Content:    res.Content,
+			})
+		}
+		return req, nil
+
+	case models.ProtocolClaude:
+		req, ok := request.(*models.ClaudeRequest)
+		if !ok {
+			return nil, fmt.Errorf("expected *models.ClaudeRequest, got %T", request)
+		}
+		assistant := resp.(*models.ClaudeResponse)
+		req.Messages = append(req.Messages, models.ClaudeMessage{Role: models.RoleAssistant, Content: assistant.Content})
+
+		var toolResults []models.ClaudeContent
+		for _, res := range results {
+			toolResults = append(toolResults, models.ClaudeContent{
+				Type:      "tool_result",
+				ToolUseID: res.ToolCallID,
+				Content:   res.Content,
+			})
+		}
+		req.Messages = append(req.Messages, models.ClaudeMessage{Role: models.RoleUser, Content: toolResults})
+		return req, nil
+
+	case models.ProtocolGemini:
+		req, ok := request.(*models.GeminiRequest)
+		if !ok {
+			return nil, fmt.Errorf("expected *models.GeminiRequest, got %T", request)
+		}
+		candidate := resp.(*models.GeminiResponse).Candidates[0]
+		req.Contents = append(req.Contents, candidate.Content)
+
+		var responseParts []models.GeminiPart
+		for i, res := range results {
+			var response map[string]interface{}
+			if err := json.Unmarshal([]byte(res.Content), &response); err != nil {
+				response = map[string]interface{}{"result": res.Content}
+			}
+			responseParts = append(responseParts, models.GeminiPart{
+				FunctionResponse: &models.GeminiFunctionResponse{
+					ID:       calls[i].ID,
+					Name:     calls[i].Name,
+					Response: response,
+				},
+			})
+		}
+		req.Contents = append(req.Contents, models.GeminiContent{Role: models.RoleFunction, Parts: responseParts})
+		return req, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported protocol for tool execution: %s", protocol)
+	}
+}
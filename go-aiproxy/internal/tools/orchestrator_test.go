@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+// fakeProvider returns queued responses in order, one per GenerateContent
+// call, so tests can script a tool_use round followed by a terminal
+// response.
+type fakeProvider struct {
+	responses []*models.ClaudeResponse
+	calls     int
+}
+
+func (p *fakeProvider) GenerateContent(ctx context.Context, model string, request interface{}) (interface{}, error) {
+	resp := p.responses[p.calls]
+	p.calls++
+	return resp, nil
+}
+
+func (p *fakeProvider) GenerateContentStream(ctx context.Context, model string, request interface{}) (io.ReadCloser, error) {
+	return nil, nil
+}
+
+func (p *fakeProvider) ListModels(ctx context.Context) (interface{}, error) { return nil, nil }
+func (p *fakeProvider) RefreshToken(ctx context.Context) error              { return nil }
+func (p *fakeProvider) GetProtocolPrefix() models.ProtocolPrefix            { return models.ProtocolClaude }
+func (p *fakeProvider) IsHealthy() bool                                    { return true }
+
+func TestOrchestrator_RunExecutesToolAndReturnsTerminalResponse(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("get_weather", "look up the weather", nil, 0, func(ctx context.Context, args json.RawMessage) (string, error) {
+		return `{"forecast":"sunny"}`, nil
+	})
+
+	provider := &fakeProvider{
+		responses: []*models.ClaudeResponse{
+			{
+				StopReason: "tool_use",
+				Content: []models.ClaudeContent{
+					{Type: "tool_use", ID: "call_1", Name: "get_weather", Input: map[string]interface{}{"city": "Tokyo"}},
+				},
+			},
+			{
+				StopReason: "end_turn",
+				Content:    []models.ClaudeContent{{Type: "text", Text: "It's sunny in Tokyo."}},
+			},
+		},
+	}
+
+	orchestrator := NewOrchestrator(registry, WithMaxToolSteps(4))
+	req := &models.ClaudeRequest{Model: "claude-3-opus-20240229"}
+
+	result, err := orchestrator.Run(context.Background(), provider, req.Model, models.ProtocolClaude, req)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	final, ok := result.(*models.ClaudeResponse)
+	if !ok || final.StopReason != "end_turn" {
+		t.Fatalf("expected terminal end_turn response, got %#v", result)
+	}
+	if provider.calls != 2 {
+		t.Fatalf("expected provider to be called twice, got %d", provider.calls)
+	}
+
+	// The tool round should have appended the assistant's tool_use message
+	// and a matching tool_result back into the conversation.
+	if len(req.Messages) != 2 {
+		t.Fatalf("expected 2 messages appended to the conversation, got %d", len(req.Messages))
+	}
+	if req.Messages[1].Content[0].ToolUseID != "call_1" {
+		t.Fatalf("expected tool_result for call_1, got %#v", req.Messages[1].Content[0])
+	}
+}
+
+func TestOrchestrator_SkipsUnconfirmedTool(t *testing.T) {
+	registry := NewRegistry()
+	ran := false
+	registry.RegisterFunc("may_delete_file", "delete a file", nil, 0, func(ctx context.Context, args json.RawMessage) (string, error) {
+		ran = true
+		return "deleted", nil
+	})
+
+	provider := &fakeProvider{
+		responses: []*models.ClaudeResponse{
+			{
+				StopReason: "tool_use",
+				Content: []models.ClaudeContent{
+					{Type: "tool_use", ID: "call_1", Name: "may_delete_file", Input: map[string]interface{}{}},
+				},
+			},
+			{StopReason: "end_turn", Content: []models.ClaudeContent{{Type: "text", Text: "ok"}}},
+		},
+	}
+
+	orchestrator := NewOrchestrator(registry)
+	req := &models.ClaudeRequest{Model: "claude-3-opus-20240229"}
+
+	if _, err := orchestrator.Run(context.Background(), provider, req.Model, models.ProtocolClaude, req); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if ran {
+		t.Fatal("expected may_ tool to be skipped without a confirmation hook")
+	}
+}
+
+func TestOrchestrator_ResultCallbackSeesEachToolResult(t *testing.T) {
+	registry := NewRegistry()
+	registry.RegisterFunc("get_weather", "look up the weather", nil, 0, func(ctx context.Context, args json.RawMessage) (string, error) {
+		return `{"forecast":"sunny"}`, nil
+	})
+
+	provider := &fakeProvider{
+		responses: []*models.ClaudeResponse{
+			{
+				StopReason: "tool_use",
+				Content: []models.ClaudeContent{
+					{Type: "tool_use", ID: "call_1", Name: "get_weather", Input: map[string]interface{}{"city": "Tokyo"}},
+				},
+			},
+			{StopReason: "end_turn", Content: []models.ClaudeContent{{Type: "text", Text: "It's sunny in Tokyo."}}},
+		},
+	}
+
+	var seen []ToolResult
+	orchestrator := NewOrchestrator(registry, WithResultCallback(func(call ToolInvocation, result ToolResult) {
+		seen = append(seen, result)
+	}))
+	req := &models.ClaudeRequest{Model: "claude-3-opus-20240229"}
+
+	if _, err := orchestrator.Run(context.Background(), provider, req.Model, models.ProtocolClaude, req); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(seen) != 1 || seen[0].ToolCallID != "call_1" || seen[0].Content != `{"forecast":"sunny"}` {
+		t.Fatalf("expected callback to observe the get_weather result, got %#v", seen)
+	}
+}
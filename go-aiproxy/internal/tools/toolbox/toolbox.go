@@ -0,0 +1,222 @@
+// Package toolbox provides a small set of ready-made tools.Registry
+// registrations — shell execution, file I/O, and HTTP fetch — so callers
+// wiring up an agentic loop don't each have to reimplement the same
+// handful of building blocks.
+package toolbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/internal/tools"
+)
+
+// RegisterShellExec registers "may_shell_exec", which splits a command
+// line into argv and execs it directly — never through /bin/sh -c — after
+// checking argv[0] against allowlist. Executing without a shell means the
+// allowlist actually bounds what runs: there's no "; rm -rf ~" or
+// "$(...)" for a shell to expand, since nothing ever interprets the
+// string as shell syntax. Running even an allowlisted binary is still
+// inherently risky (it can read/write/network freely), so the tool name
+// carries the "may_" confirmation prefix: an Orchestrator without a
+// ConfirmHook will never dispatch it.
+func RegisterShellExec(reg *tools.Registry, allowlist []string, timeout time.Duration) {
+	allowed := make(map[string]bool, len(allowlist))
+	for _, cmd := range allowlist {
+		allowed[cmd] = true
+	}
+
+	reg.RegisterFunc(
+		"may_shell_exec",
+		"Run a shell command and return its combined stdout/stderr output",
+		map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"command": map[string]interface{}{
+					"type":        "string",
+					"description": "the command line to run",
+				},
+			},
+			"required": []string{"command"},
+		},
+		timeout,
+		func(ctx context.Context, args json.RawMessage) (string, error) {
+			var params struct {
+				Command string `json:"command"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return "", fmt.Errorf("parse shell_exec arguments: %w", err)
+			}
+
+			fields := strings.Fields(params.Command)
+			if len(fields) == 0 {
+				return "", fmt.Errorf("empty command")
+			}
+			if !allowed[fields[0]] {
+				return "", fmt.Errorf("command %q is not in the allowlist", fields[0])
+			}
+
+			cmd := exec.CommandContext(ctx, fields[0], fields[1:]...)
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", strings.TrimSpace(string(output)), err)
+			}
+			return string(output), nil
+		},
+	)
+}
+
+// RegisterFileIO registers "read_file" and "may_write_file", both scoped
+// to workDir: any path that resolves outside of it is rejected. Reading
+// is unconfirmed; writing carries the "may_" prefix since it mutates the
+// user's filesystem.
+func RegisterFileIO(reg *tools.Registry, workDir string, timeout time.Duration) {
+	reg.RegisterFunc(
+		"read_file",
+		"Read a UTF-8 text file relative to the working directory",
+		map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "file path relative to the working directory",
+				},
+			},
+			"required": []string{"path"},
+		},
+		timeout,
+		func(ctx context.Context, args json.RawMessage) (string, error) {
+			var params struct {
+				Path string `json:"path"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return "", fmt.Errorf("parse read_file arguments: %w", err)
+			}
+
+			resolved, err := resolveInWorkDir(workDir, params.Path)
+			if err != nil {
+				return "", err
+			}
+
+			data, err := os.ReadFile(resolved)
+			if err != nil {
+				return "", fmt.Errorf("read %s: %w", params.Path, err)
+			}
+			return string(data), nil
+		},
+	)
+
+	reg.RegisterFunc(
+		"may_write_file",
+		"Write a UTF-8 text file relative to the working directory, creating it if needed",
+		map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "file path relative to the working directory",
+				},
+				"content": map[string]interface{}{
+					"type":        "string",
+					"description": "the full contents to write",
+				},
+			},
+			"required": []string{"path", "content"},
+		},
+		timeout,
+		func(ctx context.Context, args json.RawMessage) (string, error) {
+			var params struct {
+				Path    string `json:"path"`
+				Content string `json:"content"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return "", fmt.Errorf("parse write_file arguments: %w", err)
+			}
+
+			resolved, err := resolveInWorkDir(workDir, params.Path)
+			if err != nil {
+				return "", err
+			}
+
+			if err := os.MkdirAll(filepath.Dir(resolved), 0o755); err != nil {
+				return "", fmt.Errorf("create parent directories for %s: %w", params.Path, err)
+			}
+			if err := os.WriteFile(resolved, []byte(params.Content), 0o644); err != nil {
+				return "", fmt.Errorf("write %s: %w", params.Path, err)
+			}
+			return fmt.Sprintf("wrote %d bytes to %s", len(params.Content), params.Path), nil
+		},
+	)
+}
+
+// resolveInWorkDir joins path onto workDir and rejects the result if it
+// escapes workDir, guarding against "../../etc/passwd"-style traversal.
+func resolveInWorkDir(workDir, path string) (string, error) {
+	resolved := filepath.Join(workDir, path)
+	rel, err := filepath.Rel(workDir, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q escapes the working directory", path)
+	}
+	return resolved, nil
+}
+
+// RegisterHTTPFetch registers "http_fetch", a read-only GET of a URL.
+// It isn't confirmation-gated: it can't mutate local state, only leak
+// whatever the model already knows into an outbound request.
+func RegisterHTTPFetch(reg *tools.Registry, client *http.Client, timeout time.Duration) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	reg.RegisterFunc(
+		"http_fetch",
+		"Fetch a URL over HTTP GET and return its response body",
+		map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"url": map[string]interface{}{
+					"type":        "string",
+					"description": "the URL to fetch",
+				},
+			},
+			"required": []string{"url"},
+		},
+		timeout,
+		func(ctx context.Context, args json.RawMessage) (string, error) {
+			var params struct {
+				URL string `json:"url"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return "", fmt.Errorf("parse http_fetch arguments: %w", err)
+			}
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, params.URL, nil)
+			if err != nil {
+				return "", fmt.Errorf("build request for %s: %w", params.URL, err)
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return "", fmt.Errorf("fetch %s: %w", params.URL, err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return "", fmt.Errorf("read response body from %s: %w", params.URL, err)
+			}
+			if resp.StatusCode >= 400 {
+				return "", fmt.Errorf("fetch %s: HTTP %d: %s", params.URL, resp.StatusCode, string(body))
+			}
+			return string(body), nil
+		},
+	)
+}
@@ -0,0 +1,121 @@
+package toolbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/aiproxy/go-aiproxy/internal/tools"
+)
+
+func TestRegisterFileIO_WriteThenReadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	reg := tools.NewRegistry()
+	RegisterFileIO(reg, dir, 0)
+
+	write, _ := reg.Get("may_write_file")
+	_, err := write.Executor.Execute(context.Background(), tools.ToolInvocation{
+		ID: "call_1", Name: "may_write_file",
+		Arguments: json.RawMessage(`{"path":"notes/a.txt","content":"hello"}`),
+	})
+	if err != nil {
+		t.Fatalf("write_file: %v", err)
+	}
+
+	read, _ := reg.Get("read_file")
+	result, err := read.Executor.Execute(context.Background(), tools.ToolInvocation{
+		ID: "call_2", Name: "read_file",
+		Arguments: json.RawMessage(`{"path":"notes/a.txt"}`),
+	})
+	if err != nil || result.Content != "hello" {
+		t.Fatalf("expected to read back \"hello\", got %#v, err %v", result, err)
+	}
+}
+
+func TestRegisterFileIO_RejectsPathEscapingWorkDir(t *testing.T) {
+	dir := t.TempDir()
+	reg := tools.NewRegistry()
+	RegisterFileIO(reg, dir, 0)
+
+	read, _ := reg.Get("read_file")
+	result, err := read.Executor.Execute(context.Background(), tools.ToolInvocation{
+		ID: "call_1", Name: "read_file",
+		Arguments: json.RawMessage(`{"path":"../../etc/passwd"}`),
+	})
+	if err != nil || !result.IsError {
+		t.Fatalf("expected a traversal attempt to be rejected, got %#v, err %v", result, err)
+	}
+}
+
+func TestRegisterShellExec_RejectsCommandNotInAllowlist(t *testing.T) {
+	reg := tools.NewRegistry()
+	RegisterShellExec(reg, []string{"echo"}, 0)
+
+	def, _ := reg.Get("may_shell_exec")
+	result, err := def.Executor.Execute(context.Background(), tools.ToolInvocation{
+		ID: "call_1", Name: "may_shell_exec",
+		Arguments: json.RawMessage(`{"command":"rm -rf /"}`),
+	})
+	if err != nil || !result.IsError {
+		t.Fatalf("expected a disallowed command to be rejected, got %#v, err %v", result, err)
+	}
+}
+
+func TestRegisterShellExec_RunsAllowedCommand(t *testing.T) {
+	reg := tools.NewRegistry()
+	RegisterShellExec(reg, []string{"echo"}, 0)
+
+	def, _ := reg.Get("may_shell_exec")
+	result, err := def.Executor.Execute(context.Background(), tools.ToolInvocation{
+		ID: "call_1", Name: "may_shell_exec",
+		Arguments: json.RawMessage(`{"command":"echo hi"}`),
+	})
+	if err != nil || result.IsError || result.Content != "hi\n" {
+		t.Fatalf("expected \"hi\\n\" output, got %#v, err %v", result, err)
+	}
+}
+
+func TestRegisterShellExec_DoesNotInterpretShellMetacharacters(t *testing.T) {
+	dir := t.TempDir()
+	marker := dir + "/marker"
+
+	reg := tools.NewRegistry()
+	RegisterShellExec(reg, []string{"echo"}, 0)
+
+	def, _ := reg.Get("may_shell_exec")
+	result, err := def.Executor.Execute(context.Background(), tools.ToolInvocation{
+		ID: "call_1", Name: "may_shell_exec",
+		Arguments: json.RawMessage(`{"command":"echo hi; touch ` + marker + `"}`),
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("expected the allowlisted echo to run, got %#v, err %v", result, err)
+	}
+	if result.Content != "hi; touch "+marker+"\n" {
+		t.Fatalf("expected the %q and everything after it to be passed to echo literally, got %q", ";", result.Content)
+	}
+	if _, statErr := os.Stat(marker); statErr == nil {
+		t.Fatalf("command after %q was executed instead of passed as a literal argument", ";")
+	}
+}
+
+func TestRegisterHTTPFetch_ReturnsBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	}))
+	defer server.Close()
+
+	reg := tools.NewRegistry()
+	RegisterHTTPFetch(reg, nil, 0)
+
+	def, _ := reg.Get("http_fetch")
+	result, err := def.Executor.Execute(context.Background(), tools.ToolInvocation{
+		ID: "call_1", Name: "http_fetch",
+		Arguments: json.RawMessage(`{"url":"` + server.URL + `"}`),
+	})
+	if err != nil || result.Content != "pong" {
+		t.Fatalf("expected \"pong\" body, got %#v, err %v", result, err)
+	}
+}
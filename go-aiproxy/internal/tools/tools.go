@@ -0,0 +1,157 @@
+// Package tools implements agentic function-calling: a registry of
+// executable tools plus an orchestrator that drives a Provider through
+// repeated tool_calls/tool_use rounds until the model returns a terminal
+// message.
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// confirmationPrefix is the naming convention for tools that must not run
+// without the caller explicitly approving the specific invocation first,
+// e.g. "may_delete_file".
+const confirmationPrefix = "may_"
+
+// ToolInvocation is a single tool call requested by the model, already
+// normalized from whichever protocol produced it.
+type ToolInvocation struct {
+	ID        string
+	Name      string
+	Arguments json.RawMessage
+}
+
+// ToolResult is what gets fed back to the model after a tool runs.
+type ToolResult struct {
+	ToolCallID string
+	Content    string
+	IsError    bool
+}
+
+// ToolExecutor runs a single tool invocation and returns its result.
+type ToolExecutor interface {
+	Execute(ctx context.Context, call ToolInvocation) (ToolResult, error)
+}
+
+// ToolExecutorFunc adapts a plain function to a ToolExecutor.
+type ToolExecutorFunc func(ctx context.Context, call ToolInvocation) (ToolResult, error)
+
+func (f ToolExecutorFunc) Execute(ctx context.Context, call ToolInvocation) (ToolResult, error) {
+	return f(ctx, call)
+}
+
+// Definition describes one registered tool: its JSON-schema parameters
+// (matching models.ToolFunction.Parameters), the executor that runs it,
+// and a per-call timeout.
+type Definition struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+	Executor    ToolExecutor
+	Timeout     time.Duration
+}
+
+// RequiresConfirmation reports whether this tool's name carries the
+// "may_" convention, meaning the orchestrator must get explicit
+// confirmation before dispatching a call to it.
+func (d Definition) RequiresConfirmation() bool {
+	return strings.HasPrefix(d.Name, confirmationPrefix)
+}
+
+// Registry holds the set of tools available to an Orchestrator.
+type Registry struct {
+	tools map[string]Definition
+}
+
+// NewRegistry creates an empty tool registry.
+func NewRegistry() *Registry {
+	return &Registry{tools: make(map[string]Definition)}
+}
+
+// Register adds a tool definition, replacing any existing tool of the
+// same name.
+func (r *Registry) Register(def Definition) {
+	if def.Timeout <= 0 {
+		def.Timeout = 30 * time.Second
+	}
+	r.tools[def.Name] = def
+}
+
+// RegisterFunc registers a local Go function as a tool.
+func (r *Registry) RegisterFunc(name, description string, parameters map[string]interface{}, timeout time.Duration, fn func(ctx context.Context, args json.RawMessage) (string, error)) {
+	r.Register(Definition{
+		Name:        name,
+		Description: description,
+		Parameters:  parameters,
+		Timeout:     timeout,
+		Executor: ToolExecutorFunc(func(ctx context.Context, call ToolInvocation) (ToolResult, error) {
+			content, err := fn(ctx, call.Arguments)
+			if err != nil {
+				return ToolResult{ToolCallID: call.ID, Content: err.Error(), IsError: true}, nil
+			}
+			return ToolResult{ToolCallID: call.ID, Content: content}, nil
+		}),
+	})
+}
+
+// RegisterHTTP registers a remote tool that is invoked by POSTing the
+// call's arguments as a JSON body to endpoint and feeding the response
+// body back as the tool result.
+func (r *Registry) RegisterHTTP(name, description string, parameters map[string]interface{}, endpoint string, timeout time.Duration, client *http.Client) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	r.Register(Definition{
+		Name:        name,
+		Description: description,
+		Parameters:  parameters,
+		Timeout:     timeout,
+		Executor: ToolExecutorFunc(func(ctx context.Context, call ToolInvocation) (ToolResult, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(string(call.Arguments)))
+			if err != nil {
+				return ToolResult{}, fmt.Errorf("build remote tool request for %s: %w", name, err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := client.Do(req)
+			if err != nil {
+				return ToolResult{ToolCallID: call.ID, Content: err.Error(), IsError: true}, nil
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return ToolResult{}, fmt.Errorf("read remote tool response for %s: %w", name, err)
+			}
+
+			return ToolResult{
+				ToolCallID: call.ID,
+				Content:    string(body),
+				IsError:    resp.StatusCode >= 400,
+			}, nil
+		}),
+	})
+}
+
+// Get returns the registered definition for name, if any.
+func (r *Registry) Get(name string) (Definition, bool) {
+	def, ok := r.tools[name]
+	return def, ok
+}
+
+// Definitions returns all registered tool definitions, for building the
+// protocol-specific tool declarations sent with the request.
+func (r *Registry) Definitions() []Definition {
+	defs := make([]Definition, 0, len(r.tools))
+	for _, def := range r.tools {
+		defs = append(defs, def)
+	}
+	return defs
+}
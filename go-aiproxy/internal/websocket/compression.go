@@ -0,0 +1,99 @@
+package websocket
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// CompressionMode identifies how frame payloads for a client are encoded,
+// negotiated from the subprotocols the client offers in
+// Sec-WebSocket-Protocol.
+type CompressionMode string
+
+const (
+	// CompressionNone sends frames uncompressed.
+	CompressionNone CompressionMode = "aiproxy.raw"
+	// CompressionDeflate relies on RFC 7692 permessage-deflate, negotiated
+	// at the WebSocket layer by the upgrader's EnableCompression; the
+	// frame payload itself is left untouched here, gorilla/websocket
+	// handles the deflate framing transparently.
+	CompressionDeflate CompressionMode = "aiproxy.deflate"
+	// CompressionBrotli applies an application-level brotli encoding to
+	// the frame payload, for browsers that advertise brotli support but
+	// whose WebSocket stack doesn't do permessage-deflate well.
+	CompressionBrotli CompressionMode = "aiproxy.br"
+)
+
+// minCompressedFrameBytes is the batched-payload size above which
+// writePump's flush actually applies CompressionBrotli; smaller batches go
+// out uncompressed since brotli's framing overhead can exceed what it
+// saves on them.
+const minCompressedFrameBytes = 1024
+
+// SupportedSubprotocols lists the subprotocols offered during negotiation,
+// most preferred first.
+var SupportedSubprotocols = []string{
+	string(CompressionBrotli),
+	string(CompressionDeflate),
+	string(CompressionNone),
+}
+
+// splitSubprotocols parses a comma-separated Sec-WebSocket-Protocol header
+// value into its individual entries.
+func splitSubprotocols(header string) []string {
+	if header == "" {
+		return nil
+	}
+
+	parts := strings.Split(header, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// negotiateSubprotocol picks the best compression mode present in the
+// client-offered subprotocol list, preferring brotli > deflate > raw.
+func negotiateSubprotocol(offered []string) CompressionMode {
+	has := func(name string) bool {
+		for _, o := range offered {
+			if strings.TrimSpace(o) == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case has(string(CompressionBrotli)):
+		return CompressionBrotli
+	case has(string(CompressionDeflate)):
+		return CompressionDeflate
+	default:
+		return CompressionNone
+	}
+}
+
+// encodeFrame applies mode's application-level encoding to a batched frame
+// payload. CompressionDeflate is a no-op here since permessage-deflate is
+// applied by the websocket layer itself when EnableCompression is set.
+func encodeFrame(mode CompressionMode, data []byte) ([]byte, error) {
+	if mode != CompressionBrotli {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	w := brotli.NewWriterLevel(&buf, brotli.DefaultCompression)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,79 @@
+package websocket
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// syntheticChunks builds n small token-sized JSON chunks, the shape of a
+// streamed LLM response, to exercise batching and compression the way a
+// real token stream would.
+func syntheticChunks(n int) [][]byte {
+	chunks := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		chunks[i] = []byte(fmt.Sprintf(`{"type":"content","delta":"token-%d"}`, i))
+	}
+	return chunks
+}
+
+// BenchmarkWritePump_Unbatched reports the bytes-on-wire and frame count for
+// sending one frame per chunk with no compression, the pre-chunk0-5 baseline.
+func BenchmarkWritePump_Unbatched(b *testing.B) {
+	chunks := syntheticChunks(10000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		frames := 0
+		totalBytes := 0
+		for _, c := range chunks {
+			frames++
+			totalBytes += len(c)
+		}
+		b.ReportMetric(float64(frames), "frames/op")
+		b.ReportMetric(float64(totalBytes), "bytes/op")
+	}
+}
+
+// BenchmarkWritePump_BatchedBrotli reports the bytes-on-wire and frame count
+// when messages are batched up to DefaultWriteConfig().MaxBatchBytes and
+// compressed with brotli before being written, the chunk0-5 behavior.
+func BenchmarkWritePump_BatchedBrotli(b *testing.B) {
+	chunks := syntheticChunks(10000)
+	cfg := DefaultWriteConfig()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		frames := 0
+		totalBytes := 0
+
+		var batch [][]byte
+		batchBytes := 0
+		flush := func() {
+			if len(batch) == 0 {
+				return
+			}
+			payload := bytes.Join(batch, []byte{'\n'})
+			encoded, err := encodeFrame(CompressionBrotli, payload)
+			if err != nil {
+				b.Fatal(err)
+			}
+			frames++
+			totalBytes += len(encoded)
+			batch = batch[:0]
+			batchBytes = 0
+		}
+
+		for _, c := range chunks {
+			batch = append(batch, c)
+			batchBytes += len(c)
+			if batchBytes >= cfg.MaxBatchBytes {
+				flush()
+			}
+		}
+		flush()
+
+		b.ReportMetric(float64(frames), "frames/op")
+		b.ReportMetric(float64(totalBytes), "bytes/op")
+	}
+}
@@ -0,0 +1,76 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// deadlineTimer implements a rearmable deadline, mirroring the
+// SetReadDeadline/SetWriteDeadline convention of net.Conn but surfacing
+// expiry as a channel instead of making a blocking call return an error
+// itself. set replaces the previous cancel channel and (re)schedules a
+// time.AfterFunc that closes it when the deadline elapses; a zero
+// time.Time disarms the deadline instead, so the returned channel is never
+// closed. A later rearm never closes a channel handed out by an earlier
+// set call, so a goroutine that captured one before a rearm keeps
+// observing exactly the deadline it was given, not a moved one.
+type deadlineTimer struct {
+	mu     sync.Mutex
+	timer  *time.Timer
+	cancel chan struct{}
+}
+
+// newDeadlineTimer returns a deadlineTimer with no deadline armed.
+func newDeadlineTimer() *deadlineTimer {
+	return &deadlineTimer{}
+}
+
+// set arms the deadline for t and returns the channel that closes when it
+// elapses. A zero t disarms any pending deadline; the channel it returns is
+// simply never closed.
+func (d *deadlineTimer) set(t time.Time) chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+
+	d.cancel = make(chan struct{})
+	cancel := d.cancel
+
+	if t.IsZero() {
+		d.timer = nil
+	} else {
+		d.timer = time.AfterFunc(time.Until(t), func() {
+			close(cancel)
+		})
+	}
+
+	return cancel
+}
+
+// C returns the channel for the deadline most recently armed by set.
+func (d *deadlineTimer) C() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cancel
+}
+
+// contextWithCancelChan returns a context derived from parent that is
+// canceled either when parent is done or when cancelCh closes, along with
+// the usual CancelFunc so the caller can release it early. cancelCh may be
+// nil, meaning no deadline is armed; the returned context then behaves
+// exactly like context.WithCancel(parent).
+func contextWithCancelChan(parent context.Context, cancelCh <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	go func() {
+		select {
+		case <-cancelCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
@@ -0,0 +1,123 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestDeadlineTimer_IdleReadTimeout models a connection that never makes
+// progress: once armed, the deadline's channel must close on its own once
+// the duration elapses.
+func TestDeadlineTimer_IdleReadTimeout(t *testing.T) {
+	d := newDeadlineTimer()
+	cancelCh := d.set(time.Now().Add(10 * time.Millisecond))
+
+	select {
+	case <-cancelCh:
+	case <-time.After(time.Second):
+		t.Fatal("deadline did not fire within 1s of a 10ms timeout")
+	}
+}
+
+// TestDeadlineTimer_ZeroDisarmsDeadline verifies the net.Conn convention
+// that a zero time.Time means no deadline at all.
+func TestDeadlineTimer_ZeroDisarmsDeadline(t *testing.T) {
+	d := newDeadlineTimer()
+	cancelCh := d.set(time.Time{})
+
+	select {
+	case <-cancelCh:
+		t.Fatal("zero deadline fired")
+	case <-time.After(30 * time.Millisecond):
+	}
+}
+
+// TestDeadlineTimer_MidStreamWriteTimeout models a write deadline armed
+// before each chunk, as handleStreamingRequest does: a deadline that is
+// replaced before it elapses must never fire, while the most recently
+// armed one does.
+func TestDeadlineTimer_MidStreamWriteTimeout(t *testing.T) {
+	d := newDeadlineTimer()
+
+	staleCh := d.set(time.Now().Add(time.Hour)) // would never fire within this test anyway
+	activeCh := d.set(time.Now().Add(10 * time.Millisecond))
+
+	select {
+	case <-staleCh:
+		t.Fatal("superseded deadline fired")
+	default:
+	}
+
+	select {
+	case <-activeCh:
+	case <-time.After(time.Second):
+		t.Fatal("rearmed write deadline did not fire")
+	}
+
+	select {
+	case <-staleCh:
+		t.Fatal("superseded deadline fired after rearm elapsed")
+	default:
+	}
+}
+
+// TestDeadlineTimer_RearmAfterPartialResponse models the streaming loop's
+// behavior of rearming the deadline after every chunk: as long as a rearm
+// happens before the previous window elapses, the deadline never fires.
+func TestDeadlineTimer_RearmAfterPartialResponse(t *testing.T) {
+	d := newDeadlineTimer()
+	window := 30 * time.Millisecond
+
+	cancelCh := d.set(time.Now().Add(window))
+	for i := 0; i < 3; i++ {
+		time.Sleep(window / 2)
+		select {
+		case <-cancelCh:
+			t.Fatalf("deadline fired despite rearm on iteration %d", i)
+		default:
+		}
+		cancelCh = d.set(time.Now().Add(window))
+	}
+
+	// Without a further rearm, the last-armed deadline still elapses.
+	select {
+	case <-cancelCh:
+	case <-time.After(time.Second):
+		t.Fatal("deadline never fired once rearming stopped")
+	}
+}
+
+// TestContextWithCancelChan_CancelsOnChannelClose verifies the context
+// bridge handleNonStreamingRequest/handleStreamingRequest use to propagate
+// a deadline into a provider call's ctx.
+func TestContextWithCancelChan_CancelsOnChannelClose(t *testing.T) {
+	cancelCh := make(chan struct{})
+	ctx, cancel := contextWithCancelChan(context.Background(), cancelCh)
+	defer cancel()
+
+	close(cancelCh)
+
+	select {
+	case <-ctx.Done():
+		if ctx.Err() != context.Canceled {
+			t.Fatalf("expected context.Canceled, got %v", ctx.Err())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("context was not canceled after cancelCh closed")
+	}
+}
+
+// TestContextWithCancelChan_NilChannelNeverCancels verifies that a nil
+// cancelCh (no deadline armed) leaves the context behaving like a plain
+// context.WithCancel.
+func TestContextWithCancelChan_NilChannelNeverCancels(t *testing.T) {
+	ctx, cancel := contextWithCancelChan(context.Background(), nil)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("context canceled with no deadline armed")
+	case <-time.After(30 * time.Millisecond):
+	}
+}
@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -19,16 +20,63 @@ import (
 
 // WebSocketHandler handles WebSocket connections
 type WebSocketHandler struct {
-	hub        *Hub
-	upgrader   websocket.Upgrader
-	providers  map[string]providers.Provider
-	converter  convert.Converter
-	authKey    string
+	hub         *Hub
+	upgrader    websocket.Upgrader
+	providers   map[string]providers.Provider
+	converter   convert.Converter
+	authKey     string
+	writeConfig WriteConfig
+	ipExtractor *IPExtractor
+}
+
+// WriteConfig controls how writePump batches and compresses outgoing
+// frames. Streamed LLM tokens otherwise arrive as a flood of tiny frames,
+// one per token; batching amortizes per-frame overhead and compression
+// shrinks the repetitive JSON envelope around each chunk.
+type WriteConfig struct {
+	// MaxBatchBytes flushes the pending batch once it reaches this size.
+	MaxBatchBytes int
+	// MaxBatchDelay flushes the pending batch after this much time has
+	// passed since the first message in it arrived, even if MaxBatchBytes
+	// hasn't been reached, so latency stays bounded.
+	MaxBatchDelay time.Duration
+	// EnableCompression turns on RFC 7692 permessage-deflate negotiation
+	// on the upgrader (gorilla/websocket's EnableCompression).
+	EnableCompression bool
+}
+
+// DefaultWriteConfig returns the batching/compression defaults: a 16KB or
+// 20ms batching window, with permessage-deflate enabled.
+func DefaultWriteConfig() WriteConfig {
+	return WriteConfig{
+		MaxBatchBytes:     16 * 1024,
+		MaxBatchDelay:     20 * time.Millisecond,
+		EnableCompression: true,
+	}
+}
+
+// HandlerOption configures a WebSocketHandler at construction time.
+type HandlerOption func(*WebSocketHandler)
+
+// WithWriteConfig overrides the default batching/compression behavior.
+func WithWriteConfig(cfg WriteConfig) HandlerOption {
+	return func(h *WebSocketHandler) {
+		h.writeConfig = cfg
+	}
+}
+
+// WithIPExtractor overrides the default IPExtractor (no trusted proxies:
+// always uses RemoteAddr, and rejects any forwarded-address header) with
+// one configured for the deployment's reverse proxy setup.
+func WithIPExtractor(e *IPExtractor) HandlerOption {
+	return func(h *WebSocketHandler) {
+		h.ipExtractor = e
+	}
 }
 
 // NewWebSocketHandler creates a new WebSocket handler
-func NewWebSocketHandler(hub *Hub, providers map[string]providers.Provider, authKey string) *WebSocketHandler {
-	return &WebSocketHandler{
+func NewWebSocketHandler(hub *Hub, providers map[string]providers.Provider, authKey string, opts ...HandlerOption) *WebSocketHandler {
+	h := &WebSocketHandler{
 		hub: hub,
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
@@ -39,27 +87,86 @@ func NewWebSocketHandler(hub *Hub, providers map[string]providers.Provider, auth
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
 		},
-		providers: providers,
-		converter: convert.NewConverter(),
-		authKey:   authKey,
+		providers:   providers,
+		converter:   convert.NewConverter(),
+		authKey:     authKey,
+		writeConfig: DefaultWriteConfig(),
+		ipExtractor: NewIPExtractor(),
+	}
+
+	for _, opt := range opts {
+		opt(h)
 	}
+
+	h.upgrader.EnableCompression = h.writeConfig.EnableCompression
+
+	return h
+}
+
+// SetReadDeadline arms client's read deadline at t — the bound on how long
+// waiting for the provider's next piece of progress (a response, or the
+// next stream chunk) may take — and returns the channel that closes when it
+// elapses. A zero t disarms the deadline.
+func (h *WebSocketHandler) SetReadDeadline(client *Client, t time.Time) <-chan struct{} {
+	return client.readDeadline.set(t)
+}
+
+// SetWriteDeadline arms client's write deadline at t — the bound on how
+// long delivering the provider's next piece of progress back to this
+// client may take — and returns the channel that closes when it elapses. A
+// zero t disarms the deadline.
+func (h *WebSocketHandler) SetWriteDeadline(client *Client, t time.Time) <-chan struct{} {
+	return client.writeDeadline.set(t)
 }
 
 // HandleWebSocket handles WebSocket upgrade and connection
 func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
-	// Check authentication
+	protocolHeader := c.GetHeader("Sec-WebSocket-Protocol")
+	offered := splitSubprotocols(protocolHeader)
+
+	// Check authentication: accept the token as a query param, as the
+	// entire Sec-WebSocket-Protocol header (legacy clients), or as one
+	// entry in a comma-separated subprotocol list alongside the
+	// compression modes negotiated below.
 	token := c.Query("token")
 	if token == "" {
-		token = c.GetHeader("Sec-WebSocket-Protocol")
+		if protocolHeader == h.authKey {
+			token = protocolHeader
+		} else {
+			for _, p := range offered {
+				if p == h.authKey {
+					token = p
+					break
+				}
+			}
+		}
 	}
-	
+
 	if token != h.authKey {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication token"})
 		return
 	}
 
+	// Resolve the real client IP before upgrading: a header claiming an
+	// address from a peer this handler doesn't trust as a reverse proxy
+	// is rejected outright as a likely spoofing attempt, which can only
+	// be done with a normal HTTP response, not after the connection has
+	// already switched protocols.
+	clientIP, err := h.ipExtractor.Resolve(c.Request)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	mode := negotiateSubprotocol(offered)
+
+	var responseHeader http.Header
+	if mode != CompressionNone {
+		responseHeader = http.Header{"Sec-WebSocket-Protocol": []string{string(mode)}}
+	}
+
 	// Upgrade HTTP connection to WebSocket
-	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, responseHeader)
 	if err != nil {
 		log.Printf("Failed to upgrade connection: %v", err)
 		return
@@ -67,10 +174,15 @@ func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
 
 	// Create new client
 	client := &Client{
-		ID:   uuid.New().String(),
-		hub:  h.hub,
-		conn: conn,
-		send: make(chan []byte, 256),
+		ID:            uuid.New().String(),
+		hub:           h.hub,
+		conn:          conn,
+		send:          make(chan []byte, 256),
+		ip:            clientIP,
+		compression:   mode,
+		writeConfig:   h.writeConfig,
+		readDeadline:  newDeadlineTimer(),
+		writeDeadline: newDeadlineTimer(),
 	}
 
 	// Register client
@@ -114,6 +226,16 @@ func (h *WebSocketHandler) readPump(client *Client) {
 		switch msg.Type {
 		case MessageTypeRequest:
 			h.handleRequest(client, &msg)
+		case MessageTypeSubscribe:
+			h.handleSubscribe(client, &msg)
+		case MessageTypeUnsubscribe:
+			h.handleUnsubscribe(client, &msg)
+		case MessageTypePublish:
+			h.handlePublish(client, &msg)
+		case MessageTypeStreamAck:
+			h.handleStreamAck(client, &msg)
+		case MessageTypeStreamCancel:
+			h.handleStreamCancel(client, &msg)
 		case MessageTypeHeartbeat:
 			// Echo heartbeat back
 			client.SendMessage(&Message{
@@ -140,40 +262,96 @@ func (h *WebSocketHandler) readPump(client *Client) {
 
 // writePump pumps messages from the hub to the WebSocket connection
 func (c *Client) writePump() {
-	ticker := time.NewTicker(54 * time.Second)
+	cfg := c.writeConfig
+	if cfg.MaxBatchDelay <= 0 {
+		cfg.MaxBatchDelay = 20 * time.Millisecond
+	}
+	if cfg.MaxBatchBytes <= 0 {
+		cfg.MaxBatchBytes = 16 * 1024
+	}
+
+	pingTicker := time.NewTicker(54 * time.Second)
+	batchTimer := time.NewTimer(cfg.MaxBatchDelay)
+	batchTimer.Stop()
+
 	defer func() {
-		ticker.Stop()
+		pingTicker.Stop()
+		batchTimer.Stop()
 		c.conn.Close()
 	}()
 
+	var batch [][]byte
+	batchBytes := 0
+
+	// flush joins the pending batch with '\n' separators (as the previous
+	// single-frame concatenation did), applies the negotiated
+	// application-level compression, and writes it as one frame. Batching
+	// this way amortizes per-frame overhead for streamed token-by-token
+	// chunks instead of sending one frame per tiny message.
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+
+		payload := bytes.Join(batch, []byte{'\n'})
+		batch = batch[:0]
+		batchBytes = 0
+		batchTimer.Stop()
+
+		// Below minCompressedFrameBytes, brotli's own framing overhead can
+		// exceed what it saves, so small batches go out as a plain text
+		// frame regardless of the negotiated mode; the frame type itself
+		// (binary vs text) tells the client which one happened.
+		mode := c.compression
+		if mode == CompressionBrotli && len(payload) < minCompressedFrameBytes {
+			mode = CompressionNone
+		}
+
+		encoded, err := encodeFrame(mode, payload)
+		if err != nil {
+			return false
+		}
+
+		frameType := websocket.TextMessage
+		if mode == CompressionBrotli {
+			frameType = websocket.BinaryMessage
+		}
+
+		c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return c.conn.WriteMessage(frameType, encoded) == nil
+	}
+
 	for {
 		select {
 		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if !ok {
-				// The hub closed the channel
+				flush()
+				c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
 			}
 
-			w, err := c.conn.NextWriter(websocket.TextMessage)
-			if err != nil {
-				return
+			if len(batch) == 0 {
+				batchTimer.Reset(cfg.MaxBatchDelay)
 			}
-			w.Write(message)
+			batch = append(batch, message)
+			batchBytes += len(message)
 
-			// Add queued messages to the current WebSocket frame
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				w.Write([]byte{'\n'})
-				w.Write(<-c.send)
+			if batchBytes >= cfg.MaxBatchBytes {
+				if !flush() {
+					return
+				}
 			}
 
-			if err := w.Close(); err != nil {
+		case <-batchTimer.C:
+			if !flush() {
 				return
 			}
 
-		case <-ticker.C:
+		case <-pingTicker.C:
+			if !flush() {
+				return
+			}
 			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
@@ -182,6 +360,97 @@ func (c *Client) writePump() {
 	}
 }
 
+// handleSubscribe subscribes the client to msg.Topic, optionally replaying
+// WAL entries published since the client's last known sequence number
+// (passed as {"since_seq": N} in Metadata) before live delivery resumes.
+func (h *WebSocketHandler) handleSubscribe(client *Client, msg *Message) {
+	if msg.Topic == "" {
+		h.sendError(client, "subscribe requires a topic", nil)
+		return
+	}
+
+	var sinceSeq uint64
+	if v, ok := msg.Metadata["since_seq"]; ok {
+		if n, ok := v.(float64); ok && n >= 0 {
+			sinceSeq = uint64(n)
+		}
+	}
+
+	replay, err := h.hub.Subscribe(msg.Topic, client, sinceSeq)
+	if err != nil {
+		h.sendError(client, "Subscribe failed", err)
+		return
+	}
+
+	for _, replayMsg := range replay {
+		client.SendMessage(replayMsg)
+	}
+
+	client.SendMessage(&Message{
+		Type:      MessageTypeAuthenticated,
+		ID:        msg.ID,
+		Topic:     msg.Topic,
+		Timestamp: time.Now().Unix(),
+		Metadata: map[string]interface{}{
+			"subscribed": true,
+			"replayed":   len(replay),
+		},
+	})
+}
+
+// handleUnsubscribe removes the client from msg.Topic.
+func (h *WebSocketHandler) handleUnsubscribe(client *Client, msg *Message) {
+	if msg.Topic == "" {
+		h.sendError(client, "unsubscribe requires a topic", nil)
+		return
+	}
+
+	h.hub.Unsubscribe(msg.Topic, client.ID)
+}
+
+// handlePublish appends msg to its topic's WAL and fans it out to every
+// current subscriber.
+func (h *WebSocketHandler) handlePublish(client *Client, msg *Message) {
+	if msg.Topic == "" {
+		h.sendError(client, "publish requires a topic", nil)
+		return
+	}
+
+	published := &Message{
+		Type:      MessageTypePublish,
+		ID:        msg.ID,
+		Response:  msg.Response,
+		Metadata:  msg.Metadata,
+		Timestamp: time.Now().Unix(),
+	}
+
+	if _, err := h.hub.Publish(msg.Topic, client, published); err != nil {
+		h.sendError(client, "Publish failed", err)
+	}
+}
+
+// handleStreamAck advances the stream msg.StreamID's acknowledged
+// watermark to msg.Seq, unblocking that stream's StreamWriter.Write if it
+// was paused on backpressure.
+func (h *WebSocketHandler) handleStreamAck(client *Client, msg *Message) {
+	if msg.StreamID == "" {
+		h.sendError(client, "stream_ack requires stream_id", nil)
+		return
+	}
+	h.hub.AckStream(client.ID, msg.StreamID, msg.Seq)
+}
+
+// handleStreamCancel aborts the stream msg.StreamID, canceling the
+// Context its StreamWriter exposes so the upstream GenerateContentStream
+// call reading it can stop.
+func (h *WebSocketHandler) handleStreamCancel(client *Client, msg *Message) {
+	if msg.StreamID == "" {
+		h.sendError(client, "stream_cancel requires stream_id", nil)
+		return
+	}
+	h.hub.CancelStream(client.ID, msg.StreamID)
+}
+
 // handleRequest processes incoming AI requests
 func (h *WebSocketHandler) handleRequest(client *Client, msg *Message) {
 	// Extract provider and model
@@ -231,21 +500,43 @@ func (h *WebSocketHandler) handleRequest(client *Client, msg *Message) {
 		}
 	}
 
+	// A per-message max_duration (seconds) bounds how long this request's
+	// provider call may run; zero means unbounded, matching today's
+	// behavior.
+	var maxDuration time.Duration
+	if v, ok := msg.Metadata["max_duration"]; ok {
+		if seconds, ok := v.(float64); ok && seconds > 0 {
+			maxDuration = time.Duration(seconds * float64(time.Second))
+		}
+	}
+
 	// Handle streaming vs non-streaming
 	if isStream {
-		h.handleStreamingRequest(client, msg, providerInstance, request, fromProtocol, toProtocol)
+		h.handleStreamingRequest(client, msg, providerInstance, request, fromProtocol, toProtocol, maxDuration)
 	} else {
-		h.handleNonStreamingRequest(client, msg, providerInstance, request, fromProtocol, toProtocol)
+		h.handleNonStreamingRequest(client, msg, providerInstance, request, fromProtocol, toProtocol, maxDuration)
 	}
 }
 
-// handleNonStreamingRequest processes non-streaming requests
-func (h *WebSocketHandler) handleNonStreamingRequest(client *Client, msg *Message, provider providers.Provider, request interface{}, fromProtocol, toProtocol models.ProtocolPrefix) {
-	ctx := context.Background()
-	
+// handleNonStreamingRequest processes non-streaming requests. maxDuration,
+// if non-zero, bounds the provider call: a deadline armed from it cancels
+// the request's context and, on expiry, a timeout frame is sent instead of
+// a generic error.
+func (h *WebSocketHandler) handleNonStreamingRequest(client *Client, msg *Message, provider providers.Provider, request interface{}, fromProtocol, toProtocol models.ProtocolPrefix, maxDuration time.Duration) {
+	var cancelCh <-chan struct{}
+	if maxDuration > 0 {
+		cancelCh = h.SetReadDeadline(client, time.Now().Add(maxDuration))
+	}
+	ctx, cancel := contextWithCancelChan(context.Background(), cancelCh)
+	defer cancel()
+
 	// Make request
 	response, err := provider.GenerateContent(ctx, client.model, request)
 	if err != nil {
+		if cancelCh != nil && ctx.Err() == context.Canceled {
+			h.sendTimeout(client, msg.ID)
+			return
+		}
 		h.sendError(client, "Provider request failed", err)
 		return
 	}
@@ -273,67 +564,155 @@ func (h *WebSocketHandler) handleNonStreamingRequest(client *Client, msg *Messag
 	client.SendMessage(responseMsg)
 }
 
-// handleStreamingRequest processes streaming requests
-func (h *WebSocketHandler) handleStreamingRequest(client *Client, msg *Message, provider providers.Provider, request interface{}, fromProtocol, toProtocol models.ProtocolPrefix) {
-	ctx := context.Background()
-	
+// handleStreamingRequest processes streaming requests. maxDuration, if
+// non-zero, bounds both how long the provider may take to produce the next
+// chunk and how long delivering it back to client may take; either
+// elapsing cancels the stream's context (aborting the upstream provider
+// call) and sends a timeout frame instead of stream_end. The deadline is
+// rearmed after every chunk, so a long-but-healthy stream isn't bounded by
+// a single window - only a stall within one window trips it.
+func (h *WebSocketHandler) handleStreamingRequest(client *Client, msg *Message, provider providers.Provider, request interface{}, fromProtocol, toProtocol models.ProtocolPrefix, maxDuration time.Duration) {
+	// sw delivers MessageTypeStream/MessageTypeStreamEnd frames with
+	// client-driven backpressure (stream_ack) and exposes a Context that a
+	// client-sent stream_cancel aborts, merged below with the existing
+	// max_duration deadline so either one can stop the upstream call.
+	sw, err := h.hub.OpenStream(client.ID, msg.ID)
+	if err != nil {
+		h.sendError(client, "Failed to open stream", err)
+		return
+	}
+	sw.Provider = client.provider
+	sw.Model = client.model
+
+	var cancelCh <-chan struct{}
+	if maxDuration > 0 {
+		cancelCh = h.SetReadDeadline(client, time.Now().Add(maxDuration))
+	}
+	ctx, cancel := contextWithCancelChan(sw.Context(), cancelCh)
+
 	// Get stream
 	stream, err := provider.GenerateContentStream(ctx, client.model, request)
 	if err != nil {
+		cancel()
+		h.hub.unregisterStream(client.ID, msg.ID)
+		if cancelCh != nil && ctx.Err() == context.Canceled {
+			h.sendTimeout(client, msg.ID)
+			return
+		}
 		h.sendError(client, "Failed to start stream", err)
 		return
 	}
-	defer stream.Close()
+
+	// streamConv reconstructs multi-step tool calls (Claude's tool_use
+	// blocks stream as a series of partial_json fragments) into whatever
+	// shape toProtocol expects, buffering fragments across chunks as
+	// needed for protocols like Gemini that have no incremental form.
+	streamConv := convert.NewStreamConverter(client.model)
 
 	// Read and forward stream chunks
 	go func() {
+		defer cancel()
+		defer stream.Close()
+		defer h.hub.unregisterStream(client.ID, msg.ID)
+
+		type readResult struct {
+			n   int
+			err error
+		}
 		buffer := make([]byte, 4096)
+
 		for {
-			n, err := stream.Read(buffer)
-			if err != nil {
-				if err != io.EOF {
-					h.sendError(client, "Stream read error", err)
-				}
-				// Send stream end message
-				endMsg := &Message{
-					Type:      MessageTypeStreamEnd,
-					ID:        msg.ID,
-					ClientID:  client.ID,
-					Provider:  client.provider,
-					Model:     client.model,
-					Timestamp: time.Now().Unix(),
+			// stream.Read takes no context, so a stalled upstream read can
+			// only be interrupted by closing the stream (the deferred
+			// stream.Close above); run it in its own goroutine so this loop
+			// can still race it against the read deadline.
+			readCh := make(chan readResult, 1)
+			go func() {
+				n, err := stream.Read(buffer)
+				readCh <- readResult{n, err}
+			}()
+
+			var readDeadlineCh <-chan struct{}
+			if maxDuration > 0 {
+				readDeadlineCh = h.SetReadDeadline(client, time.Now().Add(maxDuration))
+			}
+
+			var res readResult
+			select {
+			case res = <-readCh:
+			case <-readDeadlineCh:
+				h.sendTimeout(client, msg.ID)
+				return
+			}
+
+			if res.err != nil {
+				if res.err != io.EOF {
+					h.sendError(client, "Stream read error", res.err)
 				}
-				client.SendMessage(endMsg)
-				break
+				sw.End()
+				return
 			}
 
-			if n > 0 {
-				chunk := string(buffer[:n])
-				
-				// Convert chunk if needed
-				if fromProtocol != toProtocol {
+			if res.n > 0 {
+				chunk := string(buffer[:res.n])
+
+				if se, ok := models.DecodeStreamEvent(chunk); ok {
+					convertedChunk, err := streamConv.Convert(se, toProtocol)
+					if err != nil || convertedChunk == nil {
+						continue
+					}
+					chunk = fmt.Sprintf("%v", convertedChunk)
+				} else if fromProtocol != toProtocol {
+					// Convert chunk if needed
 					convertedChunk, err := h.converter.ConvertStreamChunk(chunk, toProtocol, fromProtocol, client.model)
 					if err == nil && convertedChunk != nil {
 						chunk = fmt.Sprintf("%v", convertedChunk)
 					}
 				}
 
-				// Send stream chunk
-				streamMsg := &Message{
-					Type:      MessageTypeStream,
-					ID:        msg.ID,
-					ClientID:  client.ID,
-					Provider:  client.provider,
-					Model:     client.model,
-					Response:  chunk,
-					Timestamp: time.Now().Unix(),
+				// Send stream chunk through sw, which blocks here under
+				// backpressure once the client has a full window of
+				// frames (sw.WindowSize) unacknowledged.
+				if maxDuration > 0 {
+					writeDeadlineCh := h.SetWriteDeadline(client, time.Now().Add(maxDuration))
+					writeErrCh := make(chan error, 1)
+					go func() { _, err := sw.Write([]byte(chunk)); writeErrCh <- err }()
+
+					select {
+					case err := <-writeErrCh:
+						if err != nil {
+							return
+						}
+					case <-writeDeadlineCh:
+						h.sendTimeout(client, msg.ID)
+						return
+					}
+				} else if _, err := sw.Write([]byte(chunk)); err != nil {
+					return
 				}
-				client.SendMessage(streamMsg)
 			}
 		}
 	}()
 }
 
+// ListTopics returns the name of every pub/sub topic known to the handler's
+// hub.
+func (h *WebSocketHandler) ListTopics() []string {
+	return h.hub.ListTopics()
+}
+
+// TopicStats returns the next sequence number to be assigned, subscriber
+// count, and retained bytes for a topic.
+func (h *WebSocketHandler) TopicStats(topic string) (TopicStats, bool) {
+	return h.hub.TopicStats(topic)
+}
+
+// SetTopicAuthHook installs a per-topic authorization hook consulted before
+// a client may subscribe to or publish on a topic.
+func (h *WebSocketHandler) SetTopicAuthHook(hook func(topic string, client *Client) bool) {
+	h.hub.SetTopicAuthHook(hook)
+}
+
 // sendError sends an error message to the client
 func (h *WebSocketHandler) sendError(client *Client, message string, err error) {
 	errMsg := message
@@ -350,4 +729,20 @@ func (h *WebSocketHandler) sendError(client *Client, message string, err error)
 	}
 
 	client.SendMessage(errorMessage)
+}
+
+// sendTimeout sends a structured error frame reporting that msgID's request
+// exceeded its max_duration, distinguishing a deadline-driven cancellation
+// from an ordinary provider error via Metadata["reason"].
+func (h *WebSocketHandler) sendTimeout(client *Client, msgID string) {
+	client.SendMessage(&Message{
+		Type:      MessageTypeError,
+		ID:        msgID,
+		ClientID:  client.ID,
+		Error:     "request exceeded max_duration",
+		Timestamp: time.Now().Unix(),
+		Metadata: map[string]interface{}{
+			"reason": "timeout",
+		},
+	})
 }
\ No newline at end of file
@@ -3,6 +3,7 @@ package websocket
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"sync"
 	"time"
 
@@ -29,6 +30,37 @@ type Hub struct {
 
 	// Metrics callback
 	onMetricsUpdate func(activeConnections int)
+
+	// Topics holds pub/sub channels keyed by topic name, each backed by a
+	// WAL so a reconnecting subscriber can replay what it missed.
+	topics map[string]*Topic
+
+	// walDir is where topic WAL segment files are written.
+	walDir string
+
+	// walMaxAge/walMaxBytes bound how much of each topic's WAL is kept
+	// available for replay; either may be zero to disable that bound.
+	walMaxAge   time.Duration
+	walMaxBytes int64
+
+	// topicAuthHook, if set, is consulted before a client may subscribe to
+	// or publish on a topic.
+	topicAuthHook func(topic string, client *Client) bool
+
+	// streams holds every currently open StreamWriter, keyed by
+	// streamKey(clientID, streamID), so AckStream/CancelStream can route a
+	// client's stream_ack/stream_cancel messages to the right one.
+	streamsMu sync.RWMutex
+	streams   map[string]*StreamWriter
+}
+
+// Topic is a pub/sub channel: every message Published to it is appended to
+// its WAL and fanned out to every currently subscribed Client.
+type Topic struct {
+	mu          sync.RWMutex
+	Name        string
+	subscribers map[string]*Client
+	wal         *WAL
 }
 
 // Client represents a WebSocket client connection
@@ -39,6 +71,26 @@ type Client struct {
 	send     chan []byte
 	provider string
 	model    string
+
+	// ip is the real client IP resolved by IPExtractor at upgrade time,
+	// surfaced in the welcome message's Metadata["client_ip"]; nothing in
+	// this package rate-limits or tracks clients by it.
+	ip string
+
+	// compression is the subprotocol negotiated at upgrade time.
+	compression CompressionMode
+	// writeConfig controls writePump's batching behavior for this client.
+	writeConfig WriteConfig
+
+	// readDeadline/writeDeadline bound how long a single in-flight
+	// request's provider call may take to produce its next piece of
+	// progress (a response, or the next stream chunk) and how long
+	// delivering it back to this client may take. They are distinct from
+	// the connection-level idle timeouts readPump/writePump manage
+	// themselves; WebSocketHandler.SetReadDeadline/SetWriteDeadline arm
+	// these from a request's max_duration metadata.
+	readDeadline  *deadlineTimer
+	writeDeadline *deadlineTimer
 }
 
 // Message represents a WebSocket message
@@ -53,6 +105,21 @@ type Message struct {
 	Error     string                 `json:"error,omitempty"`
 	Timestamp int64                  `json:"timestamp"`
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+
+	// Topic-based pub/sub fields. Topic identifies the channel (e.g.
+	// "provider:openai:stream:<sessionID>"); Seq is the WAL sequence number
+	// assigned when the message was published, used by subscribers to
+	// resume replay via since_seq. A StreamWriter's frames reuse Seq for
+	// its own per-stream frame counter instead (streams and topics are
+	// never the same message).
+	Topic string `json:"topic,omitempty"`
+	Seq   uint64 `json:"seq,omitempty"`
+
+	// StreamID identifies a StreamWriter-driven stream, set on every
+	// MessageTypeStream/MessageTypeStreamEnd frame a StreamWriter sends and
+	// on the MessageTypeStreamAck/MessageTypeStreamCancel messages a client
+	// sends back to control it.
+	StreamID string `json:"stream_id,omitempty"`
 }
 
 // MessageType constants
@@ -65,16 +132,220 @@ const (
 	MessageTypeHeartbeat     = "heartbeat"
 	MessageTypeAuthenticate  = "authenticate"
 	MessageTypeAuthenticated = "authenticated"
+	MessageTypeSubscribe     = "subscribe"
+	MessageTypeUnsubscribe   = "unsubscribe"
+	MessageTypePublish       = "publish"
+	MessageTypeStreamAck     = "stream_ack"
+	MessageTypeStreamCancel  = "stream_cancel"
 )
 
 // NewHub creates a new WebSocket hub
 func NewHub() *Hub {
 	return &Hub{
-		clients:    make(map[string]*Client),
-		broadcast:  make(chan *Message, 256),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+		clients:     make(map[string]*Client),
+		broadcast:   make(chan *Message, 256),
+		register:    make(chan *Client),
+		unregister:  make(chan *Client),
+		topics:      make(map[string]*Topic),
+		walDir:      "./data/ws-wal",
+		walMaxAge:   24 * time.Hour,
+		walMaxBytes: 64 * 1024 * 1024,
+		streams:     make(map[string]*StreamWriter),
+	}
+}
+
+// SetWALDir configures where topic WAL segment files are written.
+func (h *Hub) SetWALDir(dir string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.walDir = dir
+}
+
+// SetWALRetention configures how much of each topic's WAL is kept available
+// for replay. maxAge or maxBytes may be zero to disable that bound.
+func (h *Hub) SetWALRetention(maxAge time.Duration, maxBytes int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.walMaxAge = maxAge
+	h.walMaxBytes = maxBytes
+}
+
+// SetTopicAuthHook installs a hook consulted before a client may subscribe
+// to or publish on a topic. A nil hook (the default) allows everything.
+func (h *Hub) SetTopicAuthHook(hook func(topic string, client *Client) bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.topicAuthHook = hook
+}
+
+// authorizeTopic reports whether client may access topic.
+func (h *Hub) authorizeTopic(topic string, client *Client) bool {
+	h.mu.RLock()
+	hook := h.topicAuthHook
+	h.mu.RUnlock()
+
+	if hook == nil {
+		return true
+	}
+	return hook(topic, client)
+}
+
+// getOrCreateTopic returns the Topic for name, creating its WAL on first
+// use.
+func (h *Hub) getOrCreateTopic(name string) (*Topic, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if t, ok := h.topics[name]; ok {
+		return t, nil
+	}
+
+	wal, err := NewWAL(h.walDir, name, h.walMaxAge, h.walMaxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Topic{
+		Name:        name,
+		subscribers: make(map[string]*Client),
+		wal:         wal,
+	}
+	h.topics[name] = t
+	return t, nil
+}
+
+// Subscribe adds client as a subscriber of topic and returns every message
+// published since sinceSeq so the client can replay what it missed before
+// switching to live delivery.
+func (h *Hub) Subscribe(topic string, client *Client, sinceSeq uint64) ([]*Message, error) {
+	if !h.authorizeTopic(topic, client) {
+		return nil, fmt.Errorf("not authorized for topic %s", topic)
+	}
+
+	t, err := h.getOrCreateTopic(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.subscribers[client.ID] = client
+	t.mu.Unlock()
+
+	var replay []*Message
+	for _, raw := range t.wal.ReadSince(sinceSeq) {
+		var msg Message
+		if err := json.Unmarshal(raw, &msg); err == nil {
+			replay = append(replay, &msg)
+		}
+	}
+	return replay, nil
+}
+
+// Unsubscribe removes client from topic's subscriber list.
+func (h *Hub) Unsubscribe(topic string, clientID string) {
+	h.mu.RLock()
+	t, ok := h.topics[topic]
+	h.mu.RUnlock()
+	if !ok {
+		return
 	}
+
+	t.mu.Lock()
+	delete(t.subscribers, clientID)
+	t.mu.Unlock()
+}
+
+// UnsubscribeAll removes a client from every topic it is subscribed to,
+// called when the client disconnects.
+func (h *Hub) UnsubscribeAll(clientID string) {
+	h.mu.RLock()
+	topics := make([]*Topic, 0, len(h.topics))
+	for _, t := range h.topics {
+		topics = append(topics, t)
+	}
+	h.mu.RUnlock()
+
+	for _, t := range topics {
+		t.mu.Lock()
+		delete(t.subscribers, clientID)
+		t.mu.Unlock()
+	}
+}
+
+// Publish appends msg to topic's WAL and fans it out to every current
+// subscriber, returning the sequence number it was assigned.
+func (h *Hub) Publish(topic string, publisher *Client, msg *Message) (uint64, error) {
+	if !h.authorizeTopic(topic, publisher) {
+		return 0, fmt.Errorf("not authorized for topic %s", topic)
+	}
+
+	t, err := h.getOrCreateTopic(topic)
+	if err != nil {
+		return 0, err
+	}
+
+	msg.Topic = topic
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	seq, err := t.wal.Append(data)
+	if err != nil {
+		return 0, err
+	}
+	msg.Seq = seq
+
+	t.mu.RLock()
+	subscribers := make([]*Client, 0, len(t.subscribers))
+	for _, c := range t.subscribers {
+		subscribers = append(subscribers, c)
+	}
+	t.mu.RUnlock()
+
+	for _, c := range subscribers {
+		c.SendMessage(msg)
+	}
+
+	return seq, nil
+}
+
+// ListTopics returns the name of every topic that has been created.
+func (h *Hub) ListTopics() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	names := make([]string, 0, len(h.topics))
+	for name := range h.topics {
+		names = append(names, name)
+	}
+	return names
+}
+
+// TopicStats describes the current size and activity of a topic.
+type TopicStats struct {
+	Seq         uint64
+	Subscribers int
+	Bytes       int64
+}
+
+// TopicStats returns the current stats for a topic, or ok=false if it
+// doesn't exist.
+func (h *Hub) TopicStats(topic string) (TopicStats, bool) {
+	h.mu.RLock()
+	t, ok := h.topics[topic]
+	h.mu.RUnlock()
+	if !ok {
+		return TopicStats{}, false
+	}
+
+	seq, bytes := t.wal.Stats()
+
+	t.mu.RLock()
+	subs := len(t.subscribers)
+	t.mu.RUnlock()
+
+	return TopicStats{Seq: seq, Subscribers: subs, Bytes: bytes}, true
 }
 
 // Run starts the hub's main event loop
@@ -101,8 +372,9 @@ func (h *Hub) Run(ctx context.Context) {
 				ClientID:  client.ID,
 				Timestamp: time.Now().Unix(),
 				Metadata: map[string]interface{}{
-					"version": "1.0",
+					"version":      "1.0",
 					"capabilities": []string{"streaming", "multimodal", "tools"},
+					"client_ip":    client.ip,
 				},
 			}
 			client.SendMessage(welcome)
@@ -118,7 +390,9 @@ func (h *Hub) Run(ctx context.Context) {
 				delete(h.clients, client.ID)
 				close(client.send)
 				h.mu.Unlock()
-				
+
+				h.UnsubscribeAll(client.ID)
+
 				// Update metrics
 				if h.onMetricsUpdate != nil {
 					h.onMetricsUpdate(len(h.clients))
@@ -242,6 +516,24 @@ func (c *Client) SendMessage(message *Message) error {
 	}
 }
 
+// SendMessageWithDeadline behaves like SendMessage, except that instead of
+// failing immediately when the send buffer is full, it blocks until either
+// the message is enqueued or cancelCh closes (e.g. because the caller's
+// write deadline elapsed). A nil cancelCh makes it block indefinitely.
+func (c *Client) SendMessageWithDeadline(message *Message, cancelCh <-chan struct{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case c.send <- data:
+		return nil
+	case <-cancelCh:
+		return fmt.Errorf("client send deadline exceeded")
+	}
+}
+
 // SendJSON sends a JSON object to the client
 func (c *Client) SendJSON(v interface{}) error {
 	data, err := json.Marshal(v)
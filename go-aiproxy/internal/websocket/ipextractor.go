@@ -0,0 +1,176 @@
+package websocket
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// DefaultForwardedHeaders is the header priority order IPExtractor
+// consults when WithForwardedHeaders isn't passed: X-Real-IP first (set
+// verbatim by a trusted proxy), then X-Forwarded-For (walked
+// right-to-left, since each hop appends rather than prepends), then the
+// RFC 7239 Forwarded header's for= parameter.
+var DefaultForwardedHeaders = []string{"X-Real-IP", "X-Forwarded-For", "Forwarded"}
+
+// IPExtractor resolves the real client IP for an incoming WebSocket
+// upgrade request, only trusting a forwarded-address header when the
+// request arrived from a configured reverse proxy. This mirrors the
+// layered-trust approach documented for signaling servers sitting behind
+// Apache/Caddy/nginx: a header asserting an address is only believable
+// when it comes from a peer that is itself known to set it rather than
+// merely relay whatever the real client sent.
+type IPExtractor struct {
+	trustedProxies   []*net.IPNet
+	forwardedHeaders []string
+}
+
+// IPExtractorOption configures an IPExtractor at construction time.
+type IPExtractorOption func(*IPExtractor)
+
+// WithTrustedProxies sets the CIDR ranges of reverse proxies allowed to
+// assert a client IP via a forwarded-address header. A request whose
+// RemoteAddr falls outside every configured range is never trusted to
+// forward an address, regardless of which headers it sets.
+func WithTrustedProxies(proxies []*net.IPNet) IPExtractorOption {
+	return func(e *IPExtractor) {
+		e.trustedProxies = proxies
+	}
+}
+
+// WithForwardedHeaders overrides DefaultForwardedHeaders with a custom
+// priority order. Only "X-Real-IP", "X-Forwarded-For", and "Forwarded"
+// are understood; any other name is consulted but never matches.
+func WithForwardedHeaders(headers []string) IPExtractorOption {
+	return func(e *IPExtractor) {
+		e.forwardedHeaders = headers
+	}
+}
+
+// NewIPExtractor creates an IPExtractor with DefaultForwardedHeaders and,
+// unless WithTrustedProxies is passed, no trusted proxies at all - so by
+// default Resolve always returns RemoteAddr, and rejects any request that
+// sets one of the forwarded-address headers, the safe behavior for a
+// deployment with no reverse proxy in front of it.
+func NewIPExtractor(opts ...IPExtractorOption) *IPExtractor {
+	e := &IPExtractor{forwardedHeaders: DefaultForwardedHeaders}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Resolve returns the real client IP for r. If r.RemoteAddr isn't a
+// trusted proxy, RemoteAddr is used as-is when none of the
+// forwarded-address headers is set; if any of them is set, it is treated
+// as a spoofing attempt and Resolve returns an error.
+func (e *IPExtractor) Resolve(r *http.Request) (string, error) {
+	remoteIP := hostOnly(r.RemoteAddr)
+
+	if !e.isTrustedProxy(remoteIP) {
+		for _, name := range e.forwardedHeaders {
+			if r.Header.Get(name) != "" {
+				return "", fmt.Errorf("ipextractor: %s set by untrusted peer %s", name, remoteIP)
+			}
+		}
+		return remoteIP, nil
+	}
+
+	for _, name := range e.forwardedHeaders {
+		value := r.Header.Get(name)
+		if value == "" {
+			continue
+		}
+
+		switch name {
+		case "X-Real-IP":
+			return strings.TrimSpace(value), nil
+		case "X-Forwarded-For":
+			if ip, ok := e.firstUntrustedXFF(value); ok {
+				return ip, nil
+			}
+		case "Forwarded":
+			if ip, ok := parseForwardedFor(value); ok {
+				return ip, nil
+			}
+		}
+	}
+
+	return remoteIP, nil
+}
+
+// firstUntrustedXFF walks a comma-separated X-Forwarded-For value from
+// right (the hop closest to this server) to left, skipping any address
+// that itself belongs to a trusted proxy, and returns the first one that
+// doesn't - the original client, assuming every proxy in between is
+// trusted and therefore only appended to the chain rather than rewrote
+// it.
+func (e *IPExtractor) firstUntrustedXFF(value string) (string, bool) {
+	parts := strings.Split(value, ",")
+	for i := len(parts) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(parts[i])
+		if ip == "" {
+			continue
+		}
+		if !e.isTrustedProxy(ip) {
+			return ip, true
+		}
+	}
+	return "", false
+}
+
+// isTrustedProxy reports whether ipStr falls inside one of
+// e.trustedProxies.
+func (e *IPExtractor) isTrustedProxy(ipStr string) bool {
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range e.trustedProxies {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseForwardedFor extracts the for= parameter from one RFC 7239
+// Forwarded header value, e.g. `for=192.0.2.60;proto=http;by=203.0.113.43`
+// or a quoted IPv6 form `for="[2001:db8::1]:1234"`. Only the first
+// for= token is used; RFC 7239 allows a comma-separated list of hops but
+// this proxy's trust model only ever looks at the most specific one.
+func parseForwardedFor(value string) (string, bool) {
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ',' }) {
+		part = strings.TrimSpace(part)
+		if len(part) < 4 || !strings.EqualFold(part[:4], "for=") {
+			continue
+		}
+
+		v := strings.Trim(part[4:], `"`)
+		v = strings.TrimPrefix(v, "[")
+		if idx := strings.Index(v, "]"); idx >= 0 {
+			v = v[:idx]
+		} else if idx := strings.LastIndex(v, ":"); idx >= 0 && strings.Count(v, ":") == 1 {
+			// Strip a trailing :port from an IPv4 for= value; an IPv6
+			// address without brackets has more than one colon and is
+			// left alone.
+			v = v[:idx]
+		}
+
+		if v != "" {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// hostOnly strips the port off remoteAddr ("1.2.3.4:5678" -> "1.2.3.4"),
+// returning remoteAddr unchanged if it has no port.
+func hostOnly(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
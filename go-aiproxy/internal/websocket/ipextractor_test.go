@@ -0,0 +1,108 @@
+package websocket
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func mustCIDR(t *testing.T, cidr string) *net.IPNet {
+	t.Helper()
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatalf("ParseCIDR(%q): %v", cidr, err)
+	}
+	return network
+}
+
+func TestIPExtractor_UntrustedRemoteAddrWithoutHeadersUsesRemoteAddr(t *testing.T) {
+	e := NewIPExtractor(WithTrustedProxies([]*net.IPNet{mustCIDR(t, "10.0.0.0/8")}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.RemoteAddr = "203.0.113.5:54321"
+
+	ip, err := e.Resolve(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ip != "203.0.113.5" {
+		t.Errorf("ip = %q, want RemoteAddr host 203.0.113.5", ip)
+	}
+}
+
+func TestIPExtractor_RejectsHeaderFromUntrustedPeer(t *testing.T) {
+	e := NewIPExtractor(WithTrustedProxies([]*net.IPNet{mustCIDR(t, "10.0.0.0/8")}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.RemoteAddr = "203.0.113.5:54321"
+	req.Header.Set("X-Real-IP", "198.51.100.9")
+
+	if _, err := e.Resolve(req); err == nil {
+		t.Error("expected an error when an untrusted peer sets X-Real-IP")
+	}
+}
+
+func TestIPExtractor_TrustsXRealIPFromTrustedProxy(t *testing.T) {
+	e := NewIPExtractor(WithTrustedProxies([]*net.IPNet{mustCIDR(t, "10.0.0.0/8")}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.RemoteAddr = "10.1.2.3:443"
+	req.Header.Set("X-Real-IP", "198.51.100.9")
+
+	ip, err := e.Resolve(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ip != "198.51.100.9" {
+		t.Errorf("ip = %q, want 198.51.100.9", ip)
+	}
+}
+
+func TestIPExtractor_WalksXForwardedForSkippingTrustedHops(t *testing.T) {
+	e := NewIPExtractor(WithTrustedProxies([]*net.IPNet{mustCIDR(t, "10.0.0.0/8")}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.RemoteAddr = "10.1.2.3:443"
+	// Real client, then two trusted internal hops, appended left-to-right.
+	req.Header.Set("X-Forwarded-For", "198.51.100.9, 10.0.0.1, 10.0.0.2")
+
+	ip, err := e.Resolve(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ip != "198.51.100.9" {
+		t.Errorf("ip = %q, want 198.51.100.9 (first non-trusted hop walking right-to-left)", ip)
+	}
+}
+
+func TestIPExtractor_FallsBackToForwardedHeader(t *testing.T) {
+	e := NewIPExtractor(WithTrustedProxies([]*net.IPNet{mustCIDR(t, "10.0.0.0/8")}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.RemoteAddr = "10.1.2.3:443"
+	req.Header.Set("Forwarded", `for=198.51.100.9;proto=https;by=10.0.0.1`)
+
+	ip, err := e.Resolve(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ip != "198.51.100.9" {
+		t.Errorf("ip = %q, want 198.51.100.9", ip)
+	}
+}
+
+func TestIPExtractor_NoTrustedProxiesAlwaysUsesRemoteAddr(t *testing.T) {
+	e := NewIPExtractor()
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.RemoteAddr = "203.0.113.5:54321"
+
+	ip, err := e.Resolve(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ip != "203.0.113.5" {
+		t.Errorf("ip = %q, want 203.0.113.5", ip)
+	}
+}
@@ -0,0 +1,233 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultStreamWindowSize is how many MessageTypeStream frames a
+// StreamWriter will send before an acknowledged stream_ack catches up, if
+// the caller doesn't override it via StreamWriter.WindowSize.
+const DefaultStreamWindowSize = 32
+
+// StreamWriter incrementally delivers one LLM completion's bytes to a
+// single client as a series of MessageTypeStream frames carrying a
+// monotonically increasing Seq, followed by a final MessageTypeStreamEnd
+// once End is called. Writes block once WindowSize frames are
+// unacknowledged, resuming as the client's {"type":"stream_ack",...}
+// messages arrive (see Hub.AckStream), so a slow client applies
+// backpressure to the upstream provider call instead of this either
+// buffering unboundedly or being dropped by Client.send filling up.
+//
+// The client can also abort the stream outright with
+// {"type":"stream_cancel","stream_id":...} (see Hub.CancelStream), which
+// cancels the Context a caller should be threading into its upstream
+// GenerateContentStream call.
+type StreamWriter struct {
+	// Provider and Model are copied into every frame's Provider/Model
+	// fields; set by the caller before the first Write if it wants them
+	// populated (OpenStream itself doesn't know either).
+	Provider string
+	Model    string
+
+	// WindowSize overrides DefaultStreamWindowSize. Zero means the
+	// default.
+	WindowSize uint64
+
+	hub      *Hub
+	client   *Client
+	streamID string
+
+	mu    sync.Mutex
+	seq   uint64
+	acked uint64
+	ackCh chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// OpenStream registers a new StreamWriter for streamID against clientID,
+// so Hub.AckStream/Hub.CancelStream (driven by that client's stream_ack /
+// stream_cancel messages) can find it. Returns an error if clientID isn't
+// currently connected.
+func (h *Hub) OpenStream(clientID, streamID string) (*StreamWriter, error) {
+	client, ok := h.GetClient(clientID)
+	if !ok {
+		return nil, fmt.Errorf("websocket: client %s not connected", clientID)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sw := &StreamWriter{
+		hub:      h,
+		client:   client,
+		streamID: streamID,
+		ackCh:    make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	h.registerStream(clientID, streamID, sw)
+	return sw, nil
+}
+
+// Context returns the Context a caller should pass to its upstream
+// provider call, so a client-sent stream_cancel aborts it.
+func (sw *StreamWriter) Context() context.Context {
+	return sw.ctx
+}
+
+// windowSize returns WindowSize, or DefaultStreamWindowSize if unset.
+func (sw *StreamWriter) windowSize() uint64 {
+	if sw.WindowSize > 0 {
+		return sw.WindowSize
+	}
+	return DefaultStreamWindowSize
+}
+
+// Write sends p as one MessageTypeStream frame with the next Seq,
+// blocking first if windowSize frames are already unacknowledged until
+// either the client's stream_ack catches up or the stream's Context is
+// canceled (by Hub.CancelStream or the caller's own Cancel or End).
+func (sw *StreamWriter) Write(p []byte) (int, error) {
+	if err := sw.waitForWindow(); err != nil {
+		return 0, err
+	}
+
+	sw.mu.Lock()
+	sw.seq++
+	seq := sw.seq
+	sw.mu.Unlock()
+
+	msg := &Message{
+		Type:      MessageTypeStream,
+		ID:        sw.streamID,
+		StreamID:  sw.streamID,
+		ClientID:  sw.client.ID,
+		Provider:  sw.Provider,
+		Model:     sw.Model,
+		Response:  string(p),
+		Timestamp: time.Now().Unix(),
+		Seq:       seq,
+	}
+	if err := sw.client.SendMessage(msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// waitForWindow blocks until fewer than windowSize() frames are
+// unacknowledged, or the stream's Context is done.
+func (sw *StreamWriter) waitForWindow() error {
+	for {
+		sw.mu.Lock()
+		unacked := sw.seq - sw.acked
+		full := unacked >= sw.windowSize()
+		sw.mu.Unlock()
+
+		if !full {
+			return nil
+		}
+
+		select {
+		case <-sw.ackCh:
+		case <-sw.ctx.Done():
+			return sw.ctx.Err()
+		}
+	}
+}
+
+// handleAck advances the acknowledged watermark to seq (a stale or
+// out-of-order ack that doesn't move it forward is ignored) and wakes any
+// Write blocked in waitForWindow.
+func (sw *StreamWriter) handleAck(seq uint64) {
+	sw.mu.Lock()
+	if seq > sw.acked {
+		sw.acked = seq
+	}
+	sw.mu.Unlock()
+
+	select {
+	case sw.ackCh <- struct{}{}:
+	default:
+	}
+}
+
+// Cancel cancels the stream's Context without sending anything, so an
+// upstream GenerateContentStream call reading it aborts. Called by
+// Hub.CancelStream on a client-sent stream_cancel; a caller aborting its
+// own stream early may also call it directly.
+func (sw *StreamWriter) Cancel() {
+	sw.cancel()
+}
+
+// End sends the terminal MessageTypeStreamEnd frame and unregisters the
+// stream from its Hub. Callers that finish normally must call this exactly
+// once; callers that abort early (a timeout, a failed upstream call before
+// any chunk was written) should skip it and rely on Hub.unregisterStream
+// having already been deferred instead, since sending StreamEnd on an
+// aborted stream would look like a clean finish to the client.
+func (sw *StreamWriter) End() error {
+	sw.cancel()
+	sw.hub.unregisterStream(sw.client.ID, sw.streamID)
+
+	return sw.client.SendMessage(&Message{
+		Type:      MessageTypeStreamEnd,
+		ID:        sw.streamID,
+		StreamID:  sw.streamID,
+		ClientID:  sw.client.ID,
+		Provider:  sw.Provider,
+		Model:     sw.Model,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// registerStream associates a StreamWriter with (clientID, streamID) so
+// AckStream/CancelStream can route a client's stream_ack/stream_cancel
+// messages to it.
+func (h *Hub) registerStream(clientID, streamID string, sw *StreamWriter) {
+	h.streamsMu.Lock()
+	defer h.streamsMu.Unlock()
+	h.streams[streamKey(clientID, streamID)] = sw
+}
+
+// unregisterStream removes a (clientID, streamID) entry. Safe to call more
+// than once, or on an entry that was never registered.
+func (h *Hub) unregisterStream(clientID, streamID string) {
+	h.streamsMu.Lock()
+	defer h.streamsMu.Unlock()
+	delete(h.streams, streamKey(clientID, streamID))
+}
+
+// AckStream advances clientID's streamID stream past seq, unblocking any
+// Write waiting on backpressure. A stream_ack for an unknown or
+// already-finished stream is silently ignored, since the stream may have
+// just ended before the ack arrived.
+func (h *Hub) AckStream(clientID, streamID string, seq uint64) {
+	h.streamsMu.RLock()
+	sw, ok := h.streams[streamKey(clientID, streamID)]
+	h.streamsMu.RUnlock()
+	if ok {
+		sw.handleAck(seq)
+	}
+}
+
+// CancelStream cancels clientID's streamID stream's Context. A
+// stream_cancel for an unknown or already-finished stream is silently
+// ignored.
+func (h *Hub) CancelStream(clientID, streamID string) {
+	h.streamsMu.RLock()
+	sw, ok := h.streams[streamKey(clientID, streamID)]
+	h.streamsMu.RUnlock()
+	if ok {
+		sw.Cancel()
+	}
+}
+
+// streamKey derives the map key OpenStream/registerStream/AckStream/
+// CancelStream all use to identify one client's one stream.
+func streamKey(clientID, streamID string) string {
+	return clientID + "/" + streamID
+}
@@ -0,0 +1,184 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// registerTestClient wires a bare Client (no real net.Conn) directly into
+// h.clients, which is all OpenStream/SendMessage need: SendMessage only
+// writes JSON onto Client.send.
+func registerTestClient(h *Hub, id string) *Client {
+	c := &Client{ID: id, hub: h, send: make(chan []byte, 16)}
+	h.mu.Lock()
+	h.clients[id] = c
+	h.mu.Unlock()
+	return c
+}
+
+// recvMessage drains one message off a Client's send channel, failing the
+// test if none arrives in time.
+func recvMessage(t *testing.T, c *Client) *Message {
+	t.Helper()
+	select {
+	case data := <-c.send:
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("failed to unmarshal frame: %v", err)
+		}
+		return &msg
+	case <-time.After(time.Second):
+		t.Fatal("no frame received within 1s")
+		return nil
+	}
+}
+
+func TestOpenStream_UnknownClientErrors(t *testing.T) {
+	h := NewHub()
+	if _, err := h.OpenStream("nobody", "stream-1"); err == nil {
+		t.Fatal("expected an error opening a stream for an unconnected client")
+	}
+}
+
+func TestStreamWriter_WriteSendsIncreasingSeq(t *testing.T) {
+	h := NewHub()
+	c := registerTestClient(h, "client-1")
+
+	sw, err := h.OpenStream(c.ID, "stream-1")
+	if err != nil {
+		t.Fatalf("OpenStream: %v", err)
+	}
+
+	if _, err := sw.Write([]byte("chunk-1")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if _, err := sw.Write([]byte("chunk-2")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	first := recvMessage(t, c)
+	second := recvMessage(t, c)
+
+	if first.Type != MessageTypeStream || second.Type != MessageTypeStream {
+		t.Fatalf("expected both frames to be MessageTypeStream, got %q and %q", first.Type, second.Type)
+	}
+	if first.Seq != 1 || second.Seq != 2 {
+		t.Fatalf("Seq = %d, %d, want 1, 2", first.Seq, second.Seq)
+	}
+	if first.StreamID != "stream-1" || second.StreamID != "stream-1" {
+		t.Fatalf("StreamID = %q, %q, want \"stream-1\" on both frames", first.StreamID, second.StreamID)
+	}
+}
+
+func TestStreamWriter_WriteBlocksUntilAck(t *testing.T) {
+	h := NewHub()
+	c := registerTestClient(h, "client-1")
+
+	sw, err := h.OpenStream(c.ID, "stream-1")
+	if err != nil {
+		t.Fatalf("OpenStream: %v", err)
+	}
+	sw.WindowSize = 1
+
+	if _, err := sw.Write([]byte("chunk-1")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	recvMessage(t, c)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := sw.Write([]byte("chunk-2"))
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Write returned before the client acked the first frame")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	h.AckStream(c.ID, "stream-1", 1)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Write did not unblock after AckStream")
+	}
+	recvMessage(t, c)
+}
+
+func TestStreamWriter_CancelAbortsContextAndBlockedWrite(t *testing.T) {
+	h := NewHub()
+	c := registerTestClient(h, "client-1")
+
+	sw, err := h.OpenStream(c.ID, "stream-1")
+	if err != nil {
+		t.Fatalf("OpenStream: %v", err)
+	}
+	sw.WindowSize = 1
+
+	if _, err := sw.Write([]byte("chunk-1")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	recvMessage(t, c)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := sw.Write([]byte("chunk-2"))
+		done <- err
+	}()
+
+	h.CancelStream(c.ID, "stream-1")
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("Write error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Write did not unblock after CancelStream")
+	}
+
+	select {
+	case <-sw.Context().Done():
+	default:
+		t.Fatal("Context was not canceled by CancelStream")
+	}
+}
+
+func TestStreamWriter_EndSendsStreamEndAndUnregisters(t *testing.T) {
+	h := NewHub()
+	c := registerTestClient(h, "client-1")
+
+	sw, err := h.OpenStream(c.ID, "stream-1")
+	if err != nil {
+		t.Fatalf("OpenStream: %v", err)
+	}
+
+	if err := sw.End(); err != nil {
+		t.Fatalf("End: %v", err)
+	}
+
+	end := recvMessage(t, c)
+	if end.Type != MessageTypeStreamEnd {
+		t.Fatalf("Type = %q, want MessageTypeStreamEnd", end.Type)
+	}
+
+	// Unregistered, so a stray ack/cancel for this stream is now a no-op
+	// rather than reaching the (already finished) StreamWriter.
+	h.AckStream(c.ID, "stream-1", 1)
+	h.CancelStream(c.ID, "stream-1")
+}
+
+func TestHub_AckAndCancelStream_UnknownStreamIsNoop(t *testing.T) {
+	h := NewHub()
+	registerTestClient(h, "client-1")
+
+	h.AckStream("client-1", "no-such-stream", 5)
+	h.CancelStream("client-1", "no-such-stream")
+}
@@ -0,0 +1,196 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WAL is an append-only write-ahead log for a single topic, backed by one
+// segment file on disk under a configurable directory. Each record carries a
+// monotonically increasing sequence number so a reconnecting subscriber can
+// ask to replay everything published since the last sequence it saw instead
+// of losing in-flight tokens.
+type WAL struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	nextSeq  uint64
+	entries  []walEntry
+	maxAge   time.Duration
+	maxBytes int64
+}
+
+type walEntry struct {
+	Seq       uint64
+	Data      json.RawMessage
+	Timestamp time.Time
+}
+
+type walRecord struct {
+	Seq  uint64          `json:"seq"`
+	TS   time.Time       `json:"ts"`
+	Data json.RawMessage `json:"data"`
+}
+
+// NewWAL opens (creating if necessary) the segment file for a topic. maxAge
+// and maxBytes bound how much of the replay window is retained in memory;
+// either may be zero to disable that bound.
+func NewWAL(dir, topic string, maxAge time.Duration, maxBytes int64) (*WAL, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create WAL dir: %w", err)
+	}
+
+	w := &WAL{
+		path:     filepath.Join(dir, sanitizeTopic(topic)+".wal"),
+		maxAge:   maxAge,
+		maxBytes: maxBytes,
+	}
+
+	if err := w.replayExisting(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WAL segment: %w", err)
+	}
+	w.file = file
+
+	return w, nil
+}
+
+// replayExisting loads an existing segment file into memory on startup.
+func (w *WAL) replayExisting() error {
+	f, err := os.Open(w.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open WAL segment for replay: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 8*1024*1024)
+	for scanner.Scan() {
+		var rec walRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		w.entries = append(w.entries, walEntry{Seq: rec.Seq, Data: rec.Data, Timestamp: rec.TS})
+		if rec.Seq >= w.nextSeq {
+			w.nextSeq = rec.Seq + 1
+		}
+	}
+	return scanner.Err()
+}
+
+// Append writes a new entry to the log and returns its assigned sequence.
+func (w *WAL) Append(data []byte) (uint64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	seq := w.nextSeq
+	w.nextSeq++
+
+	rec := walRecord{Seq: seq, TS: time.Now(), Data: data}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return 0, err
+	}
+	line = append(line, '\n')
+
+	if _, err := w.file.Write(line); err != nil {
+		return 0, fmt.Errorf("failed to append to WAL: %w", err)
+	}
+
+	w.entries = append(w.entries, walEntry{Seq: seq, Data: data, Timestamp: rec.TS})
+	w.gcLocked()
+
+	return seq, nil
+}
+
+// ReadSince returns the payload of every entry with sequence strictly
+// greater than since, oldest first.
+func (w *WAL) ReadSince(since uint64) [][]byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var out [][]byte
+	for _, e := range w.entries {
+		if e.Seq > since {
+			out = append(out, append([]byte(nil), e.Data...))
+		}
+	}
+	return out
+}
+
+// Stats returns the next sequence to be assigned and the bytes retained in
+// the in-memory replay window.
+func (w *WAL) Stats() (nextSeq uint64, bytes int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var total int64
+	for _, e := range w.entries {
+		total += int64(len(e.Data))
+	}
+	return w.nextSeq, total
+}
+
+// gcLocked drops entries older than maxAge or beyond maxBytes from the
+// in-memory replay window. The on-disk segment remains append-only; this
+// only bounds what a reconnecting client can replay and how much memory the
+// topic holds.
+func (w *WAL) gcLocked() {
+	if w.maxAge <= 0 && w.maxBytes <= 0 {
+		return
+	}
+
+	start := 0
+	if w.maxAge > 0 {
+		cutoff := time.Now().Add(-w.maxAge)
+		for start < len(w.entries) && w.entries[start].Timestamp.Before(cutoff) {
+			start++
+		}
+	}
+
+	if w.maxBytes > 0 {
+		var total int64
+		for i := len(w.entries) - 1; i >= start; i-- {
+			total += int64(len(w.entries[i].Data))
+			if total > w.maxBytes {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	if start > 0 {
+		w.entries = append([]walEntry(nil), w.entries[start:]...)
+	}
+}
+
+// Close closes the underlying segment file.
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+func sanitizeTopic(topic string) string {
+	b := []byte(topic)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+		default:
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
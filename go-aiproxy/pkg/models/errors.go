@@ -0,0 +1,173 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ErrorCode identifies a provider-agnostic failure category. Providers map
+// their own native error responses into one of these instead of returning
+// a bare fmt.Errorf, so callers above the provider layer (the load
+// balancer's failover decision, the HTTP response layer, /metrics) can
+// react to *why* a call failed without parsing provider-specific text.
+type ErrorCode string
+
+const (
+	// ErrCacheInit covers failures standing up the response cache or its
+	// semantic index (e.g. an unreachable embeddings endpoint).
+	ErrCacheInit ErrorCode = "cache_init_failed"
+	// ErrProviderAuth covers a provider rejecting credentials outright
+	// (bad API key, revoked OAuth grant).
+	ErrProviderAuth ErrorCode = "provider_auth_failed"
+	// ErrCredentialsExpired covers an OAuth access token that expired and
+	// could not be refreshed, distinct from ErrProviderAuth's outright
+	// rejection since the caller may just need to re-authenticate.
+	ErrCredentialsExpired ErrorCode = "credentials_expired"
+	// ErrRateLimited covers a provider's own rate limiting (HTTP 429).
+	ErrRateLimited ErrorCode = "rate_limited"
+	// ErrUpstreamTimeout covers a provider call that didn't complete in
+	// time, whether from a context deadline or the provider's own 504.
+	ErrUpstreamTimeout ErrorCode = "upstream_timeout"
+	// ErrCircuitOpen covers a request rejected because that provider's
+	// circuit breaker is currently open.
+	ErrCircuitOpen ErrorCode = "circuit_open"
+	// ErrNoHealthyInstance covers a load-balancer pool with no healthy
+	// instance left to select.
+	ErrNoHealthyInstance ErrorCode = "no_healthy_instance"
+	// ErrUpstream is the fallback for a provider error that doesn't fit
+	// any of the more specific codes above.
+	ErrUpstream ErrorCode = "upstream_error"
+)
+
+// APIError is the provider-agnostic error record every layer above a
+// provider's own HTTP client should deal in. Message is safe to surface to
+// callers; Detail carries additional, potentially provider-specific
+// context useful for logs/debugging but not guaranteed stable across
+// provider versions.
+type APIError struct {
+	Code       ErrorCode
+	Message    string
+	Detail     string
+	ProviderID string
+	// Retryable reports whether a caller can reasonably expect a retry
+	// against a *different* instance or provider to succeed. The load
+	// balancer's failover chain uses this to decide whether to fall
+	// through to the next provider or stop and surface the error as-is.
+	Retryable bool
+}
+
+// NewAPIError creates an APIError for code against providerID, defaulting
+// Retryable to DefaultRetryable(code). Callers that know better for a
+// specific failure (e.g. a 429 with a Retry-After far in the future) can
+// override Retryable directly afterward.
+func NewAPIError(code ErrorCode, providerID, message string) *APIError {
+	return &APIError{
+		Code:       code,
+		Message:    message,
+		ProviderID: providerID,
+		Retryable:  DefaultRetryable(code),
+	}
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	if e.ProviderID != "" {
+		return fmt.Sprintf("%s: %s: %s", e.ProviderID, e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// DefaultRetryable reports whether a fresh attempt against a different
+// instance is generally worth making for code, absent more specific
+// knowledge about the failure. Auth failures aren't retryable since
+// another instance behind the same provider credentials will fail the
+// same way; capacity/availability failures are.
+func DefaultRetryable(code ErrorCode) bool {
+	switch code {
+	case ErrRateLimited, ErrUpstreamTimeout, ErrCircuitOpen, ErrNoHealthyInstance, ErrUpstream:
+		return true
+	case ErrProviderAuth, ErrCredentialsExpired, ErrCacheInit:
+		return false
+	default:
+		return false
+	}
+}
+
+// HTTPStatus maps code to the HTTP status the response layer should use,
+// following the convention the rest of the server already uses (429 for
+// rate limiting, 401 for auth) and OpenAI's own error status conventions
+// for the rest.
+func (e *APIError) HTTPStatus() int {
+	switch e.Code {
+	case ErrProviderAuth, ErrCredentialsExpired:
+		return http.StatusUnauthorized
+	case ErrRateLimited:
+		return http.StatusTooManyRequests
+	case ErrUpstreamTimeout:
+		return http.StatusGatewayTimeout
+	case ErrCircuitOpen, ErrNoHealthyInstance:
+		return http.StatusServiceUnavailable
+	case ErrCacheInit:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// openAIErrorType maps code to the value OpenAI's own API puts in
+// error.type, so a client written against OpenAI's SDK handles our error
+// responses the same way it handles OpenAI's.
+func (e *APIError) openAIErrorType() string {
+	switch e.Code {
+	case ErrProviderAuth, ErrCredentialsExpired:
+		return "authentication_error"
+	case ErrRateLimited:
+		return "rate_limit_error"
+	default:
+		return "api_error"
+	}
+}
+
+// ToOpenAIResponse renders e into the {"error": {...}} JSON shape OpenAI's
+// API returns, with error.type and error.code set so clients built against
+// the OpenAI SDK can branch on them the same way they would for OpenAI
+// itself.
+func (e *APIError) ToOpenAIResponse() map[string]interface{} {
+	return map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": e.Message,
+			"type":    e.openAIErrorType(),
+			"code":    string(e.Code),
+			"param":   nil,
+		},
+	}
+}
+
+// ClassifyHTTPError builds an APIError for a non-2xx HTTP response a
+// provider's client received, the shared mapping used by every provider's
+// makeRequest instead of each re-deriving its own fmt.Errorf text.
+func ClassifyHTTPError(providerID string, statusCode int, body []byte) *APIError {
+	message := string(body)
+	if len(message) > 500 {
+		message = message[:500]
+	}
+
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		err := NewAPIError(ErrProviderAuth, providerID, "provider rejected credentials")
+		err.Detail = message
+		return err
+	case http.StatusTooManyRequests:
+		err := NewAPIError(ErrRateLimited, providerID, "provider rate limit exceeded")
+		err.Detail = message
+		return err
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		err := NewAPIError(ErrUpstreamTimeout, providerID, "provider request timed out")
+		err.Detail = message
+		return err
+	default:
+		err := NewAPIError(ErrUpstream, providerID, fmt.Sprintf("provider returned HTTP %d", statusCode))
+		err.Detail = message
+		return err
+	}
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAPIError_HTTPStatus(t *testing.T) {
+	cases := []struct {
+		code ErrorCode
+		want int
+	}{
+		{ErrProviderAuth, http.StatusUnauthorized},
+		{ErrCredentialsExpired, http.StatusUnauthorized},
+		{ErrRateLimited, http.StatusTooManyRequests},
+		{ErrUpstreamTimeout, http.StatusGatewayTimeout},
+		{ErrCircuitOpen, http.StatusServiceUnavailable},
+		{ErrNoHealthyInstance, http.StatusServiceUnavailable},
+		{ErrCacheInit, http.StatusInternalServerError},
+		{ErrUpstream, http.StatusBadGateway},
+	}
+
+	for _, tc := range cases {
+		err := NewAPIError(tc.code, "provider", "boom")
+		if got := err.HTTPStatus(); got != tc.want {
+			t.Errorf("HTTPStatus(%s) = %d, want %d", tc.code, got, tc.want)
+		}
+	}
+}
+
+func TestDefaultRetryable(t *testing.T) {
+	cases := []struct {
+		code ErrorCode
+		want bool
+	}{
+		{ErrRateLimited, true},
+		{ErrUpstreamTimeout, true},
+		{ErrCircuitOpen, true},
+		{ErrNoHealthyInstance, true},
+		{ErrProviderAuth, false},
+		{ErrCredentialsExpired, false},
+		{ErrCacheInit, false},
+	}
+
+	for _, tc := range cases {
+		if got := DefaultRetryable(tc.code); got != tc.want {
+			t.Errorf("DefaultRetryable(%s) = %v, want %v", tc.code, got, tc.want)
+		}
+	}
+}
+
+func TestNewAPIError_DefaultsRetryableFromCode(t *testing.T) {
+	err := NewAPIError(ErrRateLimited, "openai", "slow down")
+	if !err.Retryable {
+		t.Error("expected ErrRateLimited to default Retryable=true")
+	}
+
+	err = NewAPIError(ErrProviderAuth, "openai", "bad key")
+	if err.Retryable {
+		t.Error("expected ErrProviderAuth to default Retryable=false")
+	}
+}
+
+func TestAPIError_ToOpenAIResponse(t *testing.T) {
+	err := NewAPIError(ErrRateLimited, "openai", "slow down")
+	resp := err.ToOpenAIResponse()
+
+	errBody, ok := resp["error"].(map[string]interface{})
+	if !ok {
+		t.Fatal("expected an \"error\" object in the response")
+	}
+	if errBody["message"] != "slow down" {
+		t.Errorf("message = %v, want %q", errBody["message"], "slow down")
+	}
+	if errBody["type"] != "rate_limit_error" {
+		t.Errorf("type = %v, want rate_limit_error", errBody["type"])
+	}
+	if errBody["code"] != string(ErrRateLimited) {
+		t.Errorf("code = %v, want %s", errBody["code"], ErrRateLimited)
+	}
+}
+
+func TestClassifyHTTPError(t *testing.T) {
+	cases := []struct {
+		status int
+		want   ErrorCode
+	}{
+		{http.StatusUnauthorized, ErrProviderAuth},
+		{http.StatusForbidden, ErrProviderAuth},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusGatewayTimeout, ErrUpstreamTimeout},
+		{http.StatusInternalServerError, ErrUpstream},
+	}
+
+	for _, tc := range cases {
+		err := ClassifyHTTPError("openai", tc.status, []byte("body"))
+		if err.Code != tc.want {
+			t.Errorf("ClassifyHTTPError(%d).Code = %s, want %s", tc.status, err.Code, tc.want)
+		}
+		if err.ProviderID != "openai" {
+			t.Errorf("ClassifyHTTPError(%d).ProviderID = %s, want openai", tc.status, err.ProviderID)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package models
+
+import "encoding/json"
+
+// StreamEventType identifies which variant of a StreamEvent is populated.
+type StreamEventType string
+
+const (
+	StreamEventMessageStart      StreamEventType = "message_start"
+	StreamEventContentBlockDelta StreamEventType = "content_block_delta"
+	StreamEventToolUseStart      StreamEventType = "tool_use_start"
+	StreamEventInputJSONDelta    StreamEventType = "input_json_delta"
+	StreamEventContentBlockStop  StreamEventType = "content_block_stop"
+	StreamEventMessageDelta      StreamEventType = "message_delta"
+	StreamEventMessageStop       StreamEventType = "message_stop"
+)
+
+// StreamEvent is a typed union of the granular events a provider's stream
+// reader can observe, replacing the plain-text-only view of streaming.
+// Claude streams a tool call's arguments as a sequence of partial_json
+// fragments addressed by content-block Index; OpenAI streams the same
+// information as incremental delta.tool_calls[].function.arguments
+// strings; Gemini has no incremental form and only ever emits one complete
+// functionCall. A StreamEvent carries enough information for a
+// convert.StreamConverter to translate between all three.
+type StreamEvent struct {
+	Type  StreamEventType `json:"type"`
+	Index int             `json:"index,omitempty"`
+
+	// Text is the delta payload of a ContentBlockDelta text event.
+	Text string `json:"text,omitempty"`
+
+	// ToolCallID and ToolName identify the block opened by a
+	// ToolUseStart event.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	ToolName   string `json:"tool_name,omitempty"`
+
+	// PartialJSON is one fragment of a tool call's arguments, carried by
+	// an InputJSONDelta event. Fragments for a given Index must be
+	// concatenated in arrival order.
+	PartialJSON string `json:"partial_json,omitempty"`
+
+	// FinishReason is set on MessageDelta/MessageStop once known.
+	FinishReason string `json:"finish_reason,omitempty"`
+
+	// OutputTokens is the completion token count reported alongside
+	// FinishReason on a MessageDelta event.
+	OutputTokens int `json:"output_tokens,omitempty"`
+}
+
+// streamEventMarker prefixes a StreamEvent JSON-encoded as a provider's raw
+// stream chunk string, distinguishing it from the plain text deltas most
+// providers still emit. It is a control byte that cannot occur in
+// well-formed chunk text, so it never collides with real content.
+const streamEventMarker = "\x00SE:"
+
+// EncodeStreamEvent serializes ev as a raw stream chunk string so it can
+// travel through the same io.Reader byte pipeline plain text deltas use.
+func EncodeStreamEvent(ev StreamEvent) string {
+	data, _ := json.Marshal(ev)
+	return streamEventMarker + string(data)
+}
+
+// DecodeStreamEvent extracts a StreamEvent from a raw chunk string, if it
+// carries the streamEventMarker. ok is false for ordinary text chunks,
+// which callers should keep treating as plain text deltas.
+func DecodeStreamEvent(chunk string) (ev StreamEvent, ok bool) {
+	if len(chunk) <= len(streamEventMarker) || chunk[:len(streamEventMarker)] != streamEventMarker {
+		return StreamEvent{}, false
+	}
+	if err := json.Unmarshal([]byte(chunk[len(streamEventMarker):]), &ev); err != nil {
+		return StreamEvent{}, false
+	}
+	return ev, true
+}
@@ -23,6 +23,17 @@ const (
 	ProtocolOpenAI ProtocolPrefix = "openai"
 	ProtocolClaude ProtocolPrefix = "claude"
 	ProtocolGemini ProtocolPrefix = "gemini"
+	// ProtocolVertexAI is Gemini served through Vertex AI
+	// ({region}-aiplatform.googleapis.com/.../publishers/google/models/{model}:generateContent,
+	// OAuth2 service-account auth). It shares Gemini's request/response
+	// shape, so the converter treats it as a Gemini variant.
+	ProtocolVertexAI ProtocolPrefix = "vertexai"
+	// ProtocolAzureOpenAI is the OpenAI-compatible API Azure exposes under
+	// a deployment name in the path and an api-version query param. It
+	// shares OpenAI's request/response shape plus Azure's additional
+	// content-filter fields, so the converter treats it as an OpenAI
+	// variant.
+	ProtocolAzureOpenAI ProtocolPrefix = "azure-openai"
 )
 
 // Common message roles
@@ -37,17 +48,46 @@ const (
 
 // OpenAI Models
 type OpenAIRequest struct {
-	Model               string          `json:"model"`
-	Messages            []OpenAIMessage `json:"messages"`
-	MaxTokens           int             `json:"max_tokens,omitempty"`
-	Temperature         float64         `json:"temperature,omitempty"`
-	TopP                float64         `json:"top_p,omitempty"`
-	Stream              bool            `json:"stream,omitempty"`
-	Tools               []Tool          `json:"tools,omitempty"`
-	ToolChoice          interface{}     `json:"tool_choice,omitempty"`
-	ResponseFormat      interface{}     `json:"response_format,omitempty"`
-	ReasoningEffort     string          `json:"reasoning_effort,omitempty"`
-	MaxCompletionTokens int             `json:"max_completion_tokens,omitempty"`
+	Model     string          `json:"model"`
+	Messages  []OpenAIMessage `json:"messages"`
+	MaxTokens int             `json:"max_tokens,omitempty"`
+	// Temperature is a pointer so an omitted field is distinguishable from
+	// an explicit 0: callers that rely on "temperature 0 means
+	// deterministic" (e.g. cache.ShouldUseSemanticKey) would otherwise
+	// treat a request that never set temperature, expecting the
+	// provider's own non-deterministic default, the same as one that
+	// explicitly asked for determinism.
+	Temperature         *float64    `json:"temperature,omitempty"`
+	TopP                float64     `json:"top_p,omitempty"`
+	TopK                int         `json:"top_k,omitempty"`
+	Stop                interface{} `json:"stop,omitempty"` // string or []string
+	Stream              bool        `json:"stream,omitempty"`
+	Tools               []Tool      `json:"tools,omitempty"`
+	ToolChoice          interface{} `json:"tool_choice,omitempty"`
+	ResponseFormat      interface{} `json:"response_format,omitempty"`
+	ReasoningEffort     string      `json:"reasoning_effort,omitempty"`
+	MaxCompletionTokens int         `json:"max_completion_tokens,omitempty"`
+}
+
+// TemperatureOrDefault dereferences an OpenAIRequest's optional
+// Temperature, reporting def for an omitted field instead of silently
+// treating it as an explicit 0.
+func TemperatureOrDefault(temperature *float64, def float64) float64 {
+	if temperature == nil {
+		return def
+	}
+	return *temperature
+}
+
+// ResponseFormat is the canonical shape of a structured-output request,
+// normalized from whichever protocol's native response_format/
+// generationConfig fields produced it: "json_object" for free-form JSON,
+// or "json_schema" with Schema populated for a specific JSON schema.
+// Claude has no native equivalent, so the converter instead folds this
+// into a system-prompt instruction and post-hoc repairs the response.
+type ResponseFormat struct {
+	Type   string                 `json:"type"`
+	Schema map[string]interface{} `json:"schema,omitempty"`
 }
 
 type OpenAIMessage struct {
@@ -56,13 +96,28 @@ type OpenAIMessage struct {
 	Name       string      `json:"name,omitempty"`
 	ToolCalls  []ToolCall  `json:"tool_calls,omitempty"`
 	ToolCallID string      `json:"tool_call_id,omitempty"`
+
+	// ReasoningContent is the model's chain-of-thought, mirroring the
+	// reasoning_content convention used by OpenAI's reasoning models and
+	// DeepSeek/Qwen. It's populated from Claude's thinking blocks and
+	// Gemini's thought-flagged parts, and replayed back to them verbatim
+	// on a later turn instead of being folded into plain text content.
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+
+	// ReasoningSignature carries Claude's opaque thinking-block signature
+	// so it can be replayed byte-for-byte on a later turn. For a
+	// redacted_thinking block (whose content Claude never reveals),
+	// ReasoningContent is empty and this holds the block's opaque data.
+	ReasoningSignature string `json:"reasoning_signature,omitempty"`
 }
 
 type ContentPart struct {
-	Type     string    `json:"type"`
-	Text     string    `json:"text,omitempty"`
-	ImageURL *ImageURL `json:"image_url,omitempty"`
-	AudioURL *AudioURL `json:"audio_url,omitempty"`
+	Type       string        `json:"type"`
+	Text       string        `json:"text,omitempty"`
+	ImageURL   *ImageURL     `json:"image_url,omitempty"`
+	AudioURL   *AudioURL     `json:"audio_url,omitempty"`
+	InputAudio *InputAudio   `json:"input_audio,omitempty"`
+	Document   *DocumentPart `json:"document,omitempty"`
 }
 
 type ImageURL struct {
@@ -73,6 +128,22 @@ type AudioURL struct {
 	URL string `json:"url"`
 }
 
+// InputAudio is OpenAI's input_audio content part payload: base64-encoded
+// audio data plus its format (a bare extension like "wav" or "mp3").
+type InputAudio struct {
+	Data   string `json:"data"`
+	Format string `json:"format"`
+}
+
+// DocumentPart carries a base64-encoded document (e.g. a PDF) alongside
+// its MIME type. It's the normalized shape a ContentPart of type
+// "document" uses to bridge Claude's document blocks and Gemini's PDF
+// inlineData through OpenAI's representation.
+type DocumentPart struct {
+	MimeType string `json:"mime_type"`
+	Data     string `json:"data"`
+}
+
 type Tool struct {
 	Type     string       `json:"type"`
 	Function ToolFunction `json:"function"`
@@ -104,6 +175,10 @@ type OpenAIResponse struct {
 	Choices           []OpenAIChoice `json:"choices"`
 	Usage             *Usage         `json:"usage,omitempty"`
 	SystemFingerprint string         `json:"system_fingerprint,omitempty"`
+
+	// PromptFilterResults is Azure OpenAI's per-prompt content filter
+	// verdict, absent for plain OpenAI responses.
+	PromptFilterResults []AzurePromptFilterResult `json:"prompt_filter_results,omitempty"`
 }
 
 type OpenAIChoice struct {
@@ -111,6 +186,32 @@ type OpenAIChoice struct {
 	Message      *OpenAIMessage `json:"message,omitempty"`
 	Delta        *OpenAIMessage `json:"delta,omitempty"`
 	FinishReason string         `json:"finish_reason,omitempty"`
+
+	// ContentFilterResults is populated by Azure OpenAI deployments that
+	// have content filtering enabled; absent for plain OpenAI responses.
+	ContentFilterResults *AzureContentFilterResult `json:"content_filter_results,omitempty"`
+}
+
+// AzureContentFilterResult carries Azure OpenAI's per-category content
+// filter verdicts, attached to a choice's content_filter_results.
+type AzureContentFilterResult struct {
+	Hate     *AzureContentFilterCategory `json:"hate,omitempty"`
+	SelfHarm *AzureContentFilterCategory `json:"self_harm,omitempty"`
+	Sexual   *AzureContentFilterCategory `json:"sexual,omitempty"`
+	Violence *AzureContentFilterCategory `json:"violence,omitempty"`
+}
+
+type AzureContentFilterCategory struct {
+	Filtered bool   `json:"filtered"`
+	Severity string `json:"severity,omitempty"`
+}
+
+// AzurePromptFilterResult carries the content filter verdict for one
+// prompt in an Azure OpenAI request, attached to the response's
+// top-level prompt_filter_results.
+type AzurePromptFilterResult struct {
+	PromptIndex          int                       `json:"prompt_index"`
+	ContentFilterResults *AzureContentFilterResult `json:"content_filter_results,omitempty"`
 }
 
 type Usage struct {
@@ -121,16 +222,18 @@ type Usage struct {
 
 // Claude Models
 type ClaudeRequest struct {
-	Model       string            `json:"model"`
-	Messages    []ClaudeMessage   `json:"messages"`
-	System      string            `json:"system,omitempty"`
-	MaxTokens   int               `json:"max_tokens"`
-	Temperature float64           `json:"temperature,omitempty"`
-	TopP        float64           `json:"top_p,omitempty"`
-	Stream      bool              `json:"stream,omitempty"`
-	Tools       []ClaudeTool      `json:"tools,omitempty"`
-	ToolChoice  *ClaudeToolChoice `json:"tool_choice,omitempty"`
-	Thinking    *ClaudeThinking   `json:"thinking,omitempty"`
+	Model         string            `json:"model"`
+	Messages      []ClaudeMessage   `json:"messages"`
+	System        string            `json:"system,omitempty"`
+	MaxTokens     int               `json:"max_tokens"`
+	Temperature   float64           `json:"temperature,omitempty"`
+	TopP          float64           `json:"top_p,omitempty"`
+	TopK          int               `json:"top_k,omitempty"`
+	StopSequences []string          `json:"stop_sequences,omitempty"`
+	Stream        bool              `json:"stream,omitempty"`
+	Tools         []ClaudeTool      `json:"tools,omitempty"`
+	ToolChoice    *ClaudeToolChoice `json:"tool_choice,omitempty"`
+	Thinking      *ClaudeThinking   `json:"thinking,omitempty"`
 }
 
 type ClaudeMessage struct {
@@ -148,6 +251,16 @@ type ClaudeContent struct {
 	ToolUseID string                 `json:"tool_use_id,omitempty"`
 	Content   interface{}            `json:"content,omitempty"`
 	Thinking  string                 `json:"thinking,omitempty"`
+
+	// Signature authenticates a "thinking" block so Claude will accept it
+	// back verbatim on a later turn instead of rejecting a reserialized
+	// thinking block as plain text.
+	Signature string `json:"signature,omitempty"`
+
+	// Data carries a "redacted_thinking" block's opaque payload: Claude
+	// flagged the reasoning as sensitive and never reveals it as text,
+	// but the block must still be replayed back unchanged.
+	Data string `json:"data,omitempty"`
 }
 
 type ClaudeImageSource struct {
@@ -188,6 +301,52 @@ type ClaudeUsage struct {
 	OutputTokens int `json:"output_tokens"`
 }
 
+// ClaudeStreamEvent is one SSE event in Claude's streaming response
+// format, e.g. a content_block_delta carrying a text_delta, or a
+// content_block_start opening a tool_use block.
+type ClaudeStreamEvent struct {
+	Type         string                    `json:"type"`
+	Index        int                       `json:"index,omitempty"`
+	Delta        *ClaudeStreamDelta        `json:"delta,omitempty"`
+	ContentBlock *ClaudeStreamContentBlock `json:"content_block,omitempty"`
+	Usage        *ClaudeStreamUsage        `json:"usage,omitempty"`
+}
+
+type ClaudeStreamDelta struct {
+	Type string `json:"type,omitempty"`
+	Text string `json:"text,omitempty"`
+
+	// PartialJSON is a fragment of a tool_use block's input, present on
+	// input_json_delta events. Fragments must be concatenated in index
+	// order and parsed only once the block's content_block_stop arrives.
+	PartialJSON string `json:"partial_json,omitempty"`
+
+	// Thinking is a fragment of an extended-thinking block's text, present
+	// on thinking_delta events.
+	Thinking string `json:"thinking,omitempty"`
+
+	// StopReason is set on a message_delta event, once the model knows why
+	// it stopped.
+	StopReason string `json:"stop_reason,omitempty"`
+}
+
+// ClaudeStreamUsage is the usage payload on a message_delta event. Unlike
+// ClaudeUsage, it only ever reports output_tokens: input_tokens was already
+// given in message_start.
+type ClaudeStreamUsage struct {
+	OutputTokens int `json:"output_tokens"`
+}
+
+// ClaudeStreamContentBlock is the content_block payload of a
+// content_block_start event. For a tool_use block, Input starts empty and
+// is filled in across subsequent input_json_delta events.
+type ClaudeStreamContentBlock struct {
+	Type  string                 `json:"type"`
+	ID    string                 `json:"id,omitempty"`
+	Name  string                 `json:"name,omitempty"`
+	Input map[string]interface{} `json:"input,omitempty"`
+}
+
 // Gemini Models
 type GeminiRequest struct {
 	Contents          []GeminiContent          `json:"contents"`
@@ -195,6 +354,16 @@ type GeminiRequest struct {
 	GenerationConfig  *GeminiGenerationConfig  `json:"generationConfig,omitempty"`
 	Tools             []GeminiTool             `json:"tools,omitempty"`
 	ToolConfig        *GeminiToolConfig        `json:"toolConfig,omitempty"`
+
+	// SafetySettings is accepted by both the Gemini API and Vertex AI's
+	// Gemini endpoint; Vertex callers rely on it more heavily since the
+	// per-project defaults differ from the public Gemini API's.
+	SafetySettings []VertexSafetySetting `json:"safetySettings,omitempty"`
+}
+
+type VertexSafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
 }
 
 type GeminiContent struct {
@@ -208,6 +377,10 @@ type GeminiPart struct {
 	FileData         *GeminiFileData         `json:"fileData,omitempty"`
 	FunctionCall     *GeminiFunctionCall     `json:"functionCall,omitempty"`
 	FunctionResponse *GeminiFunctionResponse `json:"functionResponse,omitempty"`
+
+	// Thought flags this part as model reasoning rather than user-facing
+	// output, Gemini's equivalent of Claude's thinking blocks.
+	Thought bool `json:"thought,omitempty"`
 }
 
 type GeminiInlineData struct {
@@ -221,11 +394,13 @@ type GeminiFileData struct {
 }
 
 type GeminiFunctionCall struct {
+	ID   string                 `json:"id,omitempty"`
 	Name string                 `json:"name"`
 	Args map[string]interface{} `json:"args"`
 }
 
 type GeminiFunctionResponse struct {
+	ID       string                 `json:"id,omitempty"`
 	Name     string                 `json:"name"`
 	Response map[string]interface{} `json:"response"`
 }
@@ -237,8 +412,26 @@ type GeminiSystemInstruction struct {
 type GeminiGenerationConfig struct {
 	Temperature     float64  `json:"temperature,omitempty"`
 	TopP            float64  `json:"topP,omitempty"`
+	TopK            int      `json:"topK,omitempty"`
 	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
 	StopSequences   []string `json:"stopSequences,omitempty"`
+
+	// ResponseMimeType/ResponseSchema are Gemini's structured-output
+	// controls: set ResponseMimeType to "application/json" to require a
+	// JSON response, optionally constrained to ResponseSchema.
+	ResponseMimeType string                 `json:"responseMimeType,omitempty"`
+	ResponseSchema   map[string]interface{} `json:"responseSchema,omitempty"`
+
+	// ThinkingConfig is Gemini's extended-thinking control, the
+	// counterpart of Claude's thinking.budget_tokens and OpenAI's
+	// reasoning_effort.
+	ThinkingConfig *GeminiThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+// GeminiThinkingConfig controls how much of a Gemini response's token
+// budget may go toward internal reasoning before producing output.
+type GeminiThinkingConfig struct {
+	ThinkingBudget int `json:"thinkingBudget,omitempty"`
 }
 
 type GeminiTool struct {
@@ -308,19 +501,76 @@ type ModelInfo struct {
 
 // Provider configuration
 type ProviderConfig struct {
-	Provider         Provider
-	APIKey           string
-	BaseURL          string
-	ProjectID        string
-	OAuthCredsBase64 string
-	OAuthCredsFile   string
-	CheckModelName   string
-	UUID             string
-	IsHealthy        bool
-	LastUsed         *time.Time
-	UsageCount       int
-	ErrorCount       int
-	LastErrorTime    *time.Time
+	Provider            Provider
+	APIKey              string
+	BaseURL             string
+	ProjectID           string
+	OAuthCredsBase64    string
+	OAuthCredsFile      string
+	CheckModelName      string
+	UUID                string
+	IsHealthy           bool
+	LastUsed            *time.Time
+	UsageCount          int
+	ErrorCount          int
+	LastErrorTime       *time.Time
+	ConsecutiveFailures int
+	NextProbeAt         time.Time
+	LogLevel            string
+
+	// Google Workload Identity Federation (external_account credentials),
+	// consumed by auth.NewTokenManager as an alternative to shipping a
+	// full OAuthCredsBase64/OAuthCredsFile JSON blob: set these instead to
+	// have the TokenManager assemble the STS exchange config itself, e.g.
+	// to authenticate Gemini/Vertex from a GitHub Actions OIDC token
+	// written to disk. Only consulted when neither OAuthCredsBase64 nor
+	// OAuthCredsFile is set. WorkloadIdentityCredentialSourceFile is the
+	// path to a file containing the subject token (the common
+	// file-sourced case); the executable/URL/AWS credential sources are
+	// still reachable via a hand-authored OAuthCredsBase64/File blob.
+	WorkloadIdentityAudience             string
+	WorkloadIdentitySubjectTokenType     string
+	WorkloadIdentityCredentialSourceFile string
+	WorkloadIdentityImpersonationURL     string
+
+	// OAuth token persistence, consumed by auth.NewTokenStoreFromConfig.
+	// TokenStoreType is one of "" / "memory" (default), "file", or "redis".
+	TokenStoreType          string
+	TokenStoreDir           string
+	TokenStoreEncKeyBase64  string
+	TokenStoreRedisAddr     string
+	TokenStoreRedisPassword string
+	TokenStoreRedisDB       int
+
+	// Rate limiting and retry configuration, consumed by
+	// internal/ratelimit.Limiter and each provider client's makeRequest.
+	// RateLimitRPM/RateLimitTPM <= 0 disables that dimension's limit.
+	RateLimitRPM     int
+	RateLimitTPM     int
+	RetryBaseDelayMs int
+	RetryMaxDelayMs  int
+	RetryMaxAttempts int
+
+	// Weight influences how often pool.WeightedRandomStrategy draws this
+	// provider relative to its pool siblings. Zero or unset is treated as
+	// 1 (equal weighting), so existing configs that never set it keep
+	// behaving the way they always did.
+	Weight int
+
+	// CacheTTLSeconds and CacheMaxBodyBytes override providers.CachingProvider's
+	// global --cache-ttl/--cache-max-body-bytes defaults for just this
+	// provider. Zero/unset means "use the global default".
+	CacheTTLSeconds   int
+	CacheMaxBodyBytes int
+
+	// Location is the Vertex AI region gemini.Client.buildURL targets for
+	// OAuth/service-account requests, e.g. "europe-west4". Empty defaults
+	// to "us-central1". VertexEndpointOverride replaces the whole
+	// "<location>-aiplatform.googleapis.com" host when set, for private
+	// Service Connect endpoints or other non-standard Vertex hosts; when
+	// set, Location still selects the locations/<Location> path segment.
+	Location               string
+	VertexEndpointOverride string
 }
 
 // Helper functions for content conversion
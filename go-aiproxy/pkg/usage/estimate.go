@@ -0,0 +1,20 @@
+package usage
+
+// EstimateTokens approximates the token count of text using the
+// widely-cited heuristic of about 4 characters per token for English
+// text. It exists so streaming responses can surface a running usage
+// estimate before the upstream's final chunk reports exact counts; a real
+// tiktoken-compatible BPE vocabulary isn't available in this build, so
+// this is deliberately an approximation rather than an exact count.
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	const charsPerToken = 4
+	estimate := len(text) / charsPerToken
+	if estimate == 0 {
+		estimate = 1
+	}
+	return estimate
+}
@@ -0,0 +1,52 @@
+package usage
+
+import "github.com/aiproxy/go-aiproxy/pkg/models"
+
+// PricePer1K holds per-1000-token pricing, in USD, for one model.
+type PricePer1K struct {
+	Input  float64
+	Output float64
+}
+
+// PriceTable looks up PricePer1K by model name, falling back to Default
+// when the model has no specific entry. It is intentionally a plain map
+// rather than a provider-keyed structure, since the same model name (e.g.
+// "gpt-4o") prices the same regardless of which protocol served it.
+type PriceTable struct {
+	Default PricePer1K
+	Models  map[string]PricePer1K
+}
+
+// NewPriceTable creates a PriceTable seeded with the current list prices
+// of the models this proxy most commonly fronts. Callers can override or
+// extend Models directly; entries are looked up by exact model name.
+func NewPriceTable() *PriceTable {
+	return &PriceTable{
+		Default: PricePer1K{Input: 0.0, Output: 0.0},
+		Models: map[string]PricePer1K{
+			"gpt-4o":                   {Input: 0.005, Output: 0.015},
+			"gpt-4o-mini":              {Input: 0.00015, Output: 0.0006},
+			"gpt-3.5-turbo":            {Input: 0.0005, Output: 0.0015},
+			"claude-3-opus-20240229":   {Input: 0.015, Output: 0.075},
+			"claude-3-sonnet-20240229": {Input: 0.003, Output: 0.015},
+			"claude-3-haiku-20240307":  {Input: 0.00025, Output: 0.00125},
+			"gemini-1.5-pro":           {Input: 0.00125, Output: 0.005},
+			"gemini-1.5-flash":         {Input: 0.000075, Output: 0.0003},
+		},
+	}
+}
+
+// Price returns the PricePer1K for model, falling back to t.Default when
+// model has no specific entry.
+func (t *PriceTable) Price(model string) PricePer1K {
+	if p, ok := t.Models[model]; ok {
+		return p
+	}
+	return t.Default
+}
+
+// EstimateCost returns the USD cost of usg against model's price.
+func (t *PriceTable) EstimateCost(model string, usg models.Usage) float64 {
+	price := t.Price(model)
+	return float64(usg.PromptTokens)/1000*price.Input + float64(usg.CompletionTokens)/1000*price.Output
+}
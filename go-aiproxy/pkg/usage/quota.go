@@ -0,0 +1,75 @@
+package usage
+
+import (
+	"sync"
+	"time"
+)
+
+// keyBudget tracks how much of the rolling window's budget an API key has
+// spent so far, and when that window started.
+type keyBudget struct {
+	windowStart time.Time
+	spent       float64
+}
+
+// QuotaEnforcer rejects requests once a per-API-key budget is exceeded
+// within a rolling time window. A BudgetUSD of 0 disables enforcement
+// entirely, so deployments that don't want quotas pay no overhead.
+type QuotaEnforcer struct {
+	BudgetUSD float64
+	Window    time.Duration
+
+	mu      sync.Mutex
+	budgets map[string]*keyBudget
+}
+
+// NewQuotaEnforcer creates a QuotaEnforcer allowing up to budgetUSD of
+// estimated cost per API key within each window. A non-positive budgetUSD
+// disables enforcement.
+func NewQuotaEnforcer(budgetUSD float64, window time.Duration) *QuotaEnforcer {
+	return &QuotaEnforcer{
+		BudgetUSD: budgetUSD,
+		Window:    window,
+		budgets:   make(map[string]*keyBudget),
+	}
+}
+
+// Allow reports whether key still has budget remaining in its current
+// window. It does not itself record spend; call Charge once the request's
+// actual or estimated cost is known.
+func (q *QuotaEnforcer) Allow(key string) bool {
+	if q.BudgetUSD <= 0 {
+		return true
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	b := q.currentWindow(key)
+	return b.spent < q.BudgetUSD
+}
+
+// Charge records costUSD against key's current window.
+func (q *QuotaEnforcer) Charge(key string, costUSD float64) {
+	if q.BudgetUSD <= 0 {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	b := q.currentWindow(key)
+	b.spent += costUSD
+}
+
+// currentWindow returns key's budget tracker, resetting it if the window
+// has elapsed. Callers must hold q.mu.
+func (q *QuotaEnforcer) currentWindow(key string) *keyBudget {
+	b, ok := q.budgets[key]
+	now := time.Now()
+	if !ok || now.Sub(b.windowStart) >= q.Window {
+		b = &keyBudget{windowStart: now}
+		q.budgets[key] = b
+	}
+	return b
+}
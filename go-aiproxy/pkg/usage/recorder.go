@@ -0,0 +1,56 @@
+package usage
+
+import (
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Recorder wraps every provider call with token and cost accounting,
+// exposing Prometheus counters labeled by provider, model, and protocol
+// so usage can be sliced per deployment the same way request metrics are.
+type Recorder struct {
+	Prices *PriceTable
+
+	tokensTotal *prometheus.CounterVec
+	costTotal   *prometheus.CounterVec
+}
+
+// NewRecorder creates a Recorder and registers its Prometheus counters.
+func NewRecorder(prices *PriceTable) *Recorder {
+	if prices == nil {
+		prices = NewPriceTable()
+	}
+
+	return &Recorder{
+		Prices: prices,
+		tokensTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_usage_tokens_total",
+				Help: "Total number of tokens recorded by the usage recorder",
+			},
+			[]string{"provider", "model", "protocol", "token_type"},
+		),
+		costTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "aiproxy_usage_cost_usd_total",
+				Help: "Total estimated USD cost recorded by the usage recorder",
+			},
+			[]string{"provider", "model", "protocol"},
+		),
+	}
+}
+
+// Record charges usg against model's price and updates the token/cost
+// counters labeled by provider, model, and protocol. It returns the
+// estimated USD cost, so callers can feed it straight into a
+// QuotaEnforcer.
+func (r *Recorder) Record(provider models.Provider, model string, protocol models.ProtocolPrefix, usg models.Usage) float64 {
+	r.tokensTotal.WithLabelValues(string(provider), model, string(protocol), "prompt").Add(float64(usg.PromptTokens))
+	r.tokensTotal.WithLabelValues(string(provider), model, string(protocol), "completion").Add(float64(usg.CompletionTokens))
+
+	cost := r.Prices.EstimateCost(model, usg)
+	r.costTotal.WithLabelValues(string(provider), model, string(protocol)).Add(cost)
+
+	return cost
+}
@@ -0,0 +1,46 @@
+// Package usage tracks token consumption and estimated cost across every
+// provider protocol, so the proxy can expose a single accounting view
+// regardless of which upstream API actually served a request.
+package usage
+
+import "github.com/aiproxy/go-aiproxy/pkg/models"
+
+// ExtractUsage normalizes a provider response's token accounting into
+// models.Usage, translating Claude's InputTokens/OutputTokens and
+// Gemini's PromptTokenCount/CandidatesTokenCount into the same shape
+// OpenAI's Usage already uses. ok is false if resp carries no usage data,
+// which streaming responses commonly don't until their final chunk.
+func ExtractUsage(protocol models.ProtocolPrefix, resp interface{}) (models.Usage, bool) {
+	switch protocol {
+	case models.ProtocolOpenAI, models.ProtocolAzureOpenAI:
+		r, ok := resp.(*models.OpenAIResponse)
+		if !ok || r.Usage == nil {
+			return models.Usage{}, false
+		}
+		return *r.Usage, true
+
+	case models.ProtocolClaude:
+		r, ok := resp.(*models.ClaudeResponse)
+		if !ok || r.Usage == nil {
+			return models.Usage{}, false
+		}
+		return models.Usage{
+			PromptTokens:     r.Usage.InputTokens,
+			CompletionTokens: r.Usage.OutputTokens,
+			TotalTokens:      r.Usage.InputTokens + r.Usage.OutputTokens,
+		}, true
+
+	case models.ProtocolGemini, models.ProtocolVertexAI:
+		r, ok := resp.(*models.GeminiResponse)
+		if !ok || r.UsageMetadata == nil {
+			return models.Usage{}, false
+		}
+		return models.Usage{
+			PromptTokens:     r.UsageMetadata.PromptTokenCount,
+			CompletionTokens: r.UsageMetadata.CandidatesTokenCount,
+			TotalTokens:      r.UsageMetadata.TotalTokenCount,
+		}, true
+	}
+
+	return models.Usage{}, false
+}
@@ -0,0 +1,87 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aiproxy/go-aiproxy/pkg/models"
+)
+
+func TestExtractUsage_Claude(t *testing.T) {
+	resp := &models.ClaudeResponse{Usage: &models.ClaudeUsage{InputTokens: 10, OutputTokens: 20}}
+
+	usg, ok := ExtractUsage(models.ProtocolClaude, resp)
+	if !ok {
+		t.Fatal("expected usage to be extracted")
+	}
+	if usg.PromptTokens != 10 || usg.CompletionTokens != 20 || usg.TotalTokens != 30 {
+		t.Fatalf("unexpected usage: %#v", usg)
+	}
+}
+
+func TestExtractUsage_Gemini(t *testing.T) {
+	resp := &models.GeminiResponse{
+		UsageMetadata: &models.GeminiUsage{PromptTokenCount: 5, CandidatesTokenCount: 7, TotalTokenCount: 12},
+	}
+
+	usg, ok := ExtractUsage(models.ProtocolVertexAI, resp)
+	if !ok {
+		t.Fatal("expected usage to be extracted for a Vertex AI response")
+	}
+	if usg.PromptTokens != 5 || usg.CompletionTokens != 7 {
+		t.Fatalf("unexpected usage: %#v", usg)
+	}
+}
+
+func TestExtractUsage_MissingUsageReturnsNotOK(t *testing.T) {
+	if _, ok := ExtractUsage(models.ProtocolOpenAI, &models.OpenAIResponse{}); ok {
+		t.Fatal("expected ok=false when the response carries no usage")
+	}
+}
+
+func TestQuotaEnforcer_RejectsOverBudget(t *testing.T) {
+	q := NewQuotaEnforcer(1.0, time.Minute)
+
+	if !q.Allow("key-1") {
+		t.Fatal("expected a fresh key to be allowed")
+	}
+	q.Charge("key-1", 1.5)
+
+	if q.Allow("key-1") {
+		t.Fatal("expected key-1 to be rejected after exceeding its budget")
+	}
+	if !q.Allow("key-2") {
+		t.Fatal("expected a different key to have its own independent budget")
+	}
+}
+
+func TestQuotaEnforcer_UnlimitedWhenBudgetIsZero(t *testing.T) {
+	q := NewQuotaEnforcer(0, time.Minute)
+	q.Charge("key-1", 1000)
+
+	if !q.Allow("key-1") {
+		t.Fatal("expected a zero budget to disable enforcement")
+	}
+}
+
+func TestPriceTable_EstimateCost(t *testing.T) {
+	prices := NewPriceTable()
+	cost := prices.EstimateCost("gpt-4o", models.Usage{PromptTokens: 1000, CompletionTokens: 1000})
+
+	want := 0.005 + 0.015
+	if cost != want {
+		t.Fatalf("expected cost %f, got %f", want, cost)
+	}
+}
+
+func TestEstimateTokens_Approximates(t *testing.T) {
+	if got := EstimateTokens(""); got != 0 {
+		t.Fatalf("expected 0 tokens for empty text, got %d", got)
+	}
+	if got := EstimateTokens("hi"); got != 1 {
+		t.Fatalf("expected a short string to still estimate at least 1 token, got %d", got)
+	}
+	if got := EstimateTokens("this is sixteen ch"); got != 4 {
+		t.Fatalf("expected ~4 chars/token heuristic, got %d", got)
+	}
+}